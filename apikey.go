@@ -0,0 +1,123 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIKeyPlacement is where the API key of APIKeyOpts is sent.
+type APIKeyPlacement int
+
+const (
+	// APIKeyHeader sends the key in a header (default X-Api-Key).
+	APIKeyHeader APIKeyPlacement = iota
+	// APIKeyQuery sends the key as query parameter of the url (default apikey).
+	APIKeyQuery
+	// APIKeyPath appends the key as last path segment of the url, e.g. https://mainnet.infura.io/v3/<key>.
+	APIKeyPath
+)
+
+const (
+	defaultAPIKeyHeader = "X-Api-Key"
+	defaultAPIKeyParam  = "apikey"
+)
+
+// APIKeyOpts sends an API key with every request, see RPCClientOpts.
+//
+// The key is added to the http requests when they are sent, the endpoint of the client stays without key.
+// So the key does not show up in CallInfo.Endpoint, metrics, logs and the urls of errors.
+// It is not sent if the endpoint redirects to another host.
+// The debug dump and errors of the http client that contain the key show "[REDACTED]" instead.
+//
+// Key: the API key
+//
+// Placement: where the key is sent (default APIKeyHeader)
+//
+// Name: the name of the header (default "X-Api-Key") or query parameter (default "apikey"), not used for APIKeyPath
+type APIKeyOpts struct {
+	Key       string
+	Placement APIKeyPlacement
+	Name      string
+}
+
+// newAPIKeyMiddleware returns a middleware that adds the key of opts to the requests.
+func newAPIKeyMiddleware(opts APIKeyOpts) func(http.RoundTripper) http.RoundTripper {
+	if opts.Name == "" {
+		if opts.Placement == APIKeyQuery {
+			opts.Name = defaultAPIKeyParam
+		} else {
+			opts.Name = defaultAPIKeyHeader
+		}
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if isCrossHostRedirect(request) {
+				return next.RoundTrip(request)
+			}
+
+			// the request of the caller must not be modified
+			request = request.Clone(request.Context())
+
+			switch opts.Placement {
+			case APIKeyQuery:
+				param := url.QueryEscape(opts.Name) + "=" + url.QueryEscape(opts.Key)
+				if request.URL.RawQuery == "" {
+					request.URL.RawQuery = param
+				} else {
+					request.URL.RawQuery += "&" + param
+				}
+			case APIKeyPath:
+				rawPath := request.URL.EscapedPath()
+				request.URL.Path = strings.TrimSuffix(request.URL.Path, "/") + "/" + opts.Key
+				request.URL.RawPath = strings.TrimSuffix(rawPath, "/") + "/" + url.PathEscape(opts.Key)
+			default:
+				request.Header.Set(opts.Name, opts.Key)
+			}
+
+			response, err := next.RoundTrip(request)
+			return response, redactSecrets(err, opts.Key)
+		})
+	}
+}
+
+// redactedError is an error whose message does not contain secrets.
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactSecrets returns err with the secrets in its message replaced by "[REDACTED]".
+func redactSecrets(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	message := redactString(err.Error(), secrets...)
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, message: message}
+}
+
+// redactString returns s with the secrets, also url escaped, replaced by "[REDACTED]".
+func redactString(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.Replace(s, secret, redactedValue, -1)
+		s = strings.Replace(s, url.QueryEscape(secret), redactedValue, -1)
+		s = strings.Replace(s, url.PathEscape(secret), redactedValue, -1)
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestAPIKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	call := func(endpoint string, opts APIKeyOpts) *http.Request {
+		requests = nil
+		var info CallInfo
+		rpcClient := NewClientWithOpts(endpoint, &RPCClientOpts{APIKey: &opts})
+		_, err := rpcClient.CallContext(WithCallInfo(context.Background(), &info), "something")
+		Expect(err).To(BeNil())
+		Expect(info.Endpoint).To(Equal(endpoint))
+		Expect(requests).To(HaveLen(1))
+		return requests[0]
+	}
+
+	request := call(server.URL, APIKeyOpts{Key: "secret"})
+	Expect(request.Header.Get("X-Api-Key")).To(Equal("secret"))
+
+	request = call(server.URL, APIKeyOpts{Key: "secret", Name: "Api-Token"})
+	Expect(request.Header.Get("Api-Token")).To(Equal("secret"))
+	Expect(request.Header.Get("X-Api-Key")).To(BeEmpty())
+
+	request = call(server.URL+"/rpc?chain=1", APIKeyOpts{Key: "s&cret", Placement: APIKeyQuery})
+	Expect(request.URL.RawQuery).To(Equal("chain=1&apikey=s%26cret"))
+
+	request = call(server.URL, APIKeyOpts{Key: "secret", Placement: APIKeyQuery, Name: "key"})
+	Expect(request.URL.RawQuery).To(Equal("key=secret"))
+
+	request = call(server.URL+"/v3/", APIKeyOpts{Key: "secret", Placement: APIKeyPath})
+	Expect(request.URL.Path).To(Equal("/v3/secret"))
+
+	request = call(server.URL+"/v2?chain=1", APIKeyOpts{Key: "se/cret", Placement: APIKeyPath})
+	Expect(request.URL.EscapedPath()).To(Equal("/v2/se%2Fcret"))
+	Expect(request.URL.RawQuery).To(Equal("chain=1"))
+}
+
+func TestAPIKeyRedaction(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	for _, placement := range []APIKeyPlacement{APIKeyHeader, APIKeyQuery, APIKeyPath} {
+		var output bytes.Buffer
+		rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+			APIKey: &APIKeyOpts{Key: "secret", Placement: placement, Name: "Token"},
+			Debug:  &DebugOpts{Writer: &output},
+		})
+		_, err := rpcClient.Call("something")
+		Expect(err).To(BeNil())
+		Expect(output.String()).To(ContainSubstring("[REDACTED]"))
+		Expect(output.String()).NotTo(ContainSubstring("secret"))
+	}
+
+	// errors below the middleware that contain the key
+	failing := roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return nil, MarkTemporary(fmt.Errorf("dial %v: connection refused", request.URL))
+	})
+	_, err := newAPIKeyMiddleware(APIKeyOpts{Key: "se cret", Placement: APIKeyQuery})(failing).RoundTrip(httptest.NewRequest(http.MethodPost, "http://localhost/rpc", nil))
+	Expect(err).To(MatchError("dial http://localhost/rpc?apikey=[REDACTED]: connection refused"))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	_, err = NewClientWithOpts("http://localhost:1/rpc", &RPCClientOpts{
+		APIKey: &APIKeyOpts{Key: "secret", Placement: APIKeyPath},
+	}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).NotTo(ContainSubstring("secret"))
+
+	Expect(redactSecrets(nil, "secret")).To(BeNil())
+	plain := errors.New("connection refused")
+	Expect(redactSecrets(plain, "secret", "")).To(Equal(plain))
+}
+
+func TestAPIKeyRedirect(t *testing.T) {
+	RegisterTestingT(t)
+
+	endpoint, headers, closeServers := newRedirectServers()
+	defer closeServers()
+
+	for _, placement := range []APIKeyPlacement{APIKeyHeader, APIKeyQuery, APIKeyPath} {
+		opts := &RPCClientOpts{APIKey: &APIKeyOpts{Key: "secret-key", Placement: placement}}
+		_, err := NewClientWithOpts(endpoint+"/same", opts).Call("something")
+		Expect(err).To(BeNil())
+		_, err = NewClientWithOpts(endpoint+"/other", opts).Call("something")
+		Expect(err).To(BeNil())
+
+		endpointHeader, otherHeader := headers()
+		Expect(endpointHeader.Get("X-Api-Key") + endpointHeader.Get("X-Url")).To(ContainSubstring("secret-key"))
+		Expect(otherHeader).NotTo(BeNil())
+		Expect(otherHeader.Get("X-Api-Key")).To(BeEmpty())
+		Expect(otherHeader.Get("X-Url")).To(Equal("/target"))
+	}
+}
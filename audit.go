@@ -0,0 +1,172 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditLogOpts configures the audit log, see RPCClientOpts.
+//
+// The audit log appends a JSON line for every request to Writer, batch calls write a line for every request of the batch:
+//
+//	{"time":"2024-01-02T15:04:05.123Z","method":"getBalance","id":"0","duration_ms":12.5,"attempts":1,
+//	"endpoint":"http://my-rpc-service:8080/rpc","http_status":200,"outcome":"ok","params":"[\"0x1\"]","result":"\"0x2\""}
+//
+// time is the start of the call, outcome is "ok", "rpc_error" (with rpc_error_code) or "error" (with error).
+// The params and results are passed through the Redactor of the client.
+//
+// Writer: receives the lines (default os.Stderr), the lines of a call are written with a single call to Write.
+// Write errors are ignored.
+//
+// MaxPayloadSize: params and results are truncated to this number of bytes (default 512), they are omitted if negative
+type AuditLogOpts struct {
+	Writer         io.Writer
+	MaxPayloadSize int
+}
+
+const defaultAuditMaxPayloadSize = 512
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time          time.Time `json:"time"`
+	Method        string    `json:"method"`
+	ID            string    `json:"id"`
+	Batch         bool      `json:"batch,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Duration      float64   `json:"duration_ms"`
+	Attempts      int       `json:"attempts"`
+	Endpoint      string    `json:"endpoint,omitempty"`
+	HTTPStatus    int       `json:"http_status,omitempty"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+	RPCErrorCode  int       `json:"rpc_error_code,omitempty"`
+	Params        string    `json:"params,omitempty"`
+	Result        string    `json:"result,omitempty"`
+}
+
+type auditLog struct {
+	mutex          sync.Mutex
+	writer         io.Writer
+	maxPayloadSize int
+	redactor       Redactor
+}
+
+func newAuditLog(opts AuditLogOpts, redactor Redactor) *auditLog {
+	maxPayloadSize := opts.MaxPayloadSize
+	if maxPayloadSize == 0 {
+		maxPayloadSize = defaultAuditMaxPayloadSize
+	}
+
+	writer := opts.Writer
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	return &auditLog{
+		writer:         writer,
+		maxPayloadSize: maxPayloadSize,
+		redactor:       redactor,
+	}
+}
+
+// interceptor returns an interceptor that writes the entries of every call.
+func (a *auditLog) interceptor() Interceptor {
+	return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+		start := time.Now()
+		err := invoke(ctx)
+		a.write(a.entries(start, call))
+		return err
+	}
+}
+
+// entries returns the entries of a finished call.
+func (a *auditLog) entries(start time.Time, call *CallInfo) []auditEntry {
+	responses := make(map[string]*RPCResponse, len(call.Responses))
+	for _, response := range call.Responses {
+		if response != nil {
+			responses[response.ID.String()] = response
+		}
+	}
+
+	entries := make([]auditEntry, 0, len(call.Requests))
+	for _, request := range call.Requests {
+		if request == nil {
+			continue
+		}
+
+		entry := auditEntry{
+			Time:          start.UTC(),
+			Method:        request.Method,
+			ID:            request.ID.String(),
+			Batch:         call.Batch,
+			CorrelationID: call.CorrelationID,
+			Duration:      float64(call.Duration) / float64(time.Millisecond),
+			Attempts:      call.Attempts,
+			Endpoint:      endpointLabel(call.Endpoint),
+			HTTPStatus:    call.HTTPStatus,
+			Outcome:       "ok",
+			Params:        a.payload(request.Method, request.Params),
+		}
+
+		response := responses[entry.ID]
+		switch {
+		case call.Err != nil:
+			entry.Outcome = "error"
+			entry.Error = call.Err.Error()
+		case request.Notification:
+			entry.ID = ""
+		case response == nil:
+			entry.Outcome = "error"
+			entry.Error = "rpc response missing"
+		case response.Error != nil:
+			entry.Outcome = "rpc_error"
+			entry.RPCErrorCode = response.Error.Code
+			entry.Error = response.Error.Message
+		default:
+			entry.Result = a.payload(request.Method, response.Result)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// payload returns value as truncated json after it was passed through the redactor.
+func (a *auditLog) payload(method string, value interface{}) string {
+	if value == nil || a.maxPayloadSize < 0 {
+		return ""
+	}
+	if a.redactor != nil {
+		value = a.redactor(method, value)
+	}
+
+	js, err := json.Marshal(value)
+	if err != nil {
+		return "..."
+	}
+	if len(js) > a.maxPayloadSize {
+		return string(js[:a.maxPayloadSize]) + "..."
+	}
+
+	return string(js)
+}
+
+func (a *auditLog) write(entries []auditEntry) {
+	var lines []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.writer.Write(lines)
+}
@@ -0,0 +1,132 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func auditLines(output string) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var entry map[string]interface{}
+		Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		_, err := time.Parse(time.RFC3339Nano, entry["time"].(string))
+		Expect(err).To(BeNil())
+		Expect(entry["duration_ms"]).To(BeNumerically(">", 0))
+		delete(entry, "time")
+		delete(entry, "duration_ms")
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestAuditLog(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case bytes.HasPrefix(body, []byte("[")):
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","result":"abcdefghijklmnopqrstuvwxyz","id":0},{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":1}]`)
+		case bytes.Contains(body, []byte("login")):
+			fmt.Fprint(w, `{"jsonrpc":"2.0","result":{"token":"secret"},"id":0}`)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	rpcClient := NewClientWithOpts(server.URL+"?key=secret", &RPCClientOpts{
+		Redactor: RedactFields("password", "token"),
+		AuditLog: &AuditLogOpts{Writer: &output, MaxPayloadSize: 20},
+	})
+
+	_, err := rpcClient.Call("login", map[string]string{"password": "secret"})
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b", 1)})
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("failing")
+	Expect(err).To(HaveOccurred())
+
+	Expect(output.String()).NotTo(ContainSubstring("secret"))
+	Expect(auditLines(output.String())).To(Equal([]map[string]interface{}{
+		{
+			"method":      "login",
+			"id":          "0",
+			"attempts":    float64(1),
+			"endpoint":    server.URL,
+			"http_status": float64(200),
+			"outcome":     "ok",
+			"params":      `{"password":"[REDACT...`,
+			"result":      `{"token":"[REDACTED]...`,
+		},
+		{
+			"method":      "a",
+			"id":          "0",
+			"batch":       true,
+			"attempts":    float64(1),
+			"endpoint":    server.URL,
+			"http_status": float64(200),
+			"outcome":     "ok",
+			"result":      `"abcdefghijklmnopqrs...`,
+		},
+		{
+			"method":         "b",
+			"id":             "1",
+			"batch":          true,
+			"attempts":       float64(1),
+			"endpoint":       server.URL,
+			"http_status":    float64(200),
+			"outcome":        "rpc_error",
+			"rpc_error_code": float64(-32000),
+			"error":          "failed",
+			"params":         "[1]",
+		},
+		{
+			"method":      "failing",
+			"id":          "0",
+			"attempts":    float64(1),
+			"endpoint":    server.URL,
+			"http_status": float64(502),
+			"outcome":     "error",
+			"error":       err.Error(),
+		},
+	}))
+}
+
+func TestAuditLogWithoutPayloads(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		AuditLog:      &AuditLogOpts{Writer: &output, MaxPayloadSize: -1},
+		CorrelationID: &CorrelationIDOpts{Generate: func() string { return "my-id" }},
+	})
+
+	_, err := rpcClient.Call("something", 1)
+	Expect(err).To(BeNil())
+	Expect(auditLines(output.String())).To(Equal([]map[string]interface{}{{
+		"method":         "something",
+		"id":             "0",
+		"correlation_id": "my-id",
+		"attempts":       float64(1),
+		"endpoint":       server.URL,
+		"http_status":    float64(200),
+		"outcome":        "ok",
+	}}))
+}
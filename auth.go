@@ -0,0 +1,173 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BearerTokenOpts configures bearer token authentication, see RPCClientOpts.
+//
+// The token is sent as "Authorization: Bearer <token>". It is fetched lazily before the first request and cached.
+// Concurrent requests wait for a single call to Provider. If the server rejects a token with status 401,
+// a new token is fetched and the request is sent once more. The token is not sent if the endpoint redirects to another host.
+//
+// Provider: returns the current token, IsTokenRefresh(ctx) is true if the previous token was rejected
+//
+// TTL: how long a token is cached (default 0: until it is rejected), it is fetched for every request if negative
+type BearerTokenOpts struct {
+	Provider func(ctx context.Context) (string, error)
+	TTL      time.Duration
+}
+
+type tokenRefreshContextKey struct{}
+
+// IsTokenRefresh returns true if a token provider (see BearerTokenOpts) is called because the server
+// rejected the previous token, so that the provider can bypass its own cache.
+func IsTokenRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(tokenRefreshContextKey{}).(bool)
+	return refresh
+}
+
+// tokenCache caches the token of a provider and fetches it once for concurrent requests.
+type tokenCache struct {
+	provider func(ctx context.Context) (string, error)
+	ttl      time.Duration
+
+	mutex    sync.Mutex
+	token    string
+	expiry   time.Time
+	fetching chan struct{}
+	err      error
+
+	now func() time.Time
+}
+
+// get returns the cached token or fetches a new one.
+// If rejected is not empty, the token is fetched again unless the cached token was already replaced.
+func (c *tokenCache) get(ctx context.Context, rejected string) (string, error) {
+	for {
+		c.mutex.Lock()
+		if rejected != "" && c.token == rejected {
+			c.token = ""
+		}
+		if c.token != "" && c.ttl >= 0 && (c.ttl == 0 || c.now().Before(c.expiry)) {
+			token := c.token
+			c.mutex.Unlock()
+			return token, nil
+		}
+
+		if fetching := c.fetching; fetching != nil {
+			c.mutex.Unlock()
+			select {
+			case <-fetching:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+
+			c.mutex.Lock()
+			token, err := c.token, c.err
+			c.mutex.Unlock()
+			if err != nil {
+				return "", err
+			}
+			if token != "" {
+				return token, nil
+			}
+			// the token was not cached (negative TTL), fetch it ourselves
+			continue
+		}
+
+		fetching := make(chan struct{})
+		c.fetching = fetching
+		c.mutex.Unlock()
+
+		token, err := c.provider(context.WithValue(ctx, tokenRefreshContextKey{}, rejected != ""))
+		if err == nil && token == "" {
+			err = errors.New("empty token")
+		}
+		if err != nil {
+			err = fmt.Errorf("bearer token: %w", err)
+		}
+
+		c.mutex.Lock()
+		c.fetching = nil
+		c.err = err
+		c.token = ""
+		if err == nil && c.ttl >= 0 {
+			c.token = token
+			c.expiry = c.now().Add(c.ttl)
+		}
+		c.mutex.Unlock()
+		close(fetching)
+
+		return token, err
+	}
+}
+
+// newBearerTokenMiddleware returns a middleware that sets the bearer token of the provider
+// and sends the request again with a new token if the server responds with status 401.
+func newBearerTokenMiddleware(opts BearerTokenOpts) func(http.RoundTripper) http.RoundTripper {
+	cache := &tokenCache{provider: opts.Provider, ttl: opts.TTL, now: time.Now}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if isCrossHostRedirect(request) {
+				return next.RoundTrip(request)
+			}
+
+			token, err := cache.get(request.Context(), "")
+			if err != nil {
+				return nil, err
+			}
+
+			response, err := next.RoundTrip(withBearerToken(request, token))
+			if err != nil || response.StatusCode != http.StatusUnauthorized {
+				return response, err
+			}
+			if request.Body != nil && request.GetBody == nil {
+				// the body can't be sent again
+				return response, nil
+			}
+
+			token, err = cache.get(request.Context(), token)
+			if err != nil {
+				// return the 401 response, it is more helpful than the error of the provider
+				return response, nil
+			}
+
+			retry := withBearerToken(request, token)
+			if request.GetBody != nil {
+				body, err := request.GetBody()
+				if err != nil {
+					return response, nil
+				}
+				retry.Body = body
+			}
+			response.Body.Close()
+
+			return next.RoundTrip(retry)
+		})
+	}
+}
+
+// isCrossHostRedirect returns true if the http client follows a redirect to another host than the one of the original request.
+// The credentials of the endpoint must not be sent there, the middleware runs again for every redirect
+// and would add them after the http client removed the sensitive headers.
+func isCrossHostRedirect(request *http.Request) bool {
+	original := request
+	for original.Response != nil && original.Response.Request != nil {
+		original = original.Response.Request
+	}
+	return original.URL.Host != request.URL.Host
+}
+
+// withBearerToken returns a copy of request with the Authorization header set to token.
+func withBearerToken(request *http.Request, token string) *http.Request {
+	request = request.Clone(request.Context())
+	request.Header.Set("Authorization", "Bearer "+token)
+	return request
+}
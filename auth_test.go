@@ -0,0 +1,224 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newTokenServer returns a server that accepts the bearer token of *valid.
+func newTokenServer(valid *atomic.Value) (*httptest.Server, func() []string) {
+	var mutex sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		received = append(received, r.Header.Get("Authorization"))
+		mutex.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+
+	return server, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), received...)
+	}
+}
+
+// newRedirectServers returns an endpoint that redirects /other... to another host and /same... to the same host,
+// and a function that returns the headers of the last request of each host.
+func newRedirectServers() (string, func() (http.Header, http.Header), func()) {
+	var mutex sync.Mutex
+	var endpointHeader, otherHeader http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		otherHeader = r.Header.Clone()
+		otherHeader.Set("X-Url", r.URL.String())
+		mutex.Unlock()
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/other"):
+			http.Redirect(w, r, other.URL+"/target", http.StatusTemporaryRedirect)
+		case strings.HasPrefix(r.URL.Path, "/same"):
+			http.Redirect(w, r, "/target", http.StatusTemporaryRedirect)
+		default:
+			mutex.Lock()
+			endpointHeader = r.Header.Clone()
+			endpointHeader.Set("X-Url", r.URL.String())
+			mutex.Unlock()
+			fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+		}
+	}))
+
+	headers := func() (http.Header, http.Header) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return endpointHeader, otherHeader
+	}
+	return endpoint.URL, headers, func() {
+		endpoint.Close()
+		other.Close()
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	RegisterTestingT(t)
+
+	var valid atomic.Value
+	valid.Store("token-1")
+	server, received := newTokenServer(&valid)
+	defer server.Close()
+
+	var calls []bool
+	tokens := 0
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		BearerToken: &BearerTokenOpts{
+			Provider: func(ctx context.Context) (string, error) {
+				calls = append(calls, IsTokenRefresh(ctx))
+				tokens++
+				return fmt.Sprintf("token-%v", tokens), nil
+			},
+		},
+	})
+
+	_, err := rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("second")
+	Expect(err).To(BeNil())
+
+	// the token expired, a new token is fetched and the request is sent again
+	valid.Store("token-2")
+	_, err = rpcClient.Call("third")
+	Expect(err).To(BeNil())
+
+	Expect(calls).To(Equal([]bool{false, true}))
+	Expect(received()).To(Equal([]string{"Bearer token-1", "Bearer token-1", "Bearer token-1", "Bearer token-2"}))
+
+	// the new token is rejected as well
+	valid.Store("other")
+	_, err = rpcClient.Call("fourth")
+	Expect(err).To(HaveOccurred())
+	var httpErr *HTTPError
+	Expect(errors.As(err, &httpErr)).To(BeTrue())
+	Expect(httpErr.Code).To(Equal(http.StatusUnauthorized))
+}
+
+func TestBearerTokenConcurrent(t *testing.T) {
+	RegisterTestingT(t)
+
+	var valid atomic.Value
+	valid.Store("token")
+	server, _ := newTokenServer(&valid)
+	defer server.Close()
+
+	var fetched int32
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		BearerToken: &BearerTokenOpts{
+			Provider: func(ctx context.Context) (string, error) {
+				atomic.AddInt32(&fetched, 1)
+				time.Sleep(20 * time.Millisecond)
+				return "token", nil
+			},
+		},
+	})
+
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := rpcClient.Call("something")
+			errs <- err
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		Expect(<-errs).To(BeNil())
+	}
+	Expect(atomic.LoadInt32(&fetched)).To(Equal(int32(1)))
+}
+
+func TestBearerTokenTTL(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Unix(1000, 0)
+	tokens := 0
+	cache := &tokenCache{
+		provider: func(ctx context.Context) (string, error) {
+			tokens++
+			return fmt.Sprintf("token-%v", tokens), nil
+		},
+		ttl: time.Minute,
+		now: func() time.Time { return now },
+	}
+
+	Expect(cache.get(context.Background(), "")).To(Equal("token-1"))
+	now = now.Add(30 * time.Second)
+	Expect(cache.get(context.Background(), "")).To(Equal("token-1"))
+	now = now.Add(30 * time.Second)
+	Expect(cache.get(context.Background(), "")).To(Equal("token-2"))
+	// a token that was already replaced is not fetched again
+	Expect(cache.get(context.Background(), "token-1")).To(Equal("token-2"))
+
+	cache.ttl = -1
+	Expect(cache.get(context.Background(), "")).To(Equal("token-3"))
+	Expect(cache.get(context.Background(), "")).To(Equal("token-4"))
+}
+
+func TestBearerTokenError(t *testing.T) {
+	RegisterTestingT(t)
+
+	var valid atomic.Value
+	valid.Store("token")
+	server, received := newTokenServer(&valid)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		BearerToken: &BearerTokenOpts{
+			Provider: func(ctx context.Context) (string, error) {
+				return "", errors.New("no token")
+			},
+		},
+	})
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("bearer token: no token"))
+	Expect(received()).To(BeEmpty())
+}
+
+func TestBearerTokenRedirect(t *testing.T) {
+	RegisterTestingT(t)
+
+	endpoint, headers, closeServers := newRedirectServers()
+	defer closeServers()
+
+	opts := &RPCClientOpts{
+		BearerToken: &BearerTokenOpts{
+			Provider: func(ctx context.Context) (string, error) {
+				return "secret-token", nil
+			},
+		},
+	}
+	_, err := NewClientWithOpts(endpoint+"/same", opts).Call("something")
+	Expect(err).To(BeNil())
+	_, err = NewClientWithOpts(endpoint+"/other", opts).Call("something")
+	Expect(err).To(BeNil())
+
+	endpointHeader, otherHeader := headers()
+	Expect(endpointHeader.Get("Authorization")).To(Equal("Bearer secret-token"))
+	Expect(otherHeader).NotTo(BeNil())
+	Expect(otherHeader.Get("Authorization")).To(BeEmpty())
+}
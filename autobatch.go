@@ -0,0 +1,216 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAutoBatchWindow  = 5 * time.Millisecond
+	defaultAutoBatchMaxSize = 100
+)
+
+// AutoBatchOpts configures the coalescing of concurrent calls into batch requests, see RPCClientOpts.
+//
+// A call starts a batching window, the calls of all goroutines during the window are sent as a single batch request
+// and every caller gets the response of its call. A window with a single call sends it as a normal request.
+// Only Call(), CallNamed() and CallFor() are batched, and only if the context has no per-call options
+// (WithHeaders(), WithEndpoint(), WithEndpointVars(), WithCallInfo(), WithIdempotencyKey() or WithCorrelationID()).
+//
+// Window: how long calls are collected before the batch is sent (default 5ms)
+//
+// MaxSize: the batch is sent as soon as it has MaxSize calls (default 100)
+//
+// Methods: the method names or patterns as used by path.Match() whose calls are batched, all methods if empty
+type AutoBatchOpts struct {
+	Window  time.Duration
+	MaxSize int
+	Methods []string
+}
+
+// autoBatcher coalesces calls into batch requests.
+type autoBatcher struct {
+	client  *rpcClient
+	window  time.Duration
+	maxSize int
+	methods []string
+
+	mutex   sync.Mutex
+	pending *pendingBatch
+}
+
+// pendingBatch is a batch that collects calls, its fields are guarded by the mutex of the autoBatcher.
+type pendingBatch struct {
+	calls   []*batchedCall
+	timer   *time.Timer
+	sent    bool
+	waiting int
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// batchedCall is a call of a batch, done is closed when response and err are set.
+type batchedCall struct {
+	request  *RPCRequest
+	done     chan struct{}
+	response *RPCResponse
+	err      error
+}
+
+func newAutoBatcher(client *rpcClient, opts *AutoBatchOpts) *autoBatcher {
+	if opts == nil {
+		return nil
+	}
+
+	b := &autoBatcher{
+		client:  client,
+		window:  opts.Window,
+		maxSize: opts.MaxSize,
+		methods: opts.Methods,
+	}
+	if b.window <= 0 {
+		b.window = defaultAutoBatchWindow
+	}
+	if b.maxSize <= 0 {
+		b.maxSize = defaultAutoBatchMaxSize
+	}
+	return b
+}
+
+// batches returns true if the call of request with ctx is batched.
+func (b *autoBatcher) batches(ctx context.Context, request *RPCRequest) bool {
+	if contextHeaders(ctx) != nil || endpointKey(ctx) != "" || idempotencyKey(ctx) != "" || correlationID(ctx) != "" {
+		return false
+	}
+	if _, ok := ctx.Value(callInfoContextKey{}).(*CallInfo); ok {
+		return false
+	}
+	if !b.client.disableValidation && request.Validate() != nil {
+		// an invalid request would fail the whole batch, it fails alone instead
+		return false
+	}
+
+	if len(b.methods) == 0 {
+		return true
+	}
+	for _, pattern := range b.methods {
+		if ok, err := path.Match(pattern, request.Method); pattern == request.Method || (err == nil && ok) {
+			return true
+		}
+	}
+	return false
+}
+
+// call adds request to the pending batch and returns its response when the batch was sent.
+func (b *autoBatcher) call(ctx context.Context, request *RPCRequest) (*RPCResponse, error) {
+	c := &batchedCall{request: request, done: make(chan struct{})}
+
+	b.mutex.Lock()
+	batch := b.pending
+	if batch == nil {
+		batch = &pendingBatch{}
+		batch.ctx, batch.cancel = context.WithCancel(context.Background())
+		batch.timer = time.AfterFunc(b.window, func() { b.flush(batch) })
+		b.pending = batch
+	}
+	batch.calls = append(batch.calls, c)
+	batch.waiting++
+	full := len(batch.calls) >= b.maxSize
+	if full {
+		// following calls start a new batch
+		b.pending = nil
+	}
+	b.mutex.Unlock()
+
+	if full {
+		batch.timer.Stop()
+		go b.flush(batch)
+	}
+
+	select {
+	case <-c.done:
+		return c.response, c.err
+	case <-ctx.Done():
+		b.leave(batch)
+		return nil, &TransportError{err: fmt.Errorf("rpc call %v: %w", b.client.describeCall(request), ctx.Err())}
+	}
+}
+
+// leave is called if a caller does not wait for its response anymore, the batch is canceled if no caller waits.
+func (b *autoBatcher) leave(batch *pendingBatch) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	batch.waiting--
+	if batch.waiting > 0 {
+		return
+	}
+
+	batch.cancel()
+	if !batch.sent {
+		// nobody waits for the responses, following calls start a new batch
+		batch.sent = true
+		batch.timer.Stop()
+		if b.pending == batch {
+			b.pending = nil
+		}
+	}
+}
+
+// flush sends batch unless it was sent already.
+func (b *autoBatcher) flush(batch *pendingBatch) {
+	b.mutex.Lock()
+	if batch.sent {
+		b.mutex.Unlock()
+		return
+	}
+	batch.sent = true
+	if b.pending == batch {
+		b.pending = nil
+	}
+	calls := batch.calls
+	b.mutex.Unlock()
+
+	defer batch.cancel()
+	b.send(batch.ctx, calls)
+}
+
+// send sends the calls as batch, or as a normal request if there is only one call, and sets their responses.
+func (b *autoBatcher) send(ctx context.Context, calls []*batchedCall) {
+	defer func() {
+		for _, c := range calls {
+			close(c.done)
+		}
+	}()
+
+	if len(calls) == 1 {
+		calls[0].response, calls[0].err = b.client.generateIDAndCall(ctx, calls[0].request)
+		return
+	}
+
+	requests := make(RPCRequests, len(calls))
+	for i, c := range calls {
+		requests[i] = c.request
+	}
+	err := b.client.prepareBatch(requests)
+	var responses RPCResponses
+	if err == nil {
+		responses, err = b.client.doBatchCall(ctx, requests)
+	}
+	if err != nil {
+		for _, c := range calls {
+			c.err = err
+		}
+		return
+	}
+
+	result := NewBatchResult(requests, responses)
+	for _, c := range calls {
+		c.response = result.ByRequest(c.request)
+		if c.response == nil {
+			c.err = fmt.Errorf("rpc call %v: %w", b.client.describeCall(c.request), ErrMissingResponse)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newAutoBatchServer returns a server that echoes the first param of each request and records the size of each
+// http request, 0 for a single request.
+func newAutoBatchServer() (*httptest.Server, func() []int) {
+	var mutex sync.Mutex
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var requests []*RPCRequest
+		if err := json.Unmarshal(body, &requests); err != nil {
+			var request *RPCRequest
+			json.Unmarshal(body, &request)
+			mutex.Lock()
+			sizes = append(sizes, 0)
+			mutex.Unlock()
+			json.NewEncoder(w).Encode(&RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Result: request.Params.([]interface{})[0]})
+			return
+		}
+
+		mutex.Lock()
+		sizes = append(sizes, len(requests))
+		mutex.Unlock()
+		responses := RPCResponses{}
+		for _, request := range requests {
+			switch request.Method {
+			case "missing":
+			case "fail":
+				responses = append(responses, &RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Error: &RPCError{Code: 1, Message: "failed"}})
+			default:
+				responses = append(responses, &RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Result: request.Params.([]interface{})[0]})
+			}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	return server, func() []int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]int(nil), sizes...)
+	}
+}
+
+func TestAutoBatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, sizes := newAutoBatchServer()
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{AutoBatch: &AutoBatchOpts{Window: 50 * time.Millisecond}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var result int
+			Expect(rpcClient.CallFor(&result, "echo", i)).To(Succeed())
+			Expect(result).To(Equal(i))
+		}(i)
+	}
+	wg.Wait()
+	Expect(sizes()).To(Equal([]int{10}))
+
+	// a single call is sent as normal request
+	var result int
+	Expect(rpcClient.CallFor(&result, "echo", 1)).To(Succeed())
+	Expect(sizes()).To(Equal([]int{10, 0}))
+
+	// errors of single calls of a batch
+	errs := make([]error, 3)
+	for i, method := range []string{"echo", "fail", "missing"} {
+		wg.Add(1)
+		go func(i int, method string) {
+			defer wg.Done()
+			errs[i] = rpcClient.CallFor(&result, method, i)
+		}(i, method)
+	}
+	wg.Wait()
+	Expect(errs[0]).To(BeNil())
+	Expect(errs[1]).To(Equal(&RPCError{Code: 1, Message: "failed"}))
+	Expect(errors.Is(errs[2], ErrMissingResponse)).To(BeTrue())
+	Expect(sizes()).To(Equal([]int{10, 0, 3}))
+}
+
+func TestAutoBatch_MaxSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, sizes := newAutoBatchServer()
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{AutoBatch: &AutoBatchOpts{Window: time.Minute, MaxSize: 3}})
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := rpcClient.Call("echo", i)
+			Expect(err).To(BeNil())
+		}(i)
+	}
+	wg.Wait()
+	Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	Expect(sizes()).To(Equal([]int{3, 3}))
+}
+
+func TestAutoBatch_NotBatched(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, sizes := newAutoBatchServer()
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{AutoBatch: &AutoBatchOpts{Window: 20 * time.Millisecond, Methods: []string{"eth_*"}}})
+
+	contexts := []context.Context{
+		WithHeaders(context.Background(), http.Header{"X-Tenant": {"a"}}),
+		WithEndpoint(context.Background(), server.URL),
+		WithCallInfo(context.Background(), &CallInfo{}),
+		WithIdempotencyKey(context.Background(), "key"),
+		WithCorrelationID(context.Background(), "id"),
+	}
+	var wg sync.WaitGroup
+	for i, ctx := range contexts {
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			_, err := rpcClient.CallContext(ctx, "eth_call", i)
+			Expect(err).To(BeNil())
+		}(i, ctx)
+	}
+	// methods that are not batched
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := rpcClient.Call("other", 1)
+		Expect(err).To(BeNil())
+	}()
+	wg.Wait()
+	Expect(sizes()).To(Equal([]int{0, 0, 0, 0, 0, 0}))
+}
+
+func TestAutoBatch_Cancel(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, sizes := newAutoBatchServer()
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{AutoBatch: &AutoBatchOpts{Window: 100 * time.Millisecond}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var wg sync.WaitGroup
+	var result int
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := rpcClient.CallContext(ctx, "echo", 1)
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	}()
+	go func() {
+		defer wg.Done()
+		var result int
+		Expect(rpcClient.CallFor(&result, "echo", 2)).To(Succeed())
+		Expect(result).To(Equal(2))
+	}()
+	wg.Wait()
+	Expect(sizes()).To(Equal([]int{2}))
+
+	// a batch without waiting callers is canceled
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := rpcClient.CallContext(ctx, "echo", 1)
+	Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	time.Sleep(150 * time.Millisecond)
+	Expect(sizes()).To(Equal([]int{2}))
+
+	// following calls start a new batch
+	Expect(rpcClient.CallFor(&result, "echo", 3)).To(Succeed())
+	Expect(result).To(Equal(3))
+}
@@ -0,0 +1,118 @@
+package jsonrpc
+
+import (
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// EndpointState is the state of an endpoint that is passed to a Balancer.
+//
+// Endpoint: the URL of the endpoint
+//
+// Healthy: false while the endpoint is in cooldown after a failed request or its last health check failed
+//
+// Pending: the number of requests to the endpoint that are in flight
+//
+// Latency: the moving average of the response time of the endpoint, 0 until the first response
+//
+// LastErr: the error of the last failed request or health check, nil if there was none
+type EndpointState struct {
+	Endpoint string
+	Healthy  bool
+	Pending  int
+	Latency  time.Duration
+	LastErr  error
+}
+
+// Balancer spreads the requests of a client with multiple endpoints across the endpoints.
+//
+// Order is called for every request with the state of all endpoints and returns
+// the indexes of the endpoints in the order they are tried. It must return every index once
+// and must be safe for concurrent use. Endpoints in cooldown are moved to the end by the client.
+type Balancer interface {
+	Order(endpoints []EndpointState) []int
+}
+
+// BalancerFunc is an adapter to use a function as Balancer.
+type BalancerFunc func(endpoints []EndpointState) []int
+
+// Order calls f(endpoints).
+func (f BalancerFunc) Order(endpoints []EndpointState) []int {
+	return f(endpoints)
+}
+
+// RoundRobinBalancer returns a Balancer that sends the requests to the endpoints in turn.
+func RoundRobinBalancer() Balancer {
+	var next uint32
+	return BalancerFunc(func(endpoints []EndpointState) []int {
+		return rotated(len(endpoints), atomic.AddUint32(&next, 1)-1)
+	})
+}
+
+// WeightedBalancer returns a Balancer that chooses the endpoints randomly in proportion to their weights.
+// weights are given in the order of the endpoints, missing or non-positive weights count as 1.
+func WeightedBalancer(weights ...int) Balancer {
+	return BalancerFunc(func(endpoints []EndpointState) []int {
+		remaining := make([]int, len(endpoints))
+		total := 0
+		for i := range remaining {
+			remaining[i] = 1
+			if i < len(weights) && weights[i] > 0 {
+				remaining[i] = weights[i]
+			}
+			total += remaining[i]
+		}
+
+		order := make([]int, 0, len(endpoints))
+		for len(order) < len(endpoints) {
+			n := rand.Intn(total)
+			for i, weight := range remaining {
+				if n < weight {
+					order = append(order, i)
+					total -= weight
+					remaining[i] = 0
+					break
+				}
+				n -= weight
+			}
+		}
+
+		return order
+	})
+}
+
+// LeastPendingBalancer returns a Balancer that prefers the endpoints with the fewest requests in flight.
+// Endpoints with the same number of pending requests are used in turn.
+func LeastPendingBalancer() Balancer {
+	var next uint32
+	return BalancerFunc(func(endpoints []EndpointState) []int {
+		order := rotated(len(endpoints), atomic.AddUint32(&next, 1)-1)
+		sort.SliceStable(order, func(i, j int) bool {
+			return endpoints[order[i]].Pending < endpoints[order[j]].Pending
+		})
+		return order
+	})
+}
+
+// LowestLatencyBalancer returns a Balancer that prefers the endpoints with the lowest average response time.
+// Endpoints without a response time yet are preferred, so that every endpoint is measured.
+func LowestLatencyBalancer() Balancer {
+	return BalancerFunc(func(endpoints []EndpointState) []int {
+		order := rotated(len(endpoints), 0)
+		sort.SliceStable(order, func(i, j int) bool {
+			return endpoints[order[i]].Latency < endpoints[order[j]].Latency
+		})
+		return order
+	})
+}
+
+// rotated returns the indexes 0..n-1 starting at start modulo n.
+func rotated(n int, start uint32) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = int((uint(start) + uint(i)) % uint(n))
+	}
+	return order
+}
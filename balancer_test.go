@@ -0,0 +1,94 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRoundRobinBalancer(t *testing.T) {
+	RegisterTestingT(t)
+
+	balancer := RoundRobinBalancer()
+	endpoints := make([]EndpointState, 3)
+	Expect(balancer.Order(endpoints)).To(Equal([]int{0, 1, 2}))
+	Expect(balancer.Order(endpoints)).To(Equal([]int{1, 2, 0}))
+	Expect(balancer.Order(endpoints)).To(Equal([]int{2, 0, 1}))
+	Expect(balancer.Order(endpoints)).To(Equal([]int{0, 1, 2}))
+}
+
+func TestWeightedBalancer(t *testing.T) {
+	RegisterTestingT(t)
+
+	balancer := WeightedBalancer(8, 0, 1)
+	endpoints := make([]EndpointState, 3)
+	first := make([]int, 3)
+	for i := 0; i < 1000; i++ {
+		order := balancer.Order(endpoints)
+		Expect(order).To(ConsistOf(0, 1, 2))
+		first[order[0]]++
+	}
+
+	// weights 8:1:1
+	Expect(first[0]).To(BeNumerically(">", 700))
+	Expect(first[1]).To(BeNumerically(">", 30))
+	Expect(first[2]).To(BeNumerically(">", 30))
+}
+
+func TestLeastPendingBalancer(t *testing.T) {
+	RegisterTestingT(t)
+
+	balancer := LeastPendingBalancer()
+	endpoints := []EndpointState{{Pending: 2}, {Pending: 0}, {Pending: 1}}
+	Expect(balancer.Order(endpoints)).To(Equal([]int{1, 2, 0}))
+
+	// ties are used in turn
+	endpoints = []EndpointState{{Pending: 1}, {Pending: 0}, {Pending: 0}}
+	Expect(balancer.Order(endpoints)).To(Equal([]int{1, 2, 0}))
+	Expect(balancer.Order(endpoints)).To(Equal([]int{2, 1, 0}))
+}
+
+func TestLowestLatencyBalancer(t *testing.T) {
+	RegisterTestingT(t)
+
+	balancer := LowestLatencyBalancer()
+	endpoints := []EndpointState{{Latency: 20 * time.Millisecond}, {Latency: 10 * time.Millisecond}, {Latency: 30 * time.Millisecond}}
+	Expect(balancer.Order(endpoints)).To(Equal([]int{1, 0, 2}))
+
+	// endpoints without measurements are tried first
+	endpoints[2].Latency = 0
+	Expect(balancer.Order(endpoints)).To(Equal([]int{2, 1, 0}))
+}
+
+func TestFailoverTransport_Balancer(t *testing.T) {
+	RegisterTestingT(t)
+
+	var states []EndpointState
+	balancer := BalancerFunc(func(endpoints []EndpointState) []int {
+		states = endpoints
+		return []int{1, 0}
+	})
+
+	now := time.Now()
+	endpoint := func(name string, latency time.Duration) endpointTransport {
+		return endpointTransport{name: name, transport: transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+			now = now.Add(latency)
+			return []byte(name), nil
+		})}
+	}
+
+	transport := newTestFailoverTransport([]endpointTransport{endpoint("a", 0), endpoint("b", 10*time.Millisecond)}, FailoverOpts{Balancer: balancer})
+	transport.now = func() time.Time { return now }
+
+	response, err := transport.Send(context.Background(), []byte(`{}`))
+	Expect(err).To(BeNil())
+	Expect(string(response)).To(Equal("b"))
+	Expect(states).To(Equal([]EndpointState{{Endpoint: "a", Healthy: true}, {Endpoint: "b", Healthy: true}}))
+
+	_, err = transport.Send(context.Background(), []byte(`{}`))
+	Expect(err).To(BeNil())
+	Expect(states[1].Latency).To(Equal(10 * time.Millisecond))
+	Expect(states[1].Pending).To(Equal(0))
+}
@@ -0,0 +1,282 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchBuilder collects calls that are sent as a single batch and decodes each response into the result of its call.
+//
+// Create it with NewBatchBuilder(), e.g.
+//
+//	var balance, nonce string
+//	err := jsonrpc.NewBatchBuilder(rpcClient).
+//		Add("eth_getBalance", &balance, address, "latest").
+//		Add("eth_getTransactionCount", &nonce, address, "latest").
+//		Execute(ctx)
+//
+// A BatchBuilder is not safe for concurrent use.
+type BatchBuilder struct {
+	client   Client
+	requests RPCRequests
+	results  []interface{}
+}
+
+// NewBatchBuilder returns an empty BatchBuilder that sends its batch with client.
+func NewBatchBuilder(client Client) *BatchBuilder {
+	return &BatchBuilder{client: client}
+}
+
+// Add adds a call of method with params like Call() to the batch. The result of the response is decoded into out
+// like CallFor(), it is not decoded if out is nil.
+func (b *BatchBuilder) Add(method string, out interface{}, params ...interface{}) *BatchBuilder {
+	return b.AddRequest(NewRequest(method, params...), out)
+}
+
+// AddNotification adds a notification of method with params to the batch, see NewNotification().
+// Notifications have no response, so they do not fail.
+func (b *BatchBuilder) AddNotification(method string, params ...interface{}) *BatchBuilder {
+	return b.AddRequest(NewNotification(method, params...), nil)
+}
+
+// AddRequest adds request to the batch, see Add().
+func (b *BatchBuilder) AddRequest(request *RPCRequest, out interface{}) *BatchBuilder {
+	b.requests = append(b.requests, request)
+	b.results = append(b.results, out)
+	return b
+}
+
+// Len returns the number of calls of the batch.
+func (b *BatchBuilder) Len() int {
+	return len(b.requests)
+}
+
+// Execute sends the calls as batch with CallBatchContext() and decodes the responses into the results of the calls.
+//
+// If the batch could not be sent, the error of CallBatchContext() is returned.
+// If some calls failed, a *BatchError with the error of each call is returned: the *RPCError of its response,
+// ErrMissingResponse if the server sent no response for it, or the error of decoding the result.
+func (b *BatchBuilder) Execute(ctx context.Context) error {
+	responses, err := b.client.CallBatchContext(ctx, b.requests)
+	if err != nil {
+		return err
+	}
+
+	result := NewBatchResult(b.requests, responses)
+	errs := make([]error, len(b.requests))
+	failed := 0
+	for i, response := range result.Responses() {
+		switch {
+		case b.requests[i].Notification:
+		case response == nil:
+			errs[i] = ErrMissingResponse
+		case response.Error != nil:
+			errs[i] = response.Error
+		case b.results[i] != nil:
+			errs[i] = response.GetObject(b.results[i])
+		}
+		if errs[i] != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return &BatchError{Errors: errs, failed: failed}
+	}
+	return nil
+}
+
+// BatchError is returned by BatchBuilder.Execute() if some calls of the batch failed.
+//
+// Errors: the error of each call in the order they were added, nil if the call succeeded
+type BatchError struct {
+	Errors []error
+	failed int
+}
+
+func (e *BatchError) Error() string {
+	for i, err := range e.Errors {
+		if err != nil {
+			return fmt.Sprintf("rpc batch call: %v of %v calls failed, call %v: %v", e.failed, len(e.Errors), i, err)
+		}
+	}
+	return "rpc batch call: no calls failed"
+}
+
+// Err returns the error of the call at index i, nil if it succeeded.
+func (e *BatchError) Err(i int) error {
+	if i < 0 || i >= len(e.Errors) {
+		return nil
+	}
+	return e.Errors[i]
+}
+
+// BatchResult looks up the responses of a batch by request. Servers may send the responses of a batch in any order,
+// so the position of a response does not identify its request.
+//
+// Create it with NewBatchResult(), e.g.
+//
+//	responses, err := rpcClient.CallBatch(requests)
+//	result := jsonrpc.NewBatchResult(requests, responses)
+//	balance := result.ByRequest(requests[0])
+type BatchResult struct {
+	requests  RPCRequests
+	responses map[ID]*RPCResponse
+}
+
+// NewBatchResult returns the BatchResult of the responses of requests, which must have been sent with ids,
+// e.g. by CallBatch().
+func NewBatchResult(requests RPCRequests, responses RPCResponses) *BatchResult {
+	byID := make(map[ID]*RPCResponse, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			byID[response.ID] = response
+		}
+	}
+	return &BatchResult{requests: requests, responses: byID}
+}
+
+// ByID returns the response with id, nil if there is none.
+func (r *BatchResult) ByID(id ID) *RPCResponse {
+	return r.responses[id]
+}
+
+// ByRequest returns the response of request, nil if there is none, request is a notification
+// or request is not part of the batch.
+func (r *BatchResult) ByRequest(request *RPCRequest) *RPCResponse {
+	for _, req := range r.requests {
+		if req == request && !request.Notification {
+			return r.responses[request.ID]
+		}
+	}
+	return nil
+}
+
+// Responses returns the responses in the order of the requests, nil for a notification or a request without response.
+func (r *BatchResult) Responses() RPCResponses {
+	responses := make(RPCResponses, len(r.requests))
+	for i, request := range r.requests {
+		if request != nil && !request.Notification {
+			responses[i] = r.responses[request.ID]
+		}
+	}
+	return responses
+}
+
+// orderResponses returns the responses in the order of the requests, nil for a notification or a request without
+// response. Responses that belong to no request (e.g. an error with null id) follow the responses of the requests.
+func orderResponses(requests RPCRequests, responses RPCResponses) RPCResponses {
+	result := NewBatchResult(requests, responses)
+	ordered := result.Responses()
+	used := make(map[*RPCResponse]bool, len(ordered))
+	for _, response := range ordered {
+		if response != nil {
+			used[response] = true
+		}
+	}
+	for _, response := range responses {
+		if response != nil && !used[response] {
+			ordered = append(ordered, response)
+		}
+	}
+	return ordered
+}
+
+// Missing returns the requests without response, notifications are not missing.
+func (r *BatchResult) Missing() RPCRequests {
+	var missing RPCRequests
+	for _, request := range r.requests {
+		if request != nil && !request.Notification && r.responses[request.ID] == nil {
+			missing = append(missing, request)
+		}
+	}
+	return missing
+}
+
+// batchChunks splits requests into chunks of at most size requests, a single chunk if size <= 0.
+func batchChunks(requests []*RPCRequest, size int) [][]*RPCRequest {
+	if size <= 0 || len(requests) <= size {
+		return [][]*RPCRequest{requests}
+	}
+
+	chunks := make([][]*RPCRequest, 0, (len(requests)+size-1)/size)
+	for len(requests) > size {
+		chunks = append(chunks, requests[:size:size])
+		requests = requests[size:]
+	}
+	return append(chunks, requests)
+}
+
+// sendBatchChunks sends the chunks of a batch with up to BatchParallelism chunks at a time and merges their responses
+// in the order of the chunks. If a chunk fails or progress aborts the batch, the other chunks are canceled
+// and the error is returned.
+func (client *rpcClient) sendBatchChunks(ctx context.Context, chunks [][]*RPCRequest, progress *batchProgress) ([]*RPCResponse, error) {
+	parallelism := client.batchParallelism
+	if parallelism <= 1 {
+		var responses []*RPCResponse
+		for _, chunk := range chunks {
+			chunkResponses, err := client.sendBatchChunk(ctx, chunk)
+			if err != nil {
+				return nil, err
+			}
+			if err := progress.chunkDone(len(chunk), chunkResponses); err != nil {
+				return nil, err
+			}
+			responses = append(responses, chunkResponses...)
+		}
+		return responses, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]*RPCResponse, len(chunks))
+	semaphore := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i, chunk := range chunks {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, chunk []*RPCRequest) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			responses, err := client.sendBatchChunk(ctx, chunk)
+			if err == nil {
+				err = progress.chunkDone(len(chunk), responses)
+			}
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = responses
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		// the context of the caller is done
+		return nil, &TransportError{err: fmt.Errorf("rpc batch call%v: %w", describeCorrelationID(ctx), err)}
+	}
+
+	var responses []*RPCResponse
+	for _, chunkResponses := range results {
+		responses = append(responses, chunkResponses...)
+	}
+	return responses, nil
+}
@@ -0,0 +1,203 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+func (client *rpcClient) CallBatchStream(ctx context.Context, requests RPCRequests, onResponse func(*RPCResponse)) error {
+	if err := client.prepareBatch(requests); err != nil {
+		return err
+	}
+
+	ctx = client.withCorrelationID(ctx)
+	if !client.observed(ctx) {
+		return client.sendBatchStreamChunks(ctx, requests, onResponse)
+	}
+
+	call := &CallInfo{Requests: requests, Batch: true}
+	return client.observe(ctx, call, func(ctx context.Context) error {
+		return client.sendBatchStreamChunks(ctx, requests, func(response *RPCResponse) {
+			call.Responses = append(call.Responses, response)
+			onResponse(response)
+		})
+	})
+}
+
+// sendBatchStreamChunks sends a batch request, split into chunks of at most MaxBatchSize requests that are sent
+// one after the other, and calls onResponse for each response as it arrives.
+func (client *rpcClient) sendBatchStreamChunks(ctx context.Context, requests RPCRequests, onResponse func(*RPCResponse)) error {
+	if err := client.validateBatch(requests); err != nil {
+		return err
+	}
+
+	chunks := batchChunks(requests, client.maxBatchSize)
+	progress := newBatchProgress(ctx, len(requests), len(chunks))
+	for _, chunk := range chunks {
+		var responses []*RPCResponse
+		err := client.sendBatchStream(ctx, chunk, func(response *RPCResponse) {
+			if progress != nil {
+				responses = append(responses, response)
+			}
+			onResponse(response)
+		})
+		if err != nil {
+			return err
+		}
+		if err := progress.chunkDone(len(chunk), responses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendBatchStream sends a batch request and calls onResponse for each response as it arrives.
+func (client *rpcClient) sendBatchStream(ctx context.Context, requests RPCRequests, onResponse func(*RPCResponse)) error {
+	prefix := "rpc batch call" + describeCorrelationID(ctx)
+	body, err := client.encodeBatch(requests)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, retry := client.policy(ctx, batchMethods(requests)...)
+	defer cancel()
+
+	responseBody, err := client.sendStream(ctx, body, retry)
+	var httpErr *HTTPError
+	if err != nil && !errors.As(err, &httpErr) {
+		if responseBody != nil {
+			responseBody.Close()
+		}
+		return &TransportError{err: fmt.Errorf("%v: %w", prefix, err)}
+	}
+	if !expectsResponse(requests) {
+		// the server does not respond to notifications
+		if responseBody != nil {
+			responseBody.Close()
+		}
+		if httpErr != nil {
+			return httpErr
+		}
+		return nil
+	}
+	if responseBody == nil {
+		return responseError(prefix, httpErr, ErrMissingResponse)
+	}
+	defer responseBody.Close()
+	if client.maxBatchResponseSize > 0 {
+		responseBody = newLimitedReader(responseBody, client.maxBatchResponseSize)
+	}
+
+	count, err := client.decodeResponseStream(responseBody, onResponse)
+	if err != nil {
+		if count == 0 {
+			return responseError(prefix, httpErr, fmt.Errorf("could not decode body to rpc response: %w", err))
+		}
+		// the stream broke after some responses were received
+		return &TransportError{err: fmt.Errorf("%v: could not decode body to rpc response: %w", prefix, err)}
+	}
+	if count == 0 {
+		return responseError(prefix, httpErr, ErrMissingResponse)
+	}
+
+	return nil
+}
+
+// sendStream is like send() but returns the response body as stream if the transport supports it.
+func (client *rpcClient) sendStream(ctx context.Context, body []byte, retry *RetryPolicy) (io.ReadCloser, error) {
+	if retry == nil {
+		recordAttempt(ctx)
+		return sendStream(ctx, client.transport, body)
+	}
+
+	var responseBody io.ReadCloser
+	err := retry.do(ctx, func() error {
+		recordAttempt(ctx)
+		if responseBody != nil {
+			// the body of a failed attempt
+			responseBody.Close()
+		}
+		var err error
+		responseBody, err = sendStream(ctx, client.transport, body)
+		return err
+	})
+
+	return responseBody, err
+}
+
+// decodeResponseStream decodes the responses from r as they arrive and calls onResponse for each.
+// r may hold a JSON array of responses or a sequence of responses (e.g. newline-delimited JSON).
+// Returns the number of decoded responses.
+func (client *rpcClient) decodeResponseStream(r io.Reader, onResponse func(*RPCResponse)) (int, error) {
+	decoder := json.NewDecoder(r)
+
+	count := 0
+	decode := func() error {
+		var message json.RawMessage
+		if err := decoder.Decode(&message); err != nil {
+			return err
+		}
+
+		var response *RPCResponse
+		if err := client.decodeResponse(bytes.NewReader(message), &response); err != nil {
+			return err
+		}
+		if response != nil {
+			count++
+			onResponse(response)
+		}
+		return nil
+	}
+
+	// a JSON array is read element by element
+	for {
+		token, err := peekDelim(decoder)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if token != '[' {
+			if err := decode(); err != nil {
+				return count, err
+			}
+			continue
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			return count, err
+		}
+		for decoder.More() {
+			if err := decode(); err != nil {
+				return count, err
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return count, err
+		}
+	}
+}
+
+// peekDelim returns the first byte of the next JSON value of the decoder without consuming it.
+// Returns io.EOF at the end of the stream.
+func peekDelim(decoder *json.Decoder) (byte, error) {
+	if !decoder.More() {
+		// end of stream, read error or a stray closing delimiter
+		_, err := decoder.Token()
+		if err == nil {
+			err = errors.New("unexpected end of array or object")
+		}
+		return 0, err
+	}
+
+	// More() skipped the whitespace, so the value starts at the first buffered byte
+	var b [1]byte
+	decoder.Buffered().Read(b[:])
+	return b[0], nil
+}
@@ -0,0 +1,109 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCallBatchStream(t *testing.T) {
+	RegisterTestingT(t)
+
+	// the server streams the responses as newline-delimited JSON, the second one is slow
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"jsonrpc":"2.0","result":"first","id":0}`)
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprintln(w, `{"jsonrpc":"2.0","result":"second","id":1}`)
+	}))
+	defer server.Close()
+
+	responses := make(chan *RPCResponse, 2)
+	done := make(chan error)
+	go func() {
+		done <- NewClient(server.URL).CallBatchStream(context.Background(), RPCRequests{
+			NewRequest("first"),
+			NewRequest("second"),
+		}, func(res *RPCResponse) {
+			responses <- res
+		})
+	}()
+
+	// the first response is delivered before the second one is sent
+	var res *RPCResponse
+	Eventually(responses).Should(Receive(&res))
+	Expect(res.Result).To(Equal("first"))
+	close(release)
+	Expect(<-done).To(BeNil())
+	Expect((<-responses).Result).To(Equal("second"))
+}
+
+func TestDecodeResponseStream(t *testing.T) {
+	RegisterTestingT(t)
+
+	client := &rpcClient{strictMode: true}
+	decode := func(body string) ([]interface{}, error) {
+		var results []interface{}
+		_, err := client.decodeResponseStream(strings.NewReader(body), func(res *RPCResponse) {
+			results = append(results, res.Result)
+		})
+		return results, err
+	}
+
+	results, err := decode(`[{"jsonrpc":"2.0","result":"a","id":0}, {"jsonrpc":"2.0","result":"b","id":1}]`)
+	Expect(err).To(BeNil())
+	Expect(results).To(Equal([]interface{}{"a", "b"}))
+
+	results, err = decode("{\"jsonrpc\":\"2.0\",\"result\":\"a\",\"id\":0}\n\n{\"jsonrpc\":\"2.0\",\"result\":\"b\",\"id\":1}\n")
+	Expect(err).To(BeNil())
+	Expect(results).To(Equal([]interface{}{"a", "b"}))
+
+	results, err = decode(``)
+	Expect(err).To(BeNil())
+	Expect(results).To(BeEmpty())
+
+	// responses before a broken one are delivered
+	results, err = decode(`{"jsonrpc":"2.0","result":"a","id":0} {"jsonrpc":"2.0","result":`)
+	Expect(err).NotTo(BeNil())
+	Expect(results).To(Equal([]interface{}{"a"}))
+
+	_, err = decode(`[{"jsonrpc":"2.0","result":"a","id":0}] ]`)
+	Expect(err).NotTo(BeNil())
+
+	// strict decoding applies to every response
+	_, err = decode(`{"jsonrpc":"2.0","result":"a","id":0,"unknown":1}`)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestCallBatchStream_Errors(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, "overloaded")
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL).CallBatchStream(context.Background(), RPCRequests{NewRequest("a")}, func(*RPCResponse) {})
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	// transports without streaming support deliver the responses after the complete body was received
+	rpcClient := NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		return []byte(`[{"jsonrpc":"2.0","result":"a","id":0}]`), nil
+	}), nil)
+	var results []interface{}
+	err = rpcClient.CallBatchStream(context.Background(), RPCRequests{NewRequest("a")}, func(res *RPCResponse) {
+		results = append(results, res.Result)
+	})
+	Expect(err).To(BeNil())
+	Expect(results).To(Equal([]interface{}{"a"}))
+
+}
@@ -0,0 +1,334 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newBatchServer returns a server that responds to the requests of a batch in reverse order with the response
+// of respond, requests without a response are skipped.
+func newBatchServer(respond func(request *RPCRequest) *RPCResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []*RPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&requests); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		responses := RPCResponses{}
+		for i := len(requests) - 1; i >= 0; i-- {
+			if response := respond(requests[i]); response != nil {
+				response.JSONRPC = jsonrpcVersion
+				response.ID = requests[i].ID
+				responses = append(responses, response)
+			}
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+}
+
+func TestBatchBuilder(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newBatchServer(func(request *RPCRequest) *RPCResponse {
+		switch request.Method {
+		case "fail":
+			return &RPCResponse{Error: &RPCError{Code: -32000, Message: "failed"}}
+		case "missing":
+			return nil
+		case "object":
+			return &RPCResponse{Result: map[string]interface{}{"name": "alex"}}
+		}
+		return &RPCResponse{Result: request.Params}
+	})
+	defer server.Close()
+
+	rpcClient := NewClient(server.URL)
+
+	var numbers []int
+	var name struct{ Name string }
+	var text []string
+	batch := NewBatchBuilder(rpcClient).
+		Add("echo", &numbers, 1, 2).
+		Add("object", &name).
+		Add("echo", nil, "ignored").
+		AddRequest(NewRequest("echo", "a"), &text)
+	Expect(batch.Len()).To(Equal(4))
+	Expect(batch.Execute(context.Background())).To(Succeed())
+	Expect(numbers).To(Equal([]int{1, 2}))
+	Expect(name.Name).To(Equal("alex"))
+	Expect(text).To(Equal([]string{"a"}))
+
+	// per call errors
+	var ok []string
+	var wrongType int
+	err := NewBatchBuilder(rpcClient).
+		Add("echo", &ok, "ok").
+		Add("fail", &ok).
+		Add("missing", &ok).
+		Add("echo", &wrongType, "no number").
+		Execute(context.Background())
+	Expect(ok).To(Equal([]string{"ok"}))
+
+	var batchErr *BatchError
+	Expect(errors.As(err, &batchErr)).To(BeTrue())
+	Expect(batchErr.Errors).To(HaveLen(4))
+	Expect(batchErr.Err(0)).To(BeNil())
+	Expect(batchErr.Err(1)).To(Equal(&RPCError{Code: -32000, Message: "failed"}))
+	Expect(batchErr.Err(2)).To(Equal(ErrMissingResponse))
+	Expect(batchErr.Err(3)).NotTo(BeNil())
+	Expect(batchErr.Err(4)).To(BeNil())
+	Expect(err.Error()).To(Equal("rpc batch call: 3 of 4 calls failed, call 1: -32000:failed"))
+
+	// the batch could not be sent
+	err = NewBatchBuilder(NewClient("http://127.0.0.1:1")).Add("echo", &ok).Execute(context.Background())
+	Expect(errors.As(err, &batchErr)).To(BeFalse())
+	Expect(err).NotTo(BeNil())
+
+	err = NewBatchBuilder(rpcClient).Execute(context.Background())
+	Expect(err).To(MatchError("empty request list"))
+}
+
+func TestBatchBuilder_IDGenerator(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newBatchServer(func(request *RPCRequest) *RPCResponse {
+		return &RPCResponse{Result: fmt.Sprint(request.Params)}
+	})
+	defer server.Close()
+
+	next := 100
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{IDGenerator: IDGeneratorFunc(func() (ID, error) {
+		next++
+		return StringID(fmt.Sprint(next)), nil
+	})})
+
+	var a, b string
+	Expect(NewBatchBuilder(rpcClient).Add("echo", &a, 1).Add("echo", &b, 2).Execute(context.Background())).To(Succeed())
+	Expect(a).To(Equal("[1]"))
+	Expect(b).To(Equal("[2]"))
+}
+
+func TestBatchResult(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newBatchServer(func(request *RPCRequest) *RPCResponse {
+		if request.Method == "missing" {
+			return nil
+		}
+		return &RPCResponse{Result: request.Method}
+	})
+	defer server.Close()
+
+	requests := RPCRequests{NewRequest("a"), NewRequest("missing"), NewRequest("c")}
+	responses, err := NewClient(server.URL).CallBatch(requests)
+	Expect(err).To(BeNil())
+	// the server responds in reverse order
+	Expect(responses[0].Result).To(Equal("c"))
+
+	result := NewBatchResult(requests, responses)
+	Expect(result.ByRequest(requests[0]).Result).To(Equal("a"))
+	Expect(result.ByRequest(requests[1])).To(BeNil())
+	Expect(result.ByRequest(requests[2]).Result).To(Equal("c"))
+	Expect(result.ByRequest(NewRequest("a"))).To(BeNil())
+	Expect(result.ByID(NumberID(2)).Result).To(Equal("c"))
+	Expect(result.ByID(NumberID(5))).To(BeNil())
+
+	ordered := result.Responses()
+	Expect(ordered).To(HaveLen(3))
+	Expect(ordered[0].Result).To(Equal("a"))
+	Expect(ordered[1]).To(BeNil())
+	Expect(ordered[2].Result).To(Equal("c"))
+	Expect(result.Missing()).To(Equal(RPCRequests{requests[1]}))
+}
+
+func TestMaxBatchSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []*RPCRequest
+		json.NewDecoder(r.Body).Decode(&requests)
+		sizes = append(sizes, len(requests))
+		if requests[0].Method == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		responses := RPCResponses{}
+		for _, request := range requests {
+			responses = append(responses, &RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Result: request.Method})
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 2})
+
+	requests := RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c"), NewRequest("d"), NewRequest("e")}
+	call := &CallInfo{}
+	responses, err := rpcClient.CallBatchContext(WithCallInfo(context.Background(), call), requests)
+	Expect(err).To(BeNil())
+	Expect(sizes).To(Equal([]int{2, 2, 1}))
+	Expect(responses).To(HaveLen(5))
+	result := NewBatchResult(requests, responses)
+	for _, request := range requests {
+		Expect(result.ByRequest(request).Result).To(Equal(request.Method))
+	}
+	Expect(call.Responses).To(HaveLen(5))
+
+	// a failed chunk fails the batch
+	sizes = nil
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("fail")})
+	Expect(err).NotTo(BeNil())
+	Expect(sizes).To(Equal([]int{2, 1}))
+
+	// streamed batches
+	sizes = nil
+	var streamed []interface{}
+	err = rpcClient.CallBatchStream(context.Background(), RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c")}, func(response *RPCResponse) {
+		streamed = append(streamed, response.Result)
+	})
+	Expect(err).To(BeNil())
+	Expect(sizes).To(Equal([]int{2, 1}))
+	Expect(streamed).To(Equal([]interface{}{"a", "b", "c"}))
+}
+
+func TestBatchChunks(t *testing.T) {
+	RegisterTestingT(t)
+
+	requests := RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c")}
+	Expect(batchChunks(requests, 0)).To(HaveLen(1))
+	Expect(batchChunks(requests, 3)).To(HaveLen(1))
+	Expect(batchChunks(requests, 1)).To(Equal([][]*RPCRequest{requests[:1], requests[1:2], requests[2:]}))
+
+	chunks := batchChunks(requests, 2)
+	Expect(chunks).To(Equal([][]*RPCRequest{requests[:2], requests[2:]}))
+	// appending to a chunk does not modify the next one
+	chunks[0] = append(chunks[0], NewRequest("x"))
+	Expect(chunks[1][0].Method).To(Equal("c"))
+}
+
+func TestBatchParallelism(t *testing.T) {
+	RegisterTestingT(t)
+
+	var active, maxActive int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+
+		var requests []*RPCRequest
+		json.NewDecoder(r.Body).Decode(&requests)
+		switch requests[0].Method {
+		case "fail":
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		case "slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		default:
+			time.Sleep(20 * time.Millisecond)
+		}
+		responses := RPCResponses{}
+		for _, request := range requests {
+			responses = append(responses, &RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Result: request.Method})
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 1, BatchParallelism: 3})
+
+	var requests RPCRequests
+	for i := 0; i < 9; i++ {
+		requests = append(requests, NewRequest(fmt.Sprint(i)))
+	}
+	responses, err := rpcClient.CallBatch(requests)
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(9))
+	for i, response := range responses {
+		// merged in the order of the chunks
+		Expect(response.Result).To(Equal(fmt.Sprint(i)))
+	}
+	Expect(atomic.LoadInt32(&maxActive)).To(Equal(int32(3)))
+
+	// a failed chunk cancels the others
+	start := time.Now()
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("slow"), NewRequest("fail"), NewRequest("slow"), NewRequest("a"), NewRequest("b")})
+	var httpErr *HTTPError
+	Expect(errors.As(err, &httpErr)).To(BeTrue())
+	Expect(httpErr.Code).To(Equal(http.StatusBadRequest))
+	Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+
+	// the timeout covers all chunks
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 1, BatchParallelism: 2, BatchTimeout: 50 * time.Millisecond})
+	start = time.Now()
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("slow"), NewRequest("slow")})
+	Expect(err).NotTo(BeNil())
+	Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+}
+
+func TestOrderBatchResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newBatchServer(func(request *RPCRequest) *RPCResponse {
+		if request.Method == "missing" {
+			return nil
+		}
+		return &RPCResponse{Result: request.Method}
+	})
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{OrderBatchResponses: true})
+	responses, err := rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("missing"), NewRequest("d")})
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(4))
+	Expect(responses[0].Result).To(Equal("a"))
+	Expect(responses[1].Result).To(Equal("b"))
+	Expect(responses[2]).To(BeNil())
+	Expect(responses[3].Result).To(Equal("d"))
+
+	responses, err = rpcClient.CallBatchRaw(RPCRequests{
+		{JSONRPC: "2.0", Method: "x", ID: StringID("x")},
+		{JSONRPC: "2.0", Method: "y", ID: StringID("y")},
+	})
+	Expect(err).To(BeNil())
+	Expect(responses[0].ID).To(Equal(StringID("x")))
+	Expect(responses[1].ID).To(Equal(StringID("y")))
+
+	// the order of the server without the option
+	responses, err = NewClient(server.URL).CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(BeNil())
+	Expect(responses[0].Result).To(Equal("b"))
+}
+
+func TestOrderResponses(t *testing.T) {
+	RegisterTestingT(t)
+
+	requests := RPCRequests{NewRequest("a"), NewNotification("n"), NewRequest("b")}
+	requests[0].ID = NumberID(0)
+	requests[2].ID = NumberID(2)
+	nullError := &RPCResponse{ID: NullID(), Error: &RPCError{Code: -32700, Message: "parse error"}}
+	a := &RPCResponse{ID: NumberID(0)}
+	b := &RPCResponse{ID: NumberID(2)}
+
+	Expect(orderResponses(requests, RPCResponses{nullError, b, a})).To(Equal(RPCResponses{a, nil, b, nullError}))
+	Expect(orderResponses(requests, nil)).To(Equal(RPCResponses{nil, nil, nil}))
+}
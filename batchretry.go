@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultBatchRetries = 2
+	defaultBatchBackoff = 100 * time.Millisecond
+
+	// rpcErrorLimitExceeded is the error code of EIP-1474 for requests that exceed a rate or resource limit.
+	rpcErrorLimitExceeded = -32005
+)
+
+// RetryBatchOpts configures RetryBatch().
+//
+// MaxRetries: how often the failed requests are sent again (default 2)
+//
+// Backoff: the delay before the first retry, doubled for every further retry (default 100ms)
+//
+// Retryable: decides if the request of a response is sent again, response is nil if the server sent no response
+// for the request. By default requests without response and responses with the error code -32005
+// (limit exceeded) are retried.
+type RetryBatchOpts struct {
+	MaxRetries int
+	Backoff    time.Duration
+	Retryable  func(response *RPCResponse) bool
+}
+
+// RetryBatch sends the requests of a batch whose responses failed with a retryable error again with client,
+// instead of the whole batch, and returns the responses of the batch with the failed responses replaced.
+//
+// requests and responses are the requests and responses of a batch sent before, e.g. with CallBatch().
+// The failed requests are copied and sent as a new batch with CallBatchContext(), which gives them new ids.
+// The responses of the retries get the ids of the original requests, so the returned responses can be looked up
+// by the original requests (see NewBatchResult()). Requests that still fail keep their last response
+// or stay without response.
+//
+// If a retry could not be sent, the responses so far are returned with the error.
+func RetryBatch(ctx context.Context, client Client, requests RPCRequests, responses RPCResponses, opts *RetryBatchOpts) (RPCResponses, error) {
+	maxRetries, backoff, retryable := defaultBatchRetries, defaultBatchBackoff, retryableBatchResponse
+	if opts != nil {
+		if opts.MaxRetries > 0 {
+			maxRetries = opts.MaxRetries
+		}
+		if opts.Backoff > 0 {
+			backoff = opts.Backoff
+		}
+		if opts.Retryable != nil {
+			retryable = opts.Retryable
+		}
+	}
+
+	merged := NewBatchResult(requests, responses).Responses()
+	for retry := 0; retry < maxRetries; retry++ {
+		var failed []int
+		for i, request := range requests {
+			if request != nil && !request.Notification && retryable(merged[i]) {
+				failed = append(failed, i)
+			}
+		}
+		if len(failed) == 0 {
+			break
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return mergeBatchResponses(requests, responses, merged), ctx.Err()
+		}
+		backoff *= 2
+
+		retryRequests := make(RPCRequests, len(failed))
+		for j, i := range failed {
+			request := *requests[i]
+			retryRequests[j] = &request
+		}
+		retryResponses, err := client.CallBatchContext(ctx, retryRequests)
+		if err != nil {
+			return mergeBatchResponses(requests, responses, merged), err
+		}
+
+		result := NewBatchResult(retryRequests, retryResponses)
+		for j, i := range failed {
+			if response := result.ByRequest(retryRequests[j]); response != nil {
+				response.ID = requests[i].ID
+				merged[i] = response
+			}
+		}
+	}
+
+	return mergeBatchResponses(requests, responses, merged), nil
+}
+
+// retryableBatchResponse returns true for a missing response or a response with the error code -32005.
+func retryableBatchResponse(response *RPCResponse) bool {
+	return response == nil || (response.Error != nil && response.Error.Code == rpcErrorLimitExceeded)
+}
+
+// mergeBatchResponses returns the responses of requests in merged, which are in the order of the requests,
+// followed by the original responses that belong to no request.
+func mergeBatchResponses(requests RPCRequests, responses RPCResponses, merged RPCResponses) RPCResponses {
+	result := make(RPCResponses, 0, len(responses))
+	for _, response := range merged {
+		if response != nil {
+			result = append(result, response)
+		}
+	}
+
+	ids := make(map[ID]bool, len(requests))
+	for _, request := range requests {
+		if request != nil && !request.Notification {
+			ids[request.ID] = true
+		}
+	}
+	for _, response := range responses {
+		if response != nil && !ids[response.ID] {
+			result = append(result, response)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRetryBatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	calls := map[string]int{}
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []*RPCRequest
+		json.NewDecoder(r.Body).Decode(&requests)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		sizes = append(sizes, len(requests))
+		responses := RPCResponses{}
+		for _, request := range requests {
+			calls[request.Method]++
+			response := &RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Result: request.Method}
+			switch {
+			case request.Method == "n":
+				// a notification
+				continue
+			case request.Method == "missing" && calls[request.Method] == 1:
+				continue
+			case request.Method == "limited" && calls[request.Method] <= 2:
+				response.Result = nil
+				response.Error = &RPCError{Code: -32005, Message: "limit exceeded"}
+			case request.Method == "invalid":
+				response.Result = nil
+				response.Error = &RPCError{Code: -32602, Message: "invalid params"}
+			}
+			responses = append(responses, response)
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClient(server.URL)
+	requests := RPCRequests{NewRequest("a"), NewRequest("missing"), NewRequest("limited"), NewRequest("invalid"), NewNotification("n")}
+	responses, err := rpcClient.CallBatch(requests)
+	Expect(err).To(BeNil())
+	Expect(NewBatchResult(requests, responses).Missing()).To(HaveLen(1))
+
+	responses, err = RetryBatch(context.Background(), rpcClient, requests, responses, &RetryBatchOpts{Backoff: time.Millisecond})
+	Expect(err).To(BeNil())
+	// the first batch, then missing and limited, then limited
+	Expect(sizes).To(Equal([]int{5, 2, 1}))
+
+	result := NewBatchResult(requests, responses)
+	Expect(result.Missing()).To(BeEmpty())
+	Expect(result.ByRequest(requests[0]).Result).To(Equal("a"))
+	Expect(result.ByRequest(requests[1]).Result).To(Equal("missing"))
+	Expect(result.ByRequest(requests[2]).Result).To(Equal("limited"))
+	Expect(result.ByRequest(requests[3]).Error.Code).To(Equal(-32602))
+	Expect(responses).To(HaveLen(4))
+
+	// the original requests keep their ids
+	for i, request := range requests[:4] {
+		Expect(request.ID).To(Equal(NumberID(int64(i))))
+	}
+}
+
+func TestRetryBatch_Opts(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sizes []int
+	counting := ClientFromHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []*RPCRequest
+		json.NewDecoder(r.Body).Decode(&requests)
+		sizes = append(sizes, len(requests))
+		responses := RPCResponses{}
+		for _, request := range requests {
+			responses = append(responses, &RPCResponse{JSONRPC: jsonrpcVersion, ID: request.ID, Error: &RPCError{Code: -32000, Message: request.Method}})
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+
+	requests := RPCRequests{NewRequest("a"), NewRequest("b")}
+	responses, err := counting.CallBatch(requests)
+	Expect(err).To(BeNil())
+
+	// not retryable by default
+	_, err = RetryBatch(context.Background(), counting, requests, responses, nil)
+	Expect(err).To(BeNil())
+	Expect(sizes).To(Equal([]int{2}))
+
+	retried, err := RetryBatch(context.Background(), counting, requests, responses, &RetryBatchOpts{
+		MaxRetries: 3,
+		Backoff:    time.Millisecond,
+		Retryable: func(response *RPCResponse) bool {
+			return response == nil || response.Error.Message == "b"
+		},
+	})
+	Expect(err).To(BeNil())
+	Expect(sizes).To(Equal([]int{2, 1, 1, 1}))
+	Expect(retried).To(HaveLen(2))
+
+	// canceled while waiting for the backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	retried, err = RetryBatch(ctx, counting, requests, responses, &RetryBatchOpts{Retryable: func(*RPCResponse) bool { return true }})
+	Expect(err).To(Equal(context.Canceled))
+	Expect(retried).To(HaveLen(2))
+
+	// a retry that could not be sent
+	_, err = RetryBatch(context.Background(), NewClient("http://localhost/{missing}"), requests, responses, &RetryBatchOpts{
+		Backoff:   time.Millisecond,
+		Retryable: func(*RPCResponse) bool { return true },
+	})
+	Expect(err).NotTo(BeNil())
+}
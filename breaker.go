@@ -0,0 +1,208 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit breaker of an endpoint is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed sends all requests (default).
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all requests with ErrCircuitOpen, after too many requests failed.
+	CircuitOpen
+	// CircuitHalfOpen sends a limited number of probe requests after the open timeout.
+	// The circuit is closed if they succeed and opened again if one fails.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerOpts configures a circuit breaker that fails requests fast while an endpoint is down, see RPCClientOpts.
+//
+// Only retryable errors count as failure (see IsRetryable()), e.g. connection errors or http status 503.
+//
+// FailureThreshold: the number of consecutive failed requests that open the circuit (default 5)
+//
+// OpenTimeout: how long the circuit stays open before probe requests are sent (default 30s)
+//
+// HalfOpenRequests: the number of concurrent probe requests in half-open state (default 1)
+//
+// OnStateChange: called when the state changes, e.g. for logging. It is called while the circuit breaker is locked,
+// so it must not block.
+type CircuitBreakerOpts struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenRequests int
+	OnStateChange    func(from, to CircuitState)
+}
+
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenTimeout      = 30 * time.Second
+	defaultCircuitHalfOpenRequests = 1
+)
+
+// circuitBreaker is a Transport that fails fast while the circuit is open.
+type circuitBreaker struct {
+	transport Transport
+	opts      CircuitBreakerOpts
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int       // consecutive failures in closed state
+	openedAt time.Time // when the circuit was opened
+	probes   int       // pending probe requests in half-open state
+
+	now func() time.Time
+}
+
+// newCircuitBreaker returns a circuitBreaker for transport with defaults for all options that are not set.
+func newCircuitBreaker(transport Transport, opts CircuitBreakerOpts) *circuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultCircuitFailureThreshold
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = defaultCircuitOpenTimeout
+	}
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = defaultCircuitHalfOpenRequests
+	}
+
+	return &circuitBreaker{
+		transport: transport,
+		opts:      opts,
+		now:       time.Now,
+	}
+}
+
+func (b *circuitBreaker) Send(ctx context.Context, request []byte) ([]byte, error) {
+	probe, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := b.transport.Send(ctx, request)
+	b.done(probe, err)
+
+	return response, err
+}
+
+func (b *circuitBreaker) SendStream(ctx context.Context, request []byte) (io.ReadCloser, error) {
+	probe, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := sendStream(ctx, b.transport, request)
+	b.done(probe, err)
+
+	return responseBody, err
+}
+
+// State returns the current state of the circuit.
+func (b *circuitBreaker) State() CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.checkTimeout()
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if the request must not be sent.
+// probe is true if the request is a probe request in half-open state.
+func (b *circuitBreaker) allow() (probe bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.checkTimeout()
+	switch b.state {
+	case CircuitOpen:
+		return false, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.opts.HalfOpenRequests {
+			return false, ErrCircuitOpen
+		}
+		b.probes++
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// done records the result of a request.
+func (b *circuitBreaker) done(probe bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failed := err != nil && IsRetryable(classifyTransportError(err))
+	if probe {
+		b.probes--
+	}
+
+	switch b.state {
+	case CircuitClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.opts.FailureThreshold {
+			b.open()
+		}
+	case CircuitHalfOpen:
+		if !probe {
+			return
+		}
+		if failed {
+			b.open()
+		} else {
+			b.setState(CircuitClosed)
+		}
+	}
+}
+
+// checkTimeout switches from open to half-open state after the open timeout. Must be called with mutex held.
+func (b *circuitBreaker) checkTimeout() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.opts.OpenTimeout {
+		b.probes = 0
+		b.setState(CircuitHalfOpen)
+	}
+}
+
+// open opens the circuit. Must be called with mutex held.
+func (b *circuitBreaker) open() {
+	b.openedAt = b.now()
+	b.setState(CircuitOpen)
+}
+
+// setState changes the state. Must be called with mutex held.
+func (b *circuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+
+	from := b.state
+	b.state = state
+	b.failures = 0
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(from, state)
+	}
+}
@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sendErr error
+	sent := 0
+	transport := transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		sent++
+		if sendErr != nil {
+			return nil, sendErr
+		}
+		return []byte(`{"jsonrpc":"2.0","result":"ok","id":0}`), nil
+	})
+
+	var changes []string
+	breaker := newCircuitBreaker(transport, CircuitBreakerOpts{
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+		OnStateChange: func(from, to CircuitState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	send := func() error {
+		_, err := breaker.Send(context.Background(), []byte(`{}`))
+		return err
+	}
+
+	// errors that are not retryable don't count
+	sendErr = &HTTPError{Code: http.StatusBadRequest}
+	for i := 0; i < 5; i++ {
+		Expect(send()).NotTo(BeNil())
+	}
+	Expect(breaker.State()).To(Equal(CircuitClosed))
+
+	// consecutive failures open the circuit
+	sendErr = errors.New("connection refused")
+	Expect(send()).NotTo(BeNil())
+	Expect(send()).NotTo(BeNil())
+	sendErr = nil
+	Expect(send()).To(BeNil())
+	sendErr = errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		Expect(send()).NotTo(BeNil())
+	}
+	Expect(breaker.State()).To(Equal(CircuitOpen))
+
+	sent = 0
+	Expect(send()).To(Equal(ErrCircuitOpen))
+	Expect(sent).To(Equal(0))
+
+	// a failed probe opens the circuit again
+	now = now.Add(time.Minute)
+	Expect(breaker.State()).To(Equal(CircuitHalfOpen))
+	Expect(send()).NotTo(BeNil())
+	Expect(sent).To(Equal(1))
+	Expect(breaker.State()).To(Equal(CircuitOpen))
+
+	// a successful probe closes the circuit
+	now = now.Add(time.Minute)
+	sendErr = nil
+	Expect(send()).To(BeNil())
+	Expect(breaker.State()).To(Equal(CircuitClosed))
+
+	Expect(changes).To(Equal([]string{
+		"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed",
+	}))
+}
+
+func TestCircuitBreaker_HalfOpenRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	release := make(chan struct{})
+	breaker := newCircuitBreaker(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		<-release
+		return []byte(`{}`), nil
+	}), CircuitBreakerOpts{})
+	breaker.state = CircuitHalfOpen
+
+	// only one probe request is sent at a time
+	done := make(chan error)
+	go func() {
+		_, err := breaker.Send(context.Background(), []byte(`{}`))
+		done <- err
+	}()
+	Eventually(func() int {
+		breaker.mutex.Lock()
+		defer breaker.mutex.Unlock()
+		return breaker.probes
+	}).Should(Equal(1))
+
+	_, err := breaker.Send(context.Background(), []byte(`{}`))
+	Expect(err).To(Equal(ErrCircuitOpen))
+
+	close(release)
+	Expect(<-done).To(BeNil())
+	Expect(breaker.State()).To(Equal(CircuitClosed))
+}
+
+func TestRPCClientOpts_CircuitBreaker(t *testing.T) {
+	RegisterTestingT(t)
+
+	rpcClient := NewClientWithOpts("http://127.0.0.1:1", &RPCClientOpts{
+		CircuitBreaker: &CircuitBreakerOpts{FailureThreshold: 1},
+	})
+	_, err := rpcClient.Call("something")
+	Expect(errors.Is(err, ErrCircuitOpen)).To(BeFalse())
+
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(errors.Is(err, ErrCircuitOpen)).To(BeTrue())
+}
@@ -0,0 +1,59 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+)
+
+// Broadcast sends a notification to the clients of all persistent connections of the server,
+// e.g. of WSHandler() and SSEHandler(). params are encoded like the params of requests.
+//
+// The notification is added to the send queue of every connection without waiting. A slow client whose queue
+// is full does not get the notification, or is disconnected if ServerOpts.DisconnectSlowClients is set.
+// It returns the number of connections the notification was queued for.
+func (s *Server) Broadcast(method string, params interface{}) (int, error) {
+	return s.BroadcastFilter(nil, method, params)
+}
+
+// BroadcastFilter sends a notification like Broadcast() to the clients of the connections for which filter
+// returns true, e.g. depending on ServerConn.Value() or the http request of ServerConn.Context().
+// A nil filter selects all connections.
+func (s *Server) BroadcastFilter(filter func(conn *ServerConn) bool, method string, params interface{}) (int, error) {
+	message, err := json.Marshal(&RPCNotification{JSONRPC: jsonrpcVersion, Method: method, Params: params})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, conn := range s.connections() {
+		if filter != nil && !filter(conn) {
+			continue
+		}
+		if conn.tryWrite(message) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// connections returns the open persistent connections of the server.
+func (s *Server) connections() []*ServerConn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	conns := make([]*ServerConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+func (s *Server) addConn(conn *ServerConn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.conns[conn] = struct{}{}
+}
+
+func (s *Server) removeConn(conn *ServerConn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, conn)
+}
@@ -0,0 +1,144 @@
+package jsonrpc
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+func TestServerBroadcast(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServer()
+	server.Register("join", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var params []string
+		if err := request.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		ServerConnFromContext(ctx).Set("topic", params[0])
+		return true, nil
+	})
+	httpServer := httptest.NewServer(server.WSHandler(nil))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	clients := make([]*websocket.Conn, 3)
+	for i, topic := range []string{"news", "sports", "news"} {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		Expect(err).To(BeNil())
+		defer conn.Close()
+		clients[i] = conn
+
+		Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"join","params":["`+topic+`"],"id":1}`))).To(Succeed())
+		_, message, err := conn.ReadMessage()
+		Expect(err).To(BeNil())
+		Expect(string(message)).To(Equal(`{"jsonrpc":"2.0","result":true,"id":1}`))
+	}
+
+	sent, err := server.Broadcast("hello", []string{"everyone"})
+	Expect(err).To(BeNil())
+	Expect(sent).To(Equal(3))
+	for _, conn := range clients {
+		_, message, err := conn.ReadMessage()
+		Expect(err).To(BeNil())
+		Expect(string(message)).To(Equal(`{"jsonrpc":"2.0","method":"hello","params":["everyone"]}`))
+	}
+
+	sent, err = server.BroadcastFilter(func(conn *ServerConn) bool {
+		return conn.Value("topic") == "news"
+	}, "news", map[string]string{"title": "breaking"})
+	Expect(err).To(BeNil())
+	Expect(sent).To(Equal(2))
+	for _, conn := range []*websocket.Conn{clients[0], clients[2]} {
+		_, message, err := conn.ReadMessage()
+		Expect(err).To(BeNil())
+		Expect(string(message)).To(Equal(`{"jsonrpc":"2.0","method":"news","params":{"title":"breaking"}}`))
+	}
+
+	_, err = server.Broadcast("invalid", func() {})
+	Expect(err).NotTo(BeNil())
+
+	// closed connections are removed
+	clients[0].Close()
+	Eventually(func() int {
+		sent, _ := server.Broadcast("hello", nil)
+		return sent
+	}).Should(Equal(2))
+}
+
+// blockingConn is a messageConn whose writes block until they are released.
+type blockingConn struct {
+	writing chan []byte
+	release chan struct{}
+	closed  chan struct{}
+}
+
+func newBlockingConn() *blockingConn {
+	return &blockingConn{
+		writing: make(chan []byte, 16),
+		release: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (c *blockingConn) readMessage() ([]byte, error) {
+	<-c.closed
+	return nil, io.EOF
+}
+
+func (c *blockingConn) writeMessage(message []byte) error {
+	c.writing <- message
+	select {
+	case <-c.release:
+		return nil
+	case <-c.closed:
+		return io.ErrClosedPipe
+	}
+}
+
+func (c *blockingConn) close() error {
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return nil
+}
+
+func TestServerBroadcastSlowClient(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, disconnect := range []bool{false, true} {
+		server := NewServerWithOpts(&ServerOpts{SendQueueSize: 1, DisconnectSlowClients: disconnect})
+		conn := newBlockingConn()
+		done := make(chan struct{})
+		go func() {
+			server.serveConn(context.Background(), conn, 0)
+			close(done)
+		}()
+		Eventually(func() int { return len(server.connections()) }).Should(Equal(1))
+
+		// the first notification is written, the second queued and the third dropped
+		Expect(server.Broadcast("first", nil)).To(Equal(1))
+		Eventually(conn.writing).Should(Receive())
+		Expect(server.Broadcast("second", nil)).To(Equal(1))
+		Expect(server.Broadcast("third", nil)).To(Equal(0))
+
+		if disconnect {
+			Eventually(done).Should(BeClosed())
+			Expect(server.connections()).To(BeEmpty())
+			continue
+		}
+
+		conn.release <- struct{}{}
+		Eventually(conn.writing).Should(Receive(ContainSubstring("second")))
+		Consistently(done).ShouldNot(BeClosed())
+		conn.close()
+		Eventually(done).Should(BeClosed())
+	}
+}
@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryBudgetOpts configures a RetryBudget.
+//
+// Ratio: the maximum number of retries per request, e.g. 0.2 allows 20% additional load by retries (default 0.2)
+//
+// MinRetriesPerSecond: retries that are always allowed, so that clients with few requests can retry (default 10).
+// A negative value allows no additional retries.
+//
+// Window: the time span over which requests and retries are counted (default 10s)
+type RetryBudgetOpts struct {
+	Ratio               float64
+	MinRetriesPerSecond int
+	Window              time.Duration
+}
+
+const (
+	defaultRetryBudgetRatio               = 0.2
+	defaultRetryBudgetMinRetriesPerSecond = 10
+	defaultRetryBudgetWindow              = 10 * time.Second
+
+	// retryBudgetBuckets is the number of buckets of the sliding window.
+	retryBudgetBuckets = 10
+)
+
+// RetryBudget limits the number of retries relative to the number of requests, so that an upstream outage
+// can't multiply the traffic. Once the budget is exhausted, failed requests are not retried.
+//
+// A RetryBudget can be shared by the RetryPolicy of multiple clients to limit their retries together.
+type RetryBudget struct {
+	ratio      float64
+	minRetries float64
+	bucketSize time.Duration
+
+	mutex   sync.Mutex
+	buckets [retryBudgetBuckets]retryBudgetBucket
+
+	now func() time.Time
+}
+
+// retryBudgetBucket counts the requests and retries of a part of the window.
+type retryBudgetBucket struct {
+	index    int64
+	requests int
+	retries  int
+}
+
+// NewRetryBudget returns a new RetryBudget.
+//
+// opts: RetryBudgetOpts provide custom configuration, may be nil
+func NewRetryBudget(opts *RetryBudgetOpts) *RetryBudget {
+	budgetOpts := RetryBudgetOpts{}
+	if opts != nil {
+		budgetOpts = *opts
+	}
+
+	if budgetOpts.Ratio <= 0 {
+		budgetOpts.Ratio = defaultRetryBudgetRatio
+	}
+	if budgetOpts.MinRetriesPerSecond == 0 {
+		budgetOpts.MinRetriesPerSecond = defaultRetryBudgetMinRetriesPerSecond
+	} else if budgetOpts.MinRetriesPerSecond < 0 {
+		budgetOpts.MinRetriesPerSecond = 0
+	}
+	if budgetOpts.Window <= 0 {
+		budgetOpts.Window = defaultRetryBudgetWindow
+	}
+
+	bucketSize := budgetOpts.Window / retryBudgetBuckets
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+
+	return &RetryBudget{
+		ratio:      budgetOpts.Ratio,
+		minRetries: float64(budgetOpts.MinRetriesPerSecond) * budgetOpts.Window.Seconds(),
+		bucketSize: bucketSize,
+		now:        time.Now,
+	}
+}
+
+// request counts a request.
+func (b *RetryBudget) request() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.bucket().requests++
+}
+
+// retry counts a retry and returns true if it is within the budget.
+func (b *RetryBudget) retry() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	current := b.bucket()
+	requests, retries := 0, 0
+	for i := range b.buckets {
+		if b.buckets[i].index > current.index-retryBudgetBuckets {
+			requests += b.buckets[i].requests
+			retries += b.buckets[i].retries
+		}
+	}
+
+	if float64(retries+1) > b.minRetries+b.ratio*float64(requests) {
+		return false
+	}
+
+	current.retries++
+	return true
+}
+
+// bucket returns the bucket of the current time, it is reset if it belongs to an earlier window.
+func (b *RetryBudget) bucket() *retryBudgetBucket {
+	index := b.now().UnixNano() / int64(b.bucketSize)
+	bucket := &b.buckets[index%retryBudgetBuckets]
+	if bucket.index != index {
+		*bucket = retryBudgetBucket{index: index}
+	}
+
+	return bucket
+}
@@ -0,0 +1,71 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRetryBudget(t *testing.T) {
+	RegisterTestingT(t)
+
+	budget := NewRetryBudget(&RetryBudgetOpts{Ratio: 0.5, MinRetriesPerSecond: -1, Window: 10 * time.Second})
+	now := time.Unix(1000, 0)
+	budget.now = func() time.Time { return now }
+
+	Expect(budget.retry()).To(BeFalse())
+
+	for i := 0; i < 4; i++ {
+		budget.request()
+	}
+	Expect(budget.retry()).To(BeTrue())
+	Expect(budget.retry()).To(BeTrue())
+	Expect(budget.retry()).To(BeFalse())
+
+	// requests and retries expire after the window
+	now = now.Add(5 * time.Second)
+	budget.request()
+	budget.request()
+	Expect(budget.retry()).To(BeTrue())
+	Expect(budget.retry()).To(BeFalse())
+
+	now = now.Add(5 * time.Second)
+	Expect(budget.retry()).To(BeFalse())
+	budget.request()
+	budget.request()
+	Expect(budget.retry()).To(BeTrue())
+}
+
+func TestRetryBudget_MinRetries(t *testing.T) {
+	RegisterTestingT(t)
+
+	budget := NewRetryBudget(&RetryBudgetOpts{MinRetriesPerSecond: 1, Window: 2 * time.Second})
+	now := time.Unix(1000, 0)
+	budget.now = func() time.Time { return now }
+
+	Expect(budget.retry()).To(BeTrue())
+	Expect(budget.retry()).To(BeTrue())
+	Expect(budget.retry()).To(BeFalse())
+}
+
+func TestRetryPolicy_Budget(t *testing.T) {
+	RegisterTestingT(t)
+
+	down, attempts := newFlakyServer(100, http.StatusServiceUnavailable)
+	defer down.Close()
+
+	budget := NewRetryBudget(&RetryBudgetOpts{Ratio: 0.5, MinRetriesPerSecond: -1})
+	rpcClient := NewClientWithOpts(down.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Millisecond, Budget: budget},
+	})
+
+	// 4 requests allow 2 retries in total
+	for i := 0; i < 4; i++ {
+		_, err := rpcClient.Call("something")
+		Expect(err).NotTo(BeNil())
+	}
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(6)))
+}
@@ -0,0 +1,39 @@
+// Package compress provides brotli and zstd decoders of response content encodings for jsonrpc clients,
+// e.g. for CDNs in front of public RPC endpoints that negotiate these encodings.
+//
+// It is a separate module, so that the jsonrpc module does not depend on the compression libraries.
+package compress
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decoders returns the decoders of the "br" and "zstd" content encodings for jsonrpc.RPCClientOpts.ContentDecoders, e.g.
+//
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+//		ContentDecoders: compress.Decoders(),
+//	})
+func Decoders() map[string]jsonrpc.ContentDecoder {
+	return map[string]jsonrpc.ContentDecoder{
+		"br":   Brotli,
+		"zstd": Zstd,
+	}
+}
+
+// Brotli decodes a brotli ("br") encoded body.
+func Brotli(body io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(brotli.NewReader(body)), nil
+}
+
+// Zstd decodes a zstd encoded body.
+func Zstd(body io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
@@ -0,0 +1,62 @@
+package compress
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	"github.com/klauspost/compress/zstd"
+	. "github.com/onsi/gomega"
+)
+
+func TestDecoders(t *testing.T) {
+	RegisterTestingT(t)
+
+	result := strings.Repeat("compressed ", 1000)
+	var acceptEncodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncodings = append(acceptEncodings, r.Header.Get("Accept-Encoding"))
+		encoding := r.Header.Get("X-Encoding")
+		w.Header().Set("Content-Encoding", encoding)
+		response := fmt.Sprintf(`{"jsonrpc":"2.0","result":%q,"id":0}`, result)
+		switch encoding {
+		case "br":
+			writer := brotli.NewWriter(w)
+			fmt.Fprint(writer, response)
+			writer.Close()
+		case "zstd":
+			writer, _ := zstd.NewWriter(w)
+			fmt.Fprint(writer, response)
+			writer.Close()
+		}
+	}))
+	defer server.Close()
+
+	for _, encoding := range []string{"br", "zstd"} {
+		rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+			ContentDecoders: Decoders(),
+			CustomHeaders:   map[string]string{"X-Encoding": encoding},
+		})
+		res, err := rpcClient.Call("something")
+		Expect(err).To(BeNil())
+		Expect(res.Result).To(Equal(result))
+	}
+	Expect(acceptEncodings).To(Equal([]string{"br, gzip, zstd", "br, gzip, zstd"}))
+}
+
+func TestDecodersInvalid(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "zstd")
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"plain","id":0}`)
+	}))
+	defer server.Close()
+
+	_, err := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{ContentDecoders: Decoders()}).Call("something")
+	Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,147 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const defaultCompressionMinSize = 1024
+
+// CompressionOpts compresses the bodies of http requests with gzip, see RPCClientOpts.
+// Compressed requests are sent with "Content-Encoding: gzip", the server must support it.
+//
+// MinSize: bodies smaller than this are sent uncompressed (default 1024 bytes)
+//
+// Level: the gzip compression level (default gzip.DefaultCompression), e.g. gzip.BestSpeed
+type CompressionOpts struct {
+	MinSize int
+	Level   int
+}
+
+// compressor compresses the bodies of requests of at least minSize bytes.
+type compressor struct {
+	minSize int
+	level   int
+}
+
+func newCompressor(opts CompressionOpts) *compressor {
+	c := &compressor{minSize: opts.MinSize, level: opts.Level}
+	if c.minSize <= 0 {
+		c.minSize = defaultCompressionMinSize
+	}
+	if c.level == 0 {
+		c.level = gzip.DefaultCompression
+	}
+	return c
+}
+
+// compress returns the gzip compressed body and true, or body and false if it is too small to be compressed.
+func (c *compressor) compress(body []byte) ([]byte, bool, error) {
+	if len(body) < c.minSize {
+		return body, false, nil
+	}
+
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return nil, false, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// readRequestBody returns the uncompressed body of request without consuming it, nil if it cannot be read again.
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.GetBody == nil {
+		return nil, nil
+	}
+	reader, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil || request.Header.Get("Content-Encoding") != "gzip" {
+		return body, err
+	}
+	gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(gzipReader)
+}
+
+// ContentDecoder decodes a response body with a content encoding, see RPCClientOpts.ContentDecoders.
+// Closing the returned reader must release its resources, the body is closed by the client.
+type ContentDecoder func(body io.Reader) (io.ReadCloser, error)
+
+// newDecodingMiddleware returns a middleware that advertises the encodings of decoders and gzip in Accept-Encoding
+// and decodes the responses.
+func newDecodingMiddleware(decoders map[string]ContentDecoder) func(http.RoundTripper) http.RoundTripper {
+	all := map[string]ContentDecoder{"gzip": func(body io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(body)
+	}}
+	for encoding, decoder := range decoders {
+		all[strings.ToLower(encoding)] = decoder
+	}
+	encodings := make([]string, 0, len(all))
+	for encoding := range all {
+		encodings = append(encodings, encoding)
+	}
+	sort.Strings(encodings)
+	acceptEncoding := strings.Join(encodings, ", ")
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get("Accept-Encoding") == "" {
+				// the request of the caller must not be modified
+				request = request.Clone(request.Context())
+				request.Header.Set("Accept-Encoding", acceptEncoding)
+			}
+
+			response, err := next.RoundTrip(request)
+			if err != nil {
+				return nil, err
+			}
+			decoder, ok := all[strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))]
+			if !ok || request.Method == http.MethodHead {
+				return response, nil
+			}
+
+			decoded, err := decoder(response.Body)
+			if err != nil {
+				response.Body.Close()
+				return nil, fmt.Errorf("decode %v response: %w", response.Header.Get("Content-Encoding"), err)
+			}
+			response.Body = &decodedBody{ReadCloser: decoded, body: response.Body}
+			response.Header.Del("Content-Encoding")
+			response.Header.Del("Content-Length")
+			response.ContentLength = -1
+			response.Uncompressed = true
+			return response, nil
+		})
+	}
+}
+
+// decodedBody is a decoded response body, closing it closes the decoder and the body.
+type decodedBody struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.body.Close()
+}
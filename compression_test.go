@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCompression(t *testing.T) {
+	RegisterTestingT(t)
+
+	var encodings []string
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gzipReader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			reader = gzipReader
+		}
+		body, _ := ioutil.ReadAll(reader)
+		sizes = append(sizes, int(r.ContentLength))
+		if bytes.HasPrefix(body, []byte("[")) {
+			fmt.Fprintf(w, `[{"jsonrpc":"2.0","result":%d,"id":0},{"jsonrpc":"2.0","result":%d,"id":1}]`, len(body), len(body))
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%d,"id":0}`, len(body))
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{Compression: &CompressionOpts{MinSize: 100}})
+	large := strings.Repeat("a", 1000)
+
+	var size int
+	Expect(rpcClient.CallFor(&size, "small")).To(Succeed())
+	Expect(size).To(Equal(len(`{"method":"small","id":0,"jsonrpc":"2.0"}`)))
+
+	// the server receives the uncompressed request
+	Expect(rpcClient.CallFor(&size, "large", large)).To(Succeed())
+	Expect(size).To(Equal(len(`{"method":"large","params":[""],"id":0,"jsonrpc":"2.0"}`) + 1000))
+
+	_, err := rpcClient.CallBatch(RPCRequests{NewRequest("a", large), NewRequest("b", large)})
+	Expect(err).To(BeNil())
+
+	Expect(encodings).To(Equal([]string{"", "gzip", "gzip"}))
+	Expect(sizes[1]).To(BeNumerically("<", 200))
+
+	// GET requests have no body
+	encodings = nil
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{Compression: &CompressionOpts{MinSize: 1}, HTTPMethod: http.MethodGet}).Call("something", large)
+	Expect(err).To(BeNil())
+	Expect(encodings).To(Equal([]string{""}))
+}
+
+func TestCompressionDebug(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":1,"id":0}`)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Compression: &CompressionOpts{MinSize: 1, Level: gzip.BestSpeed},
+		Debug:       &DebugOpts{Writer: &output},
+		Redactor:    RedactFields("password"),
+	})
+	_, err := rpcClient.Call("login", map[string]string{"password": "secret"})
+	Expect(err).To(BeNil())
+
+	// the request is dumped uncompressed
+	Expect(output.String()).To(ContainSubstring(`"params":{"password":"[REDACTED]"}`))
+	Expect(output.String()).NotTo(ContainSubstring("Content-Encoding"))
+
+	_, _, err = newCompressor(CompressionOpts{Level: 42}).compress([]byte(strings.Repeat("a", 2000)))
+	Expect(err).To(HaveOccurred())
+}
+
+func TestContentDecoders(t *testing.T) {
+	RegisterTestingT(t)
+
+	var acceptEncodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncodings = append(acceptEncodings, r.Header.Get("Accept-Encoding"))
+		response := `{"jsonrpc":"2.0","result":"decoded","id":0}`
+		switch r.Header.Get("X-Encoding") {
+		case "base64":
+			w.Header().Set("Content-Encoding", "base64")
+			fmt.Fprint(w, base64.StdEncoding.EncodeToString([]byte(response)))
+		case "gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			writer := gzip.NewWriter(w)
+			fmt.Fprint(writer, response)
+			writer.Close()
+		case "invalid":
+			w.Header().Set("Content-Encoding", "gzip")
+			fmt.Fprint(w, response)
+		default:
+			fmt.Fprint(w, response)
+		}
+	}))
+	defer server.Close()
+
+	var closed int
+	decoders := map[string]ContentDecoder{"Base64": func(body io.Reader) (io.ReadCloser, error) {
+		return closeFunc{Reader: base64.NewDecoder(base64.StdEncoding, body), close: func() { closed++ }}, nil
+	}}
+	for _, encoding := range []string{"base64", "gzip", ""} {
+		rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+			ContentDecoders: decoders,
+			CustomHeaders:   map[string]string{"X-Encoding": encoding},
+		})
+		res, err := rpcClient.Call("something")
+		Expect(err).To(BeNil())
+		Expect(res.Result).To(Equal("decoded"))
+	}
+	Expect(acceptEncodings).To(Equal([]string{"base64, gzip", "base64, gzip", "base64, gzip"}))
+	Expect(closed).To(Equal(1))
+
+	_, err := NewClientWithOpts(server.URL, &RPCClientOpts{
+		ContentDecoders: decoders,
+		CustomHeaders:   map[string]string{"X-Encoding": "invalid"},
+	}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("decode gzip response"))
+}
+
+type closeFunc struct {
+	io.Reader
+	close func()
+}
+
+func (c closeFunc) Close() error {
+	c.close()
+	return nil
+}
@@ -0,0 +1,540 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClientClosed is returned for all calls on a ConnClient after Close() was called.
+var ErrClientClosed = errors.New("client closed")
+
+// ConnClient is a Client that sends all requests over a single persistent connection (e.g. a WebSocket).
+//
+// Calls can be sent concurrently from multiple goroutines. Responses are matched to the requests by their id,
+// so the ids of pending requests must be unique. Therefore Call(), CallFor() and CallBatch() always generate the ids
+// using the IDGenerator of the client (by default incrementing numbers starting at 1), also for batch requests.
+// CallRaw() and CallBatchRaw() return an error if a request id is already in use by a pending request.
+type ConnClient interface {
+	Client
+
+	// Subscribe sends a subscribe request and returns a Subscription that receives the notifications
+	// pushed by the server, e.g.
+	//   Subscribe("eth_subscribe", "newHeads")
+	//
+	// The subscription id is taken from the result of the response.
+	// The subscription is ended with Subscription.Unsubscribe(), which sends the corresponding unsubscribe request
+	// (e.g. "eth_unsubscribe"), or when the connection is closed.
+	Subscribe(method string, params ...interface{}) (*Subscription, error)
+
+	// Close closes the connection. Pending calls return an error.
+	Close() error
+}
+
+// messageConn reads and writes complete JSON-RPC messages over a persistent connection.
+//
+// readMessage is only called by a single goroutine. writeMessage is never called concurrently.
+type messageConn interface {
+	readMessage() ([]byte, error)
+	writeMessage(message []byte) error
+	close() error
+}
+
+// connClient is an rpcClient that uses a connTransport.
+type connClient struct {
+	*rpcClient
+	transport *connTransport
+}
+
+// newConnClient returns a new connClient that sends its requests over conn.
+// If reconnect is not nil, a lost connection is replaced by a new one.
+func newConnClient(conn messageConn, opts *RPCClientOpts, reconnect *reconnector) *connClient {
+	var server *Server
+	if opts != nil {
+		server = opts.Server
+	}
+	transport := newConnTransport(conn, reconnect, server)
+
+	client := &connClient{
+		rpcClient: newRPCClient(transport, opts),
+		transport: transport,
+	}
+	if client.idGenerator == nil {
+		client.idGenerator = NewSequentialIDGenerator(1)
+	}
+
+	return client
+}
+
+func (client *connClient) Close() error {
+	return client.transport.shutdown(ErrClientClosed)
+}
+
+// errReconnecting is returned for calls that are sent while a lost connection is being replaced.
+var errReconnecting = errors.New("connection lost, reconnecting")
+
+// reconnector dials a new connection with exponential backoff after the connection was lost.
+type reconnector struct {
+	dial     func() (messageConn, error)
+	delay    time.Duration // delay after the first failed attempt, doubled after every further failed attempt
+	maxDelay time.Duration
+}
+
+const (
+	defaultReconnectDelay    = 500 * time.Millisecond
+	defaultMaxReconnectDelay = 30 * time.Second
+)
+
+// newReconnector returns a reconnector for dial with the default delays if delay or maxDelay are not set.
+func newReconnector(dial func() (messageConn, error), delay, maxDelay time.Duration) *reconnector {
+	if delay <= 0 {
+		delay = defaultReconnectDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxReconnectDelay
+	}
+
+	return &reconnector{
+		dial:     dial,
+		delay:    delay,
+		maxDelay: maxDelay,
+	}
+}
+
+// pendingCall is a request that waits for its response.
+type pendingCall struct {
+	ids      []ID
+	response chan pendingResult
+}
+
+// pendingResult is either the response to a pendingCall or the reason why no response will arrive.
+type pendingResult struct {
+	message []byte
+	err     error
+}
+
+// connTransport sends requests over a persistent connection and dispatches the responses by their id.
+type connTransport struct {
+	connMutex sync.Mutex
+	conn      messageConn // nil while reconnecting
+	reconnect *reconnector
+
+	writeMutex sync.Mutex
+
+	pendingMutex  sync.Mutex
+	pending       map[ID]*pendingCall
+	subscribing   map[ID]*Subscription // by request id
+	subscriptions map[ID]*Subscription // by subscription id, only started subscriptions
+	active        map[*Subscription]struct{}
+
+	// server handles the requests received from the server, requests are dropped if nil
+	server *Server
+	ctx    context.Context // canceled when the transport is closed
+	cancel context.CancelFunc
+
+	// resubscribeMutex ensures that subscriptions are not re-established twice after reconnects in quick succession
+	resubscribeMutex sync.Mutex
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	err       error // the reason why the transport was closed, set before closed is closed
+}
+
+// newConnTransport returns a new connTransport and starts reading from conn.
+func newConnTransport(conn messageConn, reconnect *reconnector, server *Server) *connTransport {
+	t := &connTransport{
+		conn:          conn,
+		reconnect:     reconnect,
+		server:        server,
+		pending:       make(map[ID]*pendingCall),
+		subscribing:   make(map[ID]*Subscription),
+		subscriptions: make(map[ID]*Subscription),
+		active:        make(map[*Subscription]struct{}),
+		closed:        make(chan struct{}),
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	go t.readLoop(conn)
+
+	return t
+}
+
+func (t *connTransport) Send(ctx context.Context, body []byte) ([]byte, error) {
+	ids, err := messageIDs(body)
+	if err != nil {
+		return nil, err
+	}
+
+	call := &pendingCall{
+		ids:      ids,
+		response: make(chan pendingResult, 1),
+	}
+	if err := t.register(call); err != nil {
+		return nil, err
+	}
+	defer t.unregister(call)
+
+	t.connMutex.Lock()
+	conn := t.conn
+	t.connMutex.Unlock()
+	if conn == nil {
+		return nil, errReconnecting
+	}
+
+	t.writeMutex.Lock()
+	err = conn.writeMessage(body)
+	t.writeMutex.Unlock()
+	if err != nil {
+		t.connectionLost(conn, err)
+		return nil, err
+	}
+	if len(ids) == 0 {
+		// notifications, there is no response
+		return nil, nil
+	}
+
+	select {
+	case result := <-call.response:
+		return result.message, result.err
+	case <-ctx.Done():
+		// a late response is dropped, because the call is not pending anymore
+		return nil, ctx.Err()
+	case <-t.closed:
+		// the response may have arrived right before the connection was closed
+		select {
+		case result := <-call.response:
+			return result.message, result.err
+		default:
+			return nil, t.err
+		}
+	}
+}
+
+func (t *connTransport) register(call *pendingCall) error {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	select {
+	case <-t.closed:
+		return t.err
+	default:
+	}
+
+	for _, id := range call.ids {
+		if _, ok := t.pending[id]; ok {
+			return MarkPermanent(fmt.Errorf("id %v is already used by a pending request", id))
+		}
+	}
+	for _, id := range call.ids {
+		t.pending[id] = call
+	}
+
+	return nil
+}
+
+func (t *connTransport) unregister(call *pendingCall) {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	for _, id := range call.ids {
+		if t.pending[id] == call {
+			delete(t.pending, id)
+		}
+	}
+}
+
+func (t *connTransport) readLoop(conn messageConn) {
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			t.connectionLost(conn, err)
+			return
+		}
+
+		t.dispatch(conn, message)
+	}
+}
+
+// dispatch delivers a response (or batch response) to the pending call with a matching id
+// and notifications to their subscription. Other requests and notifications of the server are handled
+// by the Server of the client.
+//
+// Error responses with id null, e.g. a parse error of the server, can not be matched to a call,
+// they are delivered to all pending calls, so the calls fail instead of waiting for a response that never arrives.
+// Other messages are dropped.
+func (t *connTransport) dispatch(conn messageConn, message []byte) {
+	entries, err := parseMessage(message)
+	if err != nil {
+		return
+	}
+	ids := envelopeIDs(entries)
+
+	if isRequest(entries) {
+		if len(entries) == 1 && len(ids) == 0 && t.dispatchNotification(&entries[0]) {
+			return
+		}
+		if t.server != nil {
+			go t.serve(conn, message)
+		}
+		return
+	}
+
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	if len(ids) == 0 {
+		if hasError(entries) {
+			t.failPendingLocked(message)
+		}
+		return
+	}
+
+	for _, id := range ids {
+		call, ok := t.pending[id]
+		if !ok {
+			continue
+		}
+		if subscription, ok := t.subscribing[id]; ok {
+			t.startSubscription(subscription, message)
+		}
+		for _, callID := range call.ids {
+			delete(t.pending, callID)
+		}
+		call.response <- pendingResult{message: message}
+		return
+	}
+}
+
+// failPendingLocked delivers message, an error response without id, to all pending calls.
+// Must be called with pendingMutex held.
+func (t *connTransport) failPendingLocked(message []byte) {
+	for _, call := range t.pending {
+		for _, callID := range call.ids {
+			delete(t.pending, callID)
+		}
+		call.response <- pendingResult{message: message}
+	}
+}
+
+// serve handles a request (or batch) of the server and sends the response over conn, the connection it was received on.
+func (t *connTransport) serve(conn messageConn, message []byte) {
+	response := t.server.HandleMessage(t.ctx, message)
+	if response == nil {
+		return
+	}
+
+	t.connMutex.Lock()
+	current := t.conn
+	t.connMutex.Unlock()
+	if current != conn {
+		// the connection was lost, the server does not wait for the response anymore
+		return
+	}
+
+	t.writeMutex.Lock()
+	err := conn.writeMessage(response)
+	t.writeMutex.Unlock()
+	if err != nil {
+		t.connectionLost(conn, err)
+	}
+}
+
+// connectionLost handles a read or write error of conn. Without reconnector the transport is closed,
+// otherwise all pending calls fail and a new connection is dialed in the background.
+// Errors of connections that were already replaced are ignored.
+func (t *connTransport) connectionLost(conn messageConn, err error) {
+	err = fmt.Errorf("connection lost: %w", err)
+	if t.reconnect == nil {
+		t.shutdown(err)
+		return
+	}
+
+	t.connMutex.Lock()
+	if t.conn != conn {
+		t.connMutex.Unlock()
+		return
+	}
+	t.conn = nil
+	t.connMutex.Unlock()
+	conn.close()
+
+	t.pendingMutex.Lock()
+	for _, call := range t.pending {
+		for _, callID := range call.ids {
+			delete(t.pending, callID)
+		}
+		call.response <- pendingResult{err: err}
+	}
+	// the subscription ids are only valid for the lost connection
+	for id, subscription := range t.subscriptions {
+		subscription.started = false
+		delete(t.subscriptions, id)
+	}
+	t.pendingMutex.Unlock()
+
+	go t.redial()
+}
+
+// redial dials a new connection until it succeeds or the transport is closed,
+// and re-establishes all active subscriptions afterwards.
+func (t *connTransport) redial() {
+	delay := t.reconnect.delay
+	for {
+		conn, err := t.reconnect.dial()
+		if err == nil {
+			t.connected(conn)
+			return
+		}
+
+		select {
+		case <-t.closed:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > t.reconnect.maxDelay {
+			delay = t.reconnect.maxDelay
+		}
+	}
+}
+
+func (t *connTransport) connected(conn messageConn) {
+	t.connMutex.Lock()
+	select {
+	case <-t.closed:
+		t.connMutex.Unlock()
+		conn.close()
+		return
+	default:
+	}
+	t.conn = conn
+	t.connMutex.Unlock()
+
+	go t.readLoop(conn)
+
+	t.resubscribeMutex.Lock()
+	defer t.resubscribeMutex.Unlock()
+
+	for _, subscription := range t.lostSubscriptions() {
+		err := subscription.resubscribe()
+		var transportErr *TransportError
+		if errors.As(err, &transportErr) {
+			// the connection was lost again, the subscription is re-established after the next reconnect
+			continue
+		}
+		if err != nil {
+			t.endSubscription(subscription, fmt.Errorf("could not resubscribe: %w", err))
+			continue
+		}
+		if t.subscriptionDone(subscription) {
+			// Unsubscribe() was called while the subscription was re-established
+			subscription.call(subscription.unsubscribeMethod, []interface{}{subscription.ID()})
+		}
+	}
+}
+
+// shutdown closes the transport with the given reason. Only the first reason is kept.
+func (t *connTransport) shutdown(reason error) error {
+	var err error
+	t.closeOnce.Do(func() {
+		t.pendingMutex.Lock()
+		t.err = reason
+		t.cancel()
+		t.connMutex.Lock()
+		close(t.closed)
+		conn := t.conn
+		t.conn = nil
+		t.connMutex.Unlock()
+		for subscription := range t.active {
+			t.endSubscriptionLocked(subscription, reason)
+		}
+		t.pendingMutex.Unlock()
+
+		if conn != nil {
+			err = conn.close()
+		}
+	})
+
+	return err
+}
+
+// messageEnvelope has the members of a message, or of an entry of a batch, that are needed to dispatch it.
+// The params are kept raw, they are only decoded for notifications that are delivered.
+type messageEnvelope struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *ID             `json:"id"`
+	Method  *string         `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Error   json.RawMessage `json:"error"`
+}
+
+// parseMessage decodes a single message or batch into envelopes, a single message has one entry.
+func parseMessage(message []byte) ([]messageEnvelope, error) {
+	message = bytes.TrimSpace(message)
+	if len(message) == 0 {
+		return nil, errors.New("empty message")
+	}
+
+	if message[0] == '[' {
+		var entries []messageEnvelope
+		if err := json.Unmarshal(message, &entries); err != nil {
+			return nil, err
+		}
+		return entries, nil
+	}
+
+	var entry messageEnvelope
+	if err := json.Unmarshal(message, &entry); err != nil {
+		return nil, err
+	}
+	return []messageEnvelope{entry}, nil
+}
+
+// envelopeIDs returns the ids of the entries, entries without id or with id null are skipped.
+func envelopeIDs(entries []messageEnvelope) []ID {
+	ids := make([]ID, 0, len(entries))
+	for _, entry := range entries {
+		if entry.ID != nil && !entry.ID.IsNull() {
+			ids = append(ids, *entry.ID)
+		}
+	}
+	return ids
+}
+
+// isRequest returns true if the entries are a request, notification or batch of them, i.e. all entries have a method.
+// Responses have no method.
+func isRequest(entries []messageEnvelope) bool {
+	if len(entries) == 0 {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Method == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hasError returns true if an entry has an error object.
+func hasError(entries []messageEnvelope) bool {
+	for _, entry := range entries {
+		if len(entry.Error) > 0 && !bytes.Equal(entry.Error, []byte("null")) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageIDs returns the ids of a single request / response or of all entries of a batch.
+// Entries without id or with id null are skipped.
+func messageIDs(message []byte) ([]ID, error) {
+	entries, err := parseMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	return envelopeIDs(entries), nil
+}
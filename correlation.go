@@ -0,0 +1,57 @@
+package jsonrpc
+
+import "context"
+
+// CorrelationIDOpts configures correlation ids, see RPCClientOpts.
+//
+// A correlation id is sent as http header with every call, so that client side logs can be correlated
+// with the logs of proxies and servers. It stays the same for all retries of a call
+// and is part of the CallInfo and the error messages of the call.
+// Ids set with WithCorrelationID() are always sent.
+//
+// Header: the name of the http header (default "X-Request-ID")
+//
+// Generate: returns a new id, by default a random UUID
+type CorrelationIDOpts struct {
+	Header   string
+	Generate func() string
+}
+
+const defaultCorrelationIDHeader = "X-Request-ID"
+
+type correlationIDContextKey struct{}
+
+// WithCorrelationID returns a copy of ctx that sends id as correlation id with calls that use the context.
+// The http header is set by CorrelationIDOpts.Header and defaults to "X-Request-ID".
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, id)
+}
+
+// correlationID returns the correlation id of ctx, "" if there is none.
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDContextKey{}).(string)
+	return id
+}
+
+// withCorrelationID returns ctx with a new correlation id if the client generates ids and ctx has no id yet.
+func (client *rpcClient) withCorrelationID(ctx context.Context) context.Context {
+	opts := client.correlationID
+	if opts == nil || correlationID(ctx) != "" {
+		return ctx
+	}
+
+	generate := opts.Generate
+	if generate == nil {
+		generate = newUUID
+	}
+
+	return WithCorrelationID(ctx, generate())
+}
+
+// describeCorrelationID returns the correlation id of ctx for error messages, "" if there is none.
+func describeCorrelationID(ctx context.Context) string {
+	if id := correlationID(ctx); id != "" {
+		return " (correlation id " + id + ")"
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func newCorrelationServer(status int) (*httptest.Server, func() []string) {
+	var mutex sync.Mutex
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		ids = append(ids, r.Header.Get("X-Request-ID")+r.Header.Get("X-Correlation-ID"))
+		mutex.Unlock()
+
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+
+	return server, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), ids...)
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, ids := newCorrelationServer(http.StatusOK)
+	defer server.Close()
+
+	var hooked []string
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		CorrelationID: &CorrelationIDOpts{},
+		Hooks: &Hooks{OnRequest: func(ctx context.Context, call *CallInfo) {
+			hooked = append(hooked, call.CorrelationID)
+		}},
+	})
+
+	_, err := rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("second")})
+	Expect(err).To(HaveOccurred()) // the server does not return a batch
+
+	Expect(ids()).To(HaveLen(2))
+	Expect(ids()[0]).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+	Expect(ids()[1]).NotTo(Equal(ids()[0]))
+	Expect(hooked).To(Equal(ids()))
+	Expect(err.Error()).To(ContainSubstring("rpc batch call (correlation id " + ids()[1] + "): "))
+
+	// explicit ids
+	_, err = rpcClient.CallContext(WithCorrelationID(context.Background(), "my-id"), "third")
+	Expect(err).To(BeNil())
+	Expect(ids()[2]).To(Equal("my-id"))
+}
+
+func TestCorrelationIDRetries(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, ids := newCorrelationServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	generated := 0
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{MaxAttempts: 3},
+		CorrelationID: &CorrelationIDOpts{
+			Header: "X-Correlation-ID",
+			Generate: func() string {
+				generated++
+				return fmt.Sprintf("id-%v", generated)
+			},
+		},
+	})
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(HavePrefix("rpc call something() (correlation id id-1) status code: 503."))
+	// the id stays the same for all retries
+	Expect(ids()).To(Equal([]string{"id-1", "id-1", "id-1"}))
+}
+
+func TestCorrelationIDDisabled(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, ids := newCorrelationServer(http.StatusOK)
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Call("something")
+	Expect(err).To(BeNil())
+	Expect(ids()).To(Equal([]string{""}))
+
+	// explicit ids are sent anyway
+	_, err = NewClient(server.URL).CallContext(WithCorrelationID(context.Background(), "my-id"), "something")
+	Expect(err).To(BeNil())
+	Expect(ids()).To(Equal([]string{"", "my-id"}))
+}
@@ -0,0 +1,121 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOpts configures the CORS handling of the Server for browser clients, see ServerOpts.
+//
+// AllowedOrigins: the origins that may call the server, or patterns as used by path.Match(),
+// e.g. "https://*.example.com", "*" allows all origins. Origins that are only allowed by "*" get
+// "Access-Control-Allow-Origin: *" without credentials, so that not any website can make credentialed calls.
+//
+// AllowedHeaders: the request headers browsers may send (default Content-Type)
+//
+// ExposedHeaders: the response headers browsers may read
+//
+// AllowCredentials: browsers may send cookies and http authentication, only for origins that are allowed by other patterns than "*"
+//
+// MaxAge: how long browsers may cache the response of a preflight request, the browser default if 0
+type CORSOpts struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// cors handles the CORS headers of the requests of a Server.
+type cors struct {
+	allowedOrigins   []string
+	allowedMethods   string
+	allowedHeaders   string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+func newCORS(opts *CORSOpts, allowedMethods []string) *cors {
+	if opts == nil {
+		return nil
+	}
+
+	c := &cors{
+		allowedOrigins:   opts.AllowedOrigins,
+		allowedMethods:   strings.Join(allowedMethods, ", "),
+		allowedHeaders:   "Content-Type",
+		exposedHeaders:   strings.Join(opts.ExposedHeaders, ", "),
+		allowCredentials: opts.AllowCredentials,
+	}
+	if len(opts.AllowedHeaders) > 0 {
+		c.allowedHeaders = strings.Join(opts.AllowedHeaders, ", ")
+	}
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge / time.Second))
+	}
+	return c
+}
+
+// allowsOrigin returns true if origin may call the server, wildcard is true if it is only allowed by "*".
+func (c *cors) allowsOrigin(origin string) (allowed, wildcard bool) {
+	for _, pattern := range c.allowedOrigins {
+		if pattern == "*" {
+			wildcard = true
+			continue
+		}
+		if ok, err := path.Match(pattern, origin); strings.EqualFold(pattern, origin) || (err == nil && ok) {
+			return true, false
+		}
+	}
+	return wildcard, wildcard
+}
+
+// handle adds the CORS headers for requests from allowed origins. It returns true if the request was
+// a preflight request that has been answered.
+func (c *cors) handle(w http.ResponseWriter, r *http.Request) bool {
+	if c == nil {
+		return false
+	}
+
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	allowed, wildcard := c.allowsOrigin(origin)
+	if !allowed {
+		return false
+	}
+
+	if wildcard {
+		// browsers don't send credentials to "*"
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+		if c.allowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		if c.exposedHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+		}
+		return false
+	}
+
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	header.Set("Access-Control-Allow-Methods", c.allowedMethods)
+	header.Set("Access-Control-Allow-Headers", c.allowedHeaders)
+	if c.maxAge != "" {
+		header.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,97 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerCORS(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServerWithOpts(&ServerOpts{
+		AllowedMethods: []string{http.MethodPost, http.MethodGet},
+		CORS: &CORSOpts{
+			AllowedOrigins:   []string{"https://app.example.com", "https://*.dapp.io"},
+			AllowedHeaders:   []string{"Content-Type", "Authorization"},
+			ExposedHeaders:   []string{"X-Request-Id"},
+			AllowCredentials: true,
+			MaxAge:           10 * time.Minute,
+		},
+	})
+	server.Register("ping", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "pong", nil
+	})
+
+	serve := func(method string, origin string, requestMethod string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"ping","id":1}`))
+		request.Header.Set("Content-Type", "application/json")
+		if origin != "" {
+			request.Header.Set("Origin", origin)
+		}
+		if requestMethod != "" {
+			request.Header.Set("Access-Control-Request-Method", requestMethod)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// preflight
+	recorder := serve(http.MethodOptions, "https://app.example.com", http.MethodPost)
+	Expect(recorder.Code).To(Equal(http.StatusNoContent))
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Methods")).To(Equal("POST, GET"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Headers")).To(Equal("Content-Type, Authorization"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+	Expect(recorder.Header().Get("Access-Control-Max-Age")).To(Equal("600"))
+	Expect(recorder.Header()["Vary"]).To(Equal([]string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}))
+
+	// actual request
+	recorder = serve(http.MethodPost, "https://wallet.dapp.io", "")
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+	Expect(recorder.Body.String()).To(Equal(`{"jsonrpc":"2.0","result":"pong","id":1}`))
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://wallet.dapp.io"))
+	Expect(recorder.Header().Get("Access-Control-Expose-Headers")).To(Equal("X-Request-Id"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Methods")).To(BeEmpty())
+
+	// other origins get no CORS headers
+	recorder = serve(http.MethodOptions, "https://evil.com", http.MethodPost)
+	Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	recorder = serve(http.MethodPost, "https://evil.com", "")
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(BeEmpty())
+
+	// requests without origin are not affected
+	recorder = serve(http.MethodPost, "", "")
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(BeEmpty())
+
+	// any origin
+	server = NewServerWithOpts(&ServerOpts{CORS: &CORSOpts{AllowedOrigins: []string{"*"}}})
+	recorder = serve(http.MethodOptions, "http://localhost:3000", http.MethodPost)
+	Expect(recorder.Code).To(Equal(http.StatusNoContent))
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(Equal("*"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Methods")).To(Equal("POST"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Headers")).To(Equal("Content-Type"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Credentials")).To(BeEmpty())
+	Expect(recorder.Header().Get("Access-Control-Max-Age")).To(BeEmpty())
+
+	// credentials are only allowed for the origins of other patterns than "*"
+	server = NewServerWithOpts(&ServerOpts{CORS: &CORSOpts{AllowedOrigins: []string{"*", "https://app.example.com"}, AllowCredentials: true}})
+	recorder = serve(http.MethodPost, "https://evil.com", "")
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(Equal("*"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Credentials")).To(BeEmpty())
+	recorder = serve(http.MethodOptions, "https://evil.com", http.MethodPost)
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(Equal("*"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Credentials")).To(BeEmpty())
+	recorder = serve(http.MethodPost, "https://app.example.com", "")
+	Expect(recorder.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+	Expect(recorder.Header().Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+}
@@ -0,0 +1,225 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugOpts enables the debug dump of the raw http requests and responses, see RPCClientOpts.
+// The dump contains the http requests as they are sent, after the HTTPMiddleware.
+// Response bodies are read completely before they are returned, so streamed batch responses are not streamed.
+//
+// Writer: receives the dumps (default os.Stderr)
+//
+// RedactHeaders: the values of these headers are replaced by "[REDACTED]"
+// (default Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key)
+//
+// The params of the requests and results of the responses are passed through the Redactor of the client.
+// The key of APIKeyOpts is replaced by "[REDACTED]".
+type DebugOpts struct {
+	Writer        io.Writer
+	RedactHeaders []string
+}
+
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// newDebugMiddleware returns a middleware that dumps the http requests and responses.
+// The secrets are replaced by "[REDACTED]" in the dumps.
+func newDebugMiddleware(opts DebugOpts, redactor Redactor, secrets ...string) func(http.RoundTripper) http.RoundTripper {
+	d := &debugDumper{
+		writer:        opts.Writer,
+		redactHeaders: opts.RedactHeaders,
+		redactor:      redactor,
+		secrets:       secrets,
+	}
+	if d.writer == nil {
+		d.writer = os.Stderr
+	}
+	if d.redactHeaders == nil {
+		d.redactHeaders = defaultRedactHeaders
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			return d.roundTrip(next, request)
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+type debugDumper struct {
+	mutex         sync.Mutex
+	writer        io.Writer
+	redactHeaders []string
+	redactor      Redactor
+	secrets       []string
+}
+
+func (d *debugDumper) roundTrip(next http.RoundTripper, request *http.Request) (*http.Response, error) {
+	body, _ := readRequestBody(request)
+	methods := requestMethods(body)
+
+	dumped := request.Clone(request.Context())
+	dumped.Header = d.redactHeader(request.Header)
+	// the body is dumped uncompressed
+	dumped.Header.Del("Content-Encoding")
+	redactedBody := d.redactBody(body, "params", func(message map[string]json.RawMessage) string {
+		var method string
+		json.Unmarshal(message["method"], &method)
+		return method
+	})
+	dumped.Body = ioutil.NopCloser(bytes.NewReader(redactedBody))
+	dumped.ContentLength = int64(len(redactedBody))
+	requestDump, err := httputil.DumpRequestOut(dumped, true)
+	if err != nil {
+		requestDump = []byte(fmt.Sprintf("dump request: %v\n", err))
+	}
+
+	start := time.Now()
+	response, err := next.RoundTrip(request)
+	duration := time.Since(start)
+
+	var responseDump []byte
+	if err != nil {
+		responseDump = []byte(fmt.Sprintf("%v\n", err))
+	} else {
+		responseDump = d.dumpResponse(response, methods)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	dump := fmt.Sprintf(">>> %v %v\n%s\n<<< %v\n%s\n\n", request.Method, request.URL.Redacted(), requestDump, duration, responseDump)
+	io.WriteString(d.writer, redactString(dump, d.secrets...))
+
+	return response, err
+}
+
+// dumpResponse reads the body of response and replaces it with a copy.
+func (d *debugDumper) dumpResponse(response *http.Response, methods map[string]string) []byte {
+	body, readErr := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if readErr != nil {
+		response.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), errorReader{readErr}))
+	} else {
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	dumped := *response
+	dumped.Header = d.redactHeader(response.Header)
+	redactedBody := d.redactBody(body, "result", func(message map[string]json.RawMessage) string {
+		return methods[string(message["id"])]
+	})
+	dumped.Body = ioutil.NopCloser(bytes.NewReader(redactedBody))
+	dumped.ContentLength = int64(len(redactedBody))
+	dump, err := httputil.DumpResponse(&dumped, true)
+	if err != nil {
+		return []byte(fmt.Sprintf("dump response: %v\n", err))
+	}
+	if readErr != nil {
+		dump = append(dump, fmt.Sprintf("\nread body: %v\n", readErr)...)
+	}
+
+	return dump
+}
+
+func (d *debugDumper) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range d.redactHeaders {
+		if _, ok := redacted[http.CanonicalHeaderKey(name)]; ok {
+			redacted.Set(name, redactedValue)
+		}
+	}
+	return redacted
+}
+
+// redactBody passes the field of every JSON-RPC message of body through the redactor,
+// method returns the method of a message. Bodies that are no JSON-RPC messages are returned as they are.
+func (d *debugDumper) redactBody(body []byte, field string, method func(map[string]json.RawMessage) string) []byte {
+	if d.redactor == nil {
+		return body
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	batch := bytes.HasPrefix(trimmed, []byte("["))
+	var messages []map[string]json.RawMessage
+	if batch {
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return body
+		}
+	} else {
+		var message map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &message); err != nil {
+			return body
+		}
+		messages = append(messages, message)
+	}
+
+	for _, message := range messages {
+		value, ok := message[field]
+		if !ok {
+			continue
+		}
+		var generic interface{}
+		if err := json.Unmarshal(value, &generic); err != nil {
+			continue
+		}
+		redacted, err := json.Marshal(d.redactor(method(message), generic))
+		if err != nil {
+			redacted = []byte(`"` + redactedValue + `"`)
+		}
+		message[field] = redacted
+	}
+
+	var redacted []byte
+	var err error
+	if batch {
+		redacted, err = json.Marshal(messages)
+	} else {
+		redacted, err = json.Marshal(messages[0])
+	}
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// requestMethods returns the methods of the requests of body by their json encoded id.
+func requestMethods(body []byte) map[string]string {
+	var requests []struct {
+		Method string          `json:"method"`
+		ID     json.RawMessage `json:"id"`
+	}
+	trimmed := bytes.TrimSpace(body)
+	if !strings.HasPrefix(string(trimmed), "[") {
+		trimmed = append(append([]byte("["), trimmed...), ']')
+	}
+	json.Unmarshal(trimmed, &requests)
+
+	methods := make(map[string]string, len(requests))
+	for _, request := range requests {
+		methods[string(request.ID)] = request.Method
+	}
+	return methods
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,87 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDebug(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("X-Upstream", "node-1")
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":{"privateKey":"secret","balance":1},"id":0}`)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		CustomHeaders: map[string]string{"Authorization": "Bearer secret", "X-Client": "test"},
+		Redactor:      RedactFields("password", "privateKey"),
+		Debug:         &DebugOpts{Writer: &output},
+	})
+
+	res, err := rpcClient.Call("login", map[string]string{"user": "alex", "password": "secret"})
+	Expect(err).To(BeNil())
+	// the response is returned unchanged
+	Expect(res.Result).To(HaveKeyWithValue("privateKey", "secret"))
+
+	dump := output.String()
+	Expect(dump).NotTo(ContainSubstring("secret"))
+	Expect(dump).To(ContainSubstring(">>> POST " + server.URL))
+	Expect(dump).To(ContainSubstring("Authorization: [REDACTED]"))
+	Expect(dump).To(ContainSubstring("X-Client: test"))
+	Expect(dump).To(ContainSubstring(`"params":{"password":"[REDACTED]","user":"alex"}`))
+	Expect(dump).To(ContainSubstring("HTTP/1.1 200 OK"))
+	Expect(dump).To(ContainSubstring("Set-Cookie: [REDACTED]"))
+	Expect(dump).To(ContainSubstring("X-Upstream: node-1"))
+	Expect(dump).To(ContainSubstring(`"result":{"balance":1,"privateKey":"[REDACTED]"}`))
+}
+
+func TestDebugBatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"jsonrpc":"2.0","result":{"token":"secret"},"id":0},{"jsonrpc":"2.0","result":{"token":"public"},"id":1}]`)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Redactor: func(method string, params interface{}) interface{} {
+			// only the results of "secret" are redacted
+			if method == "secret" {
+				return redactedValue
+			}
+			return params
+		},
+		Debug: &DebugOpts{Writer: &output},
+	})
+
+	_, err := rpcClient.CallBatch(RPCRequests{NewRequest("secret", "key"), NewRequest("public", "key")})
+	Expect(err).To(BeNil())
+
+	dump := output.String()
+	Expect(dump).NotTo(ContainSubstring(`{"token":"secret"}`))
+	Expect(dump).To(ContainSubstring(`"method":"public","params":["key"]`))
+	Expect(dump).To(ContainSubstring(`"result":"[REDACTED]"`))
+	Expect(dump).To(ContainSubstring(`"result":{"token":"public"}`))
+}
+
+func TestDebugTransportError(t *testing.T) {
+	RegisterTestingT(t)
+
+	var output bytes.Buffer
+	rpcClient := NewClientWithOpts("http://127.0.0.1:1", &RPCClientOpts{Debug: &DebugOpts{Writer: &output}})
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(output.String()).To(ContainSubstring(">>> POST http://127.0.0.1:1"))
+	Expect(output.String()).To(ContainSubstring("connection refused"))
+}
@@ -0,0 +1,287 @@
+package jsonrpc
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DigestAuthOpts configures HTTP digest authentication (RFC 7616), see RPCClientOpts.
+//
+// The first request is sent without credentials. The challenge of the server is answered and reused
+// for the following requests with an increasing nonce count, until the server sends a new (e.g. stale) nonce.
+// The algorithms MD5, SHA-256 and SHA-512-256 (also as -sess variants) and the qop values auth and auth-int are supported.
+// Only challenges of the host of the endpoint are answered, not of another host the endpoint redirects to.
+//
+// Username, Password: the credentials
+type DigestAuthOpts struct {
+	Username string
+	Password string
+}
+
+// digestChallenge is a parsed WWW-Authenticate digest challenge.
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+	stale     bool
+}
+
+// digestAlgorithms are the supported algorithms from the weakest to the strongest.
+var digestAlgorithms = []string{"MD5", "SHA-256", "SHA-512-256"}
+
+// parseDigestChallenges returns the strongest supported digest challenge of the WWW-Authenticate headers,
+// nil if there is none.
+func parseDigestChallenges(header http.Header) *digestChallenge {
+	var best *digestChallenge
+	bestStrength := -1
+	for _, value := range header[http.CanonicalHeaderKey("WWW-Authenticate")] {
+		if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+			continue
+		}
+
+		params := parseAuthParams(value[7:])
+		challenge := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+			stale:     strings.EqualFold(params["stale"], "true"),
+		}
+		if challenge.algorithm == "" {
+			challenge.algorithm = "MD5"
+		}
+
+		qops := strings.Split(params["qop"], ",")
+		for _, qop := range qops {
+			qop = strings.TrimSpace(qop)
+			if qop == "auth" || (qop == "auth-int" && challenge.qop == "") {
+				challenge.qop = qop
+			}
+		}
+		if params["qop"] != "" && challenge.qop == "" {
+			continue
+		}
+
+		strength := -1
+		for i, algorithm := range digestAlgorithms {
+			if strings.EqualFold(strings.TrimSuffix(strings.ToUpper(challenge.algorithm), "-SESS"), algorithm) {
+				strength = i
+			}
+		}
+		if strength > bestStrength && challenge.nonce != "" {
+			best, bestStrength = challenge, strength
+		}
+	}
+
+	return best
+}
+
+// parseAuthParams parses the comma separated auth-params of a challenge, e.g. realm="x", qop="auth,auth-int".
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var value strings.Builder
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++ // closing quote
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value.WriteString(strings.TrimSpace(s[:end]))
+			s = s[end:]
+		}
+		params[key] = value.String()
+	}
+}
+
+// digestHash returns the hash function of an algorithm.
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "SHA-256":
+		return sha256.New
+	case "SHA-512-256":
+		return sha512.New512_256
+	default:
+		return md5.New
+	}
+}
+
+// authorization returns the Authorization header of a request with nonce count nc and client nonce cnonce.
+func (c *digestChallenge) authorization(opts DigestAuthOpts, method, uri string, body []byte, nc uint32, cnonce string) string {
+	newHash := digestHash(c.algorithm)
+	h := func(s string) string {
+		hash := newHash()
+		hash.Write([]byte(s))
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	ha1 := h(opts.Username + ":" + c.realm + ":" + opts.Password)
+	if strings.HasSuffix(strings.ToUpper(c.algorithm), "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+	if c.qop == "auth-int" {
+		ha2 = h(method + ":" + uri + ":" + h(string(body)))
+	}
+
+	count := fmt.Sprintf("%08x", nc)
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + count + ":" + cnonce + ":" + c.qop + ":" + ha2)
+	}
+
+	username := opts.Username
+	if c.userhash {
+		username = h(opts.Username + ":" + c.realm)
+	}
+
+	params := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", c.realm),
+		fmt.Sprintf("nonce=%q", c.nonce),
+		fmt.Sprintf("uri=%q", uri),
+		"algorithm=" + c.algorithm,
+		fmt.Sprintf("response=%q", response),
+	}
+	if c.opaque != "" {
+		params = append(params, fmt.Sprintf("opaque=%q", c.opaque))
+	}
+	if c.qop != "" {
+		params = append(params, "qop="+c.qop, "nc="+count, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if c.userhash {
+		params = append(params, "userhash=true")
+	}
+
+	return "Digest " + strings.Join(params, ", ")
+}
+
+func newCnonce() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strings.Replace(newUUID(), "-", "", -1)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// digestAuth answers the digest challenges of a server.
+type digestAuth struct {
+	opts DigestAuthOpts
+
+	mutex     sync.Mutex
+	challenge *digestChallenge
+	nc        uint32
+}
+
+// newDigestAuthMiddleware returns a middleware that answers the digest challenges of the server.
+func newDigestAuthMiddleware(opts DigestAuthOpts) func(http.RoundTripper) http.RoundTripper {
+	auth := &digestAuth{opts: opts}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if isCrossHostRedirect(request) {
+				// another host the endpoint redirects to must not get an answer for the credentials
+				return next.RoundTrip(request)
+			}
+			return auth.roundTrip(next, request)
+		})
+	}
+}
+
+func (a *digestAuth) roundTrip(next http.RoundTripper, request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.GetBody != nil {
+		reader, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		body, err = ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sent, authorized := a.authorize(request, body, nil)
+	response, err := next.RoundTrip(authorized)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	challenge := parseDigestChallenges(response.Header)
+	if challenge == nil || (sent != nil && sent.nonce == challenge.nonce && !challenge.stale) {
+		// no digest challenge or the credentials were rejected
+		return response, nil
+	}
+	if request.Body != nil && request.GetBody == nil {
+		// the body can't be sent again
+		return response, nil
+	}
+
+	_, authorized = a.authorize(request, body, challenge)
+	if request.GetBody != nil {
+		retryBody, err := request.GetBody()
+		if err != nil {
+			return response, nil
+		}
+		authorized.Body = retryBody
+	}
+	response.Body.Close()
+
+	return next.RoundTrip(authorized)
+}
+
+// authorize returns the challenge that is answered and a copy of request with the Authorization header.
+// If challenge is not nil, it replaces the current challenge. Without a challenge the request is returned as it is.
+func (a *digestAuth) authorize(request *http.Request, body []byte, challenge *digestChallenge) (*digestChallenge, *http.Request) {
+	a.mutex.Lock()
+	if challenge != nil {
+		a.challenge, a.nc = challenge, 0
+	}
+	challenge = a.challenge
+	a.nc++
+	nc := a.nc
+	a.mutex.Unlock()
+
+	if challenge == nil {
+		return nil, request
+	}
+
+	authorized := request.Clone(request.Context())
+	authorized.Header.Set("Authorization", challenge.authorization(a.opts, request.Method, request.URL.RequestURI(), body, nc, newCnonce()))
+	return challenge, authorized
+}
@@ -0,0 +1,151 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	RegisterTestingT(t)
+
+	// example of RFC 7616 section 3.9.1
+	header := http.Header{}
+	header.Add("WWW-Authenticate", `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`)
+	header.Add("WWW-Authenticate", `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`)
+
+	challenge := parseDigestChallenges(header)
+	Expect(challenge).To(Equal(&digestChallenge{
+		realm:     "http-auth@example.org",
+		nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+		algorithm: "SHA-256",
+		qop:       "auth",
+	}))
+
+	opts := DigestAuthOpts{Username: "Mufasa", Password: "Circle of Life"}
+	cnonce := "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	Expect(challenge.authorization(opts, "GET", "/dir/index.html", nil, 1, cnonce)).To(Equal(
+		`Digest username="Mufasa", realm="http-auth@example.org", nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", ` +
+			`uri="/dir/index.html", algorithm=SHA-256, response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", ` +
+			`opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS", qop=auth, nc=00000001, cnonce="f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"`,
+	))
+
+	challenge.algorithm = "MD5"
+	Expect(challenge.authorization(opts, "GET", "/dir/index.html", nil, 1, cnonce)).To(ContainSubstring(`response="8ca523f5e9506fed4657c9700eebdbec"`))
+}
+
+func TestParseAuthParams(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(parseAuthParams(`realm="a \"b\", c", nonce=xyz, stale=TRUE,qop="auth"`)).To(Equal(map[string]string{
+		"realm": `a "b", c`,
+		"nonce": "xyz",
+		"stale": "TRUE",
+		"qop":   "auth",
+	}))
+	Expect(parseAuthParams(`realm="unterminated`)).To(Equal(map[string]string{"realm": "unterminated"}))
+	Expect(parseAuthParams("")).To(BeEmpty())
+}
+
+func TestDigestAuth(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	nonce := "nonce-1"
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		authorization := r.Header.Get("Authorization")
+		authorizations = append(authorizations, authorization)
+
+		params := parseAuthParams(strings.TrimPrefix(authorization, "Digest "))
+		challenge := &digestChallenge{realm: "rpc", nonce: nonce, algorithm: "SHA-256", qop: "auth"}
+		var nc uint32
+		fmt.Sscanf(params["nc"], "%x", &nc)
+		expected := challenge.authorization(DigestAuthOpts{Username: "user", Password: "secret"}, r.Method, r.URL.RequestURI(), nil, nc, params["cnonce"])
+		if authorization != expected {
+			stale := ""
+			if params["nonce"] != "" && params["nonce"] != nonce {
+				stale = ", stale=true"
+			}
+			w.Header().Add("WWW-Authenticate", `Basic realm="rpc"`)
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="rpc", qop="auth", algorithm=SHA-256, nonce=%q%v`, nonce, stale))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL+"/rpc?x=1", &RPCClientOpts{
+		DigestAuth: &DigestAuthOpts{Username: "user", Password: "secret"},
+	})
+
+	_, err := rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("second")
+	Expect(err).To(BeNil())
+
+	// the nonce expired
+	mutex.Lock()
+	nonce = "nonce-2"
+	mutex.Unlock()
+	_, err = rpcClient.Call("third")
+	Expect(err).To(BeNil())
+
+	Expect(authorizations).To(HaveLen(5))
+	Expect(authorizations[0]).To(BeEmpty())
+	Expect(authorizations[1]).To(ContainSubstring(`nonce="nonce-1", uri="/rpc?x=1"`))
+	Expect(authorizations[1]).To(ContainSubstring("nc=00000001"))
+	// the challenge is reused
+	Expect(authorizations[2]).To(ContainSubstring("nc=00000002"))
+	Expect(authorizations[3]).To(ContainSubstring(`nonce="nonce-1"`))
+	Expect(authorizations[4]).To(ContainSubstring(`nonce="nonce-2"`))
+	Expect(authorizations[4]).To(ContainSubstring("nc=00000001"))
+
+	// wrong credentials are not retried endlessly
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{
+		DigestAuth: &DigestAuthOpts{Username: "user", Password: "wrong"},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("status code: 401"))
+	Expect(authorizations).To(HaveLen(7))
+}
+
+func TestDigestAuthRedirect(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	var authorizations []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mutex.Unlock()
+		w.Header().Set("WWW-Authenticate", `Digest realm="rpc", qop="auth", algorithm=SHA-256, nonce="nonce"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.RedirectHandler(other.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		DigestAuth: &DigestAuthOpts{Username: "user", Password: "secret"},
+	})
+	_, err := rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("status code: 401"))
+
+	// the challenge of the other host is not answered
+	mutex.Lock()
+	defer mutex.Unlock()
+	Expect(authorizations).To(Equal([]string{""}))
+}
@@ -0,0 +1,113 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type endpointContextKey struct{}
+
+// WithEndpoint returns a copy of ctx that sends the http requests of the calls that use the context to endpoint
+// instead of the endpoint of the client, e.g. to an archive node for historical queries.
+// The auth, headers and transport options of the client are used for the endpoint.
+//
+// The endpoint may contain {name} vars, see WithEndpointVars().
+// The endpoint replaces the endpoints of a client created with NewClientWithEndpoints(), so a failed request is
+// retried at the same endpoint. Websocket, TCP and IPC clients ignore the endpoint.
+func WithEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointContextKey{}, endpoint)
+}
+
+// contextEndpoint returns the endpoint of ctx, "" if there is none.
+func contextEndpoint(ctx context.Context) string {
+	endpoint, _ := ctx.Value(endpointContextKey{}).(string)
+	return endpoint
+}
+
+type endpointVarsContextKey struct{}
+
+// WithEndpointVars returns a copy of ctx that substitutes vars in the endpoint template of the calls that use the context,
+// e.g. {"network": "testnet"} for the endpoint "https://{network}.example.com/rpc". They replace RPCClientOpts.EndpointVars
+// and the vars of a parent context of the same name.
+func WithEndpointVars(ctx context.Context, vars map[string]string) context.Context {
+	merged := make(map[string]string, len(vars))
+	for name, value := range contextEndpointVars(ctx) {
+		merged[name] = value
+	}
+	for name, value := range vars {
+		merged[name] = value
+	}
+	return context.WithValue(ctx, endpointVarsContextKey{}, merged)
+}
+
+// contextEndpointVars returns the endpoint vars of ctx, nil if there are none.
+func contextEndpointVars(ctx context.Context) map[string]string {
+	vars, _ := ctx.Value(endpointVarsContextKey{}).(map[string]string)
+	return vars
+}
+
+// expandEndpoint replaces the {name} vars of template with the vars of ctx or defaults.
+// The values are escaped as URL path segments. It is an error if a var has no value.
+func expandEndpoint(ctx context.Context, template string, defaults map[string]string) (string, error) {
+	if !strings.Contains(template, "{") {
+		return template, nil
+	}
+
+	vars := contextEndpointVars(ctx)
+	var expanded strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("endpoint %q: unclosed {", template)
+		}
+		name := rest[start+1 : start+end]
+		value, ok := vars[name]
+		if !ok {
+			value, ok = defaults[name]
+		}
+		if !ok {
+			return "", fmt.Errorf("endpoint %q: no value for {%v}", template, name)
+		}
+		expanded.WriteString(rest[:start])
+		expanded.WriteString(url.PathEscape(value))
+		rest = rest[start+end+1:]
+	}
+	expanded.WriteString(rest)
+
+	return expanded.String(), nil
+}
+
+// endpointKey returns a key of the endpoint and endpoint vars of ctx, calls with different keys may be sent to
+// different endpoints.
+func endpointKey(ctx context.Context) string {
+	vars := contextEndpointVars(ctx)
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	key := contextEndpoint(ctx)
+	for _, name := range names {
+		key += "\x00" + name + "=" + vars[name]
+	}
+	return key
+}
+
+// endpointFor returns the endpoint of ctx if it is set, the endpoint of the transport otherwise,
+// with the endpoint vars substituted.
+func (t *httpTransport) endpointFor(ctx context.Context) (string, error) {
+	endpoint := t.endpoint
+	if e := contextEndpoint(ctx); e != "" {
+		endpoint = e
+	}
+	return expandEndpoint(ctx, endpoint, t.endpointVars)
+}
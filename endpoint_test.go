@@ -0,0 +1,130 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWithEndpoint(t *testing.T) {
+	RegisterTestingT(t)
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","result":[%q,%q,%q],"id":0}`, name, r.URL.Path, r.Header.Get("Authorization"))
+		}))
+	}
+	primary := newServer("primary")
+	defer primary.Close()
+	archive := newServer("archive")
+	defer archive.Close()
+
+	rpcClient := NewClientWithOpts(primary.URL, &RPCClientOpts{
+		CustomHeaders: map[string]string{"Authorization": "Bearer token"},
+	})
+
+	var result []string
+	Expect(rpcClient.CallFor(&result, "eth_getBalance")).To(Succeed())
+	Expect(result).To(Equal([]string{"primary", "/", "Bearer token"}))
+
+	call := &CallInfo{}
+	ctx := WithCallInfo(WithEndpoint(context.Background(), archive.URL+"/rpc"), call)
+	Expect(rpcClient.CallForContext(ctx, &result, "eth_getBalance")).To(Succeed())
+	Expect(result).To(Equal([]string{"archive", "/rpc", "Bearer token"}))
+	Expect(call.Endpoint).To(Equal(archive.URL + "/rpc"))
+
+	// batches and GET requests
+	call = &CallInfo{}
+	rpcClient.CallBatchContext(WithCallInfo(WithEndpoint(context.Background(), archive.URL), call), RPCRequests{NewRequest("a")})
+	Expect(call.Endpoint).To(Equal(archive.URL))
+
+	getClient := NewClientWithOpts(primary.URL, &RPCClientOpts{HTTPMethod: http.MethodGet})
+	Expect(getClient.CallForContext(WithEndpoint(context.Background(), archive.URL+"/get"), &result, "a")).To(Succeed())
+	Expect(result[:2]).To(Equal([]string{"archive", "/get"}))
+
+	// an invalid endpoint is not retried
+	_, err := rpcClient.CallContext(WithEndpoint(context.Background(), "://invalid"), "a")
+	Expect(err).NotTo(BeNil())
+	Expect(IsRetryable(err)).To(BeFalse())
+}
+
+func TestWithEndpoint_Singleflight(t *testing.T) {
+	RegisterTestingT(t)
+
+	s := newSingleflight(&SingleflightOpts{})
+	request := NewRequest("eth_getBalance", "0x1")
+	Expect(s.key(context.Background(), request)).NotTo(Equal(s.key(WithEndpoint(context.Background(), "http://archive"), request)))
+	Expect(s.key(WithEndpoint(context.Background(), "http://archive"), request)).To(Equal(s.key(WithEndpoint(context.Background(), "http://archive"), request)))
+}
+
+func TestWithEndpointVars(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%q,"id":0}`, r.URL.EscapedPath())
+	}))
+	defer server.Close()
+
+	vars := map[string]string{"network": "mainnet", "version": "v1"}
+	rpcClient := NewClientWithOpts(server.URL+"/{network}/rpc/{version}", &RPCClientOpts{EndpointVars: vars})
+	// the vars are copied
+	vars["network"] = "modified"
+
+	var path string
+	Expect(rpcClient.CallFor(&path, "something")).To(Succeed())
+	Expect(path).To(Equal("/mainnet/rpc/v1"))
+
+	ctx := WithEndpointVars(context.Background(), map[string]string{"network": "testnet"})
+	Expect(rpcClient.CallForContext(ctx, &path, "something")).To(Succeed())
+	Expect(path).To(Equal("/testnet/rpc/v1"))
+
+	// vars of the parent are kept, values are escaped
+	nested := WithEndpointVars(ctx, map[string]string{"version": "a/b"})
+	Expect(rpcClient.CallForContext(nested, &path, "something")).To(Succeed())
+	Expect(path).To(Equal("/testnet/rpc/a%2Fb"))
+
+	// vars of an endpoint of WithEndpoint
+	ctx = WithEndpoint(ctx, server.URL+"/archive/{network}")
+	Expect(rpcClient.CallForContext(ctx, &path, "something")).To(Succeed())
+	Expect(path).To(Equal("/archive/testnet"))
+
+	// a missing var is not retried
+	_, err := NewClient(server.URL + "/{network}").Call("something")
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("no value for {network}"))
+	Expect(IsRetryable(err)).To(BeFalse())
+}
+
+func TestExpandEndpoint(t *testing.T) {
+	RegisterTestingT(t)
+
+	ctx := context.Background()
+	tests := []struct {
+		template string
+		expanded string
+		err      string
+	}{
+		{"https://example.com/rpc", "https://example.com/rpc", ""},
+		{"https://{network}.example.com/rpc/{version}", "https://near.example.com/rpc/2", ""},
+		{"https://example.com/{network}{network}", "https://example.com/nearnear", ""},
+		{"https://example.com/{missing}", "", "no value for {missing}"},
+		{"https://example.com/{network", "", "unclosed {"},
+	}
+	for _, test := range tests {
+		expanded, err := expandEndpoint(ctx, test.template, map[string]string{"network": "near", "version": "2"})
+		if test.err != "" {
+			Expect(err).To(MatchError(ContainSubstring(test.err)))
+			continue
+		}
+		Expect(err).To(BeNil())
+		Expect(expanded).To(Equal(test.expanded))
+	}
+
+	key := endpointKey(WithEndpointVars(ctx, map[string]string{"a": "1", "b": "2"}))
+	Expect(key).To(Equal(endpointKey(WithEndpointVars(WithEndpointVars(ctx, map[string]string{"b": "2"}), map[string]string{"a": "1"}))))
+	Expect(key).NotTo(Equal(endpointKey(WithEndpointVars(ctx, map[string]string{"a": "2", "b": "2"}))))
+}
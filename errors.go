@@ -0,0 +1,71 @@
+package jsonrpc
+
+import (
+	"errors"
+)
+
+// ErrMissingResponse is returned if the server sent no response for a request, e.g. an empty body.
+// It is the error of a call of a batch without a response, see BatchError.
+var ErrMissingResponse = errors.New("rpc response missing")
+
+// IsRetryable returns true if the failed request may succeed when it is sent again.
+//
+// The decision is based on the first error in the chain that implements Temporary() bool:
+//   - TransportError: retryable unless the request was canceled or a tls certificate error occurred
+//   - HTTPError: retryable for status codes 408, 425, 429, 502, 503 and 504
+//   - errors marked with MarkTemporary() or MarkPermanent()
+//
+// All other errors, including RPCError responses of the server, are not retryable.
+//
+// Note that a retryable error does not mean the server did not process the request,
+// so only idempotent methods should be retried blindly.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var classified interface{ Temporary() bool }
+	if errors.As(err, &classified) {
+		return classified.Temporary()
+	}
+
+	return false
+}
+
+// MarkTemporary wraps err so that IsRetryable(err) returns true.
+// It returns nil if err is nil.
+func MarkTemporary(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &classifiedError{err: err, temporary: true}
+}
+
+// MarkPermanent wraps err so that IsRetryable(err) returns false.
+// It returns nil if err is nil.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &classifiedError{err: err, temporary: false}
+}
+
+// classifiedError is an error that was explicitly marked as temporary or permanent.
+type classifiedError struct {
+	err       error
+	temporary bool
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+func (e *classifiedError) Temporary() bool {
+	return e.temporary
+}
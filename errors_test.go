@@ -0,0 +1,64 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestIsRetryable(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(IsRetryable(nil)).To(BeFalse())
+	Expect(IsRetryable(errors.New("unknown"))).To(BeFalse())
+	Expect(IsRetryable(&RPCError{Code: -32601, Message: "method not found"})).To(BeFalse())
+	Expect(IsRetryable(context.Canceled)).To(BeFalse())
+
+	Expect(IsRetryable(&HTTPError{Code: http.StatusServiceUnavailable, err: errors.New("503")})).To(BeTrue())
+	Expect(IsRetryable(&HTTPError{Code: http.StatusTooManyRequests, err: errors.New("429")})).To(BeTrue())
+	Expect(IsRetryable(&HTTPError{Code: http.StatusBadRequest, err: errors.New("400")})).To(BeFalse())
+	Expect(IsRetryable(&HTTPError{Code: http.StatusInternalServerError, err: errors.New("500")})).To(BeFalse())
+
+	Expect(IsRetryable(&TransportError{err: errors.New("connection refused")})).To(BeTrue())
+	Expect(IsRetryable(&TransportError{err: fmt.Errorf("rpc call: %w", context.Canceled)})).To(BeFalse())
+	Expect(IsRetryable(&TransportError{err: fmt.Errorf("rpc call: %w", MarkPermanent(errors.New("invalid url")))})).To(BeFalse())
+
+	Expect(IsRetryable(MarkTemporary(errors.New("try again")))).To(BeTrue())
+	Expect(IsRetryable(MarkTemporary(context.Canceled))).To(BeTrue())
+	Expect(IsRetryable(MarkPermanent(&HTTPError{Code: http.StatusServiceUnavailable, err: errors.New("503")}))).To(BeFalse())
+	Expect(IsRetryable(fmt.Errorf("wrapped: %w", MarkTemporary(errors.New("try again"))))).To(BeTrue())
+	Expect(MarkTemporary(nil)).To(BeNil())
+	Expect(MarkPermanent(nil)).To(BeNil())
+}
+
+func TestRpcClient_ErrorClassification(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	rpcClient := NewClient(server.URL)
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	server.Close()
+
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("something")})
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	_, err = NewClient("://invalid").Call("something")
+	Expect(err).NotTo(BeNil())
+	Expect(IsRetryable(err)).To(BeFalse())
+}
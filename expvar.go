@@ -0,0 +1,127 @@
+package jsonrpc
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"sync"
+)
+
+// expvarMutex guards the lookup and creation of the expvar maps.
+var expvarMutex sync.Mutex
+
+// expvarCounters publishes the counters of a client as expvar.Map, see RPCClientOpts.Expvar.
+//
+// calls: the number of calls
+//
+// errors: the number of failed calls
+//
+// rpc_errors: the number of responses that hold an RPCError
+//
+// in_flight: the number of running calls
+//
+// requests: the number of requests sent to the transport, including retries
+//
+// bytes_sent, bytes_received: the size of the encoded requests and responses
+type expvarCounters struct {
+	calls         *expvar.Int
+	errors        *expvar.Int
+	rpcErrors     *expvar.Int
+	inFlight      *expvar.Int
+	requests      *expvar.Int
+	bytesSent     *expvar.Int
+	bytesReceived *expvar.Int
+}
+
+// newExpvarCounters returns the counters published under name.
+// Clients with the same name share the counters.
+func newExpvarCounters(name string) *expvarCounters {
+	expvarMutex.Lock()
+	defer expvarMutex.Unlock()
+
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		// panics if name is used by another var, like expvar.Publish()
+		m = expvar.NewMap(name)
+	}
+
+	counter := func(key string) *expvar.Int {
+		if v, ok := m.Get(key).(*expvar.Int); ok {
+			return v
+		}
+		v := new(expvar.Int)
+		m.Set(key, v)
+		return v
+	}
+
+	return &expvarCounters{
+		calls:         counter("calls"),
+		errors:        counter("errors"),
+		rpcErrors:     counter("rpc_errors"),
+		inFlight:      counter("in_flight"),
+		requests:      counter("requests"),
+		bytesSent:     counter("bytes_sent"),
+		bytesReceived: counter("bytes_received"),
+	}
+}
+
+// interceptor returns an interceptor that counts the calls.
+func (c *expvarCounters) interceptor() Interceptor {
+	return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+		c.calls.Add(1)
+		c.inFlight.Add(1)
+		defer c.inFlight.Add(-1)
+
+		err := invoke(ctx)
+		if err != nil {
+			c.errors.Add(1)
+		}
+		for _, response := range call.Responses {
+			if response != nil && response.Error != nil {
+				c.rpcErrors.Add(1)
+			}
+		}
+
+		return err
+	}
+}
+
+// countingTransport counts the requests and bytes sent with a transport.
+type countingTransport struct {
+	transport Transport
+	counters  *expvarCounters
+}
+
+func (t *countingTransport) Send(ctx context.Context, request []byte) ([]byte, error) {
+	t.counters.requests.Add(1)
+	t.counters.bytesSent.Add(int64(len(request)))
+
+	response, err := t.transport.Send(ctx, request)
+	t.counters.bytesReceived.Add(int64(len(response)))
+
+	return response, err
+}
+
+func (t *countingTransport) SendStream(ctx context.Context, request []byte) (io.ReadCloser, error) {
+	t.counters.requests.Add(1)
+	t.counters.bytesSent.Add(int64(len(request)))
+
+	response, err := sendStream(ctx, t.transport, request)
+	if response == nil {
+		return nil, err
+	}
+
+	return &countingReadCloser{ReadCloser: response, counter: t.counters.bytesReceived}, err
+}
+
+// countingReadCloser adds the number of bytes read to counter.
+type countingReadCloser struct {
+	io.ReadCloser
+	counter *expvar.Int
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.counter.Add(int64(n))
+	return n, err
+}
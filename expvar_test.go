@@ -0,0 +1,86 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func expvarValues(name string) map[string]int64 {
+	var values map[string]int64
+	Expect(json.Unmarshal([]byte(expvar.Get(name).String()), &values)).To(Succeed())
+	return values
+}
+
+func TestExpvar(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := `{"jsonrpc":"2.0","result":"ok","id":0}`
+	errorResponse := `{"jsonrpc":"2.0","error":{"code":-32601,"message":"not found"},"id":0}`
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			fmt.Fprint(w, response)
+		case 3:
+			fmt.Fprint(w, errorResponse)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	opts := &RPCClientOpts{Expvar: "jsonrpc_test", Retry: &RetryPolicy{MaxAttempts: 2}}
+	rpcClient := NewClientWithOpts(server.URL, opts)
+
+	_, err := rpcClient.Call("a")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("b")
+	Expect(err).To(BeNil())
+
+	// clients with the same name share the counters
+	_, err = NewClientWithOpts(server.URL, opts).Call("c")
+	Expect(err).To(HaveOccurred())
+
+	request := func(method string) int64 {
+		body, _ := json.Marshal(NewRequest(method))
+		return int64(len(body))
+	}
+	Expect(expvarValues("jsonrpc_test")).To(Equal(map[string]int64{
+		"calls":          3,
+		"errors":         1,
+		"rpc_errors":     1,
+		"in_flight":      0,
+		"requests":       4,
+		"bytes_sent":     2*request("a") + request("b") + request("c"),
+		"bytes_received": int64(len(response) + len(errorResponse)),
+	}))
+}
+
+func TestExpvarStream(t *testing.T) {
+	RegisterTestingT(t)
+
+	response := `[{"jsonrpc":"2.0","result":"ok","id":0},{"jsonrpc":"2.0","result":"ok","id":1}]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, response)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{Expvar: "jsonrpc_test_stream"})
+	err := rpcClient.CallBatchStream(context.Background(), RPCRequests{NewRequest("a"), NewRequest("b")}, func(*RPCResponse) {})
+	Expect(err).To(BeNil())
+
+	values := expvarValues("jsonrpc_test_stream")
+	Expect(values["calls"]).To(Equal(int64(1)))
+	Expect(values["requests"]).To(Equal(int64(1)))
+	Expect(values["bytes_received"]).To(Equal(int64(len(response))))
+}
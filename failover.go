@@ -0,0 +1,400 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FailoverOrder defines in which order the endpoints of a client with multiple endpoints are tried.
+type FailoverOrder int
+
+const (
+	// FailoverOrderPriority tries the endpoints in the order they were given, so the first endpoint is the primary (default).
+	FailoverOrderPriority FailoverOrder = iota
+	// FailoverOrderRandom tries the endpoints in random order for every request.
+	FailoverOrderRandom
+	// FailoverOrderSticky tries the endpoint that succeeded last first, so that the requests stay with a secondary endpoint
+	// after a failover. While a secondary endpoint is used, the first endpoint (the primary) is probed every Cooldown
+	// with the health check (an http GET request if HealthCheck is nil) and used again as soon as the probe succeeds.
+	FailoverOrderSticky
+)
+
+// FailoverOpts configures the failover between the endpoints of a client created with NewClientWithEndpoints()
+// or NewClientWithResolver().
+//
+// Order: the order in which the endpoints are tried (see FailoverOrder)
+//
+// Balancer: chooses the order of the endpoints for every request, e.g. to spread the requests across all endpoints (see Balancer), overrides Order
+//
+// Cooldown: how long an endpoint is skipped after a request to it failed, unless all endpoints failed (default 30s)
+//
+// HealthCheck: checks the endpoints in the background and skips unhealthy endpoints (see HealthCheckOpts), disabled if nil
+//
+// Hedge: sends a duplicate of slow requests to the next endpoint (see HedgeOpts), disabled if nil
+//
+// ResolveInterval: how often the endpoints of a client created with NewClientWithResolver() are resolved (default 30s)
+type FailoverOpts struct {
+	Order           FailoverOrder
+	Balancer        Balancer
+	Cooldown        time.Duration
+	HealthCheck     *HealthCheckOpts
+	Hedge           *HedgeOpts
+	ResolveInterval time.Duration
+}
+
+const defaultFailoverCooldown = 30 * time.Second
+
+// EndpointsClient is a Client that sends its requests to one of multiple endpoints.
+type EndpointsClient interface {
+	Client
+
+	// Endpoints returns the current state of all endpoints in the order they were given.
+	Endpoints() []EndpointState
+
+	// Close stops the health checks and the resolution of the endpoints.
+	Close() error
+}
+
+type endpointsClient struct {
+	*rpcClient
+	transport *failoverTransport
+}
+
+func (client *endpointsClient) Endpoints() []EndpointState {
+	return client.transport.states()
+}
+
+func (client *endpointsClient) Close() error {
+	client.transport.close()
+	return nil
+}
+
+// NewClientWithEndpoints returns a new EndpointsClient that sends its requests to one of multiple redundant endpoints.
+//
+// If a request fails with a retryable error (see IsRetryable()), e.g. a connection error or status 503,
+// it is sent to the next endpoint. The failed endpoint is skipped for the cooldown period.
+// Note that a failed request may have been processed, so the failover can duplicate non-idempotent requests.
+//
+// endpoints: JSON-RPC service URLs to which JSON-RPC requests are sent.
+//
+// opts: RPCClientOpts provide custom configuration. A CircuitBreaker is used per endpoint.
+// Concurrency limits both the requests of the client and the requests per endpoint.
+func NewClientWithEndpoints(endpoints []string, opts *RPCClientOpts) EndpointsClient {
+	clientOpts, failoverOpts := endpointsClientOpts(opts)
+	transport := newFailoverTransport(newEndpointFactory(clientOpts, failoverOpts), failoverOpts)
+	transport.setEndpoints(endpoints)
+	transport.start()
+
+	clientOpts.CircuitBreaker = nil
+	return &endpointsClient{
+		rpcClient: newRPCClient(transport, &clientOpts),
+		transport: transport,
+	}
+}
+
+// endpointsClientOpts returns copies of opts and its FailoverOpts.
+func endpointsClientOpts(opts *RPCClientOpts) (RPCClientOpts, FailoverOpts) {
+	clientOpts := RPCClientOpts{}
+	if opts != nil {
+		clientOpts = *opts
+	}
+
+	failoverOpts := FailoverOpts{}
+	if clientOpts.Failover != nil {
+		failoverOpts = *clientOpts.Failover
+	}
+
+	return clientOpts, failoverOpts
+}
+
+// newEndpointFactory returns a function that creates the transport of an endpoint URL.
+func newEndpointFactory(clientOpts RPCClientOpts, failoverOpts FailoverOpts) func(endpoint string) endpointTransport {
+	return func(endpoint string) endpointTransport {
+		httpTransport := NewHTTPTransport(endpoint, &clientOpts).(*httpTransport)
+		var transport Transport = httpTransport
+		if clientOpts.CircuitBreaker != nil {
+			transport = newCircuitBreaker(transport, *clientOpts.CircuitBreaker)
+		}
+		if clientOpts.Concurrency != nil && clientOpts.Concurrency.MaxInFlightPerEndpoint > 0 {
+			transport = newLimiter(transport, clientOpts.Concurrency.MaxInFlightPerEndpoint, *clientOpts.Concurrency)
+		}
+
+		healthCheck := failoverOpts.HealthCheck
+		if healthCheck == nil && failoverOpts.Order == FailoverOrderSticky {
+			// the primary endpoint is probed with an http GET request
+			healthCheck = &HealthCheckOpts{}
+		}
+
+		return endpointTransport{
+			name:      endpoint,
+			transport: transport,
+			probe:     newProbe(httpTransport, healthCheck, &clientOpts),
+		}
+	}
+}
+
+// endpointTransport is the Transport of an endpoint with the name that is used in error messages.
+// probe checks the health of the endpoint, nil if health checks and FailoverOrderSticky are disabled.
+type endpointTransport struct {
+	name      string
+	transport Transport
+	probe     func(ctx context.Context) error
+}
+
+// endpoint is an endpoint of a failoverTransport with its state, which is guarded by the mutex of the failoverTransport.
+type endpoint struct {
+	endpointTransport
+
+	failedUntil time.Time     // the end of the cooldown
+	pending     int           // the number of requests in flight
+	latency     time.Duration // the moving average of the response time
+	unhealthy   bool          // whether the last health check failed
+	lastErr     error         // the last error of a request or health check
+}
+
+// failoverTransport sends a request to the next endpoint if it failed.
+type failoverTransport struct {
+	newEndpoint func(name string) endpointTransport
+	order       FailoverOrder
+	balancer    Balancer
+	cooldown    time.Duration
+	hedge       *hedger
+	healthCheck *HealthCheckOpts
+
+	mutex     sync.Mutex
+	endpoints []*endpoint
+	active    *endpoint // the endpoint that succeeded last, only set with FailoverOrderSticky
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	now func() time.Time
+}
+
+// latencyWeight is the weight of a new response time in the moving average.
+const latencyWeight = 0.2
+
+// newFailoverTransport returns a failoverTransport without endpoints, which are created by newEndpoint.
+// The endpoints are set by setEndpoints, start() starts the health checks.
+func newFailoverTransport(newEndpoint func(name string) endpointTransport, opts FailoverOpts) *failoverTransport {
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultFailoverCooldown
+	}
+
+	return &failoverTransport{
+		newEndpoint: newEndpoint,
+		order:       opts.Order,
+		balancer:    opts.Balancer,
+		cooldown:    opts.Cooldown,
+		hedge:       newHedger(opts.Hedge),
+		healthCheck: opts.HealthCheck,
+		closed:      make(chan struct{}),
+		now:         time.Now,
+	}
+}
+
+// start starts the health checks, if they are enabled, and the probes of the primary endpoint with FailoverOrderSticky.
+func (t *failoverTransport) start() {
+	if t.healthCheck != nil {
+		go t.healthCheckLoop(t.healthCheck.withDefaults())
+	}
+	if t.order == FailoverOrderSticky {
+		timeout := defaultHealthCheckTimeout
+		if t.healthCheck != nil {
+			timeout = t.healthCheck.withDefaults().Timeout
+		}
+		go t.primaryProbeLoop(t.cooldown, timeout)
+	}
+}
+
+// setEndpoints replaces the endpoints, endpoints that are kept keep their state.
+func (t *failoverTransport) setEndpoints(names []string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	existing := make(map[string]*endpoint, len(t.endpoints))
+	for _, e := range t.endpoints {
+		existing[e.name] = e
+	}
+
+	endpoints := make([]*endpoint, 0, len(names))
+	for _, name := range names {
+		e, ok := existing[name]
+		if !ok {
+			e = &endpoint{endpointTransport: t.newEndpoint(name)}
+		}
+		delete(existing, name)
+		endpoints = append(endpoints, e)
+	}
+	t.endpoints = endpoints
+}
+
+func (t *failoverTransport) Send(ctx context.Context, request []byte) ([]byte, error) {
+	candidates := t.candidates()
+	if len(candidates) == 0 {
+		return nil, MarkPermanent(errors.New("no endpoints"))
+	}
+
+	if t.hedge != nil && len(candidates) > 1 && t.hedge.hedged(request) {
+		return t.sendHedged(ctx, request, candidates)
+	}
+
+	var response []byte
+	var err error
+	for _, e := range candidates {
+		var failed bool
+		response, failed, err = t.sendTo(ctx, e, request)
+		if !failed {
+			break
+		}
+	}
+
+	return response, err
+}
+
+// sendTo sends a request to an endpoint. failed is true if the request should be sent to the next endpoint.
+func (t *failoverTransport) sendTo(ctx context.Context, e *endpoint, request []byte) ([]byte, bool, error) {
+	started := t.started(e)
+	response, err := e.transport.Send(ctx, request)
+	if ctx.Err() != nil {
+		t.cancelled(e)
+		return response, false, err
+	}
+	if errors.Is(err, ErrConcurrencyLimit) {
+		// the endpoint is busy, but did not fail
+		t.cancelled(e)
+		return response, true, fmt.Errorf("endpoint %v: %w", e.name, err)
+	}
+	if err == nil || !IsRetryable(classifyTransportError(err)) {
+		t.finished(e, started, nil)
+		return response, false, err
+	}
+
+	t.finished(e, started, err)
+	return response, true, fmt.Errorf("endpoint %v: %w", e.name, err)
+}
+
+// candidates returns the endpoints in the order they are tried.
+// Endpoints in cooldown or unhealthy endpoints are tried last.
+func (t *failoverTransport) candidates() []*endpoint {
+	t.mutex.Lock()
+	endpoints := t.endpoints
+	active := t.active
+	var states []EndpointState
+	if t.balancer != nil {
+		states = t.statesLocked()
+	}
+	t.mutex.Unlock()
+
+	var order []int
+	if t.balancer != nil {
+		order = t.balancer.Order(states)
+	} else {
+		order = make([]int, len(endpoints))
+		for i := range order {
+			order[i] = i
+		}
+		if t.order == FailoverOrderRandom {
+			rand.Shuffle(len(order), func(i, j int) {
+				order[i], order[j] = order[j], order[i]
+			})
+		}
+		if t.order == FailoverOrderSticky {
+			for i, e := range endpoints {
+				if e == active {
+					// try the active endpoint first, the others in their order
+					copy(order[1:i+1], order[:i])
+					order[0] = i
+					break
+				}
+			}
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.now()
+	available := make([]*endpoint, 0, len(order))
+	var coolingDown []*endpoint
+	for _, i := range order {
+		e := endpoints[i]
+		if e.unhealthy || now.Before(e.failedUntil) {
+			coolingDown = append(coolingDown, e)
+		} else {
+			available = append(available, e)
+		}
+	}
+
+	return append(available, coolingDown...)
+}
+
+// states returns the current state of every endpoint.
+func (t *failoverTransport) states() []EndpointState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.statesLocked()
+}
+
+func (t *failoverTransport) statesLocked() []EndpointState {
+	now := t.now()
+	states := make([]EndpointState, len(t.endpoints))
+	for i, e := range t.endpoints {
+		states[i] = EndpointState{
+			Endpoint: e.name,
+			Healthy:  !e.unhealthy && !now.Before(e.failedUntil),
+			Pending:  e.pending,
+			Latency:  e.latency,
+			LastErr:  e.lastErr,
+		}
+	}
+
+	return states
+}
+
+// started counts a request to an endpoint as pending and returns when it was started.
+func (t *failoverTransport) started(e *endpoint) time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e.pending++
+	return t.now()
+}
+
+// cancelled removes a pending request to an endpoint without recording a result, e.g. a hedged request that lost.
+func (t *failoverTransport) cancelled(e *endpoint) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e.pending--
+}
+
+// finished records the response time of a request to an endpoint or starts its cooldown if the request failed.
+func (t *failoverTransport) finished(e *endpoint, started time.Time, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e.pending--
+	now := t.now()
+	if err != nil {
+		e.failedUntil = now.Add(t.cooldown)
+		e.lastErr = err
+		return
+	}
+
+	if t.order == FailoverOrderSticky {
+		t.active = e
+	}
+
+	latency := now.Sub(started)
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency += time.Duration(latencyWeight * float64(latency-e.latency))
+	}
+}
@@ -0,0 +1,201 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newTestFailoverTransport returns a failoverTransport with the given endpoints.
+func newTestFailoverTransport(endpoints []endpointTransport, opts FailoverOpts) *failoverTransport {
+	byName := make(map[string]endpointTransport, len(endpoints))
+	names := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		byName[e.name] = e
+		names = append(names, e.name)
+	}
+
+	transport := newFailoverTransport(func(name string) endpointTransport {
+		return byName[name]
+	}, opts)
+	transport.setEndpoints(names)
+
+	return transport
+}
+
+// endpointNames returns the names of endpoints.
+func endpointNames(endpoints []*endpoint) []string {
+	names := make([]string, 0, len(endpoints))
+	for _, e := range endpoints {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+func TestNewClientWithEndpoints(t *testing.T) {
+	RegisterTestingT(t)
+
+	down, downAttempts := newFlakyServer(100, http.StatusServiceUnavailable)
+	defer down.Close()
+	up, upAttempts := newFlakyServer(0, http.StatusServiceUnavailable)
+	defer up.Close()
+
+	rpcClient := NewClientWithEndpoints([]string{down.URL, up.URL}, nil)
+	res, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+	Expect(atomic.LoadInt32(downAttempts)).To(Equal(int32(1)))
+	Expect(atomic.LoadInt32(upAttempts)).To(Equal(int32(1)))
+
+	// the failed endpoint is skipped during the cooldown
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(atomic.LoadInt32(downAttempts)).To(Equal(int32(1)))
+	Expect(atomic.LoadInt32(upAttempts)).To(Equal(int32(2)))
+
+	// errors that are not retryable are returned without failover
+	bad, badAttempts := newFlakyServer(100, http.StatusBadRequest)
+	defer bad.Close()
+
+	rpcClient = NewClientWithEndpoints([]string{bad.URL, up.URL}, nil)
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(atomic.LoadInt32(badAttempts)).To(Equal(int32(1)))
+
+	// the last error is returned if all endpoints fail
+	rpcClient = NewClientWithEndpoints([]string{down.URL, down.URL}, nil)
+	_, err = rpcClient.Call("something")
+	var httpErr *HTTPError
+	Expect(errors.As(err, &httpErr)).To(BeTrue())
+	Expect(httpErr.Code).To(Equal(http.StatusServiceUnavailable))
+}
+
+func TestFailoverTransport(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sent []string
+	failing := map[string]bool{}
+	endpoint := func(name string) endpointTransport {
+		return endpointTransport{name: name, transport: transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+			sent = append(sent, name)
+			if failing[name] {
+				return nil, errors.New("connection refused")
+			}
+			return []byte(name), nil
+		})}
+	}
+
+	transport := newTestFailoverTransport([]endpointTransport{endpoint("a"), endpoint("b"), endpoint("c")}, FailoverOpts{Cooldown: time.Minute})
+	now := time.Now()
+	transport.now = func() time.Time { return now }
+	send := func() string {
+		response, err := transport.Send(context.Background(), []byte(`{}`))
+		Expect(err).To(BeNil())
+		return string(response)
+	}
+
+	Expect(send()).To(Equal("a"))
+
+	failing["a"] = true
+	Expect(send()).To(Equal("b"))
+	Expect(sent).To(Equal([]string{"a", "a", "b"}))
+
+	// endpoints in cooldown are tried last
+	failing["b"] = true
+	sent = nil
+	Expect(send()).To(Equal("c"))
+	Expect(sent).To(Equal([]string{"b", "c"}))
+
+	failing["c"] = true
+	failing["a"] = false
+	sent = nil
+	Expect(send()).To(Equal("a"))
+	Expect(sent).To(Equal([]string{"c", "a"}))
+
+	// after the cooldown the priority order is used again
+	failing["b"] = false
+	now = now.Add(time.Minute)
+	sent = nil
+	Expect(send()).To(Equal("a"))
+	Expect(sent).To(Equal([]string{"a"}))
+
+	// random order tries every endpoint once
+	transport = newTestFailoverTransport([]endpointTransport{endpoint("a"), endpoint("b"), endpoint("c")}, FailoverOpts{Order: FailoverOrderRandom})
+	failing = map[string]bool{"a": true, "b": true, "c": true}
+	sent = nil
+	_, err := transport.Send(context.Background(), []byte(`{}`))
+	Expect(err).NotTo(BeNil())
+	Expect(sent).To(ConsistOf("a", "b", "c"))
+}
+
+func TestFailoverTransport_Sticky(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mutex sync.Mutex
+	failing := map[string]bool{}
+	setFailing := func(name string, fail bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		failing[name] = fail
+	}
+	isFailing := func(name string) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return failing[name]
+	}
+	endpoint := func(name string) endpointTransport {
+		return endpointTransport{
+			name: name,
+			transport: transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+				if isFailing(name) {
+					return nil, errors.New("connection refused")
+				}
+				return []byte(name), nil
+			}),
+			probe: func(ctx context.Context) error {
+				if isFailing(name) {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+		}
+	}
+
+	transport := newTestFailoverTransport([]endpointTransport{endpoint("primary"), endpoint("a"), endpoint("b")}, FailoverOpts{
+		Order:    FailoverOrderSticky,
+		Cooldown: 20 * time.Millisecond,
+	})
+	transport.start()
+	defer transport.close()
+
+	send := func() string {
+		response, err := transport.Send(context.Background(), []byte(`{}`))
+		Expect(err).To(BeNil())
+		return string(response)
+	}
+
+	Expect(send()).To(Equal("primary"))
+
+	// the requests stay with the secondary after the cooldown of the primary
+	setFailing("primary", true)
+	Expect(send()).To(Equal("a"))
+	time.Sleep(50 * time.Millisecond)
+	Expect(send()).To(Equal("a"))
+
+	// and with the next secondary if it fails
+	setFailing("a", true)
+	Expect(send()).To(Equal("b"))
+	setFailing("a", false)
+	Expect(send()).To(Equal("b"))
+
+	// the primary is used again after a successful probe
+	setFailing("primary", false)
+	Eventually(send).Should(Equal("primary"))
+	Expect(send()).To(Equal("primary"))
+}
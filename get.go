@@ -0,0 +1,75 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+)
+
+// GETEncoding defines how requests are encoded in the url of http GET requests.
+type GETEncoding int
+
+const (
+	// GETEncodingPayload sends the whole request (or batch) as JSON in a single query parameter,
+	// e.g. ?request={"jsonrpc":"2.0","method":"sum","params":[3,4],"id":1}
+	GETEncodingPayload GETEncoding = iota
+	// GETEncodingFields sends the fields of the request as separate query parameters with base64 encoded params,
+	// as described by "JSON-RPC over HTTP", e.g. ?jsonrpc=2.0&method=sum&params=WzMsNF0%3D&id=1
+	//
+	// Batch requests can not be sent with this encoding.
+	GETEncodingFields
+)
+
+// defaultGETParam is the query parameter for GETEncodingPayload.
+const defaultGETParam = "request"
+
+// getURL returns endpoint with the encoded request added to the query.
+func getURL(endpoint string, body []byte, encoding GETEncoding, param string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	switch encoding {
+	case GETEncodingFields:
+		if err := addRequestFields(query, body); err != nil {
+			return "", err
+		}
+	default:
+		query.Set(param, string(body))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// addRequestFields adds the fields of an encoded request as query parameters.
+func addRequestFields(query url.Values, body []byte) error {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		return errors.New("batch requests can not be sent as GET request with GETEncodingFields")
+	}
+
+	var request struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		ID      *ID             `json:"id"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return err
+	}
+
+	query.Set("jsonrpc", request.JSONRPC)
+	query.Set("method", request.Method)
+	if len(request.Params) > 0 {
+		query.Set("params", base64.StdEncoding.EncodeToString(request.Params))
+	}
+	if request.ID != nil && !request.ID.IsNull() {
+		query.Set("id", request.ID.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestGETEncoding(t *testing.T) {
+	RegisterTestingT(t)
+
+	requests := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		json.NewEncoder(w).Encode(&RPCResponse{JSONRPC: "2.0", Result: "ok", ID: NumberID(1)})
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL+"?key=abc", &RPCClientOpts{
+		HTTPMethod:  "get",
+		IDGenerator: NewSequentialIDGenerator(1),
+	})
+	res, err := rpcClient.Call("sum", 3, 4)
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+	req := <-requests
+	Expect(req.Method).To(Equal(http.MethodGet))
+	query := req.URL.Query()
+	Expect(query.Get("key")).To(Equal("abc"))
+	Expect(query.Get("request")).To(Equal(`{"method":"sum","params":[3,4],"id":1,"jsonrpc":"2.0"}`))
+
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{
+		HTTPMethod:  http.MethodGet,
+		GETEncoding: GETEncodingFields,
+		IDGenerator: NewSequentialIDGenerator(1),
+	})
+	_, err = rpcClient.Call("sum", 3, 4)
+	Expect(err).To(BeNil())
+	query = (<-requests).URL.Query()
+	Expect(query.Get("jsonrpc")).To(Equal("2.0"))
+	Expect(query.Get("method")).To(Equal("sum"))
+	Expect(query.Get("params")).To(Equal("WzMsNF0="))
+	Expect(query.Get("id")).To(Equal("1"))
+
+	// batches can not be encoded as fields
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("sum", 3, 4)})
+	Expect(err).To(MatchError(ContainSubstring("batch requests can not be sent")))
+	Expect(IsRetryable(err)).To(BeFalse())
+}
+
+func TestHTTPMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{HTTPMethod: http.MethodPut})
+	rpcClient.Call("add", 1, 2)
+
+	req := <-requestChan
+	Expect(req.request.Method).To(Equal(http.MethodPut))
+	Expect(req.body).To(Equal(`{"method":"add","params":[1,2],"id":0,"jsonrpc":"2.0"}`))
+}
@@ -1,5 +1,16 @@
 module github.com/aurora-is-near/go-jsonrpc/v3
 
-go 1.12
+go 1.21
 
-require github.com/onsi/gomega v1.5.0
+require (
+	github.com/Microsoft/go-winio v0.4.16
+	github.com/gorilla/websocket v1.5.3
+	github.com/onsi/gomega v1.5.0
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4
+)
+
+require (
+	golang.org/x/sys v0.0.0-20210330210617-4fbd30eecc44 // indirect
+	golang.org/x/text v0.3.3 // indirect
+	gopkg.in/yaml.v2 v2.2.1 // indirect
+)
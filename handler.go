@@ -0,0 +1,57 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// handlerEndpoint is the endpoint of clients created by ClientFromHandler().
+// Only its path is visible to the handler.
+const handlerEndpoint = "http://localhost/"
+
+// ClientFromHandler returns a Client that sends its requests directly to handler, without network listener.
+// The requests take the same code path as for http clients, which is useful for tests and embedded servers.
+func ClientFromHandler(handler http.Handler) Client {
+	return ClientFromHandlerWithOpts(handler, nil)
+}
+
+// ClientFromHandlerWithOpts returns a Client with custom configuration that sends its requests directly to handler.
+//
+// opts: RPCClientOpts provide custom configuration. If HTTPClient is set, a copy of it is used with a different Transport.
+func ClientFromHandlerWithOpts(handler http.Handler, opts *RPCClientOpts) Client {
+	handlerOpts := RPCClientOpts{}
+	if opts != nil {
+		handlerOpts = *opts
+	}
+
+	httpClient := &http.Client{}
+	if handlerOpts.HTTPClient != nil {
+		*httpClient = *handlerOpts.HTTPClient
+	}
+	httpClient.Transport = &handlerRoundTripper{handler: handler}
+	handlerOpts.HTTPClient = httpClient
+
+	return NewClientWithOpts(handlerEndpoint, &handlerOpts)
+}
+
+// handlerRoundTripper is an http.RoundTripper that serves the requests with an http.Handler.
+type handlerRoundTripper struct {
+	handler http.Handler
+}
+
+func (rt *handlerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// turn the client request into a server request
+	serverReq := req.Clone(req.Context())
+	serverReq.RequestURI = req.URL.RequestURI()
+	serverReq.RemoteAddr = "127.0.0.1:0"
+	if serverReq.Body == nil {
+		serverReq.Body = http.NoBody
+	}
+
+	recorder := httptest.NewRecorder()
+	rt.handler.ServeHTTP(recorder, serverReq)
+
+	res := recorder.Result()
+	res.Request = req
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestClientFromHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var request testRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(w).Encode(request.response())
+	})
+
+	rpcClient := ClientFromHandlerWithOpts(handler, &RPCClientOpts{
+		CustomHeaders: map[string]string{"Authorization": "Bearer token"},
+	})
+	res, err := rpcClient.Call("hello", 1)
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+
+	// http errors are reported as for http clients
+	_, err = ClientFromHandler(handler).Call("hello")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(err.(*HTTPError).Code).To(Equal(http.StatusUnauthorized))
+}
@@ -0,0 +1,30 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+)
+
+type headersContextKey struct{}
+
+// WithHeaders returns a copy of ctx that sends the headers with the http requests of the calls that use the context,
+// e.g. a tenant id for a single call. They replace CustomHeaders of the same name.
+// Headers of a parent context are kept unless they are replaced by a header of the same name.
+//
+// The header is copied, it can be modified afterwards.
+func WithHeaders(ctx context.Context, header http.Header) context.Context {
+	merged := contextHeaders(ctx).Clone()
+	if merged == nil {
+		merged = make(http.Header, len(header))
+	}
+	for name, values := range header {
+		merged[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, headersContextKey{}, merged)
+}
+
+// contextHeaders returns the headers of ctx, nil if there are none.
+func contextHeaders(ctx context.Context) http.Header {
+	header, _ := ctx.Value(headersContextKey{}).(http.Header)
+	return header
+}
@@ -0,0 +1,57 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWithHeaders(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":[%q,%q,%q],"id":0}`,
+			r.Header.Get("X-Tenant"), r.Header.Get("X-Client"), fmt.Sprint(r.Header["X-Trace"]))
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		CustomHeaders: map[string]string{"X-Client": "default", "X-Tenant": "none"},
+	})
+
+	header := http.Header{"x-tenant": {"a"}}
+	ctx := WithHeaders(context.Background(), header)
+	// the header is copied
+	header.Set("X-Tenant", "modified")
+
+	var result []string
+	Expect(rpcClient.CallForContext(ctx, &result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"a", "default", "[]"}))
+
+	// headers of the parent are kept unless they are replaced
+	nested := WithHeaders(ctx, http.Header{"X-Client": {"override"}, "X-Trace": {"1", "2"}})
+	Expect(rpcClient.CallForContext(nested, &result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"a", "override", "[1 2]"}))
+
+	// the headers do not leak to other calls
+	Expect(rpcClient.CallFor(&result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"none", "default", "[]"}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			var result []string
+			err := rpcClient.CallForContext(WithHeaders(context.Background(), http.Header{"X-Tenant": {tenant}}), &result, "something")
+			Expect(err).To(BeNil())
+			Expect(result[0]).To(Equal(tenant))
+		}(fmt.Sprint(i))
+	}
+	wg.Wait()
+}
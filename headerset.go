@@ -0,0 +1,71 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"sync"
+)
+
+// HeaderSet is a set of headers that can be changed while the client is in use, e.g. to rotate a session header
+// or to remove a header that is no longer valid. See RPCClientOpts.HeaderSet.
+//
+// The zero value is an empty set. A HeaderSet is safe for concurrent use, the changes apply to the next requests.
+type HeaderSet struct {
+	mu     sync.RWMutex
+	header http.Header
+}
+
+// NewHeaderSet returns a HeaderSet with the given headers.
+func NewHeaderSet(headers map[string]string) *HeaderSet {
+	s := &HeaderSet{}
+	for k, v := range headers {
+		s.Set(k, v)
+	}
+	return s
+}
+
+// Set sets the header name to value, replacing any existing values.
+func (s *HeaderSet) Set(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.header == nil {
+		s.header = make(http.Header)
+	}
+	s.header.Set(name, value)
+}
+
+// Delete removes the header name.
+func (s *HeaderSet) Delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header.Del(name)
+}
+
+// Clear removes all headers.
+func (s *HeaderSet) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = nil
+}
+
+// Header returns a copy of the headers.
+func (s *HeaderSet) Header() http.Header {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	header := make(http.Header, len(s.header))
+	for name, values := range s.header {
+		header[name] = append([]string(nil), values...)
+	}
+	return header
+}
+
+// apply sets the headers on header, it is a no-op for a nil set.
+func (s *HeaderSet) apply(header http.Header) {
+	if s == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, values := range s.header {
+		header[name] = append([]string(nil), values...)
+	}
+}
@@ -0,0 +1,79 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHeaderSet(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":[%q,%q],"id":0}`, r.Header.Get("X-Session"), r.Header.Get("X-Client"))
+	}))
+	defer server.Close()
+
+	headers := NewHeaderSet(map[string]string{"x-session": "1"})
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		CustomHeaders: map[string]string{"X-Client": "default", "X-Session": "none"},
+		HeaderSet:     headers,
+	})
+
+	var result []string
+	Expect(rpcClient.CallFor(&result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"1", "default"}))
+
+	headers.Set("X-Session", "2")
+	headers.Set("X-Client", "override")
+	Expect(rpcClient.CallFor(&result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"2", "override"}))
+
+	// deleted headers fall back to CustomHeaders
+	headers.Delete("x-client")
+	Expect(rpcClient.CallFor(&result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"2", "default"}))
+
+	headers.Clear()
+	Expect(headers.Header()).To(BeEmpty())
+	Expect(rpcClient.CallFor(&result, "something")).To(Succeed())
+	Expect(result).To(Equal([]string{"none", "default"}))
+
+	// changes while calls are running
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			headers.Set("X-Session", fmt.Sprint(i))
+			headers.Delete("X-Session")
+			headers.Clear()
+		}(i)
+		go func() {
+			defer wg.Done()
+			var result []string
+			Expect(rpcClient.CallFor(&result, "something")).To(Succeed())
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHeaderSetZeroValue(t *testing.T) {
+	RegisterTestingT(t)
+
+	var headers HeaderSet
+	headers.Delete("X-Missing")
+	headers.Clear()
+	headers.Set("X-Session", "1")
+
+	header := headers.Header()
+	Expect(header).To(Equal(http.Header{"X-Session": {"1"}}))
+
+	// the returned header is a copy
+	header.Set("X-Session", "modified")
+	Expect(headers.Header().Get("X-Session")).To(Equal("1"))
+}
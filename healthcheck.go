@@ -0,0 +1,192 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheckOpts configures the background health checks of the endpoints of a client created with NewClientWithEndpoints().
+// Unhealthy endpoints are tried last until a health check succeeds again. A successful health check ends the cooldown of an endpoint.
+//
+// Method: the JSON-RPC method that is called without params to check an endpoint, e.g. "web3_clientVersion".
+// Every JSON-RPC response counts as healthy, also an error response.
+// If empty, an http GET request is sent to the endpoint and every status code below 500 counts as healthy.
+//
+// Interval: the time between the health checks (default 10s)
+//
+// Timeout: the timeout of a health check (default 5s)
+type HealthCheckOpts struct {
+	Method   string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+)
+
+func (opts HealthCheckOpts) withDefaults() HealthCheckOpts {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultHealthCheckInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultHealthCheckTimeout
+	}
+	return opts
+}
+
+// newProbe returns the health check of an endpoint, nil if opts is nil.
+func newProbe(transport *httpTransport, opts *HealthCheckOpts, clientOpts *RPCClientOpts) func(ctx context.Context) error {
+	if opts == nil {
+		return nil
+	}
+
+	if opts.Method == "" {
+		return transport.ping
+	}
+
+	client := newRPCClient(transport, &RPCClientOpts{
+		CompatibilityMode: clientOpts.CompatibilityMode,
+		EmptyParams:       clientOpts.EmptyParams,
+	})
+	method := opts.Method
+	return func(ctx context.Context) error {
+		_, err := client.CallContext(ctx, method)
+		return err
+	}
+}
+
+// ping sends an http GET request to the endpoint.
+func (t *httpTransport) ping(ctx context.Context) error {
+	endpoint, err := t.endpointFor(ctx)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("User-Agent", t.userAgent)
+	for k, v := range t.customHeaders {
+		request.Header.Set(k, v)
+	}
+	t.headerSet.apply(request.Header)
+
+	httpResponse, err := t.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	httpResponse.Body.Close()
+
+	if httpResponse.StatusCode >= 500 {
+		return &HTTPError{
+			Code: httpResponse.StatusCode,
+			err:  fmt.Errorf("health check status code: %v", httpResponse.StatusCode),
+		}
+	}
+
+	return nil
+}
+
+// healthCheckLoop checks all endpoints every interval until the transport is closed.
+func (t *failoverTransport) healthCheckLoop(opts HealthCheckOpts) {
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		t.checkHealth(opts.Timeout)
+
+		select {
+		case <-t.closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkHealth checks all endpoints concurrently.
+func (t *failoverTransport) checkHealth(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-t.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	t.mutex.Lock()
+	endpoints := t.endpoints
+	t.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			t.setHealth(e, e.probe(ctx))
+		}(e)
+	}
+	wg.Wait()
+}
+
+// primaryProbeLoop probes the primary endpoint every interval while another endpoint is active
+// and makes it the active endpoint again as soon as a probe succeeds.
+func (t *failoverTransport) primaryProbeLoop(interval time.Duration, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.closed:
+			return
+		case <-ticker.C:
+		}
+
+		t.mutex.Lock()
+		var primary *endpoint
+		if len(t.endpoints) > 0 && t.active != nil && t.active != t.endpoints[0] {
+			primary = t.endpoints[0]
+		}
+		t.mutex.Unlock()
+		if primary == nil || primary.probe == nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := primary.probe(ctx)
+		cancel()
+
+		t.setHealth(primary, err)
+		if err == nil {
+			t.mutex.Lock()
+			t.active = primary
+			t.mutex.Unlock()
+		}
+	}
+}
+
+// setHealth records the result of the health check of an endpoint.
+func (t *failoverTransport) setHealth(e *endpoint, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	e.unhealthy = err != nil
+	if err != nil {
+		e.lastErr = err
+	} else {
+		e.failedUntil = time.Time{}
+	}
+}
+
+// close stops the health checks.
+func (t *failoverTransport) close() {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+	})
+}
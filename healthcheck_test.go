@@ -0,0 +1,97 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newHealthServer returns a server that responds with status 503 while healthy is 0
+// and counts the JSON-RPC requests of method.
+func newHealthServer(method string) (*httptest.Server, *int32, *int32) {
+	healthy := int32(1)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var request RPCRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err == nil && request.Method == method {
+				atomic.AddInt32(&calls, 1)
+			}
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+
+	return server, &healthy, &calls
+}
+
+func TestHealthCheck(t *testing.T) {
+	RegisterTestingT(t)
+
+	first, firstHealthy, firstChecks := newHealthServer("web3_clientVersion")
+	defer first.Close()
+	second, _, secondChecks := newHealthServer("web3_clientVersion")
+	defer second.Close()
+
+	rpcClient := NewClientWithEndpoints([]string{first.URL, second.URL}, &RPCClientOpts{
+		Failover: &FailoverOpts{
+			HealthCheck: &HealthCheckOpts{
+				Method:   "web3_clientVersion",
+				Interval: 10 * time.Millisecond,
+			},
+		},
+	})
+	defer rpcClient.Close()
+
+	Eventually(func() int32 { return atomic.LoadInt32(firstChecks) }).Should(BeNumerically(">=", 1))
+	Eventually(func() int32 { return atomic.LoadInt32(secondChecks) }).Should(BeNumerically(">=", 1))
+	Expect(rpcClient.Endpoints()[0].Healthy).To(BeTrue())
+	Expect(rpcClient.Endpoints()[0].Endpoint).To(Equal(first.URL))
+
+	// an unhealthy endpoint is skipped
+	atomic.StoreInt32(firstHealthy, 0)
+	Eventually(func() bool { return rpcClient.Endpoints()[0].Healthy }).Should(BeFalse())
+	Expect(rpcClient.Endpoints()[0].LastErr).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(rpcClient.Endpoints()[1].Healthy).To(BeTrue())
+
+	Expect(endpointNames(rpcClient.(*endpointsClient).transport.candidates())).To(Equal([]string{second.URL, first.URL}))
+
+	// it is used again as soon as a health check succeeds
+	atomic.StoreInt32(firstHealthy, 1)
+	Eventually(func() bool { return rpcClient.Endpoints()[0].Healthy }).Should(BeTrue())
+}
+
+func TestHealthCheck_Ping(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, healthy, _ := newHealthServer("")
+	defer server.Close()
+
+	rpcClient := NewClientWithEndpoints([]string{server.URL}, &RPCClientOpts{
+		Failover: &FailoverOpts{
+			HealthCheck: &HealthCheckOpts{Interval: 10 * time.Millisecond},
+		},
+	})
+	defer rpcClient.Close()
+
+	atomic.StoreInt32(healthy, 0)
+	Eventually(func() bool { return rpcClient.Endpoints()[0].Healthy }).Should(BeFalse())
+	atomic.StoreInt32(healthy, 1)
+	Eventually(func() bool { return rpcClient.Endpoints()[0].Healthy }).Should(BeTrue())
+
+	// a failed request starts the cooldown
+	atomic.StoreInt32(healthy, 0)
+	rpcClient.Close()
+	_, err := rpcClient.Call("something")
+	Expect(err).NotTo(BeNil())
+	Expect(rpcClient.Endpoints()[0].Healthy).To(BeFalse())
+}
@@ -0,0 +1,132 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// HedgeOpts configures request hedging for a client created with NewClientWithEndpoints().
+//
+// If there is no response after Delay, a duplicate of the request is sent to the next endpoint.
+// The first successful response is returned and the other request is cancelled.
+// This reduces the tail latency at the cost of additional load, so only read-only methods should be hedged.
+//
+// Delay: the time after which the duplicate request is sent (default 100ms)
+//
+// Methods: the methods that are hedged, a batch request is only hedged if all its methods are listed
+type HedgeOpts struct {
+	Delay   time.Duration
+	Methods []string
+}
+
+const defaultHedgeDelay = 100 * time.Millisecond
+
+// hedger decides which requests are hedged.
+type hedger struct {
+	delay   time.Duration
+	methods map[string]bool
+}
+
+// newHedger returns nil if opts is nil or no methods are hedged.
+func newHedger(opts *HedgeOpts) *hedger {
+	if opts == nil || len(opts.Methods) == 0 {
+		return nil
+	}
+
+	h := &hedger{
+		delay:   opts.Delay,
+		methods: make(map[string]bool, len(opts.Methods)),
+	}
+	if h.delay <= 0 {
+		h.delay = defaultHedgeDelay
+	}
+	for _, method := range opts.Methods {
+		h.methods[method] = true
+	}
+
+	return h
+}
+
+// hedged returns true if all methods of an encoded request or batch request are hedged.
+func (h *hedger) hedged(request []byte) bool {
+	type methodOnly struct {
+		Method string `json:"method"`
+	}
+
+	var requests []methodOnly
+	request = bytes.TrimSpace(request)
+	if len(request) > 0 && request[0] == '[' {
+		if err := json.Unmarshal(request, &requests); err != nil {
+			return false
+		}
+	} else {
+		var single methodOnly
+		if err := json.Unmarshal(request, &single); err != nil {
+			return false
+		}
+		requests = append(requests, single)
+	}
+
+	if len(requests) == 0 {
+		return false
+	}
+	for _, r := range requests {
+		if !h.methods[r.Method] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type hedgeResult struct {
+	response []byte
+	failed   bool
+	err      error
+}
+
+// sendHedged sends a request to the first candidate and a duplicate to the next candidate after the hedge delay.
+// If a request fails, it is sent to the next candidate immediately.
+// Returns the first result that did not fail, the requests that are still in flight are cancelled.
+func (t *failoverTransport) sendHedged(ctx context.Context, request []byte, candidates []*endpoint) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, len(candidates))
+	next, running := 0, 0
+	send := func() {
+		go func(e *endpoint) {
+			response, failed, err := t.sendTo(ctx, e, request)
+			results <- hedgeResult{response: response, failed: failed, err: err}
+		}(candidates[next])
+		next++
+		running++
+	}
+
+	send()
+	timer := time.NewTimer(t.hedge.delay)
+	defer timer.Stop()
+
+	var last hedgeResult
+	for running > 0 {
+		select {
+		case <-timer.C:
+			if next < len(candidates) {
+				send()
+			}
+		case result := <-results:
+			running--
+			if !result.failed {
+				return result.response, result.err
+			}
+			last = result
+			if running == 0 && next < len(candidates) {
+				send()
+			}
+		}
+	}
+
+	return last.response, last.err
+}
@@ -0,0 +1,82 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHedger_Hedged(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(newHedger(nil)).To(BeNil())
+	Expect(newHedger(&HedgeOpts{})).To(BeNil())
+
+	h := newHedger(&HedgeOpts{Methods: []string{"eth_call", "eth_getBalance"}})
+	Expect(h.delay).To(Equal(defaultHedgeDelay))
+	Expect(h.hedged([]byte(`{"jsonrpc":"2.0","method":"eth_call","id":1}`))).To(BeTrue())
+	Expect(h.hedged([]byte(`{"jsonrpc":"2.0","method":"eth_sendRawTransaction","id":1}`))).To(BeFalse())
+	Expect(h.hedged([]byte(`[{"method":"eth_call","id":1},{"method":"eth_getBalance","id":2}]`))).To(BeTrue())
+	Expect(h.hedged([]byte(`[{"method":"eth_call","id":1},{"method":"eth_sendRawTransaction","id":2}]`))).To(BeFalse())
+	Expect(h.hedged([]byte(`[]`))).To(BeFalse())
+	Expect(h.hedged([]byte(`invalid`))).To(BeFalse())
+}
+
+func TestFailoverTransport_Hedge(t *testing.T) {
+	RegisterTestingT(t)
+
+	cancelled := make(chan string, 2)
+	endpoint := func(name string, delay time.Duration, err error) endpointTransport {
+		return endpointTransport{name: name, transport: transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+			select {
+			case <-time.After(delay):
+				return []byte(name), err
+			case <-ctx.Done():
+				cancelled <- name
+				return nil, ctx.Err()
+			}
+		})}
+	}
+	call := []byte(`{"jsonrpc":"2.0","method":"eth_call","id":1}`)
+	send := []byte(`{"jsonrpc":"2.0","method":"eth_sendRawTransaction","id":1}`)
+
+	transport := newTestFailoverTransport([]endpointTransport{
+		endpoint("slow", time.Minute, nil),
+		endpoint("fast", 0, nil),
+	}, FailoverOpts{Hedge: &HedgeOpts{Delay: 10 * time.Millisecond, Methods: []string{"eth_call"}}})
+
+	// the hedged request wins and the slow request is cancelled
+	response, err := transport.Send(context.Background(), call)
+	Expect(err).To(BeNil())
+	Expect(string(response)).To(Equal("fast"))
+	Eventually(cancelled).Should(Receive(Equal("slow")))
+
+	// methods that are not listed are not hedged
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = transport.Send(ctx, send)
+	Expect(err).To(Equal(context.DeadlineExceeded))
+	Eventually(cancelled).Should(Receive(Equal("slow")))
+
+	// a failed request is sent to the next endpoint without waiting for the delay
+	transport = newTestFailoverTransport([]endpointTransport{
+		endpoint("down", 0, errors.New("connection refused")),
+		endpoint("up", 0, nil),
+	}, FailoverOpts{Hedge: &HedgeOpts{Delay: time.Minute, Methods: []string{"eth_call"}}})
+
+	response, err = transport.Send(context.Background(), call)
+	Expect(err).To(BeNil())
+	Expect(string(response)).To(Equal("up"))
+
+	// the last error is returned if all requests fail
+	transport = newTestFailoverTransport([]endpointTransport{
+		endpoint("a", 0, errors.New("connection refused")),
+		endpoint("b", 0, errors.New("connection refused")),
+	}, FailoverOpts{Hedge: &HedgeOpts{Methods: []string{"eth_call"}}})
+
+	_, err = transport.Send(context.Background(), call)
+	Expect(err).NotTo(BeNil())
+}
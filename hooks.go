@@ -0,0 +1,39 @@
+package jsonrpc
+
+import "context"
+
+// Hooks are called for every call of a client, e.g. for logging, auditing or custom metrics, see RPCClientOpts.
+// All hooks are optional and must be safe for concurrent use.
+// They receive the CallInfo of the call, see CallInfo.Method() and CallInfo.Requests for the method, params and ids.
+//
+// OnRequest: called before the call is sent
+//
+// OnResponse: called when the call returned responses, which may hold RPC errors
+//
+// OnError: called instead of OnResponse if the call failed, call.Err is the error and call.Duration is set
+type Hooks struct {
+	OnRequest  func(ctx context.Context, call *CallInfo)
+	OnResponse func(ctx context.Context, call *CallInfo)
+	OnError    func(ctx context.Context, call *CallInfo)
+}
+
+// interceptor returns an interceptor that calls the hooks.
+// It is the innermost interceptor, so the hooks see the context and result of the call as it is sent.
+func (hooks *Hooks) interceptor() Interceptor {
+	return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+		if hooks.OnRequest != nil {
+			hooks.OnRequest(ctx, call)
+		}
+
+		err := invoke(ctx)
+		if err != nil {
+			if hooks.OnError != nil {
+				hooks.OnError(ctx, call)
+			}
+		} else if hooks.OnResponse != nil {
+			hooks.OnResponse(ctx, call)
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,70 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHooks(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	var mutex sync.Mutex
+	var events []string
+	record := func(event string) func(ctx context.Context, call *CallInfo) {
+		return func(ctx context.Context, call *CallInfo) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			request := call.Requests[0]
+			events = append(events, fmt.Sprintf("%v %v %v %v %v", event, request.Method, request.Params, request.ID, call.Err != nil))
+			if event != "request" {
+				Expect(call.Duration).To(BeNumerically(">", 0))
+			}
+		}
+	}
+
+	interceptorCalled := false
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Hooks: &Hooks{
+			OnRequest:  record("request"),
+			OnResponse: record("response"),
+			OnError:    record("error"),
+		},
+		Interceptors: []Interceptor{func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+			interceptorCalled = true
+			return invoke(ctx)
+		}},
+	})
+
+	_, err := rpcClient.Call("something", 1, 2)
+	Expect(err).To(BeNil())
+
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{
+		CustomHeaders: map[string]string{"X-Fail": "true"},
+		Hooks:         &Hooks{OnRequest: record("request"), OnError: record("error")},
+	})
+	_, err = rpcClient.Call("failing")
+	Expect(err).To(HaveOccurred())
+
+	Expect(interceptorCalled).To(BeTrue())
+	Expect(events).To(Equal([]string{
+		"request something [1 2] 0 false",
+		"response something [1 2] 0 false",
+		"request failing <nil> 0 false",
+		"error failing <nil> 0 true",
+	}))
+}
@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// HTTPProtocol selects the HTTP version of http clients.
+type HTTPProtocol int
+
+const (
+	// HTTPProtocolDefault uses the defaults of net/http:
+	// HTTP/2 for https endpoints if the server supports it, HTTP/1.1 otherwise.
+	HTTPProtocolDefault HTTPProtocol = iota
+	// HTTPProtocolHTTP2 uses HTTP/2 for https endpoints (HTTP/1.1 if the server does not support it)
+	// and keeps the connections alive with health checks, so that concurrent calls are multiplexed over a single connection.
+	HTTPProtocolHTTP2
+	// HTTPProtocolH2C uses HTTP/2 without TLS with prior knowledge (h2c), e.g. for internal http:// endpoints.
+	// All calls are multiplexed over a single connection. The server must support h2c, https endpoints are not supported.
+	HTTPProtocolH2C
+)
+
+const (
+	// http2ReadIdleTimeout is the time after which an idle HTTP/2 connection is checked with a ping.
+	http2ReadIdleTimeout = 30 * time.Second
+	// http2PingTimeout is the time after which a connection is closed if the ping is not answered.
+	http2PingTimeout = 15 * time.Second
+)
+
+// newHTTPProtocolTransport returns an http.RoundTripper for the given protocol.
+// tlsConfig is used for https endpoints if it is not nil, h2c does not use TLS.
+// proxy selects the proxy of a request if it is not nil (see http.Transport), h2c does not use proxies.
+// pool tunes the connections if it is not nil, h2c only uses its DialTimeout and KeepAlive.
+func newHTTPProtocolTransport(protocol HTTPProtocol, tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error), pool *ConnectionPoolOpts) http.RoundTripper {
+	switch protocol {
+	case HTTPProtocolH2C:
+		dialer := pool.dialer()
+		return &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.Dial(network, addr)
+			},
+			ReadIdleTimeout: http2ReadIdleTimeout,
+			PingTimeout:     http2PingTimeout,
+		}
+	case HTTPProtocolHTTP2:
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ForceAttemptHTTP2 = true
+		transport.TLSClientConfig = tlsConfig
+		if proxy != nil {
+			transport.Proxy = proxy
+		}
+		if pool != nil {
+			pool.apply(transport)
+		}
+		h2Transport, err := http2.ConfigureTransports(transport)
+		if err != nil {
+			// only fails if the transport is already configured for HTTP/2
+			return transport
+		}
+		h2Transport.ReadIdleTimeout = http2ReadIdleTimeout
+		h2Transport.PingTimeout = http2PingTimeout
+		return transport
+	default:
+		if tlsConfig == nil && proxy == nil && pool == nil {
+			return http.DefaultTransport
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		if proxy != nil {
+			transport.Proxy = proxy
+		}
+		if pool != nil {
+			pool.apply(transport)
+		}
+		return transport
+	}
+}
@@ -0,0 +1,53 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// protoHandler responds to every request with the HTTP version of the request as result.
+var protoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var request testRequest
+	json.NewDecoder(r.Body).Decode(&request)
+	json.NewEncoder(w).Encode(&RPCResponse{JSONRPC: "2.0", Result: r.Proto, ID: request.ID})
+})
+
+func TestHTTPProtocolH2C(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(h2c.NewHandler(protoHandler, &http2.Server{}))
+	defer server.Close()
+
+	res, err := NewClient(server.URL).Call("proto")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("HTTP/1.1"))
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{HTTPProtocol: HTTPProtocolH2C})
+	res, err = rpcClient.Call("proto")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("HTTP/2.0"))
+}
+
+func TestHTTPProtocolHTTP2(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewUnstartedServer(protoHandler)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewClientWithOpts(server.URL, &RPCClientOpts{HTTPProtocol: HTTPProtocolHTTP2})
+	// trust the certificate of the test server
+	transport := client.(*rpcClient).transport.(*httpTransport).httpClient.Transport.(*http.Transport)
+	transport.TLSClientConfig.RootCAs = server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	res, err := client.Call("proto")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("HTTP/2.0"))
+}
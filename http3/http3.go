@@ -0,0 +1,63 @@
+// Package http3 provides JSON-RPC clients that send their requests over HTTP/3 (QUIC).
+//
+// HTTP/3 can reduce the tail latency on lossy networks, because a lost packet only delays the call it belongs to.
+// The package is a separate module, so that quic-go is only a dependency if HTTP/3 is used.
+package http3
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	"github.com/quic-go/quic-go"
+	quichttp3 "github.com/quic-go/quic-go/http3"
+)
+
+// ClientOpts can be provided to NewClientWithOpts() to change configuration of the client.
+//
+// RPCClientOpts: the same options as for http clients. If HTTPClient is set, a copy of it is used with the HTTP/3 Transport.
+// HTTPProtocol is not used.
+//
+// TLSConfig: provide a custom tls.Config (e.g. to trust a private certificate authority), RPCClientOpts.TLSConfig if nil
+//
+// QUICConfig: provide a custom quic.Config (e.g. to change timeouts)
+type ClientOpts struct {
+	jsonrpc.RPCClientOpts
+	TLSConfig  *tls.Config
+	QUICConfig *quic.Config
+}
+
+// NewClient returns a new Client that sends its requests over HTTP/3 with default configuration.
+//
+// endpoint: JSON-RPC service URL (https://) to which JSON-RPC requests are sent.
+func NewClient(endpoint string) jsonrpc.Client {
+	return NewClientWithOpts(endpoint, nil)
+}
+
+// NewClientWithOpts returns a new Client that sends its requests over HTTP/3 with custom configuration.
+//
+// endpoint: JSON-RPC service URL (https://) to which JSON-RPC requests are sent.
+//
+// opts: ClientOpts provide custom configuration
+func NewClientWithOpts(endpoint string, opts *ClientOpts) jsonrpc.Client {
+	if opts == nil {
+		opts = &ClientOpts{}
+	}
+
+	rpcOpts := opts.RPCClientOpts
+	httpClient := &http.Client{}
+	if rpcOpts.HTTPClient != nil {
+		*httpClient = *rpcOpts.HTTPClient
+	}
+	tlsConfig := opts.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = rpcOpts.TLSConfig
+	}
+	httpClient.Transport = &quichttp3.Transport{
+		TLSClientConfig: tlsConfig,
+		QUICConfig:      opts.QUICConfig,
+	}
+	rpcOpts.HTTPClient = httpClient
+
+	return jsonrpc.NewClientWithOpts(endpoint, &rpcOpts)
+}
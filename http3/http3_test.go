@@ -0,0 +1,75 @@
+package http3
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	. "github.com/onsi/gomega"
+	quichttp3 "github.com/quic-go/quic-go/http3"
+)
+
+// newTestCertificate returns a self-signed certificate for 127.0.0.1 and a pool that trusts it.
+func newTestCertificate() (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "jsonrpc test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestHTTP3Client(t *testing.T) {
+	RegisterTestingT(t)
+
+	cert, pool := newTestCertificate()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	Expect(err).To(BeNil())
+
+	server := &quichttp3.Server{
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request jsonrpc.RPCRequest
+			json.NewDecoder(r.Body).Decode(&request)
+			json.NewEncoder(w).Encode(&jsonrpc.RPCResponse{JSONRPC: "2.0", Result: r.Proto, ID: request.ID})
+		}),
+	}
+	go server.Serve(conn)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts("https://"+conn.LocalAddr().String(), &ClientOpts{
+		TLSConfig: &tls.Config{RootCAs: pool},
+	})
+	res, err := rpcClient.Call("proto")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("HTTP/3.0"))
+}
@@ -0,0 +1,288 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+)
+
+type idKind int
+
+const (
+	idNumber idKind = iota
+	idString
+	idNull
+)
+
+// ID represents the id of a JSON-RPC request or response.
+//
+// The specification allows numbers, strings and null as id. Use NumberID(), StringID() or NullID() to create one.
+// The zero value is the number 0.
+//
+// IDs are comparable, so they can be compared with == and used as map keys.
+// Note that the number 1 and the string "1" are different ids.
+//
+// See: http://www.jsonrpc.org/specification#request_object
+type ID struct {
+	kind idKind
+	num  int64
+	str  string
+}
+
+// NumberID returns an id that is sent as json number.
+func NumberID(id int64) ID {
+	return ID{kind: idNumber, num: id}
+}
+
+// StringID returns an id that is sent as json string.
+func StringID(id string) ID {
+	return ID{kind: idString, str: id}
+}
+
+// NullID returns an id that is sent as json null.
+//
+// Servers respond with a null id if the id of the request could not be detected (e.g. on parse errors).
+func NullID() ID {
+	return ID{kind: idNull}
+}
+
+// IsNumber returns true if the id is a number.
+func (id ID) IsNumber() bool {
+	return id.kind == idNumber
+}
+
+// IsString returns true if the id is a string.
+func (id ID) IsString() bool {
+	return id.kind == idString
+}
+
+// IsNull returns true if the id is null.
+func (id ID) IsNull() bool {
+	return id.kind == idNull
+}
+
+// Number returns the numeric value of the id and true, or 0 and false if the id is not a number.
+func (id ID) Number() (int64, bool) {
+	if id.kind != idNumber {
+		return 0, false
+	}
+
+	return id.num, true
+}
+
+// Str returns the string value of the id and true, or "" and false if the id is not a string.
+func (id ID) Str() (string, bool) {
+	if id.kind != idString {
+		return "", false
+	}
+
+	return id.str, true
+}
+
+// String returns a human readable representation of the id: numbers and strings as they are and "null" for null ids.
+func (id ID) String() string {
+	switch id.kind {
+	case idString:
+		return id.str
+	case idNull:
+		return "null"
+	default:
+		return strconv.FormatInt(id.num, 10)
+	}
+}
+
+// MarshalJSON encodes the id as json number, string or null.
+func (id ID) MarshalJSON() ([]byte, error) {
+	switch id.kind {
+	case idString:
+		return json.Marshal(id.str)
+	case idNull:
+		return []byte("null"), nil
+	default:
+		return []byte(strconv.FormatInt(id.num, 10)), nil
+	}
+}
+
+// UnmarshalJSON decodes a json number, string or null to an id.
+//
+// Numbers must be integers that fit into int64.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*id = NullID()
+	case len(data) > 0 && data[0] == '"':
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*id = StringID(str)
+	default:
+		num, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid id %s: must be an integer, a string or null", data)
+		}
+		*id = NumberID(num)
+	}
+
+	return nil
+}
+
+// IDGenerator generates the ids of outgoing requests.
+//
+// Provide one with RPCClientOpts to use e.g. UUIDs or prefixed ids that are unique across multiple client instances.
+// Implementations must be safe for concurrent use.
+type IDGenerator interface {
+	NextID() (ID, error)
+}
+
+// IDGeneratorFunc is an adapter to use an ordinary function as IDGenerator.
+type IDGeneratorFunc func() (ID, error)
+
+// NextID calls f().
+func (f IDGeneratorFunc) NextID() (ID, error) {
+	return f()
+}
+
+// SequentialIDGenerator generates incrementing number ids.
+//
+// Create one with NewSequentialIDGenerator() or NewSequentialIDGeneratorWithOpts(). It can be shared between clients.
+type SequentialIDGenerator struct {
+	// nextID is accessed atomically and must be the first field to be 64-bit aligned on 32-bit platforms.
+	nextID    int64
+	exhausted int32
+	start     int64
+	max       int64
+	rollover  RolloverPolicy
+}
+
+// ErrIDsExhausted is returned by a SequentialIDGenerator with RolloverError policy after the maximum id was generated.
+var ErrIDsExhausted = errors.New("request ids exhausted")
+
+// MaxSafeIntegerID is the largest id that can be represented exactly by servers that parse numbers as float64 (e.g. javascript servers).
+const MaxSafeIntegerID = 1<<53 - 1
+
+// RolloverPolicy defines what a SequentialIDGenerator does after the maximum id was generated.
+type RolloverPolicy int
+
+const (
+	// RolloverWrap continues with the start id (default).
+	RolloverWrap RolloverPolicy = iota
+	// RolloverError returns ErrIDsExhausted for all further ids.
+	RolloverError
+)
+
+// SequentialIDGeneratorOpts can be provided to NewSequentialIDGeneratorWithOpts() to change configuration of SequentialIDGenerator.
+//
+// Start: the first id
+//
+// Max: the largest id that is generated, math.MaxInt64 if 0. Must not be smaller than Start.
+// Use MaxSafeIntegerID for servers that can not handle ids larger than 2^53-1.
+//
+// Rollover: what happens after Max was generated (see RolloverPolicy)
+type SequentialIDGeneratorOpts struct {
+	Start    int64
+	Max      int64
+	Rollover RolloverPolicy
+}
+
+// NewSequentialIDGenerator returns an IDGenerator that generates the ids start, start+1, start+2, ...
+//
+// After math.MaxInt64 it continues with start.
+func NewSequentialIDGenerator(start int64) *SequentialIDGenerator {
+	return NewSequentialIDGeneratorWithOpts(&SequentialIDGeneratorOpts{
+		Start: start,
+	})
+}
+
+// NewSequentialIDGeneratorWithOpts returns an IDGenerator that generates incrementing ids with custom configuration.
+//
+// opts: SequentialIDGeneratorOpts provide custom configuration
+func NewSequentialIDGeneratorWithOpts(opts *SequentialIDGeneratorOpts) *SequentialIDGenerator {
+	generator := &SequentialIDGenerator{
+		max: math.MaxInt64,
+	}
+
+	if opts == nil {
+		return generator
+	}
+
+	generator.start = opts.Start
+	generator.nextID = opts.Start
+	generator.rollover = opts.Rollover
+
+	if opts.Max != 0 {
+		generator.max = opts.Max
+	}
+	if generator.max < generator.start {
+		generator.max = generator.start
+	}
+
+	return generator
+}
+
+// NextID returns the next number id.
+//
+// NextID is lock-free, so it can be used from many goroutines without contention.
+func (g *SequentialIDGenerator) NextID() (ID, error) {
+	for {
+		if atomic.LoadInt32(&g.exhausted) != 0 {
+			return ID{}, ErrIDsExhausted
+		}
+
+		id := atomic.LoadInt64(&g.nextID)
+		if id >= g.max && g.rollover == RolloverError {
+			// only one caller gets the last id
+			if atomic.CompareAndSwapInt32(&g.exhausted, 0, 1) {
+				return NumberID(id), nil
+			}
+			continue
+		}
+
+		next := g.start
+		if id < g.max {
+			next = id + 1
+		}
+		if atomic.CompareAndSwapInt64(&g.nextID, id, next) {
+			return NumberID(id), nil
+		}
+	}
+}
+
+// Peek returns the id that is returned by the next call to NextID() and false if the ids are exhausted.
+func (g *SequentialIDGenerator) Peek() (int64, bool) {
+	return atomic.LoadInt64(&g.nextID), atomic.LoadInt32(&g.exhausted) == 0
+}
+
+// UUIDGenerator returns an IDGenerator that generates random (version 4) UUIDs as string ids.
+func UUIDGenerator() IDGenerator {
+	return IDGeneratorFunc(func() (ID, error) {
+		var uuid [16]byte
+		if _, err := rand.Read(uuid[:]); err != nil {
+			return ID{}, err
+		}
+		uuid[6] = (uuid[6] & 0x0f) | 0x40 // version 4
+		uuid[8] = (uuid[8] & 0x3f) | 0x80 // variant 10
+
+		return StringID(fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])), nil
+	})
+}
+
+// PrefixIDGenerator returns an IDGenerator that generates string ids with the given prefix,
+// e.g. "tenant-a-" and the ids 0, 1, 2 of next result in "tenant-a-0", "tenant-a-1", "tenant-a-2".
+func PrefixIDGenerator(prefix string, next IDGenerator) IDGenerator {
+	return IDGeneratorFunc(func() (ID, error) {
+		id, err := next.NextID()
+		if err != nil {
+			return ID{}, err
+		}
+
+		return StringID(prefix + id.String()), nil
+	})
+}
@@ -0,0 +1,222 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestID_JSON(t *testing.T) {
+	RegisterTestingT(t)
+
+	js, err := json.Marshal([]ID{NumberID(1), StringID("abc"), NullID(), {}})
+	Expect(err).To(BeNil())
+	Expect(string(js)).To(Equal(`[1,"abc",null,0]`))
+
+	var ids []ID
+	err = json.Unmarshal([]byte(`[1, "abc", null, "1", -42]`), &ids)
+	Expect(err).To(BeNil())
+	Expect(ids).To(Equal([]ID{NumberID(1), StringID("abc"), NullID(), StringID("1"), NumberID(-42)}))
+	Expect(ids[0]).NotTo(Equal(ids[3]))
+
+	err = json.Unmarshal([]byte(`[1.5]`), &ids)
+	Expect(err).NotTo(BeNil())
+
+	err = json.Unmarshal([]byte(`[{}]`), &ids)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestID_Accessors(t *testing.T) {
+	RegisterTestingT(t)
+
+	num, ok := NumberID(5).Number()
+	Expect(ok).To(BeTrue())
+	Expect(num).To(Equal(int64(5)))
+	_, ok = StringID("5").Number()
+	Expect(ok).To(BeFalse())
+
+	str, ok := StringID("abc").Str()
+	Expect(ok).To(BeTrue())
+	Expect(str).To(Equal("abc"))
+	_, ok = NumberID(5).Str()
+	Expect(ok).To(BeFalse())
+
+	Expect(NumberID(5).IsNumber()).To(BeTrue())
+	Expect(StringID("abc").IsString()).To(BeTrue())
+	Expect(NullID().IsNull()).To(BeTrue())
+	Expect(ID{}).To(Equal(NumberID(0)))
+
+	Expect(NumberID(5).String()).To(Equal("5"))
+	Expect(StringID("abc").String()).To(Equal("abc"))
+	Expect(NullID().String()).To(Equal("null"))
+}
+
+func TestRpcClient_StringID(t *testing.T) {
+	RegisterTestingT(t)
+	rpcClient := NewClient(httpServer.URL)
+
+	responseBody = `{"result": 1, "id": "req-1", "jsonrpc": "2.0"}`
+	res, err := rpcClient.CallRaw(&RPCRequest{
+		Method:  "something",
+		ID:      StringID("req-1"),
+		JSONRPC: "2.0",
+	})
+	Expect((<-requestChan).body).To(Equal(`{"method":"something","id":"req-1","jsonrpc":"2.0"}`))
+	Expect(err).To(BeNil())
+	Expect(res.ID).To(Equal(StringID("req-1")))
+
+	responseBody = `[{"result": 1, "id": "a"}, {"result": 2, "id": null}]`
+	batchRes, err := rpcClient.CallBatchRaw(RPCRequests{
+		{Method: "something", ID: StringID("a"), JSONRPC: "2.0"},
+	})
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(batchRes.GetByID(StringID("a"))).NotTo(BeNil())
+	Expect(batchRes.AsMap()[NullID()]).NotTo(BeNil())
+}
+
+func TestIDGenerators(t *testing.T) {
+	RegisterTestingT(t)
+
+	sequential := NewSequentialIDGenerator(5)
+	id, err := sequential.NextID()
+	Expect(err).To(BeNil())
+	Expect(id).To(Equal(NumberID(5)))
+	id, _ = sequential.NextID()
+	Expect(id).To(Equal(NumberID(6)))
+
+	prefixed := PrefixIDGenerator("tenant-", NewSequentialIDGenerator(0))
+	id, _ = prefixed.NextID()
+	Expect(id).To(Equal(StringID("tenant-0")))
+
+	uuid := UUIDGenerator()
+	id1, err := uuid.NextID()
+	Expect(err).To(BeNil())
+	id2, _ := uuid.NextID()
+	Expect(id1).NotTo(Equal(id2))
+	str, ok := id1.Str()
+	Expect(ok).To(BeTrue())
+	Expect(str).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+}
+
+func TestRpcClient_IDGenerator(t *testing.T) {
+	RegisterTestingT(t)
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		IDGenerator: NewSequentialIDGenerator(10),
+	})
+
+	responseBody = `{"result": 1, "id": 10}`
+	rpcClient.Call("first")
+	Expect((<-requestChan).body).To(Equal(`{"method":"first","id":10,"jsonrpc":"2.0"}`))
+
+	responseBody = `{"result": 1, "id": 11}`
+	rpcClient.Call("second")
+	Expect((<-requestChan).body).To(Equal(`{"method":"second","id":11,"jsonrpc":"2.0"}`))
+
+	responseBody = `[{"result": 1, "id": 12}, {"result": 2, "id": 13}]`
+	rpcClient.CallBatch(RPCRequests{
+		NewRequest("third"),
+		NewRequest("fourth"),
+	})
+	Expect((<-requestChan).body).To(Equal(`[{"method":"third","id":12,"jsonrpc":"2.0"},{"method":"fourth","id":13,"jsonrpc":"2.0"}]`))
+
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		IDGenerator: IDGeneratorFunc(func() (ID, error) {
+			return ID{}, errors.New("no ids left")
+		}),
+	})
+	_, err := rpcClient.Call("first")
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("no ids left"))
+}
+
+func TestSequentialIDGenerator_Rollover(t *testing.T) {
+	RegisterTestingT(t)
+
+	wrapping := NewSequentialIDGeneratorWithOpts(&SequentialIDGeneratorOpts{
+		Start: 1,
+		Max:   3,
+	})
+	var ids []ID
+	for i := 0; i < 5; i++ {
+		id, err := wrapping.NextID()
+		Expect(err).To(BeNil())
+		ids = append(ids, id)
+	}
+	Expect(ids).To(Equal([]ID{NumberID(1), NumberID(2), NumberID(3), NumberID(1), NumberID(2)}))
+	next, ok := wrapping.Peek()
+	Expect(ok).To(BeTrue())
+	Expect(next).To(Equal(int64(3)))
+
+	failing := NewSequentialIDGeneratorWithOpts(&SequentialIDGeneratorOpts{
+		Start:    1,
+		Max:      2,
+		Rollover: RolloverError,
+	})
+	failing.NextID()
+	id, err := failing.NextID()
+	Expect(err).To(BeNil())
+	Expect(id).To(Equal(NumberID(2)))
+	_, err = failing.NextID()
+	Expect(err).To(Equal(ErrIDsExhausted))
+	_, ok = failing.Peek()
+	Expect(ok).To(BeFalse())
+
+	// no overflow at the largest int64
+	maxed := NewSequentialIDGenerator(math.MaxInt64)
+	id, _ = maxed.NextID()
+	Expect(id).To(Equal(NumberID(math.MaxInt64)))
+	id, _ = maxed.NextID()
+	Expect(id).To(Equal(NumberID(math.MaxInt64)))
+}
+
+func TestSequentialIDGenerator_Concurrent(t *testing.T) {
+	RegisterTestingT(t)
+
+	generator := NewSequentialIDGeneratorWithOpts(&SequentialIDGeneratorOpts{
+		Start:    1,
+		Max:      1000,
+		Rollover: RolloverError,
+	})
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		seen  = make(map[ID]bool)
+		fails int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				id, err := generator.NextID()
+				mutex.Lock()
+				if err != nil {
+					fails++
+				} else {
+					seen[id] = true
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every id is generated exactly once
+	Expect(len(seen)).To(Equal(1000))
+	Expect(fails).To(Equal(1000))
+}
+
+func BenchmarkSequentialIDGenerator(b *testing.B) {
+	generator := NewSequentialIDGenerator(0)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			generator.NextID()
+		}
+	})
+}
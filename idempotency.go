@@ -0,0 +1,86 @@
+package jsonrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	mathrand "math/rand"
+	"path"
+)
+
+// IdempotencyKeyOpts configures idempotency keys, see RPCClientOpts.
+//
+// An idempotency key is sent as http header and stays the same for all retries of a call,
+// so that gateways that support idempotency can deduplicate retried requests.
+// Keys set with WithIdempotencyKey() are always sent.
+//
+// Header: the name of the http header (default "Idempotency-Key")
+//
+// Methods: the method names or patterns as used by path.Match() for which keys are generated, for all calls if empty.
+// A batch request gets a key if one of its methods matches.
+//
+// Generate: returns a new key, by default a random UUID
+type IdempotencyKeyOpts struct {
+	Header   string
+	Methods  []string
+	Generate func() string
+}
+
+const defaultIdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx that sends key as idempotency key with calls that use the context.
+// The http header is set by IdempotencyKeyOpts.Header and defaults to "Idempotency-Key".
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// idempotencyKey returns the idempotency key of ctx, "" if there is none.
+func idempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// withIdempotencyKey returns ctx with a new idempotency key if the client generates keys for one of the methods
+// and ctx has no key yet.
+func (client *rpcClient) withIdempotencyKey(ctx context.Context, methods []string) context.Context {
+	opts := client.idempotencyKey
+	if opts == nil || idempotencyKey(ctx) != "" {
+		return ctx
+	}
+
+	matched := len(opts.Methods) == 0
+	for _, method := range methods {
+		for _, pattern := range opts.Methods {
+			if ok, err := path.Match(pattern, method); pattern == method || (err == nil && ok) {
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return ctx
+	}
+
+	generate := opts.Generate
+	if generate == nil {
+		generate = newUUID
+	}
+
+	return WithIdempotencyKey(ctx, generate())
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// a key that is unique with high probability is good enough
+		binary.LittleEndian.PutUint64(b[0:8], mathrand.Uint64())
+		binary.LittleEndian.PutUint64(b[8:16], mathrand.Uint64())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
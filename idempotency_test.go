@@ -0,0 +1,122 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newKeyServer returns a server that fails the first failures requests with 503 and records the header of all requests.
+func newKeyServer(header string, failures int) (*httptest.Server, func() []string) {
+	var mutex sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		keys = append(keys, r.Header.Get(header))
+		fail := len(keys) <= failures
+		mutex.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if bytes.HasPrefix(body, []byte("[")) {
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","result":"ok","id":0}]`)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+
+	return server, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, keys := newKeyServer("Idempotency-Key", 2)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Retry:          &RetryPolicy{InitialBackoff: time.Millisecond},
+		IdempotencyKey: &IdempotencyKeyOpts{},
+	})
+
+	// the key stays the same for all retries
+	_, err := rpcClient.Call("eth_sendRawTransaction")
+	Expect(err).To(BeNil())
+	Expect(keys()).To(HaveLen(3))
+	Expect(keys()[0]).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+	Expect(keys()[1]).To(Equal(keys()[0]))
+	Expect(keys()[2]).To(Equal(keys()[0]))
+
+	// every call gets a new key
+	_, err = rpcClient.Call("eth_sendRawTransaction")
+	Expect(err).To(BeNil())
+	Expect(keys()[3]).NotTo(Equal(keys()[0]))
+
+	// a key of the caller is used
+	_, err = rpcClient.CallContext(WithIdempotencyKey(context.Background(), "my-key"), "eth_sendRawTransaction")
+	Expect(err).To(BeNil())
+	Expect(keys()[4]).To(Equal("my-key"))
+}
+
+func TestIdempotencyKey_Methods(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, keys := newKeyServer("X-Request-Key", 0)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		IdempotencyKey: &IdempotencyKeyOpts{
+			Header:   "X-Request-Key",
+			Methods:  []string{"eth_send*"},
+			Generate: func() string { return "generated" },
+		},
+	})
+
+	_, err := rpcClient.Call("eth_call")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("eth_sendRawTransaction")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("eth_call"), NewRequest("eth_sendTransaction")})
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallContext(WithIdempotencyKey(context.Background(), "my-key"), "eth_call")
+	Expect(err).To(BeNil())
+
+	Expect(keys()).To(Equal([]string{"", "generated", "generated", "my-key"}))
+
+	// without options, keys of the caller are sent with the default header
+	server, keys = newKeyServer("Idempotency-Key", 0)
+	defer server.Close()
+
+	_, err = NewClient(server.URL).CallContext(WithIdempotencyKey(context.Background(), "my-key"), "eth_call")
+	Expect(err).To(BeNil())
+	Expect(keys()).To(Equal([]string{"my-key"}))
+}
+
+func TestNewUUID(t *testing.T) {
+	RegisterTestingT(t)
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		id := newUUID()
+		Expect(uuid.MatchString(id)).To(BeTrue())
+		Expect(seen[id]).To(BeFalse())
+		seen[id] = true
+	}
+}
@@ -0,0 +1,193 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CallInfo describes a call for an Interceptor or Metrics.
+// All fields except Requests, Batch and CorrelationID are set when the invoker of the interceptor returns.
+//
+// Requests: the requests of the call, a single request unless Batch is true
+//
+// Batch: true for batch calls
+//
+// CorrelationID: the correlation id of the call (see CorrelationIDOpts), empty if there is none
+//
+// Responses: the responses of the call, if the call returned responses
+//
+// Err: the error of the call, RPC errors are only part of the responses
+//
+// Duration: the duration of the call including all retries
+//
+// Attempts: the number of times the request was sent, including retries
+//
+// Endpoint: the endpoint of the last attempt, empty if it is not known (e.g. for a custom Transport)
+//
+// HTTPStatus: the http status code of the last attempt, 0 if there was no http response
+//
+// ResponseHeader: the http headers of the response of the last attempt, e.g. rate limit headers of the provider,
+// nil if there was no http response
+type CallInfo struct {
+	Requests       RPCRequests
+	Batch          bool
+	CorrelationID  string
+	Responses      RPCResponses
+	Err            error
+	Duration       time.Duration
+	Attempts       int
+	Endpoint       string
+	HTTPStatus     int
+	ResponseHeader http.Header
+}
+
+// Method returns the method of a single request or "batch" for a batch call.
+func (c *CallInfo) Method() string {
+	if c.Batch || len(c.Requests) != 1 || c.Requests[0] == nil {
+		return "batch"
+	}
+	return c.Requests[0].Method
+}
+
+// Invoker sends a call, see Interceptor.
+type Invoker func(ctx context.Context) error
+
+// Interceptor wraps the calls of a client, e.g. for tracing or logging, see RPCClientOpts.
+// It must call invoke to send the call, ctx may be replaced, e.g.
+//
+//	func(ctx context.Context, call *jsonrpc.CallInfo, invoke jsonrpc.Invoker) error {
+//		start := time.Now()
+//		err := invoke(ctx)
+//		log.Printf("%v took %v", call.Method(), time.Since(start))
+//		return err
+//	}
+//
+// The error returned by invoke is the error of the call, RPC errors are only part of the responses.
+// The error returned by the interceptor is returned to the caller.
+type Interceptor func(ctx context.Context, call *CallInfo, invoke Invoker) error
+
+// WithCallInfo returns a copy of ctx that makes calls which use the context fill in call,
+// e.g. to log the duration, attempts, endpoint, http status and response headers of a specific call:
+//
+//	call := &jsonrpc.CallInfo{}
+//	response, err := rpcClient.CallContext(jsonrpc.WithCallInfo(ctx, call), "getBalance")
+//	log.Printf("getBalance took %v on %v", call.Duration, call.Endpoint)
+//	remaining := call.ResponseHeader.Get("X-RateLimit-Remaining")
+//
+// call is set when the call returns. It is not set if the call was coalesced into an identical call (see SingleflightOpts).
+// Use a separate CallInfo for every call.
+func WithCallInfo(ctx context.Context, call *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoContextKey{}, call)
+}
+
+type callInfoContextKey struct{}
+
+// observed returns true if the call of ctx is passed to interceptors or metrics or reported with WithCallInfo().
+func (client *rpcClient) observed(ctx context.Context) bool {
+	if len(client.interceptors) > 0 || client.metrics != nil {
+		return true
+	}
+	_, ok := ctx.Value(callInfoContextKey{}).(*CallInfo)
+	return ok
+}
+
+// observe calls invoke through the interceptors of the client, the first interceptor is the outermost.
+// The fields of call are set when invoke returns, then call is passed to the metrics of the client
+// and copied to the CallInfo of WithCallInfo().
+func (client *rpcClient) observe(ctx context.Context, call *CallInfo, invoke Invoker) error {
+	call.CorrelationID = correlationID(ctx)
+	send := func(ctx context.Context) error {
+		record := &callRecord{}
+		start := time.Now()
+		err := invoke(context.WithValue(ctx, callRecordContextKey{}, record))
+		record.finish(call, time.Since(start), err)
+		return err
+	}
+
+	for i := len(client.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := client.interceptors[i], send
+		send = func(ctx context.Context) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	err := send(ctx)
+	if client.metrics != nil {
+		client.metrics.ObserveCall(call)
+	}
+	if target, ok := ctx.Value(callInfoContextKey{}).(*CallInfo); ok {
+		*target = *call
+	}
+
+	return err
+}
+
+type callRecordContextKey struct{}
+
+// callRecord collects the attempts of a call from the transports, which may run concurrently (e.g. hedged requests).
+type callRecord struct {
+	mutex      sync.Mutex
+	done       bool
+	attempts   int
+	endpoint   string
+	httpStatus int
+	header     http.Header
+}
+
+// recordAttempt counts an attempt of the call of ctx.
+func recordAttempt(ctx context.Context) {
+	if record, ok := ctx.Value(callRecordContextKey{}).(*callRecord); ok {
+		record.mutex.Lock()
+		defer record.mutex.Unlock()
+		record.attempts++
+	}
+}
+
+// recordEndpoint sets the endpoint, http status code (0 if there is no response) and response header of the call of ctx.
+func recordEndpoint(ctx context.Context, endpoint string, httpStatus int, header http.Header) {
+	if record, ok := ctx.Value(callRecordContextKey{}).(*callRecord); ok {
+		record.mutex.Lock()
+		defer record.mutex.Unlock()
+		if !record.done {
+			record.endpoint = endpoint
+			record.httpStatus = httpStatus
+			record.header = header
+		}
+	}
+}
+
+// finish sets the fields of call, later attempts (e.g. a cancelled hedged request) are ignored.
+func (r *callRecord) finish(call *CallInfo, duration time.Duration, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.done = true
+	call.Err = err
+	call.Duration = duration
+	call.Attempts = r.attempts
+	call.Endpoint = r.endpoint
+	call.HTTPStatus = r.httpStatus
+	call.ResponseHeader = r.header
+}
+
+// wrapRoundTripper returns a copy of httpClient whose transport is wrapped by the middleware,
+// the first middleware is the outermost.
+func wrapRoundTripper(httpClient *http.Client, middleware []func(http.RoundTripper) http.RoundTripper) *http.Client {
+	if len(middleware) == 0 {
+		return httpClient
+	}
+
+	wrapped := *httpClient
+	roundTripper := wrapped.Transport
+	if roundTripper == nil {
+		roundTripper = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		roundTripper = middleware[i](roundTripper)
+	}
+	wrapped.Transport = roundTripper
+
+	return &wrapped
+}
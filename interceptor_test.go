@@ -0,0 +1,145 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type testContextKey string
+
+func TestInterceptors(t *testing.T) {
+	RegisterTestingT(t)
+
+	var calls []string
+	interceptor := func(name string) Interceptor {
+		return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+			calls = append(calls, name+" "+call.Method())
+			err := invoke(context.WithValue(ctx, testContextKey(name), true))
+			calls = append(calls, fmt.Sprintf("%v %v %v", name, len(call.Responses), err))
+			return err
+		}
+	}
+
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Interceptors: []Interceptor{interceptor("outer"), interceptor("inner")},
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{
+			func(next http.RoundTripper) http.RoundTripper {
+				return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+					// the context of the interceptors is passed to the http request
+					request.Header.Set("X-Outer", fmt.Sprint(request.Context().Value(testContextKey("outer"))))
+					return next.RoundTrip(request)
+				})
+			},
+		},
+	})
+
+	res, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+	Expect(calls).To(Equal([]string{"outer something", "inner something", "inner 1 <nil>", "outer 1 <nil>"}))
+	Expect(headers.Get("X-Outer")).To(Equal("true"))
+
+	// the error of an interceptor is returned
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{
+		Interceptors: []Interceptor{func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+			return errors.New("denied")
+		}},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(MatchError("denied"))
+}
+
+func TestInterceptors_Batch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"jsonrpc":"2.0","result":1,"id":0},{"jsonrpc":"2.0","result":2,"id":1}]`)
+	}))
+	defer server.Close()
+
+	var infos []*CallInfo
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Interceptors: []Interceptor{func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+			infos = append(infos, call)
+			return invoke(ctx)
+		}},
+	})
+
+	_, err := rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(BeNil())
+
+	var streamed int
+	err = rpcClient.CallBatchStream(context.Background(), RPCRequests{NewRequest("a"), NewRequest("b")}, func(*RPCResponse) {
+		streamed++
+	})
+	Expect(err).To(BeNil())
+	Expect(streamed).To(Equal(2))
+
+	Expect(infos).To(HaveLen(2))
+	for _, info := range infos {
+		Expect(info.Batch).To(BeTrue())
+		Expect(info.Method()).To(Equal("batch"))
+		Expect(info.Requests).To(HaveLen(2))
+		Expect(info.Responses).To(HaveLen(2))
+	}
+}
+
+func TestWithCallInfo(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, _ := newFlakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{Retry: &RetryPolicy{MaxAttempts: 3}})
+
+	call := &CallInfo{}
+	res, err := rpcClient.CallContext(WithCallInfo(context.Background(), call), "something", 1)
+	Expect(err).To(BeNil())
+	Expect(call.Method()).To(Equal("something"))
+	Expect(call.Batch).To(BeFalse())
+	Expect(call.Responses).To(Equal(RPCResponses{res}))
+	Expect(call.Err).To(BeNil())
+	Expect(call.Attempts).To(Equal(3))
+	Expect(call.Endpoint).To(Equal(server.URL))
+	Expect(call.HTTPStatus).To(Equal(http.StatusOK))
+	Expect(call.Duration).To(BeNumerically(">", 0))
+
+	Expect(call.ResponseHeader.Get("Content-Type")).NotTo(BeEmpty())
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	call = &CallInfo{}
+	_, err = NewClient(failing.URL).CallBatchContext(WithCallInfo(context.Background(), call), RPCRequests{NewRequest("a")})
+	Expect(err).To(HaveOccurred())
+	Expect(call.Method()).To(Equal("batch"))
+	Expect(call.Err).To(Equal(err))
+	Expect(call.Attempts).To(Equal(1))
+	Expect(call.Endpoint).To(Equal(failing.URL))
+	Expect(call.HTTPStatus).To(Equal(http.StatusNotFound))
+	Expect(call.ResponseHeader.Get("X-RateLimit-Remaining")).To(Equal("0"))
+
+	// no response
+	failing.Close()
+	call = &CallInfo{}
+	_, err = NewClient(failing.URL).CallContext(WithCallInfo(context.Background(), call), "something")
+	Expect(err).To(HaveOccurred())
+	Expect(call.HTTPStatus).To(Equal(0))
+	Expect(call.ResponseHeader).To(BeNil())
+}
@@ -0,0 +1,52 @@
+package jsonrpc
+
+import "time"
+
+// IPCClientOpts can be provided to DialIPCWithOpts() to change configuration of the IPC client.
+//
+// RPCClientOpts: the same options as for http clients. HTTPClient and CustomHeaders are not used.
+//
+// Reconnect, ReconnectDelay, MaxReconnectDelay: see WSClientOpts
+type IPCClientOpts struct {
+	RPCClientOpts
+	Reconnect         bool
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+}
+
+// DialIPC connects to a JSON-RPC endpoint on a unix domain socket (e.g. "/home/user/.ethereum/geth.ipc"),
+// or on Windows a named pipe (e.g. `\\.\pipe\geth.ipc`), and returns a ConnClient with default configuration.
+//
+// The messages are sent as a stream of JSON values, as done by geth and nearcore.
+func DialIPC(path string) (ConnClient, error) {
+	return DialIPCWithOpts(path, nil)
+}
+
+// DialIPCWithOpts connects to a JSON-RPC endpoint on a unix domain socket or named pipe and returns a ConnClient with custom configuration.
+//
+// opts: IPCClientOpts provide custom configuration
+func DialIPCWithOpts(path string, opts *IPCClientOpts) (ConnClient, error) {
+	if opts == nil {
+		opts = &IPCClientOpts{}
+	}
+
+	dial := func() (messageConn, error) {
+		conn, err := dialIPC(path)
+		if err != nil {
+			return nil, err
+		}
+		return newStreamConn(conn), nil
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return nil, &TransportError{err: err}
+	}
+
+	var reconnect *reconnector
+	if opts.Reconnect {
+		reconnect = newReconnector(dial, opts.ReconnectDelay, opts.MaxReconnectDelay)
+	}
+
+	return newConnClient(conn, &opts.RPCClientOpts, reconnect), nil
+}
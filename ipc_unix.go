@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package jsonrpc
+
+import "net"
+
+// dialIPC connects to a unix domain socket.
+func dialIPC(path string) (net.Conn, error) {
+	return net.Dial("unix", path)
+}
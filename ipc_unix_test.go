@@ -0,0 +1,132 @@
+//go:build !windows
+// +build !windows
+
+package jsonrpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// ipcTestServer is a JSON-RPC server on a unix domain socket that responds to every request with respondTestRequest().
+type ipcTestServer struct {
+	listener net.Listener
+	dir      string
+	mutex    sync.Mutex
+	conns    []net.Conn
+}
+
+func newIPCTestServer() *ipcTestServer {
+	dir, err := ioutil.TempDir("", "jsonrpc")
+	if err != nil {
+		panic(err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, "test.ipc"))
+	if err != nil {
+		panic(err)
+	}
+
+	s := &ipcTestServer{listener: listener, dir: dir}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			s.conns = append(s.conns, conn)
+			s.mutex.Unlock()
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *ipcTestServer) serve(conn net.Conn) {
+	var writeMutex sync.Mutex
+	encoder := json.NewEncoder(conn)
+	write := func(v interface{}) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		encoder.Encode(v)
+	}
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var message json.RawMessage
+		if err := decoder.Decode(&message); err != nil {
+			return
+		}
+		go respondTestRequest(message, write)
+	}
+}
+
+func (s *ipcTestServer) path() string {
+	return s.listener.Addr().String()
+}
+
+func (s *ipcTestServer) dropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *ipcTestServer) close() {
+	s.listener.Close()
+	s.dropConnections()
+	os.RemoveAll(s.dir)
+}
+
+func TestIPCClient(t *testing.T) {
+	RegisterTestingT(t)
+	server := newIPCTestServer()
+	defer server.close()
+
+	rpcClient, err := DialIPCWithOpts(server.path(), &IPCClientOpts{Reconnect: true, ReconnectDelay: 10 * time.Millisecond})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.Call("hello", 1, 2)
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+
+	responses, err := rpcClient.CallBatch(RPCRequests{
+		NewRequest("first"),
+		NewRequest("second"),
+	})
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(2))
+	Expect(responses.GetByID(NumberID(2)).Result).To(Equal("first"))
+	Expect(responses.GetByID(NumberID(3)).Result).To(Equal("second"))
+
+	subscription, err := rpcClient.Subscribe("test_subscribe", 2)
+	Expect(err).To(BeNil())
+	for i := 0; i < 2; i++ {
+		Expect((<-subscription.Notifications()).Params).To(HaveKeyWithValue("subscription", subscription.ID().String()))
+	}
+
+	// the subscription is re-established after a reconnect
+	server.dropConnections()
+	var notification *RPCNotification
+	Eventually(subscription.Notifications()).Should(Receive(&notification))
+	Expect(notification.Params).To(HaveKeyWithValue("subscription", subscription.ID().String()))
+}
+
+func TestDialIPC_Error(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := DialIPC(filepath.Join(os.TempDir(), "jsonrpc-does-not-exist.ipc"))
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+}
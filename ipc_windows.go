@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package jsonrpc
+
+import (
+	"net"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// pipeDialTimeout is the time to wait for a busy named pipe to become available.
+const pipeDialTimeout = 2 * time.Second
+
+// dialIPC connects to a named pipe, e.g. `\\.\pipe\geth.ipc`.
+func dialIPC(path string) (net.Conn, error) {
+	timeout := pipeDialTimeout
+	return winio.DialPipe(path, &timeout)
+}
@@ -0,0 +1,66 @@
+//go:build windows
+// +build windows
+
+package jsonrpc
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/Microsoft/go-winio"
+	. "github.com/onsi/gomega"
+)
+
+func TestIPCClient_NamedPipe(t *testing.T) {
+	RegisterTestingT(t)
+
+	path := `\\.\pipe\jsonrpc-test`
+	listener, err := winio.ListenPipe(path, nil)
+	Expect(err).To(BeNil())
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestPipe(conn)
+		}
+	}()
+
+	rpcClient, err := DialIPC(path)
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+
+	subscription, err := rpcClient.Subscribe("test_subscribe", 1)
+	Expect(err).To(BeNil())
+	Expect((<-subscription.Notifications()).Params).To(HaveKeyWithValue("subscription", subscription.ID().String()))
+}
+
+func serveTestPipe(conn net.Conn) {
+	defer conn.Close()
+
+	var writeMutex sync.Mutex
+	encoder := json.NewEncoder(conn)
+	write := func(v interface{}) {
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		encoder.Encode(v)
+	}
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var message json.RawMessage
+		if err := decoder.Decode(&message); err != nil {
+			return
+		}
+		go respondTestRequest(message, write)
+	}
+}
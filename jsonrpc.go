@@ -1,14 +1,19 @@
-// Package jsonrpc provides a JSON-RPC 2.0 client that sends JSON-RPC requests and receives JSON-RPC responses using HTTP.
+// Package jsonrpc provides a JSON-RPC 2.0 client that sends JSON-RPC requests and receives JSON-RPC responses using HTTP, WebSocket or IPC.
 package jsonrpc
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 )
 
 const (
@@ -17,7 +22,8 @@ const (
 
 // RPCClient sends JSON-RPC requests over HTTP to the provided JSON-RPC backend.
 //
-// RPCClient is created using the factory function NewClient().
+// RPCClient is created using the factory function NewClient(), or NewClientWithTransport() for other transports.
+// The clients of this package implement Client, which adds further methods to RPCClient.
 type RPCClient interface {
 	// Call is used to send a JSON-RPC request to the server endpoint.
 	//
@@ -79,11 +85,15 @@ type RPCClient interface {
 	// - field Params is sent as provided, so Params: 2 forms an invalid json (correct would be Params: []int{2})
 	// - you can use the helper function Params(1, 2, 3) to use the same format as in Call()
 	// - field JSONRPC is overwritten and set to value: "2.0"
-	// - field ID is overwritten and set incrementally and maps to the array position (e.g. requests[5].ID == 5)
+	// - field ID is overwritten and set incrementally and maps to the array position (e.g. requests[5].ID == NumberID(5)),
+	//   or set by the IDGenerator of the client if one was provided
+	// - notifications (see NewNotification()) are sent without id and get no response,
+	//   a batch of only notifications returns empty RPCResponses
 	//
 	//
 	// Returns RPCResponses that is of type []*RPCResponse
-	// - note that a list of RPCResponses can be received unordered so it can happen that: responses[i] != responses[i].ID
+	// - note that a list of RPCResponses can be received unordered so it can happen that: responses[i] != responses[i].ID,
+	//   unless the client was created with OrderBatchResponses
 	// - RPCPersponses is enriched with helper functions e.g.: responses.HasError() returns  true if one of the responses holds an RPCError
 	CallBatch(requests RPCRequests) (RPCResponses, error)
 
@@ -94,13 +104,13 @@ type RPCClient interface {
 	//
 	// CallBatchRaw(RPCRequests{
 	//   &RPCRequest{
-	//     ID: 123,            // this won't be replaced in CallBatchRaw
+	//     ID: NumberID(123),  // this won't be replaced in CallBatchRaw
 	//     JSONRPC: "wrong",   // this won't be replaced in CallBatchRaw
 	//     Method: "myMethod1",
 	//     Params: []int{1},   // there is no magic, be sure to only use array or object
 	//   },
 	//   &RPCRequest{
-	//     ID: 612,
+	//     ID: StringID("a"),
 	//     JSONRPC: "2.0",
 	//     Method: "myMethod2",
 	//     Params: Params("Alex", 35, true), // you can use helper function Params() (see doc)
@@ -114,13 +124,64 @@ type RPCClient interface {
 	CallBatchRaw(requests RPCRequests) (RPCResponses, error)
 }
 
+// Client is an RPCClient with the methods that were added to the clients of this package later:
+// variants with context, by-name params, streamed batches and call statistics.
+// RPCClient stays unchanged, so that existing implementations and mocks of it still compile.
+//
+// The clients returned by NewClient(), NewClientWithOpts() and NewClientWithTransport() implement Client.
+// All methods have a variant with context (e.g. CallContext()) to cancel the request or set a deadline.
+// The methods without context use context.Background().
+type Client interface {
+	RPCClient
+
+	// CallContext is like Call() but the request is aborted when ctx is done.
+	CallContext(ctx context.Context, method string, params ...interface{}) (*RPCResponse, error)
+
+	// CallNamed is like Call() but sends the params by-name as json object.
+	//
+	// Many JSON-RPC servers require by-name params, e.g.:
+	//   CallNamed("getPerson", map[string]interface{}{"name": "Alex", "age": 35}) -> {"method": "getPerson", "params": {"name": "Alex", "age": 35}}
+	//   CallNamed("getinfo", nil) -> {"method": "getinfo"}
+	//   CallNamed("getinfo", map[string]interface{}{}) -> {"method": "getinfo", "params": {}}
+	CallNamed(method string, params map[string]interface{}) (*RPCResponse, error)
+
+	// CallNamedContext is like CallNamed() but the request is aborted when ctx is done.
+	CallNamedContext(ctx context.Context, method string, params map[string]interface{}) (*RPCResponse, error)
+
+	// CallRawContext is like CallRaw() but the request is aborted when ctx is done.
+	CallRawContext(ctx context.Context, request *RPCRequest) (*RPCResponse, error)
+
+	// CallForContext is like CallFor() but the request is aborted when ctx is done.
+	CallForContext(ctx context.Context, out interface{}, method string, params ...interface{}) error
+
+	// CallBatchContext is like CallBatch() but the request is aborted when ctx is done.
+	CallBatchContext(ctx context.Context, requests RPCRequests) (RPCResponses, error)
+
+	// CallBatchRawContext is like CallBatchRaw() but the request is aborted when ctx is done.
+	CallBatchRawContext(ctx context.Context, requests RPCRequests) (RPCResponses, error)
+
+	// CallBatchStream is like CallBatchContext() but calls onResponse for every response as soon as it is received,
+	// for servers that stream the responses of a batch (e.g. as newline-delimited JSON), so that a slow request
+	// does not delay the others. A JSON array is decoded incrementally as well.
+	//
+	// onResponse is called from the calling goroutine, CallBatchStream returns after the last response.
+	// An error is returned if the batch could not be sent or a response could not be decoded,
+	// onResponse may have been called for some responses before.
+	CallBatchStream(ctx context.Context, requests RPCRequests, onResponse func(*RPCResponse)) error
+
+	// Stats returns the rolling statistics of the calls by method and endpoint, sorted by method and endpoint
+	// (see CallStats). It returns nil unless RPCClientOpts.Stats is set.
+	Stats() []CallStats
+}
+
 // RPCRequest represents a JSON-RPC request object.
 //
 // Method: string containing the method to be invoked
 //
 // Params: can be nil. if not must be an json array or object
 //
-// ID: may always set to 1 for single requests. Should be unique for every request in one batch request.
+// ID: may always be set to 0 for single requests. Should be unique for every request in one batch request.
+// Can be a number, a string or null (see ID).
 //
 // JSONRPC: must always be set to "2.0" for JSON-RPC version 2.0
 //
@@ -153,8 +214,10 @@ type RPCClient interface {
 type RPCRequest struct {
 	Method  string      `json:"method"`
 	Params  interface{} `json:"params,omitempty"`
-	ID      int         `json:"id"`
+	ID      ID          `json:"id"`
 	JSONRPC string      `json:"jsonrpc"`
+	// Notification: the request is sent without id and the server does not respond to it (see NewNotification())
+	Notification bool `json:"-"`
 }
 
 // NewRequest returns a new RPCRequest that can be created using the same convenient parameter syntax as Call()
@@ -170,13 +233,31 @@ func NewRequest(method string, params ...interface{}) *RPCRequest {
 	return request
 }
 
+// NewNamedRequest returns a new RPCRequest that sends the params by-name as json object (see CallNamed())
+//
+// e.g. NewNamedRequest("myMethod", map[string]interface{}{"name": "Alex", "age": 35})
+func NewNamedRequest(method string, params map[string]interface{}) *RPCRequest {
+	request := &RPCRequest{
+		Method:  method,
+		JSONRPC: jsonrpcVersion,
+	}
+
+	// a nil map must not be sent as "params": null
+	if params != nil {
+		request.Params = params
+	}
+
+	return request
+}
+
 // RPCResponse represents a JSON-RPC response object.
 //
 // Result: holds the result of the rpc call if no error occurred, nil otherwise. can be nil even on success.
 //
 // Error: holds an RPCError object if an error occurred. must be nil on success.
 //
-// ID: may always be 0 for single requests. is unique for each request in a batch call (see CallBatch())
+// ID: may always be 0 for single requests. is unique for each request in a batch call (see CallBatch()).
+// null if the server could not detect the id of the request.
 //
 // JSONRPC: must always be set to "2.0" for JSON-RPC version 2.0
 //
@@ -185,7 +266,7 @@ type RPCResponse struct {
 	JSONRPC string      `json:"jsonrpc"`
 	Result  interface{} `json:"result,omitempty"`
 	Error   *RPCError   `json:"error,omitempty"`
-	ID      int         `json:"id"`
+	ID      ID          `json:"id"`
 }
 
 // RPCError represents a JSON-RPC error object if an RPC error occurred.
@@ -214,39 +295,347 @@ func (e *RPCError) Error() string {
 // and the body could not be parsed to a valid RPCResponse object that holds a RPCError.
 //
 // Otherwise a RPCResponse object is returned with a RPCError field that is not nil.
+//
+// RetryAfter is the delay requested by the server with the Retry-After header (e.g. for 429 or 503), 0 if there was none.
 type HTTPError struct {
-	Code int
-	err  error
+	Code       int
+	RetryAfter time.Duration
+	err        error
 }
 
 // Error function is provided to be used as error object.
 func (e *HTTPError) Error() string {
+	if e.err == nil {
+		return "status code: " + strconv.Itoa(e.Code)
+	}
 	return e.err.Error()
 }
 
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+// Temporary returns true if the status code indicates that the request may succeed when it is retried
+// (408, 425, 429, 502, 503, 504).
+func (e *HTTPError) Temporary() bool {
+	switch e.Code {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// TransportError represents an error that occurred while sending the request or receiving the response,
+// e.g. the connection was refused or reset, or a timeout occurred.
+//
+// The server may or may not have processed the request.
+type TransportError struct {
+	err error
+}
+
+// Error function is provided to be used as error object.
+func (e *TransportError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *TransportError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+// Temporary returns true if the request may succeed when it is retried.
+// This is the case for all transport errors except canceled requests, tls certificate errors and too large responses.
+func (e *TransportError) Temporary() bool {
+	var classified *classifiedError
+	if errors.As(e.err, &classified) {
+		return classified.temporary
+	}
+
+	var tooLarge *ResponseTooLargeError
+	if errors.As(e.err, &tooLarge) {
+		return false
+	}
+
+	if errors.Is(e.err, context.Canceled) {
+		return false
+	}
+
+	var (
+		unknownAuthorityError x509.UnknownAuthorityError
+		certificateError      x509.CertificateInvalidError
+		hostnameError         x509.HostnameError
+		recordHeaderError     tls.RecordHeaderError
+	)
+	if errors.As(e.err, &unknownAuthorityError) || errors.As(e.err, &certificateError) ||
+		errors.As(e.err, &hostnameError) || errors.As(e.err, &recordHeaderError) {
+		return false
+	}
+
+	return true
+}
+
 type rpcClient struct {
-	endpoint      string
-	httpClient    *http.Client
-	customHeaders map[string]string
+	transport            Transport
+	compatibilityMode    bool
+	strictMode           bool
+	redactor             Redactor
+	paramsInErrors       bool
+	idGenerator          IDGenerator
+	emptyParams          EmptyParams
+	disableValidation    bool
+	maxResponseSize      int64
+	maxBatchResponseSize int64
+	maxBatchSize         int
+	batchParallelism     int
+	batchTimeout         time.Duration
+	orderBatchResponses  bool
+	maxBatchRequests     int
+	maxBatchBytes        int
+	retry                *RetryPolicy
+	methodPolicies       []methodPolicy
+	idempotencyKey       *IdempotencyKeyOpts
+	correlationID        *CorrelationIDOpts
+	singleflight         *singleflight
+	autoBatcher          *autoBatcher
+	interceptors         []Interceptor
+	metrics              Metrics
+	stats                *callStats
 }
 
 // RPCClientOpts can be provided to NewClientWithOpts() to change configuration of RPCClient.
 //
+// EndpointVars: the values of {name} vars in the endpoint URL, e.g. {"network": "mainnet"} for
+// "https://{network}.example.com/rpc". Calls replace them with WithEndpointVars().
+//
 // HTTPClient: provide a custom http.Client (e.g. to set a proxy, or tls options)
 //
-// CustomHeaders: provide custom headers, e.g. to set BasicAuth
+// CustomHeaders: provide custom headers, e.g. to set BasicAuth. The map is copied when the client is created.
+//
+// HeaderSet: headers that can be set, deleted and cleared while the client is in use (see HeaderSet),
+// they replace CustomHeaders of the same name
+//
+// UserAgent: the User-Agent header of all requests (default "go-jsonrpc/<version>"), a User-Agent of CustomHeaders takes precedence
+//
+// CompatibilityMode: tolerate JSON-RPC 1.0 and other non-conformant servers (see below)
+//
+// StrictMode: reject responses with unknown fields or a jsonrpc field other than "2.0" (see below)
+//
+// Redactor: called before params are echoed into error messages, e.g. to hide private keys (see RedactFields()).
+// Error messages only contain the params if a Redactor is set or ParamsInErrors is enabled.
+//
+// ParamsInErrors: echo the params unredacted into error messages, only enable it if params never contain secrets
+//
+// IDGenerator: generates the ids of requests sent with Call(), CallFor() and CallBatch() (see IDGenerator).
+// By default single requests have the id 0 and batch requests have their position in the batch as id.
+//
+// EmptyParams: how requests without params are sent by Call(), CallNamed(), CallFor() and CallBatch() (see EmptyParams)
+//
+// DisableValidation: send requests without validating them first (see RPCRequest.Validate() and RPCRequests.Validate()),
+// e.g. to call reserved extension methods like "rpc.discover"
+//
+// MaxResponseSize: the maximum size of the response body of a call in bytes, no limit if <= 0.
+// Larger responses are rejected with a ResponseTooLargeError as soon as the limit is exceeded, before they are decoded.
+//
+// MaxBatchResponseSize: the maximum size of the response body of a batch call in bytes, no limit if <= 0.
+// The limit applies to each http request of a batch that is split by MaxBatchSize.
+// Clients of streams with FramingContentLength close the connection if a message exceeds the larger of both limits.
+//
+// MaxBatchSize: the maximum number of requests of a batch, no limit if <= 0. Larger batches are split into
+// multiple http requests and their responses are merged.
+//
+// BatchParallelism: the number of http requests of a batch split by MaxBatchSize that are sent concurrently
+// (default 1: one after the other). If one fails, the others are canceled. Streamed batches (CallBatchStream())
+// are always sent one after the other.
+//
+// BatchTimeout: the deadline of a batch call including all http requests of a split batch, no deadline if 0
+//
+// OrderBatchResponses: CallBatch() and CallBatchRaw() return the responses in the order of the requests, matched by id,
+// so that responses[i] is the response of requests[i] or nil if the server sent no response for it.
+// Responses that belong to no request (e.g. an error with null id) follow the responses of the requests.
+//
+// MaxBatchRequests: the maximum number of requests of a batch, no limit if <= 0. Larger batches are rejected with
+// an error that wraps ErrInvalidRequest before they are sent. Unlike MaxBatchSize, the batch is not split.
+//
+// MaxBatchBytes: the maximum size of the encoded requests of a batch http request in bytes, no limit if <= 0.
+// Larger batches are rejected with an error that wraps ErrInvalidRequest before they are sent.
+// The limit applies to each http request of a batch that is split by MaxBatchSize.
+//
+// HTTPProtocol: the HTTP version that is used if no HTTPClient is provided (see HTTPProtocol)
+//
+// TLSConfig: the TLS configuration for https endpoints if no HTTPClient is provided,
+// e.g. for client certificates (mTLS) or a private certificate authority (see LoadTLSConfig())
+// The TCP client (DialTCPWithOpts()) connects with TLS if it is set.
+//
+// Proxy: sends the requests through an http or SOCKS5 proxy (see ProxyOpts), only used if no HTTPClient is provided.
+// Without Proxy, the proxy of the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY is used.
+// Not supported by the TCP client (DialTCPWithOpts()).
+//
+// ConnectionPool: tunes the connections of the http transport, e.g. MaxIdleConnsPerHost for high request rates
+// (see ConnectionPoolOpts), only used if no HTTPClient is provided
+//
+// CookieJar: stores the cookies of the responses and sends them with the following requests, e.g. sticky session cookies
+// of load balancers so that all requests are sent to the same backend. Also used with HTTPClient, replacing its Jar.
+// The cookies are stored by host, so a jar can be shared by the endpoints of a client.
+//
+// CertificatePins: connections fail unless the certificate of the server matches a pin (see CertificatePinOpts),
+// only used if no HTTPClient is provided
+//
+// HTTPMethod: the HTTP method of the requests (default "POST"). With "GET" the request is sent in the url (see GETEncoding),
+// all other methods send it as body.
+//
+// GETEncoding: how requests are encoded in the url of GET requests (see GETEncoding)
+//
+// GETParam: the query parameter for GETEncodingPayload (default "request")
+//
+// Compression: compresses large request bodies with gzip (see CompressionOpts), disabled if nil
+//
+// ContentDecoders: decoders of response content encodings by name, e.g. "br" and "zstd" of the compress module.
+// The encodings and gzip are advertised in the Accept-Encoding header and the responses are decoded.
+// Without decoders only gzip is advertised and decoded by net/http.
+//
+// Retry: retry failed requests with exponential backoff (see RetryPolicy), requests are not retried if nil
+//
+// CircuitBreaker: fail requests fast while the endpoint is down (see CircuitBreakerOpts), disabled if nil
+//
+// Failover: how the endpoints of a client created with NewClientWithEndpoints() are used (see FailoverOpts)
+//
+// Concurrency: limits the number of concurrent requests (see ConcurrencyOpts), unlimited if nil
+//
+// MethodPolicies: retry policies and timeouts for specific methods (see MethodPolicy), the first matching policy is used
+//
+// IdempotencyKey: sends an idempotency key header that stays the same for all retries of a call (see IdempotencyKeyOpts), disabled if nil
+//
+// CorrelationID: sends a correlation id header with every call, e.g. X-Request-ID (see CorrelationIDOpts), disabled if nil
+//
+// Singleflight: coalesces concurrent identical calls into a single request (see SingleflightOpts), disabled if nil
+//
+// AutoBatch: coalesces concurrent calls into batch requests (see AutoBatchOpts), disabled if nil
+//
+// Interceptors: wrap every call, e.g. for tracing (see Interceptor), the first interceptor is the outermost
+//
+// Metrics: records metrics of every call (see Metrics)
+//
+// Hooks: callbacks before and after every call (see Hooks), called inside of the Interceptors
+//
+// Expvar: publishes the counters of the client as expvar.Map with this name, disabled if empty.
+// The map contains the number of calls, errors (failed calls), rpc_errors, in_flight calls, requests (including retries),
+// bytes_sent and bytes_received. Clients with the same name share the counters.
+//
+// AuditLog: appends a JSON line for every request and response to an io.Writer (see AuditLogOpts), disabled if nil
+//
+// Stats: keeps rolling statistics of the calls for Stats() (see StatsOpts), disabled if nil
+//
+// SlowCall: reports calls that take longer than a threshold (see SlowCallOpts), disabled if nil
+//
+// PprofLabels: labels the goroutines of calls with the pprof labels rpc_method and endpoint,
+// so that CPU and block profiles attribute time to methods and endpoints
+//
+// HTTPMiddleware: wrap the http.RoundTripper of the http client, e.g. to inspect or modify the http requests,
+// the first middleware is the outermost
+//
+// SignRequest: called with every http request and its body (nil for GET requests) after all headers are set,
+// right before it is sent, e.g. to add an HMAC signature header. The request is not sent if it returns an error.
+//
+// BearerToken: sends a bearer token from a provider in the Authorization header (see BearerTokenOpts), disabled if nil
+//
+// JWT: sends a new signed JWT as bearer token with every request (see JWTOpts), disabled if nil
+//
+// DigestAuth: answers HTTP digest authentication challenges with the credentials (see DigestAuthOpts), disabled if nil
+//
+// APIKey: sends an API key in a header, query parameter or path segment (see APIKeyOpts), disabled if nil
+//
+// Debug: dumps the raw http requests and responses to an io.Writer (see DebugOpts), disabled if nil
+//
+// Server: handles the requests and notifications that the server sends to clients of persistent connections
+// (websocket, IPC, TCP and streams), e.g. callbacks. Not used by http clients. Requests are dropped if nil.
+//
+// By default unknown fields and the jsonrpc field of responses are ignored. In strict mode unknown fields are rejected
+// and a jsonrpc field other than "2.0" is an error. In compatibility mode, which takes precedence over strict mode, unknown fields are ignored, a missing or "1.0"/"1.1" jsonrpc field is accepted,
+// and an error that is not a valid error object (e.g. a plain string) is translated to an RPCError.
+// The returned RPCResponse always has the normal 2.0 shape (JSONRPC is set to "2.0").
 type RPCClientOpts struct {
-	HTTPClient    *http.Client
-	CustomHeaders map[string]string
+	EndpointVars         map[string]string
+	HTTPClient           *http.Client
+	CustomHeaders        map[string]string
+	HeaderSet            *HeaderSet
+	UserAgent            string
+	CompatibilityMode    bool
+	StrictMode           bool
+	Redactor             Redactor
+	ParamsInErrors       bool
+	IDGenerator          IDGenerator
+	EmptyParams          EmptyParams
+	DisableValidation    bool
+	MaxResponseSize      int64
+	MaxBatchResponseSize int64
+	MaxBatchSize         int
+	BatchParallelism     int
+	BatchTimeout         time.Duration
+	OrderBatchResponses  bool
+	MaxBatchRequests     int
+	MaxBatchBytes        int
+	HTTPProtocol         HTTPProtocol
+	TLSConfig            *tls.Config
+	Proxy                *ProxyOpts
+	ConnectionPool       *ConnectionPoolOpts
+	CookieJar            http.CookieJar
+	CertificatePins      *CertificatePinOpts
+	HTTPMethod           string
+	GETEncoding          GETEncoding
+	GETParam             string
+	Compression          *CompressionOpts
+	ContentDecoders      map[string]ContentDecoder
+	Retry                *RetryPolicy
+	CircuitBreaker       *CircuitBreakerOpts
+	Failover             *FailoverOpts
+	Concurrency          *ConcurrencyOpts
+	MethodPolicies       []MethodPolicy
+	IdempotencyKey       *IdempotencyKeyOpts
+	CorrelationID        *CorrelationIDOpts
+	Singleflight         *SingleflightOpts
+	AutoBatch            *AutoBatchOpts
+	Interceptors         []Interceptor
+	Metrics              Metrics
+	Hooks                *Hooks
+	Expvar               string
+	AuditLog             *AuditLogOpts
+	Stats                *StatsOpts
+	SlowCall             *SlowCallOpts
+	PprofLabels          bool
+	HTTPMiddleware       []func(http.RoundTripper) http.RoundTripper
+	SignRequest          func(request *http.Request, body []byte) error
+	BearerToken          *BearerTokenOpts
+	JWT                  *JWTOpts
+	DigestAuth           *DigestAuthOpts
+	APIKey               *APIKeyOpts
+	Debug                *DebugOpts
+	Server               *Server
 }
 
+// EmptyParams defines how requests without params are sent.
+//
+// Some servers require "params": [] while others reject it.
+type EmptyParams int
+
+const (
+	// EmptyParamsOmit omits the params field (default), e.g. {"method": "getinfo"}
+	EmptyParamsOmit EmptyParams = iota
+	// EmptyParamsArray sends an empty array, e.g. {"method": "getinfo", "params": []}
+	EmptyParamsArray
+	// EmptyParamsObject sends an empty object, e.g. {"method": "getinfo", "params": {}}
+	EmptyParamsObject
+)
+
 // RPCResponses is of type []*RPCResponse.
 // This type is used to provide helper functions on the result list
 type RPCResponses []*RPCResponse
 
 // AsMap returns the responses as map with response id as key.
-func (res RPCResponses) AsMap() map[int]*RPCResponse {
-	resMap := make(map[int]*RPCResponse, 0)
+func (res RPCResponses) AsMap() map[ID]*RPCResponse {
+	resMap := make(map[ID]*RPCResponse, 0)
 	for _, r := range res {
 		resMap[r.ID] = r
 	}
@@ -255,7 +644,7 @@ func (res RPCResponses) AsMap() map[int]*RPCResponse {
 }
 
 // GetByID returns the response object of the given id, nil if it does not exist.
-func (res RPCResponses) GetByID(id int) *RPCResponse {
+func (res RPCResponses) GetByID(id ID) *RPCResponse {
 	for _, r := range res {
 		if r.ID == id {
 			return r
@@ -279,43 +668,103 @@ func (res RPCResponses) HasError() bool {
 // This type is used to provide helper functions on the request list
 type RPCRequests []*RPCRequest
 
-// NewClient returns a new RPCClient instance with default configuration.
+// NewClient returns a new Client instance with default configuration.
 //
 // endpoint: JSON-RPC service URL to which JSON-RPC requests are sent.
-func NewClient(endpoint string) RPCClient {
+func NewClient(endpoint string) Client {
 	return NewClientWithOpts(endpoint, nil)
 }
 
-// NewClientWithOpts returns a new RPCClient instance with custom configuration.
+// NewClientWithOpts returns a new Client instance with custom configuration.
 //
 // endpoint: JSON-RPC service URL to which JSON-RPC requests are sent.
 //
 // opts: RPCClientOpts provide custom configuration
-func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
+func NewClientWithOpts(endpoint string, opts *RPCClientOpts) Client {
+	return NewClientWithTransport(NewHTTPTransport(endpoint, opts), opts)
+}
+
+// NewClientWithTransport returns a new Client instance that sends its requests using a custom Transport.
+//
+// transport: sends the encoded requests, e.g. NewHTTPTransport()
+//
+// opts: RPCClientOpts provide custom configuration, HTTPClient, CustomHeaders and HTTPProtocol are not used
+func NewClientWithTransport(transport Transport, opts *RPCClientOpts) Client {
+	return newRPCClient(transport, opts)
+}
+
+// newRPCClient returns a new rpcClient that sends its requests using the given transport.
+// Only the transport independent options of opts are used.
+func newRPCClient(transport Transport, opts *RPCClientOpts) *rpcClient {
 	rpcClient := &rpcClient{
-		endpoint:      endpoint,
-		httpClient:    &http.Client{},
-		customHeaders: make(map[string]string),
+		transport: transport,
 	}
 
 	if opts == nil {
 		return rpcClient
 	}
 
-	if opts.HTTPClient != nil {
-		rpcClient.httpClient = opts.HTTPClient
+	rpcClient.compatibilityMode = opts.CompatibilityMode
+	rpcClient.strictMode = opts.StrictMode
+	rpcClient.redactor = opts.Redactor
+	rpcClient.paramsInErrors = opts.ParamsInErrors
+	rpcClient.idGenerator = opts.IDGenerator
+	rpcClient.emptyParams = opts.EmptyParams
+	rpcClient.disableValidation = opts.DisableValidation
+	rpcClient.maxResponseSize = opts.MaxResponseSize
+	rpcClient.maxBatchResponseSize = opts.MaxBatchResponseSize
+	rpcClient.maxBatchSize = opts.MaxBatchSize
+	rpcClient.batchParallelism = opts.BatchParallelism
+	rpcClient.batchTimeout = opts.BatchTimeout
+	rpcClient.orderBatchResponses = opts.OrderBatchResponses
+	rpcClient.maxBatchRequests = opts.MaxBatchRequests
+	rpcClient.maxBatchBytes = opts.MaxBatchBytes
+	if opts.Retry != nil {
+		rpcClient.retry = opts.Retry.withDefaults()
 	}
-
-	if opts.CustomHeaders != nil {
-		for k, v := range opts.CustomHeaders {
-			rpcClient.customHeaders[k] = v
-		}
+	rpcClient.methodPolicies = newMethodPolicies(opts.MethodPolicies, rpcClient.retry)
+	rpcClient.idempotencyKey = opts.IdempotencyKey
+	rpcClient.correlationID = opts.CorrelationID
+	rpcClient.singleflight = newSingleflight(opts.Singleflight)
+	rpcClient.autoBatcher = newAutoBatcher(rpcClient, opts.AutoBatch)
+	rpcClient.interceptors = opts.Interceptors
+	if opts.Hooks != nil {
+		rpcClient.interceptors = append(append([]Interceptor{}, rpcClient.interceptors...), opts.Hooks.interceptor())
+	}
+	if opts.AuditLog != nil {
+		rpcClient.interceptors = append(append([]Interceptor{}, rpcClient.interceptors...), newAuditLog(*opts.AuditLog, opts.Redactor).interceptor())
+	}
+	if opts.Stats != nil {
+		rpcClient.stats = newCallStats(*opts.Stats)
+		rpcClient.interceptors = append(append([]Interceptor{}, rpcClient.interceptors...), rpcClient.stats.interceptor())
+	}
+	if opts.SlowCall != nil {
+		rpcClient.interceptors = append(append([]Interceptor{}, rpcClient.interceptors...), rpcClient.slowCallInterceptor(*opts.SlowCall))
+	}
+	if opts.PprofLabels {
+		rpcClient.interceptors = append([]Interceptor{pprofInterceptor}, rpcClient.interceptors...)
+	}
+	if opts.Expvar != "" {
+		counters := newExpvarCounters(opts.Expvar)
+		rpcClient.interceptors = append([]Interceptor{counters.interceptor()}, rpcClient.interceptors...)
+		rpcClient.transport = &countingTransport{transport: rpcClient.transport, counters: counters}
+	}
+	rpcClient.metrics = opts.Metrics
+	if opts.CircuitBreaker != nil {
+		rpcClient.transport = newCircuitBreaker(rpcClient.transport, *opts.CircuitBreaker)
+	}
+	if opts.Concurrency != nil && opts.Concurrency.MaxInFlight > 0 {
+		rpcClient.transport = newLimiter(rpcClient.transport, opts.Concurrency.MaxInFlight, *opts.Concurrency)
 	}
 
 	return rpcClient
 }
 
 func (client *rpcClient) Call(method string, params ...interface{}) (*RPCResponse, error) {
+	return client.CallContext(context.Background(), method, params...)
+}
+
+func (client *rpcClient) CallContext(ctx context.Context, method string, params ...interface{}) (*RPCResponse, error) {
 
 	request := &RPCRequest{
 		Method:  method,
@@ -323,16 +772,67 @@ func (client *rpcClient) Call(method string, params ...interface{}) (*RPCRespons
 		JSONRPC: jsonrpcVersion,
 	}
 
-	return client.doCall(request)
+	return client.callWithID(ctx, request)
+}
+
+func (client *rpcClient) CallNamed(method string, params map[string]interface{}) (*RPCResponse, error) {
+	return client.CallNamedContext(context.Background(), method, params)
+}
+
+func (client *rpcClient) CallNamedContext(ctx context.Context, method string, params map[string]interface{}) (*RPCResponse, error) {
+
+	return client.callWithID(ctx, NewNamedRequest(method, params))
+}
+
+// callWithID sends a request with an id of the client, identical calls are coalesced if singleflight is enabled
+// and calls are sent as batches if auto batching is enabled.
+func (client *rpcClient) callWithID(ctx context.Context, request *RPCRequest) (*RPCResponse, error) {
+	client.setEmptyParams(request)
+
+	call := client.generateIDAndCall
+	if client.autoBatcher != nil && client.autoBatcher.batches(ctx, request) {
+		call = client.autoBatcher.call
+	}
+
+	if client.singleflight != nil {
+		if key := client.singleflight.key(ctx, request); key != "" {
+			return client.singleflight.do(ctx, key, func() (*RPCResponse, error) {
+				return call(ctx, request)
+			})
+		}
+	}
+
+	return call(ctx, request)
+}
+
+// generateIDAndCall sets the id of the request using the id generator of the client, if there is one, and sends it.
+func (client *rpcClient) generateIDAndCall(ctx context.Context, request *RPCRequest) (*RPCResponse, error) {
+	if client.idGenerator != nil {
+		id, err := client.idGenerator.NextID()
+		if err != nil {
+			return nil, fmt.Errorf("rpc call %v: could not generate id: %w", client.describeCall(request), err)
+		}
+		request.ID = id
+	}
+
+	return client.doCall(ctx, request)
 }
 
 func (client *rpcClient) CallRaw(request *RPCRequest) (*RPCResponse, error) {
+	return client.CallRawContext(context.Background(), request)
+}
+
+func (client *rpcClient) CallRawContext(ctx context.Context, request *RPCRequest) (*RPCResponse, error) {
 
-	return client.doCall(request)
+	return client.doCall(ctx, request)
 }
 
 func (client *rpcClient) CallFor(out interface{}, method string, params ...interface{}) error {
-	rpcResponse, err := client.Call(method, params...)
+	return client.CallForContext(context.Background(), out, method, params...)
+}
+
+func (client *rpcClient) CallForContext(ctx context.Context, out interface{}, method string, params ...interface{}) error {
+	rpcResponse, err := client.CallContext(ctx, method, params...)
 	if err != nil {
 		return err
 	}
@@ -345,161 +845,467 @@ func (client *rpcClient) CallFor(out interface{}, method string, params ...inter
 }
 
 func (client *rpcClient) CallBatch(requests RPCRequests) (RPCResponses, error) {
+	return client.CallBatchContext(context.Background(), requests)
+}
+
+func (client *rpcClient) CallBatchContext(ctx context.Context, requests RPCRequests) (RPCResponses, error) {
+	if err := client.prepareBatch(requests); err != nil {
+		return nil, err
+	}
+
+	return client.doOrderedBatchCall(ctx, requests)
+}
+
+// prepareBatch sets the version, ids and empty params of the requests of a batch.
+func (client *rpcClient) prepareBatch(requests RPCRequests) error {
 	if len(requests) == 0 {
-		return nil, errors.New("empty request list")
+		return errors.New("empty request list")
 	}
 
 	for i, req := range requests {
-		req.ID = i
+		req.ID = NumberID(int64(i))
 		req.JSONRPC = jsonrpcVersion
+		client.setEmptyParams(req)
+
+		if client.idGenerator != nil && !req.Notification {
+			id, err := client.idGenerator.NextID()
+			if err != nil {
+				return fmt.Errorf("rpc batch call: could not generate id: %w", err)
+			}
+			req.ID = id
+		}
 	}
 
-	return client.doBatchCall(requests)
+	return nil
 }
 
 func (client *rpcClient) CallBatchRaw(requests RPCRequests) (RPCResponses, error) {
+	return client.CallBatchRawContext(context.Background(), requests)
+}
+
+func (client *rpcClient) CallBatchRawContext(ctx context.Context, requests RPCRequests) (RPCResponses, error) {
 	if len(requests) == 0 {
 		return nil, errors.New("empty request list")
 	}
 
-	return client.doBatchCall(requests)
+	return client.doOrderedBatchCall(ctx, requests)
 }
 
-func (client *rpcClient) newRequest(req interface{}) (*http.Request, error) {
+// doOrderedBatchCall sends a batch and returns the responses in the order of the requests if OrderBatchResponses is set.
+func (client *rpcClient) doOrderedBatchCall(ctx context.Context, requests RPCRequests) (RPCResponses, error) {
+	responses, err := client.doBatchCall(ctx, requests)
+	if err != nil || !client.orderBatchResponses {
+		return responses, err
+	}
 
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	return orderResponses(requests, responses), nil
+}
+
+// setEmptyParams sets the params of a request without params according to the empty params option of the client.
+func (client *rpcClient) setEmptyParams(request *RPCRequest) {
+	if request.Params != nil {
+		return
 	}
 
-	request, err := http.NewRequest("POST", client.endpoint, bytes.NewReader(body))
-	if err != nil {
-		return nil, err
+	switch client.emptyParams {
+	case EmptyParamsArray:
+		request.Params = []interface{}{}
+	case EmptyParamsObject:
+		request.Params = map[string]interface{}{}
 	}
+}
 
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("Accept", "application/json")
+func (client *rpcClient) doCall(ctx context.Context, RPCRequest *RPCRequest) (*RPCResponse, error) {
+	ctx = client.withCorrelationID(ctx)
+	if !client.observed(ctx) {
+		return client.sendCall(ctx, RPCRequest)
+	}
 
-	// set default headers first, so that even content type and accept can be overwritten
-	for k, v := range client.customHeaders {
-		request.Header.Set(k, v)
+	var rpcResponse *RPCResponse
+	call := &CallInfo{Requests: RPCRequests{RPCRequest}}
+	err := client.observe(ctx, call, func(ctx context.Context) error {
+		var err error
+		rpcResponse, err = client.sendCall(ctx, RPCRequest)
+		if rpcResponse != nil {
+			call.Responses = []*RPCResponse{rpcResponse}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return request, nil
+	return rpcResponse, nil
 }
 
-func (client *rpcClient) doCall(RPCRequest *RPCRequest) (*RPCResponse, error) {
+// sendCall sends a single request and decodes its response.
+func (client *rpcClient) sendCall(ctx context.Context, RPCRequest *RPCRequest) (*RPCResponse, error) {
+	// the description is only built for errors, it marshals the params
+	callName := func() string {
+		return client.describeCall(RPCRequest) + describeCorrelationID(ctx)
+	}
 
-	httpRequest, err := client.newRequest(RPCRequest)
-	if err != nil {
-		return nil, fmt.Errorf("rpc call %v(): %v", RPCRequest.Method, err.Error())
+	if !client.disableValidation {
+		if err := RPCRequest.Validate(); err != nil {
+			return nil, fmt.Errorf("rpc call %v: %w", callName(), err)
+		}
 	}
-	httpResponse, err := client.httpClient.Do(httpRequest)
+
+	body, err := json.Marshal(RPCRequest)
 	if err != nil {
-		return nil, fmt.Errorf("rpc call %v(): %v", RPCRequest.Method, err.Error())
+		return nil, fmt.Errorf("rpc call %v: %v", callName(), err.Error())
+	}
+	ctx, cancel, retry := client.policy(ctx, RPCRequest.Method)
+	defer cancel()
+
+	response, err := client.send(ctx, body, retry, client.maxResponseSize)
+	var httpErr *HTTPError
+	if err != nil && !errors.As(err, &httpErr) {
+		return nil, &TransportError{err: fmt.Errorf("rpc call %v: %w", callName(), err)}
 	}
-	defer httpResponse.Body.Close()
 
 	var rpcResponse *RPCResponse
-	decoder := json.NewDecoder(httpResponse.Body)
-	// decoder.DisallowUnknownFields()
-	decoder.UseNumber()
-	err = decoder.Decode(&rpcResponse)
+	err = client.decodeResponse(bytes.NewReader(response), &rpcResponse)
 
 	// parsing error
 	if err != nil {
-		// if we have some http error, return it
-		if httpResponse.StatusCode >= 400 {
-			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc call %v() status code: %v. could not decode body to rpc response: %v", RPCRequest.Method, httpResponse.StatusCode, err.Error()),
-			}
-		}
-		return nil, fmt.Errorf("rpc call %v() status code: %v. could not decode body to rpc response: %v", RPCRequest.Method, httpResponse.StatusCode, err.Error())
+		return nil, responseError("rpc call "+callName(), httpErr, fmt.Errorf("could not decode body to rpc response: %w", err))
 	}
 
 	// response body empty
 	if rpcResponse == nil {
-		// if we have some http error, return it
-		if httpResponse.StatusCode >= 400 {
-			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc call %v() status code: %v. rpc response missing", RPCRequest.Method, httpResponse.StatusCode),
-			}
-		}
-		return nil, fmt.Errorf("rpc call %v() status code: %v. rpc response missing", RPCRequest.Method, httpResponse.StatusCode)
+		return nil, responseError("rpc call "+callName(), httpErr, ErrMissingResponse)
 	}
 
 	return rpcResponse, nil
 }
 
-func (client *rpcClient) doBatchCall(rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
-	httpRequest, err := client.newRequest(rpcRequest)
+func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
+	ctx = client.withCorrelationID(ctx)
+	if !client.observed(ctx) {
+		return client.sendBatchCall(ctx, rpcRequest)
+	}
+
+	var rpcResponses []*RPCResponse
+	call := &CallInfo{Requests: rpcRequest, Batch: true}
+	err := client.observe(ctx, call, func(ctx context.Context) error {
+		var err error
+		rpcResponses, err = client.sendBatchCall(ctx, rpcRequest)
+		call.Responses = rpcResponses
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("rpc batch call: %v", err.Error())
+		return nil, err
 	}
-	httpResponse, err := client.httpClient.Do(httpRequest)
+
+	return rpcResponses, nil
+}
+
+// sendBatchCall sends a batch request, split into chunks of at most MaxBatchSize requests, and decodes its responses.
+func (client *rpcClient) sendBatchCall(ctx context.Context, rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
+	if err := client.validateBatch(rpcRequest); err != nil {
+		return nil, err
+	}
+
+	if client.batchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.batchTimeout)
+		defer cancel()
+	}
+
+	chunks := batchChunks(rpcRequest, client.maxBatchSize)
+	progress := newBatchProgress(ctx, len(rpcRequest), len(chunks))
+	if len(chunks) == 1 {
+		responses, err := client.sendBatchChunk(ctx, rpcRequest)
+		if err != nil {
+			return nil, err
+		}
+		if err := progress.chunkDone(len(rpcRequest), responses); err != nil {
+			return nil, err
+		}
+		return responses, nil
+	}
+
+	return client.sendBatchChunks(ctx, chunks, progress)
+}
+
+// sendBatchChunk sends the requests of a batch as a single http request and decodes its responses.
+func (client *rpcClient) sendBatchChunk(ctx context.Context, rpcRequest []*RPCRequest) ([]*RPCResponse, error) {
+	prefix := "rpc batch call" + describeCorrelationID(ctx)
+	body, err := client.encodeBatch(rpcRequest)
 	if err != nil {
-		return nil, fmt.Errorf("rpc batch call: %v", err.Error())
+		return nil, err
+	}
+	ctx, cancel, retry := client.policy(ctx, batchMethods(rpcRequest)...)
+	defer cancel()
+
+	response, err := client.send(ctx, body, retry, client.maxBatchResponseSize)
+	var httpErr *HTTPError
+	if err != nil && !errors.As(err, &httpErr) {
+		return nil, &TransportError{err: fmt.Errorf("%v: %w", prefix, err)}
+	}
+	if !expectsResponse(rpcRequest) {
+		// the server does not respond to notifications
+		if httpErr != nil {
+			return nil, httpErr
+		}
+		return RPCResponses{}, nil
 	}
-	defer httpResponse.Body.Close()
 
 	var rpcResponse RPCResponses
-	decoder := json.NewDecoder(httpResponse.Body)
-	// decoder.DisallowUnknownFields()
-	decoder.UseNumber()
-	err = decoder.Decode(&rpcResponse)
+	err = client.decodeBatchResponse(bytes.NewReader(response), &rpcResponse)
 
 	// parsing error
 	if err != nil {
-		// if we have some http error, return it
-		if httpResponse.StatusCode >= 400 {
-			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc batch call status code: %v. could not decode body to rpc response: %v", httpResponse.StatusCode, err.Error()),
-			}
-		}
-		return nil, fmt.Errorf("rpc batch call status code: %v. could not decode body to rpc response: %v", httpResponse.StatusCode, err.Error())
+		return nil, responseError(prefix, httpErr, fmt.Errorf("could not decode body to rpc response: %w", err))
 	}
 
 	// response body empty
 	if rpcResponse == nil || len(rpcResponse) == 0 {
-		// if we have some http error, return it
-		if httpResponse.StatusCode >= 400 {
-			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc batch call status code: %v. rpc response missing", httpResponse.StatusCode),
+		return nil, responseError(prefix, httpErr, ErrMissingResponse)
+	}
+
+	return rpcResponse, nil
+}
+
+// send sends an encoded request with the transport of the client and retries it according to the retry policy,
+// which may be nil. Response bodies larger than limit are rejected (no limit if limit <= 0).
+func (client *rpcClient) send(ctx context.Context, body []byte, retry *RetryPolicy, limit int64) ([]byte, error) {
+	if retry == nil {
+		recordAttempt(ctx)
+		return client.receive(ctx, body, limit)
+	}
+
+	var response []byte
+	err := retry.do(ctx, func() error {
+		recordAttempt(ctx)
+		var err error
+		response, err = client.receive(ctx, body, limit)
+		return err
+	})
+
+	return response, err
+}
+
+// validateBatch checks the number of requests of a batch and validates them, unless validation is disabled
+// (see RPCRequests.Validate()).
+func (client *rpcClient) validateBatch(rpcRequest []*RPCRequest) error {
+	if client.maxBatchRequests > 0 && len(rpcRequest) > client.maxBatchRequests {
+		return fmt.Errorf("rpc batch call: %w: %v requests exceed the limit of %v requests", ErrInvalidRequest, len(rpcRequest), client.maxBatchRequests)
+	}
+
+	if client.disableValidation {
+		return nil
+	}
+	if err := RPCRequests(rpcRequest).Validate(); err != nil {
+		return fmt.Errorf("rpc batch call: %w", err)
+	}
+
+	return nil
+}
+
+// encodeBatch encodes the requests of a batch and checks the size of the encoded batch.
+func (client *rpcClient) encodeBatch(rpcRequest []*RPCRequest) ([]byte, error) {
+	body, err := json.Marshal(rpcRequest)
+	if err != nil {
+		return nil, fmt.Errorf("rpc batch call: %v", err.Error())
+	}
+
+	if client.maxBatchBytes > 0 && len(body) > client.maxBatchBytes {
+		return nil, fmt.Errorf("rpc batch call: %w: %v bytes exceed the limit of %v bytes", ErrInvalidRequest, len(body), client.maxBatchBytes)
+	}
+
+	return body, nil
+}
+
+// decodeResponse decodes a single rpc response from r.
+// out stays nil if the body holds a json null.
+func (client *rpcClient) decodeResponse(r io.Reader, out **RPCResponse) error {
+	if !client.compatibilityMode {
+		decoder := json.NewDecoder(r)
+		if client.strictMode {
+			decoder.DisallowUnknownFields()
+		}
+		decoder.UseNumber()
+		if err := decoder.Decode(out); err != nil {
+			return err
+		}
+		if *out != nil && client.strictMode {
+			return (*out).checkVersion()
+		}
+		return nil
+	}
+
+	var compat *compatResponse
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&compat); err != nil {
+		return err
+	}
+	if compat == nil {
+		return nil
+	}
+
+	res, err := compat.toRPCResponse()
+	if err != nil {
+		return err
+	}
+	*out = res
+
+	return nil
+}
+
+// decodeBatchResponse decodes a list of rpc responses from r.
+func (client *rpcClient) decodeBatchResponse(r io.Reader, out *RPCResponses) error {
+	if !client.compatibilityMode {
+		decoder := json.NewDecoder(r)
+		if client.strictMode {
+			decoder.DisallowUnknownFields()
+		}
+		decoder.UseNumber()
+		if err := decoder.Decode(out); err != nil {
+			return err
+		}
+		if !client.strictMode {
+			return nil
+		}
+		for _, res := range *out {
+			if res == nil {
+				continue
+			}
+			if err := res.checkVersion(); err != nil {
+				return err
 			}
 		}
-		return nil, fmt.Errorf("rpc batch call status code: %v. rpc response missing", httpResponse.StatusCode)
+		return nil
 	}
 
-	return rpcResponse, nil
+	var compat []*compatResponse
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&compat); err != nil {
+		return err
+	}
+	if compat == nil {
+		return nil
+	}
+
+	responses := make(RPCResponses, 0, len(compat))
+	for _, c := range compat {
+		if c == nil {
+			continue
+		}
+		res, err := c.toRPCResponse()
+		if err != nil {
+			return err
+		}
+		responses = append(responses, res)
+	}
+	*out = responses
+
+	return nil
+}
+
+// checkVersion returns an error if the response explicitly states a version other than 2.0.
+// A missing jsonrpc field is tolerated.
+func (RPCResponse *RPCResponse) checkVersion() error {
+	if RPCResponse.JSONRPC != "" && RPCResponse.JSONRPC != jsonrpcVersion {
+		return fmt.Errorf("unsupported jsonrpc version %q", RPCResponse.JSONRPC)
+	}
+
+	return nil
+}
+
+// compatResponse is used to decode responses of JSON-RPC 1.0 and other non-conformant servers.
+type compatResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result"`
+	Error   json.RawMessage `json:"error"`
+	ID      ID              `json:"id"`
+}
+
+// compatErrorCode is used for errors of non-conformant servers that do not provide an error code.
+const compatErrorCode = -32000
+
+func (c *compatResponse) toRPCResponse() (*RPCResponse, error) {
+	switch c.JSONRPC {
+	case "", "1.0", "1.1", jsonrpcVersion:
+	default:
+		return nil, fmt.Errorf("unsupported jsonrpc version %q", c.JSONRPC)
+	}
+
+	res := &RPCResponse{
+		JSONRPC: jsonrpcVersion,
+		Result:  c.Result,
+		ID:      c.ID,
+	}
+
+	rpcError, err := compatError(c.Error)
+	if err != nil {
+		return nil, err
+	}
+	res.Error = rpcError
+
+	return res, nil
+}
+
+// compatError translates the error member of a non-conformant response to an RPCError.
+func compatError(raw json.RawMessage) (*RPCError, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	switch raw[0] {
+	case '{':
+		var rpcError RPCError
+		if err := json.Unmarshal(raw, &rpcError); err == nil {
+			return &rpcError, nil
+		}
+	case '"':
+		var message string
+		if err := json.Unmarshal(raw, &message); err != nil {
+			return nil, err
+		}
+		return &RPCError{Code: compatErrorCode, Message: message}, nil
+	}
+
+	var data interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return &RPCError{Code: compatErrorCode, Message: "server error", Data: data}, nil
 }
 
 // Params is a helper function that uses the same parameter syntax as Call().
 // But you should consider to always use NewRequest() instead.
 //
 // e.g. to manually create an RPCRequest object:
-// request := &RPCRequest{
-//   Method: "myMethod",
-//   Params: Params("Alex", 35, true),
-// }
+//
+//	request := &RPCRequest{
+//	  Method: "myMethod",
+//	  Params: Params("Alex", 35, true),
+//	}
 //
 // same with new request:
 // request := NewRequest("myMethod", "Alex", 35, true)
 //
 // If you know what you are doing you can omit the Params() call but potentially create incorrect rpc requests:
-// request := &RPCRequest{
-//   Method: "myMethod",
-//   Params: 2, <-- invalid since a single primitive value must be wrapped in an array --> no magic without Params()
-// }
+//
+//	request := &RPCRequest{
+//	  Method: "myMethod",
+//	  Params: 2, <-- invalid since a single primitive value must be wrapped in an array --> no magic without Params()
+//	}
 //
 // correct:
-// request := &RPCRequest{
-//   Method: "myMethod",
-//   Params: []int{2}, <-- valid since a single primitive value must be wrapped in an array
-// }
+//
+//	request := &RPCRequest{
+//	  Method: "myMethod",
+//	  Params: []int{2}, <-- valid since a single primitive value must be wrapped in an array
+//	}
 func Params(params ...interface{}) interface{} {
 	var finalParams interface{}
 
@@ -508,7 +1314,10 @@ func Params(params ...interface{}) interface{} {
 		switch len(params) {
 		case 0: // no parameters were provided, do nothing so finalParam is nil and will be omitted
 		case 1: // one param was provided, use it directly as is, or wrap primitive types in array
-			if params[0] != nil {
+			if raw, ok := params[0].(rawParams); ok {
+				// ParamsRaw() was used, so no magic at all
+				finalParams = raw.params
+			} else if params[0] != nil {
 				var typeOf reflect.Type
 
 				// traverse until nil or not a pointer type
@@ -544,6 +1353,30 @@ func Params(params ...interface{}) interface{} {
 	return finalParams
 }
 
+// ParamsRaw marks a single value to be sent as params exactly as provided, without wrapping it in an array.
+//
+// Call() and Params() decide by the type of a single value if it must be wrapped in an array.
+// Use ParamsRaw() if that decision is not what you want, e.g. for types with custom json encoding
+// or for params that were already encoded:
+//
+//	Call("myMethod", ParamsRaw(json.RawMessage(`{"name":"Alex"}`))) -> {"method": "myMethod", "params": {"name":"Alex"}}
+//	Call("myMethod", ParamsRaw(nil)) -> {"method": "myMethod"}
+//
+// Note that the spec only allows a json array or object as params.
+func ParamsRaw(params interface{}) interface{} {
+	return rawParams{params: params}
+}
+
+// rawParams is returned by ParamsRaw().
+type rawParams struct {
+	params interface{}
+}
+
+// MarshalJSON encodes the wrapped params, so rawParams can also be used in multi params or as RPCRequest.Params.
+func (r rawParams) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.params)
+}
+
 // GetInt converts the rpc response to an int64 and returns it.
 //
 // If result was not an integer an error is returned.
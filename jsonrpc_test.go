@@ -1,6 +1,8 @@
 package jsonrpc
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -40,6 +42,31 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// rpcClientMock implements only the original methods of RPCClient, like the mocks of users of the package.
+type rpcClientMock struct{}
+
+func (rpcClientMock) Call(method string, params ...interface{}) (*RPCResponse, error) {
+	return nil, nil
+}
+func (rpcClientMock) CallRaw(request *RPCRequest) (*RPCResponse, error) { return nil, nil }
+func (rpcClientMock) CallFor(out interface{}, method string, params ...interface{}) error {
+	return nil
+}
+func (rpcClientMock) CallBatch(requests RPCRequests) (RPCResponses, error)    { return nil, nil }
+func (rpcClientMock) CallBatchRaw(requests RPCRequests) (RPCResponses, error) { return nil, nil }
+
+func TestRPCClientInterface(t *testing.T) {
+	RegisterTestingT(t)
+
+	var mock RPCClient = rpcClientMock{}
+	Expect(mock).NotTo(BeNil())
+
+	// the clients of the package still are RPCClients
+	var rpcClient RPCClient = NewClient("http://localhost")
+	_, ok := rpcClient.(Client)
+	Expect(ok).To(BeTrue())
+}
+
 func TestSimpleRpcCallHeaderCorrect(t *testing.T) {
 	RegisterTestingT(t)
 
@@ -171,6 +198,75 @@ func TestRpcClient_Call(t *testing.T) {
 	Expect((<-requestChan).body).To(Equal(`{"method":"nestedStruct","params":{"name":"Mars","properties":{"distance":54600000,"color":"red"}},"id":0,"jsonrpc":"2.0"}`))
 }
 
+func TestRpcClient_CallParamsRaw(t *testing.T) {
+	RegisterTestingT(t)
+	rpcClient := NewClient(httpServer.URL)
+
+	rpcClient.Call("rawObject", ParamsRaw(json.RawMessage(`{"name":"Alex"}`)))
+	Expect((<-requestChan).body).To(Equal(`{"method":"rawObject","params":{"name":"Alex"},"id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.Call("rawArray", ParamsRaw([]int{1, 2, 3}))
+	Expect((<-requestChan).body).To(Equal(`{"method":"rawArray","params":[1,2,3],"id":0,"jsonrpc":"2.0"}`))
+
+	// structs are sent unchanged as well
+	rpcClient.Call("rawStruct", ParamsRaw(&Person{Name: "Alex"}))
+	Expect((<-requestChan).body).To(Equal(`{"method":"rawStruct","params":{"name":"Alex","age":0,"country":""},"id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.Call("rawNil", ParamsRaw(nil))
+	Expect((<-requestChan).body).To(Equal(`{"method":"rawNil","id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.CallRaw(&RPCRequest{Method: "rawRequest", Params: ParamsRaw([]string{"a"}), JSONRPC: "2.0"})
+	Expect((<-requestChan).body).To(Equal(`{"method":"rawRequest","params":["a"],"id":0,"jsonrpc":"2.0"}`))
+}
+
+func TestRpcClient_EmptyParams(t *testing.T) {
+	RegisterTestingT(t)
+
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		EmptyParams: EmptyParamsArray,
+	})
+
+	rpcClient.Call("noParams")
+	Expect((<-requestChan).body).To(Equal(`{"method":"noParams","params":[],"id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.Call("withParams", 1)
+	Expect((<-requestChan).body).To(Equal(`{"method":"withParams","params":[1],"id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.CallBatch(RPCRequests{NewRequest("noParams")})
+	Expect((<-requestChan).body).To(Equal(`[{"method":"noParams","params":[],"id":0,"jsonrpc":"2.0"}]`))
+
+	// raw requests are sent as they are
+	rpcClient.CallRaw(&RPCRequest{Method: "noParams", JSONRPC: "2.0"})
+	Expect((<-requestChan).body).To(Equal(`{"method":"noParams","id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		EmptyParams: EmptyParamsObject,
+	})
+
+	rpcClient.CallNamed("noParams", nil)
+	Expect((<-requestChan).body).To(Equal(`{"method":"noParams","params":{},"id":0,"jsonrpc":"2.0"}`))
+}
+
+func TestRpcClient_CallNamed(t *testing.T) {
+	RegisterTestingT(t)
+	rpcClient := NewClient(httpServer.URL)
+
+	rpcClient.CallNamed("namedParams", map[string]interface{}{"name": "Alex", "age": 35})
+	Expect((<-requestChan).body).To(Equal(`{"method":"namedParams","params":{"age":35,"name":"Alex"},"id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.CallNamed("nilParams", nil)
+	Expect((<-requestChan).body).To(Equal(`{"method":"nilParams","id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.CallNamed("emptyParams", map[string]interface{}{})
+	Expect((<-requestChan).body).To(Equal(`{"method":"emptyParams","params":{},"id":0,"jsonrpc":"2.0"}`))
+
+	rpcClient.CallBatch(RPCRequests{
+		NewNamedRequest("namedParams", map[string]interface{}{"name": "Alex"}),
+		NewNamedRequest("nilParams", nil),
+	})
+	Expect((<-requestChan).body).To(Equal(`[{"method":"namedParams","params":{"name":"Alex"},"id":0,"jsonrpc":"2.0"},{"method":"nilParams","id":1,"jsonrpc":"2.0"}]`))
+}
+
 func TestRpcClient_CallBatch(t *testing.T) {
 	RegisterTestingT(t)
 	rpcClient := NewClient(httpServer.URL)
@@ -186,8 +282,17 @@ func TestRpcClient_CallBatch(t *testing.T) {
 		Ingredients: []string{"rum", "cola"},
 	}
 
-	// invalid parameters are possible by manually defining *RPCRequest
-	rpcClient.CallBatch(RPCRequests{
+	// invalid parameters are rejected before sending
+	_, err := rpcClient.CallBatch(RPCRequests{
+		{
+			Method: "singleRequest",
+			Params: 3, // invalid, should be []int{3}
+		},
+	})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+
+	// invalid parameters are possible by manually defining *RPCRequest if validation is disabled
+	NewClientWithOpts(httpServer.URL, &RPCClientOpts{DisableValidation: true}).CallBatch(RPCRequests{
 		{
 			Method: "singleRequest",
 			Params: 3, // invalid, should be []int{3}
@@ -283,14 +388,14 @@ func TestRpcClient_CallBatch(t *testing.T) {
 		{
 			Method:  "myMethod1",
 			Params:  []int{1},
-			ID:      123,   // will be forced to requests[i].ID == i unless you use CallBatchRaw
-			JSONRPC: "7.0", // will be forced to "2.0"  unless you use CallBatchRaw
+			ID:      NumberID(123), // will be forced to requests[i].ID == i unless you use CallBatchRaw
+			JSONRPC: "7.0",         // will be forced to "2.0"  unless you use CallBatchRaw
 		},
 		{
 			Method:  "myMethod2",
 			Params:  &person,
-			ID:      321,     // will be forced to requests[i].ID == i unless you use CallBatchRaw
-			JSONRPC: "wrong", // will be forced to "2.0" unless you use CallBatchRaw
+			ID:      NumberID(321), // will be forced to requests[i].ID == i unless you use CallBatchRaw
+			JSONRPC: "wrong",       // will be forced to "2.0" unless you use CallBatchRaw
 		},
 	}
 	rpcClient.CallBatch(requests)
@@ -303,13 +408,13 @@ func TestRpcClient_CallBatch(t *testing.T) {
 		{
 			Method:  "myMethod1",
 			Params:  []int{1},
-			ID:      123,
+			ID:      NumberID(123),
 			JSONRPC: "7.0",
 		},
 		{
 			Method:  "myMethod2",
 			Params:  &person,
-			ID:      321,
+			ID:      NumberID(321),
 			JSONRPC: "wrong",
 		},
 	}
@@ -338,9 +443,9 @@ func TestRpcJsonResponseStruct(t *testing.T) {
 	Expect(err).NotTo(BeNil())
 	Expect(res).To(BeNil())
 
-	// field "anotherField" not allowed in rpc response is an error
+	// field "anotherField" not allowed in rpc response is an error in strict mode
 	responseBody = `{ "anotherField": "norpc"}`
-	res, err = rpcClient.Call("something", 1, 2, 3)
+	res, err = NewClientWithOpts(httpServer.URL, &RPCClientOpts{StrictMode: true}).Call("something", 1, 2, 3)
 	<-requestChan
 	Expect(err).NotTo(BeNil())
 	Expect(res).To(BeNil())
@@ -711,7 +816,7 @@ func TestRpcBatchJsonResponseStruct(t *testing.T) {
 	<-requestChan
 	Expect(err).To(BeNil())
 	Expect(res[0].Result).To(Equal("ok"))
-	Expect(res[0].ID).To(Equal(0))
+	Expect(res[0].ID).To(Equal(NumberID(0)))
 
 	// result with error null is ok
 	responseBody = `[{"result": "ok", "error": null}]`
@@ -811,10 +916,10 @@ func TestRpcBatchJsonResponseStruct(t *testing.T) {
 	Expect(err).To(BeNil())
 
 	Expect(res[0].Error).To(BeNil())
-	Expect(res[0].ID).To(Equal(0))
+	Expect(res[0].ID).To(Equal(NumberID(0)))
 
 	Expect(res[1].Error).To(BeNil())
-	Expect(res[1].ID).To(Equal(2))
+	Expect(res[1].ID).To(Equal(NumberID(2)))
 
 	err = res[0].GetObject(&p)
 	Expect(p.Name).To(Equal("Alex"))
@@ -860,13 +965,13 @@ func TestRpcBatchJsonResponseStruct(t *testing.T) {
 	Expect(res.HasError()).To(BeFalse())
 	resMap := res.AsMap()
 
-	int1, _ := resMap[1].GetInt()
-	int123, _ := resMap[123].GetInt()
+	int1, _ := resMap[NumberID(1)].GetInt()
+	int123, _ := resMap[NumberID(123)].GetInt()
 	Expect(int1).To(Equal(int64(1)))
 	Expect(int123).To(Equal(int64(123)))
 
 	// check if getByID works
-	int123, _ = res.GetByID(123).GetInt()
+	int123, _ = res.GetByID(NumberID(123)).GetInt()
 	Expect(int123).To(Equal(int64(123)))
 
 	// check if error occurred
@@ -1127,6 +1232,93 @@ func TestRpcClient_CallFor(t *testing.T) {
 		Expect(intArray).To(ContainElement(3))*/
 }
 
+func TestRpcClient_CompatibilityMode(t *testing.T) {
+	RegisterTestingT(t)
+	lenientClient := NewClient(httpServer.URL)
+	strictClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		StrictMode: true,
+	})
+	compatClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		CompatibilityMode: true,
+	})
+
+	// the version and unknown fields are ignored by default
+	responseBody = `{"result": 1, "error": null, "id": 0, "jsonrpc": "1.0", "anotherField": "norpc"}`
+	res, err := lenientClient.Call("something")
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal(json.Number("1")))
+
+	// version 1.0 and unknown fields are rejected in strict mode
+	responseBody = `{"result": 1, "error": null, "id": 0, "jsonrpc": "1.0"}`
+	res, err = strictClient.Call("something")
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(res).To(BeNil())
+
+	responseBody = `{"result": 1, "error": null, "id": 0, "jsonrpc": "2.0", "anotherField": "norpc"}`
+	res, err = strictClient.Call("something")
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(res).To(BeNil())
+
+	// version 1.0 is translated to 2.0 in compatibility mode
+	responseBody = `{"result": 1, "error": null, "id": 0, "jsonrpc": "1.0"}`
+	res, err = compatClient.Call("something")
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(res.JSONRPC).To(Equal("2.0"))
+	Expect(res.Error).To(BeNil())
+	i, err := res.GetInt()
+	Expect(err).To(BeNil())
+	Expect(i).To(Equal(int64(1)))
+
+	// missing version and unknown fields are tolerated
+	responseBody = `{"result": null, "error": null, "id": 0, "anotherField": "norpc"}`
+	res, err = compatClient.Call("something")
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(res.JSONRPC).To(Equal("2.0"))
+	Expect(res.Result).To(BeNil())
+	Expect(res.Error).To(BeNil())
+
+	// plain string errors are translated to an RPCError
+	responseBody = `{"result": null, "error": "something wrong", "id": 0}`
+	res, err = compatClient.Call("something")
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(res.Error.Code).To(Equal(-32000))
+	Expect(res.Error.Message).To(Equal("something wrong"))
+
+	// error objects are kept as they are
+	responseBody = `{"error": {"code": 123, "message": "something wrong", "extra": true}}`
+	res, err = compatClient.Call("something")
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(res.Error.Code).To(Equal(123))
+	Expect(res.Error.Message).To(Equal("something wrong"))
+
+	// unknown versions are still an error
+	responseBody = `{"result": 1, "jsonrpc": "3.0"}`
+	res, err = compatClient.Call("something")
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(res).To(BeNil())
+
+	// batch responses are translated as well
+	responseBody = `[{"result": "ok", "error": null, "id": 0, "jsonrpc": "1.1"}, {"result": null, "error": "failed", "id": 1}]`
+	batchRes, err := compatClient.CallBatch(RPCRequests{
+		NewRequest("something", 1),
+		NewRequest("something", 2),
+	})
+	<-requestChan
+	Expect(err).To(BeNil())
+	Expect(len(batchRes)).To(Equal(2))
+	Expect(batchRes[0].JSONRPC).To(Equal("2.0"))
+	Expect(batchRes[0].Result).To(Equal("ok"))
+	Expect(batchRes[1].Error.Message).To(Equal("failed"))
+}
+
 type Person struct {
 	Name    string `json:"name"`
 	Age     int    `json:"age"`
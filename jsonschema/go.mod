@@ -0,0 +1,18 @@
+module github.com/aurora-is-near/go-jsonrpc/v3/jsonschema
+
+go 1.21
+
+require (
+	github.com/aurora-is-near/go-jsonrpc/v3 v3.1.0
+	github.com/onsi/gomega v1.5.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+)
+
+require (
+	github.com/Microsoft/go-winio v0.4.16 // indirect
+	github.com/gorilla/websocket v1.5.3 // indirect
+	golang.org/x/net v0.0.0-20210405180319-a5a99cb37ef4 // indirect
+	golang.org/x/sys v0.0.0-20210330210617-4fbd30eecc44 // indirect
+	golang.org/x/text v0.3.3 // indirect
+	gopkg.in/yaml.v2 v2.2.1 // indirect
+)
@@ -0,0 +1,123 @@
+// Package jsonschema validates the params of requests to a jsonrpc.Server against JSON Schemas,
+// so handlers don't have to validate their params themselves.
+//
+// It is a separate module, so that the jsonrpc module does not depend on the JSON Schema library.
+package jsonschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	schema "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Violation is a part of the params that does not match the schema, the data of invalid params errors
+// is a list of violations.
+//
+// Location: the JSON pointer of the invalid value in the params, e.g. "/0/to", "" for the params themselves
+//
+// Message: describes the violation
+type Violation struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// Validator holds the JSON Schemas of methods and validates the params of their requests.
+type Validator struct {
+	mutex   sync.RWMutex
+	schemas map[string]*schema.Schema
+}
+
+// NewValidator returns a Validator without schemas.
+func NewValidator() *Validator {
+	return &Validator{schemas: make(map[string]*schema.Schema)}
+}
+
+// Add attaches a JSON Schema to method, replacing an earlier schema of method. The schema describes the params
+// as a whole: an array for positional params or an object for named params. Requests without params are
+// validated as null.
+//
+// It returns an error if the schema can not be compiled.
+func (v *Validator) Add(method string, jsonSchema string) error {
+	compiled, err := schema.CompileString("urn:jsonrpc:method:"+method, jsonSchema)
+	if err != nil {
+		return err
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.schemas[method] = compiled
+	return nil
+}
+
+// MustAdd is like Add but panics if the schema can not be compiled.
+func (v *Validator) MustAdd(method string, jsonSchema string) {
+	if err := v.Add(method, jsonSchema); err != nil {
+		panic(err)
+	}
+}
+
+// Middleware is a jsonrpc.Middleware that validates the params of requests whose methods have a schema
+// before the handler is called. Invalid params are rejected with -32602 (invalid params) and the violations as data,
+// e.g. {"code":-32602,"message":"invalid params","data":[{"location":"/0","message":"expected string, but got number"}]}.
+func (v *Validator) Middleware(next jsonrpc.Handler) jsonrpc.Handler {
+	return func(ctx context.Context, request *jsonrpc.ServerRequest) (interface{}, error) {
+		v.mutex.RLock()
+		compiled := v.schemas[request.Method]
+		v.mutex.RUnlock()
+
+		if compiled != nil {
+			if err := validate(compiled, request.Params); err != nil {
+				return nil, err
+			}
+		}
+		return next(ctx, request)
+	}
+}
+
+// validate returns an invalid params error if params do not match compiled.
+func validate(compiled *schema.Schema, params json.RawMessage) error {
+	var value interface{}
+	if len(params) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(params))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return jsonrpc.InvalidParams([]Violation{{Message: err.Error()}})
+		}
+	}
+
+	err := compiled.Validate(value)
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*schema.ValidationError)
+	if !ok {
+		return jsonrpc.InvalidParams([]Violation{{Message: err.Error()}})
+	}
+	violations := violationsOf(validationErr, nil)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Location < violations[j].Location
+	})
+	return jsonrpc.InvalidParams(violations)
+}
+
+// violationsOf appends the causes of err without further causes to violations.
+func violationsOf(err *schema.ValidationError, violations []Violation) []Violation {
+	if len(err.Causes) == 0 {
+		return append(violations, Violation{
+			Location: err.InstanceLocation,
+			Message:  strings.TrimSpace(err.Message),
+		})
+	}
+
+	for _, cause := range err.Causes {
+		violations = violationsOf(cause, violations)
+	}
+	return violations
+}
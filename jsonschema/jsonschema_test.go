@@ -0,0 +1,77 @@
+package jsonschema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	. "github.com/onsi/gomega"
+)
+
+func TestValidator(t *testing.T) {
+	RegisterTestingT(t)
+
+	validator := NewValidator()
+	validator.MustAdd("transfer", `{
+		"type": "array",
+		"minItems": 2,
+		"prefixItems": [
+			{"type": "string", "pattern": "^0x[0-9a-f]{40}$"},
+			{"type": "integer", "minimum": 1}
+		]
+	}`)
+	validator.MustAdd("named", `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string"}}
+	}`)
+	Expect(validator.Add("broken", `{"type": 1}`)).NotTo(Succeed())
+	Expect(func() { validator.MustAdd("broken", `{`) }).To(Panic())
+
+	server := jsonrpc.NewServerWithOpts(&jsonrpc.ServerOpts{Middleware: []jsonrpc.Middleware{validator.Middleware}})
+	handled := 0
+	for _, method := range []string{"transfer", "named", "free"} {
+		server.Register(method, func(ctx context.Context, request *jsonrpc.ServerRequest) (interface{}, error) {
+			handled++
+			return "ok", nil
+		})
+	}
+
+	tests := []struct {
+		request  string
+		response string
+	}{
+		{
+			`{"jsonrpc":"2.0","method":"transfer","params":["0x00000000000000000000000000000000000000aa",5],"id":1}`,
+			`{"jsonrpc":"2.0","result":"ok","id":1}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"transfer","params":["alice",0],"id":2}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params","data":[` +
+				`{"location":"/0","message":"does not match pattern '^0x[0-9a-f]{40}$'"},` +
+				`{"location":"/1","message":"must be \u003e= 1 but found 0"}]},"id":2}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"transfer","params":["0x00000000000000000000000000000000000000aa"],"id":3}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params","data":[{"location":"","message":"minimum 2 items required, but found 1 items"}]},"id":3}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"named","params":{"name":"bob"},"id":4}`,
+			`{"jsonrpc":"2.0","result":"ok","id":4}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"named","id":5}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params","data":[{"location":"","message":"expected object, but got null"}]},"id":5}`,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"free","params":[1,2,3],"id":6}`,
+			`{"jsonrpc":"2.0","result":"ok","id":6}`,
+		},
+	}
+
+	for _, test := range tests {
+		response := server.HandleMessage(context.Background(), []byte(test.request))
+		Expect(string(response)).To(Equal(test.response), test.request)
+	}
+	Expect(handled).To(Equal(3))
+}
@@ -0,0 +1,214 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+const defaultJWTTTL = time.Minute
+
+// JWTOpts mints a short-lived signed JWT for every http request, see RPCClientOpts.
+// The token is sent as "Authorization: Bearer <token>", retries get a new token.
+// No token is sent if the endpoint redirects to another host.
+//
+// Key: the signing key, the algorithm depends on its type:
+// []byte for HS256, *rsa.PrivateKey for RS256, *ecdsa.PrivateKey for ES256, ES384 or ES512 (by curve)
+// and ed25519.PrivateKey for EdDSA
+//
+// KeyID: the "kid" header of the tokens, omitted if empty
+//
+// Claims: a template of claims that are added to every token, e.g. "iss", "sub" or "aud"
+//
+// MethodClaim: if not empty, the method of the request is added as claim of this name,
+// an array of the methods for batch requests. It is not set for GET requests.
+//
+// TTL: the lifetime of the tokens (default 1 minute). Every token gets "iat" and "exp" claims and a unique "jti".
+type JWTOpts struct {
+	Key         interface{}
+	KeyID       string
+	Claims      map[string]interface{}
+	MethodClaim string
+	TTL         time.Duration
+}
+
+// jwtMinter signs tokens with the key of its opts.
+type jwtMinter struct {
+	opts      JWTOpts
+	algorithm string
+	sign      func(signingInput []byte) ([]byte, error)
+
+	now func() time.Time
+}
+
+// newJWTMinter returns a minter for opts or an error if the type of the key is not supported.
+func newJWTMinter(opts JWTOpts) (*jwtMinter, error) {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultJWTTTL
+	}
+	minter := &jwtMinter{opts: opts, now: time.Now}
+
+	switch key := opts.Key.(type) {
+	case []byte:
+		minter.algorithm = "HS256"
+		minter.sign = func(signingInput []byte) ([]byte, error) {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(signingInput)
+			return mac.Sum(nil), nil
+		}
+	case *rsa.PrivateKey:
+		minter.algorithm = "RS256"
+		minter.sign = func(signingInput []byte) ([]byte, error) {
+			hash := sha256.Sum256(signingInput)
+			return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		}
+	case *ecdsa.PrivateKey:
+		var newHash func() hash.Hash
+		switch key.Curve.Params().BitSize {
+		case 256:
+			minter.algorithm, newHash = "ES256", sha256.New
+		case 384:
+			minter.algorithm, newHash = "ES384", sha512.New384
+		case 521:
+			minter.algorithm, newHash = "ES512", sha512.New
+		default:
+			return nil, fmt.Errorf("jwt: unsupported curve %v", key.Curve.Params().Name)
+		}
+		minter.sign = func(signingInput []byte) ([]byte, error) {
+			h := newHash()
+			h.Write(signingInput)
+			size := (key.Curve.Params().BitSize + 7) / 8
+			r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+			if err != nil {
+				return nil, err
+			}
+			// the signature is r and s as fixed size big endian integers
+			signature := make([]byte, 2*size)
+			fillBytes(r, signature[:size])
+			fillBytes(s, signature[size:])
+			return signature, nil
+		}
+	case ed25519.PrivateKey:
+		minter.algorithm = "EdDSA"
+		minter.sign = func(signingInput []byte) ([]byte, error) {
+			return ed25519.Sign(key, signingInput), nil
+		}
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %T", opts.Key)
+	}
+
+	return minter, nil
+}
+
+// fillBytes writes the absolute value of x as big endian integer into buf, which must be large enough.
+func fillBytes(x *big.Int, buf []byte) {
+	b := x.Bytes()
+	copy(buf[len(buf)-len(b):], b)
+}
+
+// mint returns a signed token for a request with the given methods.
+func (m *jwtMinter) mint(methods []string) (string, error) {
+	header := map[string]string{"alg": m.algorithm, "typ": "JWT"}
+	if m.opts.KeyID != "" {
+		header["kid"] = m.opts.KeyID
+	}
+
+	claims := make(map[string]interface{}, len(m.opts.Claims)+4)
+	for name, value := range m.opts.Claims {
+		claims[name] = value
+	}
+	now := m.now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(m.opts.TTL).Unix()
+	claims["jti"] = newUUID()
+	if m.opts.MethodClaim != "" && len(methods) == 1 {
+		claims[m.opts.MethodClaim] = methods[0]
+	} else if m.opts.MethodClaim != "" && len(methods) > 1 {
+		claims[m.opts.MethodClaim] = methods
+	}
+
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("jwt: %w", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+
+	signature, err := m.sign([]byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("jwt: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// newJWTMiddleware returns a middleware that sends a new token with every request.
+func newJWTMiddleware(opts JWTOpts) func(http.RoundTripper) http.RoundTripper {
+	minter, minterErr := newJWTMinter(opts)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if isCrossHostRedirect(request) {
+				return next.RoundTrip(request)
+			}
+			if minterErr != nil {
+				return nil, MarkPermanent(minterErr)
+			}
+
+			var methods []string
+			if opts.MethodClaim != "" {
+				body, err := readRequestBody(request)
+				if err != nil {
+					return nil, err
+				}
+				methods = bodyMethods(body)
+			}
+
+			token, err := minter.mint(methods)
+			if err != nil {
+				return nil, MarkPermanent(err)
+			}
+
+			// the request of the caller must not be modified
+			request = request.Clone(request.Context())
+			request.Header.Set("Authorization", "Bearer "+token)
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// bodyMethods returns the methods of the requests of a http request body in their order.
+func bodyMethods(data []byte) []string {
+	var requests []struct {
+		Method string `json:"method"`
+	}
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		trimmed = append(append([]byte("["), trimmed...), ']')
+	}
+	if err := json.Unmarshal(trimmed, &requests); err != nil {
+		return nil
+	}
+
+	methods := make([]string, 0, len(requests))
+	for _, request := range requests {
+		methods = append(methods, request.Method)
+	}
+	return methods
+}
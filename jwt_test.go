@@ -0,0 +1,192 @@
+package jsonrpc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// parseJWT returns the header and claims of token and verifies its signature with verify.
+func parseJWT(token string, verify func(signingInput, signature []byte) bool) (map[string]interface{}, map[string]interface{}) {
+	parts := strings.Split(token, ".")
+	Expect(parts).To(HaveLen(3))
+
+	decode := func(part string) []byte {
+		data, err := base64.RawURLEncoding.DecodeString(part)
+		Expect(err).To(BeNil())
+		return data
+	}
+	var header, claims map[string]interface{}
+	Expect(json.Unmarshal(decode(parts[0]), &header)).To(Succeed())
+	Expect(json.Unmarshal(decode(parts[1]), &claims)).To(Succeed())
+	Expect(verify([]byte(parts[0]+"."+parts[1]), decode(parts[2]))).To(BeTrue(), "invalid signature")
+
+	return header, claims
+}
+
+func TestJWT(t *testing.T) {
+	RegisterTestingT(t)
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	key := []byte("secret")
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		JWT: &JWTOpts{
+			Key:         key,
+			KeyID:       "key-1",
+			Claims:      map[string]interface{}{"iss": "client", "aud": "rpc"},
+			MethodClaim: "method",
+			TTL:         30 * time.Second,
+		},
+	})
+	_, err := rpcClient.Call("eth_blockNumber")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("eth_blockNumber")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(HaveOccurred()) // the server answers with a single response
+
+	Expect(tokens).To(HaveLen(3))
+	verify := func(signingInput, signature []byte) bool {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(signingInput)
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+	header, claims := parseJWT(tokens[0], verify)
+	Expect(header).To(Equal(map[string]interface{}{"alg": "HS256", "typ": "JWT", "kid": "key-1"}))
+	Expect(claims).To(HaveKeyWithValue("iss", "client"))
+	Expect(claims).To(HaveKeyWithValue("aud", "rpc"))
+	Expect(claims).To(HaveKeyWithValue("method", "eth_blockNumber"))
+	Expect(claims["exp"].(float64) - claims["iat"].(float64)).To(Equal(30.0))
+	Expect(claims["iat"]).To(BeNumerically("~", time.Now().Unix(), 2))
+
+	// every request gets a new token
+	_, second := parseJWT(tokens[1], verify)
+	Expect(second["jti"]).NotTo(Equal(claims["jti"]))
+
+	_, batch := parseJWT(tokens[2], verify)
+	Expect(batch).To(HaveKeyWithValue("method", []interface{}{"a", "b"}))
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	RegisterTestingT(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).To(BeNil())
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	Expect(err).To(BeNil())
+
+	verifyECDSA := func(key *ecdsa.PrivateKey, digest func([]byte) []byte) func(signingInput, signature []byte) bool {
+		return func(signingInput, signature []byte) bool {
+			size := len(signature) / 2
+			r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+			return ecdsa.Verify(&key.PublicKey, digest(signingInput), r, s)
+		}
+	}
+	ecKeys := map[elliptic.Curve]*ecdsa.PrivateKey{}
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		ecKeys[curve], err = ecdsa.GenerateKey(curve, rand.Reader)
+		Expect(err).To(BeNil())
+	}
+
+	tests := []struct {
+		key       interface{}
+		algorithm string
+		verify    func(signingInput, signature []byte) bool
+	}{
+		{rsaKey, "RS256", func(signingInput, signature []byte) bool {
+			hash := sha256.Sum256(signingInput)
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, hash[:], signature) == nil
+		}},
+		{ecKeys[elliptic.P256()], "ES256", verifyECDSA(ecKeys[elliptic.P256()], func(b []byte) []byte {
+			hash := sha256.Sum256(b)
+			return hash[:]
+		})},
+		{ecKeys[elliptic.P384()], "ES384", verifyECDSA(ecKeys[elliptic.P384()], func(b []byte) []byte {
+			hash := sha512.Sum384(b)
+			return hash[:]
+		})},
+		{ecKeys[elliptic.P521()], "ES512", verifyECDSA(ecKeys[elliptic.P521()], func(b []byte) []byte {
+			hash := sha512.Sum512(b)
+			return hash[:]
+		})},
+		{edKey, "EdDSA", func(signingInput, signature []byte) bool {
+			return ed25519.Verify(edKey.Public().(ed25519.PublicKey), signingInput, signature)
+		}},
+	}
+	for _, test := range tests {
+		minter, err := newJWTMinter(JWTOpts{Key: test.key})
+		Expect(err).To(BeNil())
+		token, err := minter.mint(nil)
+		Expect(err).To(BeNil())
+		header, claims := parseJWT(token, test.verify)
+		Expect(header).To(HaveKeyWithValue("alg", test.algorithm))
+		Expect(header).NotTo(HaveKey("kid"))
+		Expect(claims["exp"].(float64) - claims["iat"].(float64)).To(Equal(60.0))
+	}
+}
+
+func TestJWTErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := newJWTMinter(JWTOpts{Key: "secret"})
+	Expect(err).To(MatchError("jwt: unsupported key type string"))
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{
+		JWT: &JWTOpts{Key: []byte("secret"), Claims: map[string]interface{}{"invalid": func() {}}},
+	}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("jwt: json: unsupported type"))
+	Expect(IsRetryable(err)).To(BeFalse())
+
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{JWT: &JWTOpts{Key: 42}}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("jwt: unsupported key type int"))
+	Expect(requests).To(Equal(0))
+}
+
+func TestJWTRedirect(t *testing.T) {
+	RegisterTestingT(t)
+
+	endpoint, headers, closeServers := newRedirectServers()
+	defer closeServers()
+
+	opts := &RPCClientOpts{JWT: &JWTOpts{Key: []byte("secret")}}
+	_, err := NewClientWithOpts(endpoint+"/same", opts).Call("something")
+	Expect(err).To(BeNil())
+	_, err = NewClientWithOpts(endpoint+"/other", opts).Call("something")
+	Expect(err).To(BeNil())
+
+	endpointHeader, otherHeader := headers()
+	Expect(endpointHeader.Get("Authorization")).To(HavePrefix("Bearer "))
+	Expect(otherHeader).NotTo(BeNil())
+	Expect(otherHeader.Get("Authorization")).To(BeEmpty())
+}
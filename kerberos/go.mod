@@ -0,0 +1,25 @@
+module github.com/aurora-is-near/go-jsonrpc/v3/kerberos
+
+go 1.21
+
+require (
+	github.com/aurora-is-near/go-jsonrpc/v3 v3.1.0
+	github.com/jcmturner/gokrb5/v8 v8.4.4
+	github.com/onsi/gomega v1.5.0
+)
+
+require (
+	github.com/Microsoft/go-winio v0.4.16 // indirect
+	github.com/gorilla/websocket v1.5.3 // indirect
+	github.com/hashicorp/go-uuid v1.0.3 // indirect
+	github.com/jcmturner/aescts/v2 v2.0.0 // indirect
+	github.com/jcmturner/dnsutils/v2 v2.0.0 // indirect
+	github.com/jcmturner/gofork v1.7.6 // indirect
+	github.com/jcmturner/goidentity/v6 v6.0.1 // indirect
+	github.com/jcmturner/rpc/v2 v2.0.3 // indirect
+	golang.org/x/crypto v0.6.0 // indirect
+	golang.org/x/net v0.7.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
@@ -0,0 +1,120 @@
+// Package kerberos authenticates jsonrpc clients with Kerberos using SPNEGO (HTTP Negotiate authentication),
+// e.g. for RPC gateways behind proxies with Windows integrated authentication.
+//
+// It is a separate module, so that the jsonrpc module does not depend on github.com/jcmturner/gokrb5.
+package kerberos
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// Opts configures the Negotiate authentication.
+//
+// Client: the logged in Kerberos client, e.g. of client.NewWithKeytab() or client.NewFromCCache()
+//
+// SPN: the service principal name of the endpoint (default "HTTP/<canonical host name of the endpoint>")
+//
+// Preemptive: sends the token with the first request instead of after a "WWW-Authenticate: Negotiate" challenge.
+// After a challenge all further requests send a token preemptively.
+type Opts struct {
+	Client     *client.Client
+	SPN        string
+	Preemptive bool
+}
+
+// Middleware returns http middleware for jsonrpc.RPCClientOpts.HTTPMiddleware that answers Negotiate challenges
+// with a Kerberos service ticket, e.g.
+//
+//	cfg, err := config.Load("/etc/krb5.conf")
+//	ccache, err := credentials.LoadCCache("/tmp/krb5cc_1000")
+//	krbClient, err := client.NewFromCCache(ccache, cfg)
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+//		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{
+//			kerberos.Middleware(kerberos.Opts{Client: krbClient}),
+//		},
+//	})
+//
+// A request that is rejected with status 401 and a Negotiate challenge is sent once more with a token.
+func Middleware(opts Opts) func(http.RoundTripper) http.RoundTripper {
+	return newMiddleware(opts, func(request *http.Request) error {
+		if opts.Client == nil {
+			return errors.New("kerberos: no client")
+		}
+		return spnego.SetSPNEGOHeader(opts.Client, request, opts.SPN)
+	})
+}
+
+// newMiddleware returns the middleware with setHeader setting the Authorization header of a request.
+func newMiddleware(opts Opts, setHeader func(request *http.Request) error) func(http.RoundTripper) http.RoundTripper {
+	var negotiated int32
+	if opts.Preemptive {
+		negotiated = 1
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			if atomic.LoadInt32(&negotiated) == 1 {
+				return authenticate(next, request, setHeader)
+			}
+
+			response, err := next.RoundTrip(request)
+			if err != nil || !negotiateChallenge(response) {
+				return response, err
+			}
+			if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+				// the body cannot be sent again
+				return response, nil
+			}
+
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+			atomic.StoreInt32(&negotiated, 1)
+			return authenticate(next, request, setHeader)
+		})
+	}
+}
+
+// authenticate sends a copy of request with a token.
+func authenticate(next http.RoundTripper, request *http.Request, setHeader func(request *http.Request) error) (*http.Response, error) {
+	// the request of the caller must not be modified
+	authenticated := request.Clone(request.Context())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		authenticated.Body = body
+	}
+	if err := setHeader(authenticated); err != nil {
+		return nil, err
+	}
+
+	return next.RoundTrip(authenticated)
+}
+
+// negotiateChallenge returns true if response rejects the request with a Negotiate challenge.
+func negotiateChallenge(response *http.Response) bool {
+	if response.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	for _, challenge := range response.Header.Values("WWW-Authenticate") {
+		scheme := strings.Fields(challenge)
+		if len(scheme) > 0 && strings.EqualFold(scheme[0], "Negotiate") {
+			return true
+		}
+	}
+	return false
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
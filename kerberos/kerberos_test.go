@@ -0,0 +1,111 @@
+package kerberos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	. "github.com/onsi/gomega"
+)
+
+// newServer returns a server that requires "Authorization: Negotiate token" and the bodies it received.
+func newServer() (*httptest.Server, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.Header.Get("Authorization")+" "+string(body))
+		if r.Header.Get("Authorization") != "Negotiate token" {
+			w.Header().Add("WWW-Authenticate", `Basic realm="rpc"`)
+			w.Header().Add("WWW-Authenticate", "Negotiate")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	return server, &bodies
+}
+
+func setHeader(request *http.Request) error {
+	request.Header.Set("Authorization", "Negotiate token")
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, bodies := newServer()
+	defer server.Close()
+
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{newMiddleware(Opts{}, setHeader)},
+	})
+	res, err := rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+	// after the challenge the tokens are sent preemptively
+	_, err = rpcClient.Call("second")
+	Expect(err).To(BeNil())
+
+	Expect(*bodies).To(Equal([]string{
+		` {"method":"first","id":0,"jsonrpc":"2.0"}`,
+		`Negotiate token {"method":"first","id":0,"jsonrpc":"2.0"}`,
+		`Negotiate token {"method":"second","id":0,"jsonrpc":"2.0"}`,
+	}))
+}
+
+func TestMiddlewarePreemptive(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, bodies := newServer()
+	defer server.Close()
+
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{newMiddleware(Opts{Preemptive: true}, setHeader)},
+	})
+	_, err := rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	Expect(*bodies).To(HaveLen(1))
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, bodies := newServer()
+	defer server.Close()
+
+	// a rejected token is not sent again
+	var calls int32
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{newMiddleware(Opts{}, func(request *http.Request) error {
+			atomic.AddInt32(&calls, 1)
+			request.Header.Set("Authorization", "Negotiate invalid")
+			return nil
+		})},
+	})
+	_, err := rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.(*jsonrpc.HTTPError).Code).To(Equal(http.StatusUnauthorized))
+	Expect(*bodies).To(HaveLen(2))
+	Expect(calls).To(Equal(int32(1)))
+
+	rpcClient = jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{newMiddleware(Opts{}, func(*http.Request) error {
+			return errors.New("no ticket")
+		})},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("no ticket"))
+
+	rpcClient = jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{Middleware(Opts{Preemptive: true})},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("kerberos: no client"))
+}
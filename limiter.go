@@ -0,0 +1,127 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrConcurrencyLimit is returned without sending the request if the maximum number of requests is in flight
+// and the request can not wait in the queue.
+var ErrConcurrencyLimit = errors.New("too many requests in flight")
+
+// ConcurrencyOpts limits the number of concurrent requests of a client, see RPCClientOpts.
+// Every attempt of a retried request counts separately. A streamed response counts until its body is closed.
+//
+// MaxInFlight: the maximum number of requests in flight, unlimited if 0
+//
+// MaxInFlightPerEndpoint: the maximum number of requests in flight per endpoint of a client created with NewClientWithEndpoints(),
+// unlimited if 0. If a request can not be sent to an endpoint, it is sent to the next endpoint.
+//
+// QueueSize: the maximum number of requests that wait for a free slot, unlimited if 0.
+// If negative, requests fail immediately with ErrConcurrencyLimit.
+//
+// QueueTimeout: the maximum time a request waits for a free slot, until the context is done if 0
+type ConcurrencyOpts struct {
+	MaxInFlight            int
+	MaxInFlightPerEndpoint int
+	QueueSize              int
+	QueueTimeout           time.Duration
+}
+
+// limiter is a Transport that limits the number of concurrent requests.
+type limiter struct {
+	transport    Transport
+	slots        chan struct{}
+	queueSize    int32
+	queueTimeout time.Duration
+	waiting      int32
+}
+
+// newLimiter returns a limiter for transport that allows maxInFlight concurrent requests.
+func newLimiter(transport Transport, maxInFlight int, opts ConcurrencyOpts) *limiter {
+	return &limiter{
+		transport:    transport,
+		slots:        make(chan struct{}, maxInFlight),
+		queueSize:    int32(opts.QueueSize),
+		queueTimeout: opts.QueueTimeout,
+	}
+}
+
+func (l *limiter) Send(ctx context.Context, request []byte) ([]byte, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer l.release()
+
+	return l.transport.Send(ctx, request)
+}
+
+func (l *limiter) SendStream(ctx context.Context, request []byte) (io.ReadCloser, error) {
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	responseBody, err := sendStream(ctx, l.transport, request)
+	if responseBody == nil {
+		l.release()
+		return nil, err
+	}
+
+	return &releasingReadCloser{ReadCloser: responseBody, release: l.release}, err
+}
+
+// acquire waits for a free slot.
+func (l *limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.queueSize < 0 {
+		return MarkPermanent(ErrConcurrencyLimit)
+	}
+	waiting := atomic.AddInt32(&l.waiting, 1)
+	defer atomic.AddInt32(&l.waiting, -1)
+	if l.queueSize > 0 && waiting > l.queueSize {
+		return MarkPermanent(fmt.Errorf("%w: queue is full", ErrConcurrencyLimit))
+	}
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return MarkPermanent(fmt.Errorf("%w: no free slot after %v", ErrConcurrencyLimit, l.queueTimeout))
+	}
+}
+
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// releasingReadCloser calls release once when it is closed.
+type releasingReadCloser struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releasingReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
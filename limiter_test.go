@@ -0,0 +1,177 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLimiter(t *testing.T) {
+	RegisterTestingT(t)
+
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	transport := transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		<-release
+		return request, nil
+	})
+
+	l := newLimiter(transport, 2, ConcurrencyOpts{})
+	done := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := l.Send(context.Background(), []byte(`{}`))
+			done <- err
+		}()
+	}
+
+	Eventually(func() int32 { return atomic.LoadInt32(&l.waiting) }).Should(Equal(int32(3)))
+	Expect(atomic.LoadInt32(&inFlight)).To(Equal(int32(2)))
+
+	close(release)
+	for i := 0; i < 5; i++ {
+		Expect(<-done).To(BeNil())
+	}
+	Expect(atomic.LoadInt32(&maxInFlight)).To(Equal(int32(2)))
+}
+
+func TestLimiter_Queue(t *testing.T) {
+	RegisterTestingT(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	transport := transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		<-release
+		return request, nil
+	})
+	send := func(l *limiter, ctx context.Context) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := l.Send(ctx, []byte(`{}`))
+			done <- err
+		}()
+		return done
+	}
+
+	// without queue
+	l := newLimiter(transport, 1, ConcurrencyOpts{QueueSize: -1})
+	send(l, context.Background())
+	Eventually(func() int { return len(l.slots) }).Should(Equal(1))
+	_, err := l.Send(context.Background(), []byte(`{}`))
+	Expect(errors.Is(err, ErrConcurrencyLimit)).To(BeTrue())
+	Expect(IsRetryable(err)).To(BeFalse())
+
+	// full queue
+	l = newLimiter(transport, 1, ConcurrencyOpts{QueueSize: 1})
+	send(l, context.Background())
+	Eventually(func() int { return len(l.slots) }).Should(Equal(1))
+	send(l, context.Background())
+	Eventually(func() int32 { return atomic.LoadInt32(&l.waiting) }).Should(Equal(int32(1)))
+	_, err = l.Send(context.Background(), []byte(`{}`))
+	Expect(errors.Is(err, ErrConcurrencyLimit)).To(BeTrue())
+
+	// queue timeout
+	l = newLimiter(transport, 1, ConcurrencyOpts{QueueTimeout: 10 * time.Millisecond})
+	send(l, context.Background())
+	Eventually(func() int { return len(l.slots) }).Should(Equal(1))
+	_, err = l.Send(context.Background(), []byte(`{}`))
+	Expect(errors.Is(err, ErrConcurrencyLimit)).To(BeTrue())
+
+	// the context ends the wait
+	l = newLimiter(transport, 1, ConcurrencyOpts{})
+	send(l, context.Background())
+	Eventually(func() int { return len(l.slots) }).Should(Equal(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := send(l, ctx)
+	Eventually(func() int32 { return atomic.LoadInt32(&l.waiting) }).Should(Equal(int32(1)))
+	cancel()
+	Eventually(done).Should(Receive(Equal(context.Canceled)))
+}
+
+func TestLimiter_SendStream(t *testing.T) {
+	RegisterTestingT(t)
+
+	transport := transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		return request, nil
+	})
+
+	l := newLimiter(transport, 1, ConcurrencyOpts{QueueSize: -1})
+	body, err := l.SendStream(context.Background(), []byte(`{}`))
+	Expect(err).To(BeNil())
+	Expect(len(l.slots)).To(Equal(1))
+
+	data, err := ioutil.ReadAll(body)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal(`{}`))
+
+	// the slot is released when the body is closed
+	Expect(len(l.slots)).To(Equal(1))
+	Expect(body.Close()).To(BeNil())
+	Expect(body.Close()).To(BeNil())
+	Expect(len(l.slots)).To(Equal(0))
+}
+
+func TestConcurrencyPerEndpoint(t *testing.T) {
+	RegisterTestingT(t)
+
+	release := make(chan struct{})
+	var first, second int32
+	newServer := func(requests *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(requests, 1)
+			<-release
+			w.Write([]byte(`{"jsonrpc":"2.0","result":"ok","id":0}`))
+		}))
+	}
+	firstServer := newServer(&first)
+	defer firstServer.Close()
+	secondServer := newServer(&second)
+	defer secondServer.Close()
+
+	rpcClient := NewClientWithEndpoints([]string{firstServer.URL, secondServer.URL}, &RPCClientOpts{
+		Concurrency: &ConcurrencyOpts{MaxInFlightPerEndpoint: 1, QueueSize: -1},
+	})
+
+	done := make(chan error, 3)
+	call := func() {
+		go func() {
+			_, err := rpcClient.Call("something")
+			done <- err
+		}()
+	}
+
+	// the second request is sent to the second endpoint, the third fails
+	call()
+	Eventually(func() int32 { return atomic.LoadInt32(&first) }).Should(Equal(int32(1)))
+	call()
+	Eventually(func() int32 { return atomic.LoadInt32(&second) }).Should(Equal(int32(1)))
+	call()
+	var err error
+	Eventually(done).Should(Receive(&err))
+	Expect(errors.Is(err, ErrConcurrencyLimit)).To(BeTrue())
+
+	close(release)
+	Expect(<-done).To(BeNil())
+	Expect(<-done).To(BeNil())
+
+	// busy endpoints are not put in cooldown
+	for _, endpoint := range rpcClient.Endpoints() {
+		Expect(endpoint.Healthy).To(BeTrue())
+		Expect(endpoint.Pending).To(Equal(0))
+	}
+}
@@ -0,0 +1,9 @@
+package jsonrpc
+
+// Metrics records metrics of the calls of a client, see RPCClientOpts.
+// The prometheus module provides an implementation for Prometheus.
+type Metrics interface {
+	// ObserveCall is called after every call, with all fields of call set (see CallInfo).
+	// It must be safe for concurrent use.
+	ObserveCall(call *CallInfo)
+}
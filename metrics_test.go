@@ -0,0 +1,82 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type testMetrics struct {
+	mutex sync.Mutex
+	calls []CallInfo
+}
+
+func (m *testMetrics) ObserveCall(call *CallInfo) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = append(m.calls, *call)
+}
+
+func TestMetrics(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, _ := newFlakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	metrics := &testMetrics{}
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Retry:   &RetryPolicy{MaxAttempts: 3},
+		Metrics: metrics,
+	})
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(metrics.calls).To(HaveLen(1))
+	call := metrics.calls[0]
+	Expect(call.Method()).To(Equal("something"))
+	Expect(call.Err).To(BeNil())
+	Expect(call.Responses).To(HaveLen(1))
+	Expect(call.Attempts).To(Equal(2))
+	Expect(call.Endpoint).To(Equal(server.URL))
+	Expect(call.HTTPStatus).To(Equal(http.StatusOK))
+	Expect(call.Duration).To(BeNumerically(">", 0))
+}
+
+func TestMetricsError(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	metrics := &testMetrics{}
+	var intercepted CallInfo
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Metrics: metrics,
+		Interceptors: []Interceptor{func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+			err := invoke(ctx)
+			// the fields are set when invoke returns
+			intercepted = *call
+			return fmt.Errorf("intercepted: %w", err)
+		}},
+	})
+
+	_, err := rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(HaveOccurred())
+	Expect(intercepted.HTTPStatus).To(Equal(http.StatusBadGateway))
+	Expect(intercepted.Attempts).To(Equal(1))
+	Expect(intercepted.Err).To(HaveOccurred())
+
+	Expect(metrics.calls).To(HaveLen(1))
+	call := metrics.calls[0]
+	Expect(call.Method()).To(Equal("batch"))
+	Expect(call.HTTPStatus).To(Equal(http.StatusBadGateway))
+	Expect(call.Endpoint).To(Equal(server.URL))
+	Expect(call.Err).To(Equal(intercepted.Err))
+}
@@ -0,0 +1,84 @@
+package jsonrpc
+
+import "strings"
+
+// Namespace is a group of methods of a Server whose names begin with the name of the namespace and "_",
+// e.g. "eth_blockNumber" in the namespace "eth". The methods of a namespace can be disabled and enabled at any time,
+// calls of disabled methods are answered with -32601 (method not found) as if they were not registered.
+type Namespace struct {
+	server *Server
+	name   string
+}
+
+// Namespace returns the namespace name of the server, e.g. "eth", "net" or "debug".
+// Methods that are registered otherwise, e.g. with Register() or RegisterService(), are part of the namespace as well.
+func (s *Server) Namespace(name string) *Namespace {
+	return &Namespace{server: s, name: name}
+}
+
+// Name returns the name of the namespace.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// Register registers handler for the method name of the namespace, e.g. "blockNumber" for "eth_blockNumber".
+func (n *Namespace) Register(name string, handler Handler) {
+	n.server.Register(namespaceMethodName(n.name, name), handler)
+}
+
+// RegisterService registers the methods of service in the namespace, see Server.RegisterService().
+func (n *Namespace) RegisterService(service interface{}) {
+	n.server.RegisterService(n.name, service)
+}
+
+// RegisterSubscription registers a subscription in the namespace, see Server.RegisterSubscription().
+func (n *Namespace) RegisterSubscription(name string, handler SubscriptionHandler) {
+	n.server.RegisterSubscription(n.name, name, handler)
+}
+
+// Enable serves the methods of the namespace again after Disable(). A namespace that is disabled
+// on the parent of a version (see Server.Version()) stays disabled in the version.
+func (n *Namespace) Enable() {
+	n.server.mutex.Lock()
+	defer n.server.mutex.Unlock()
+	delete(n.server.disabledNamespaces, n.name)
+}
+
+// Disable stops serving the methods of the namespace, calls are answered with -32601 (method not found).
+func (n *Namespace) Disable() {
+	n.server.mutex.Lock()
+	defer n.server.mutex.Unlock()
+	n.server.disabledNamespaces[n.name] = true
+}
+
+// Enabled returns true if the methods of the namespace are served.
+func (n *Namespace) Enabled() bool {
+	return n.server.namespaceEnabled(n.name)
+}
+
+// namespaceEnabled returns true if namespace is neither disabled on the server nor on its parent.
+func (s *Server) namespaceEnabled(namespace string) bool {
+	s.mutex.RLock()
+	disabled := s.disabledNamespaces[namespace]
+	s.mutex.RUnlock()
+	if disabled {
+		return false
+	}
+	return s.parent == nil || s.parent.namespaceEnabled(namespace)
+}
+
+// methodNamespace returns the namespace of method, the part before the first "_", or "" if it has none.
+func methodNamespace(method string) string {
+	if i := strings.IndexByte(method, '_'); i > 0 {
+		return method[:i]
+	}
+	return ""
+}
+
+// namespaceMethodName returns the name of the method name of namespace.
+func namespaceMethodName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "_" + name
+}
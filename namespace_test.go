@@ -0,0 +1,55 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerNamespace(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServerWithOpts(&ServerOpts{DisabledNamespaces: []string{"debug"}})
+	eth := server.Namespace("eth")
+	Expect(eth.Name()).To(Equal("eth"))
+	eth.Register("blockNumber", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "0x10", nil
+	})
+	server.Namespace("debug").RegisterService(&calculator{})
+	server.Register("ping", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "pong", nil
+	})
+	rpcClient := ClientFromHandler(server)
+
+	var blockNumber string
+	Expect(rpcClient.CallFor(&blockNumber, "eth_blockNumber")).To(Succeed())
+	Expect(blockNumber).To(Equal("0x10"))
+
+	// disabled namespaces are not served
+	debug := server.Namespace("debug")
+	Expect(debug.Enabled()).To(BeFalse())
+	response, err := rpcClient.Call("debug_add", 1, 2)
+	Expect(err).To(BeNil())
+	Expect(response.Error).To(Equal(&RPCError{Code: CodeMethodNotFound, Message: "method not found: debug_add"}))
+
+	debug.Enable()
+	Expect(debug.Enabled()).To(BeTrue())
+	var sum int
+	Expect(rpcClient.CallFor(&sum, "debug_add", 1, 2)).To(Succeed())
+	Expect(sum).To(Equal(3))
+
+	eth.Disable()
+	Expect(eth.Enabled()).To(BeFalse())
+	response, err = rpcClient.Call("eth_blockNumber")
+	Expect(err).To(BeNil())
+	Expect(response.Error.Code).To(Equal(CodeMethodNotFound))
+
+	// methods without namespace belong to the namespace ""
+	Expect(rpcClient.CallFor(new(string), "ping")).To(Succeed())
+	server.Namespace("").Disable()
+	response, err = rpcClient.Call("ping")
+	Expect(err).To(BeNil())
+	Expect(response.Error.Code).To(Equal(CodeMethodNotFound))
+	Expect(rpcClient.CallFor(&sum, "debug_add", 1, 2)).To(Succeed())
+}
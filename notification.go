@@ -0,0 +1,46 @@
+package jsonrpc
+
+import "encoding/json"
+
+// NewNotification returns a notification, a request without id that the server does not respond to.
+// Notifications are sent in batches, e.g. with CallBatch(), which return responses only for the other requests.
+//
+// e.g. NewNotification("log", "started")
+func NewNotification(method string, params ...interface{}) *RPCRequest {
+	request := NewRequest(method, params...)
+	request.Notification = true
+	return request
+}
+
+// notificationJSON is the json encoding of a notification, a request without id.
+type notificationJSON struct {
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	JSONRPC string      `json:"jsonrpc"`
+}
+
+// requestJSON has the fields of RPCRequest without its methods.
+type requestJSON RPCRequest
+
+// MarshalJSON encodes the request, without id if it is a notification.
+func (RPCRequest RPCRequest) MarshalJSON() ([]byte, error) {
+	if !RPCRequest.Notification {
+		return json.Marshal(requestJSON(RPCRequest))
+	}
+
+	return json.Marshal(notificationJSON{
+		Method:  RPCRequest.Method,
+		Params:  RPCRequest.Params,
+		JSONRPC: RPCRequest.JSONRPC,
+	})
+}
+
+// expectsResponse returns true if the server responds to at least one of the requests.
+func expectsResponse(requests []*RPCRequest) bool {
+	for _, request := range requests {
+		if request == nil || !request.Notification {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewNotification(t *testing.T) {
+	RegisterTestingT(t)
+
+	notification := NewNotification("log", "started")
+	Expect(notification.Notification).To(BeTrue())
+	data, err := json.Marshal(notification)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal(`{"method":"log","params":["started"],"jsonrpc":"2.0"}`))
+
+	// values are encoded the same way
+	data, err = json.Marshal(*notification)
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal(`{"method":"log","params":["started"],"jsonrpc":"2.0"}`))
+
+	data, err = json.Marshal(NewRequest("log"))
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal(`{"method":"log","id":0,"jsonrpc":"2.0"}`))
+}
+
+// newNotificationServer returns a server that responds only to the requests with id and records the request bodies.
+// A batch of only notifications is answered with 204 No Content.
+func newNotificationServer(bodies chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+
+		var requests []map[string]interface{}
+		json.Unmarshal(body, &requests)
+		responses := []map[string]interface{}{}
+		for _, request := range requests {
+			if id, ok := request["id"]; ok {
+				responses = append(responses, map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": request["method"]})
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+}
+
+func TestBatchNotifications(t *testing.T) {
+	RegisterTestingT(t)
+
+	bodies := make(chan string, 10)
+	server := newNotificationServer(bodies)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{IDGenerator: NewSequentialIDGenerator(1)})
+
+	requests := RPCRequests{NewRequest("a"), NewNotification("log", "x"), NewRequest("b")}
+	responses, err := rpcClient.CallBatch(requests)
+	Expect(err).To(BeNil())
+	Expect(<-bodies).To(Equal(`[{"method":"a","id":1,"jsonrpc":"2.0"},{"method":"log","params":["x"],"jsonrpc":"2.0"},{"method":"b","id":2,"jsonrpc":"2.0"}]`))
+	Expect(responses).To(HaveLen(2))
+
+	result := NewBatchResult(requests, responses)
+	Expect(result.ByRequest(requests[0]).Result).To(Equal("a"))
+	Expect(result.ByRequest(requests[1])).To(BeNil())
+	Expect(result.ByRequest(requests[2]).Result).To(Equal("b"))
+	Expect(result.Missing()).To(BeEmpty())
+	ordered := result.Responses()
+	Expect(ordered).To(HaveLen(3))
+	Expect(ordered[1]).To(BeNil())
+
+	// a batch of only notifications has no response
+	responses, err = rpcClient.CallBatch(RPCRequests{NewNotification("log", "y"), NewNotification("log", "z")})
+	Expect(err).To(BeNil())
+	Expect(responses).To(BeEmpty())
+	Expect(<-bodies).To(Equal(`[{"method":"log","params":["y"],"jsonrpc":"2.0"},{"method":"log","params":["z"],"jsonrpc":"2.0"}]`))
+
+	err = rpcClient.CallBatchStream(context.Background(), RPCRequests{NewNotification("log")}, func(*RPCResponse) {
+		panic("no response expected")
+	})
+	Expect(err).To(BeNil())
+	<-bodies
+
+	// chunks of only notifications
+	chunked := NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 2})
+	responses, err = chunked.CallBatch(RPCRequests{NewNotification("log"), NewNotification("log"), NewRequest("a")})
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(1))
+	Expect(responses[0].Result).To(Equal("a"))
+}
+
+func TestBatchBuilder_Notifications(t *testing.T) {
+	RegisterTestingT(t)
+
+	bodies := make(chan string, 10)
+	server := newNotificationServer(bodies)
+	defer server.Close()
+
+	var a string
+	err := NewBatchBuilder(NewClient(server.URL)).
+		Add("a", &a).
+		AddNotification("log", "x").
+		Execute(context.Background())
+	Expect(err).To(BeNil())
+	Expect(a).To(Equal("a"))
+
+	Expect(NewBatchBuilder(NewClient(server.URL)).AddNotification("log").Execute(context.Background())).To(Succeed())
+}
+
+func TestBatchNotifications_WebSocket(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	responses, err := rpcClient.CallBatchContext(ctx, RPCRequests{NewNotification("log"), NewNotification("log")})
+	Expect(err).To(BeNil())
+	Expect(responses).To(BeEmpty())
+}
@@ -0,0 +1,76 @@
+// Package oauth2 authenticates jsonrpc clients with OAuth2 tokens of golang.org/x/oauth2.
+//
+// It is a separate module, so that the jsonrpc module does not depend on golang.org/x/oauth2.
+package oauth2
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	xoauth2 "golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ClientCredentials returns options for jsonrpc.RPCClientOpts.BearerToken that use the client credentials flow, e.g.
+//
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+//		BearerToken: oauth2.ClientCredentials(&clientcredentials.Config{
+//			ClientID:     "myID",
+//			ClientSecret: "mySecret",
+//			TokenURL:     "https://provider.example/oauth/token",
+//		}),
+//	})
+//
+// The token is cached until shortly before it expires. If the server rejects it with status 401,
+// a new token is requested and the request is sent once more.
+func ClientCredentials(config *clientcredentials.Config) *jsonrpc.BearerTokenOpts {
+	return newBearerTokenOpts(config.Token)
+}
+
+// TokenSource returns options for jsonrpc.RPCClientOpts.BearerToken that use the tokens of ts.
+//
+// The token is cached until shortly before it expires. If the server rejects it with status 401,
+// a new token is requested from ts and the request is sent once more. Token sources that cache tokens themselves
+// (e.g. oauth2.ReuseTokenSource) return the same token until it expires.
+func TokenSource(ts xoauth2.TokenSource) *jsonrpc.BearerTokenOpts {
+	return newBearerTokenOpts(func(context.Context) (*xoauth2.Token, error) {
+		return ts.Token()
+	})
+}
+
+// newBearerTokenOpts returns options that cache the tokens of fetch.
+func newBearerTokenOpts(fetch func(ctx context.Context) (*xoauth2.Token, error)) *jsonrpc.BearerTokenOpts {
+	source := &tokenSource{fetch: fetch}
+
+	return &jsonrpc.BearerTokenOpts{
+		Provider: source.token,
+		// the tokens are cached by their expiry
+		TTL: -1,
+	}
+}
+
+// tokenSource caches the token of fetch until it expires or is rejected.
+type tokenSource struct {
+	fetch func(ctx context.Context) (*xoauth2.Token, error)
+
+	mutex  sync.Mutex
+	cached *xoauth2.Token
+}
+
+func (s *tokenSource) token(ctx context.Context) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.cached.Valid() && !jsonrpc.IsTokenRefresh(ctx) {
+		return s.cached.AccessToken, nil
+	}
+
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.cached = token
+
+	return token.AccessToken, nil
+}
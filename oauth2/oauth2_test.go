@@ -0,0 +1,80 @@
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	. "github.com/onsi/gomega"
+	xoauth2 "golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func TestClientCredentials(t *testing.T) {
+	RegisterTestingT(t)
+
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "myID" || secret != "mySecret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%v","token_type":"bearer","expires_in":3600}`, atomic.AddInt32(&issued, 1))
+	}))
+	defer tokenServer.Close()
+
+	var valid atomic.Value
+	valid.Store("token-1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		BearerToken: ClientCredentials(&clientcredentials.Config{
+			ClientID:     "myID",
+			ClientSecret: "mySecret",
+			TokenURL:     tokenServer.URL,
+		}),
+	})
+
+	_, err := rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("second")
+	Expect(err).To(BeNil())
+	Expect(atomic.LoadInt32(&issued)).To(Equal(int32(1)))
+
+	// the token was revoked, a new one is requested
+	valid.Store("token-2")
+	_, err = rpcClient.Call("third")
+	Expect(err).To(BeNil())
+	Expect(atomic.LoadInt32(&issued)).To(Equal(int32(2)))
+}
+
+func TestTokenSource(t *testing.T) {
+	RegisterTestingT(t)
+
+	var authorization atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		BearerToken: TokenSource(xoauth2.StaticTokenSource(&xoauth2.Token{AccessToken: "static"})),
+	})
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(authorization.Load()).To(Equal("Bearer static"))
+}
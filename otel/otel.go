@@ -0,0 +1,153 @@
+// Package otel adds OpenTelemetry tracing to jsonrpc clients.
+//
+// It is a separate module, so that the jsonrpc module does not depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	otelglobal "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/aurora-is-near/go-jsonrpc/v3/otel"
+
+// Opts configures the tracing.
+//
+// TracerProvider: creates the tracer (default the global TracerProvider)
+//
+// Propagator: injects the trace context into the http headers (default the global TextMapPropagator)
+type Opts struct {
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+}
+
+func (opts *Opts) tracer() trace.Tracer {
+	tracerProvider := otelglobal.GetTracerProvider()
+	if opts != nil && opts.TracerProvider != nil {
+		tracerProvider = opts.TracerProvider
+	}
+	return tracerProvider.Tracer(instrumentationName)
+}
+
+func (opts *Opts) propagator() propagation.TextMapPropagator {
+	if opts != nil && opts.Propagator != nil {
+		return opts.Propagator
+	}
+	return otelglobal.GetTextMapPropagator()
+}
+
+// Instrument returns a copy of opts with the Interceptor and the HTTPMiddleware of this package, e.g.
+//
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, otel.Instrument(&jsonrpc.RPCClientOpts{}, nil))
+//
+// opts: the options of the client, may be nil
+//
+// otelOpts: Opts provide custom configuration, may be nil
+func Instrument(opts *jsonrpc.RPCClientOpts, otelOpts *Opts) *jsonrpc.RPCClientOpts {
+	instrumented := jsonrpc.RPCClientOpts{}
+	if opts != nil {
+		instrumented = *opts
+	}
+
+	instrumented.Interceptors = append([]jsonrpc.Interceptor{Interceptor(otelOpts)}, instrumented.Interceptors...)
+	instrumented.HTTPMiddleware = append(instrumented.HTTPMiddleware, HTTPMiddleware(otelOpts))
+
+	return &instrumented
+}
+
+// Interceptor returns a jsonrpc.Interceptor that creates a client span for every call.
+// The span is named after the method ("batch" for batch calls) and records the error of the call
+// and the RPC error of a single response.
+func Interceptor(opts *Opts) jsonrpc.Interceptor {
+	tracer := opts.tracer()
+
+	return func(ctx context.Context, call *jsonrpc.CallInfo, invoke jsonrpc.Invoker) error {
+		attributes := []attribute.KeyValue{
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", call.Method()),
+		}
+		if call.Batch {
+			attributes = append(attributes, attribute.Int("rpc.jsonrpc.batch_size", len(call.Requests)))
+		} else if len(call.Requests) == 1 && call.Requests[0] != nil {
+			attributes = append(attributes,
+				attribute.String("rpc.jsonrpc.version", call.Requests[0].JSONRPC),
+				attribute.String("rpc.jsonrpc.request_id", call.Requests[0].ID.String()),
+			)
+		}
+
+		ctx, span := tracer.Start(ctx, call.Method(), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+		defer span.End()
+
+		err := invoke(ctx)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		errorCount := 0
+		for _, response := range call.Responses {
+			if response != nil && response.Error != nil {
+				errorCount++
+				if !call.Batch {
+					span.SetAttributes(
+						attribute.Int("rpc.jsonrpc.error_code", response.Error.Code),
+						attribute.String("rpc.jsonrpc.error_message", response.Error.Message),
+					)
+					span.SetStatus(codes.Error, response.Error.Message)
+				}
+			}
+		}
+		if call.Batch && errorCount > 0 {
+			span.SetAttributes(attribute.Int("rpc.jsonrpc.error_count", errorCount))
+		}
+
+		return nil
+	}
+}
+
+// HTTPMiddleware returns a middleware for jsonrpc.RPCClientOpts.HTTPMiddleware that injects the trace context
+// into the http headers and sets the endpoint of the request on the span of the call.
+func HTTPMiddleware(opts *Opts) func(http.RoundTripper) http.RoundTripper {
+	propagator := opts.propagator()
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			ctx := request.Context()
+			request = request.Clone(ctx)
+			propagator.Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+			span := trace.SpanFromContext(ctx)
+			if span.IsRecording() {
+				attributes := []attribute.KeyValue{
+					attribute.String("server.address", request.URL.Hostname()),
+					attribute.String("url.full", request.URL.Redacted()),
+				}
+				if port, err := strconv.Atoi(request.URL.Port()); err == nil {
+					attributes = append(attributes, attribute.Int("server.port", port))
+				}
+				span.SetAttributes(attributes...)
+			}
+
+			response, err := next.RoundTrip(request)
+			if response != nil && span.IsRecording() {
+				span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+			}
+
+			return response, err
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
@@ -0,0 +1,111 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func attributeMap(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestInstrument(t *testing.T) {
+	RegisterTestingT(t)
+
+	traceparents := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		if r.URL.Path == "/error" {
+			fmt.Fprint(w, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":0}`)
+			return
+		}
+		if r.URL.Path == "/batch" {
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","result":1,"id":0},{"jsonrpc":"2.0","error":{"code":1,"message":"failed"},"id":1}]`)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	opts := &Opts{TracerProvider: tracerProvider, Propagator: propagation.TraceContext{}}
+
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, Instrument(nil, opts))
+	_, err := rpcClient.Call("eth_blockNumber")
+	Expect(err).To(BeNil())
+
+	spans := recorder.Ended()
+	Expect(spans).To(HaveLen(1))
+	span := spans[0]
+	Expect(span.Name()).To(Equal("eth_blockNumber"))
+	Expect(span.Status().Code).To(Equal(codes.Unset))
+	attributes := attributeMap(span)
+	Expect(attributes["rpc.system"].AsString()).To(Equal("jsonrpc"))
+	Expect(attributes["rpc.method"].AsString()).To(Equal("eth_blockNumber"))
+	Expect(attributes["server.address"].AsString()).To(Equal("127.0.0.1"))
+	Expect(attributes["http.response.status_code"].AsInt64()).To(Equal(int64(200)))
+
+	// the trace context is propagated
+	traceparent := <-traceparents
+	Expect(traceparent).To(ContainSubstring(span.SpanContext().TraceID().String()))
+
+	// rpc errors
+	rpcClient = jsonrpc.NewClientWithOpts(server.URL+"/error", Instrument(nil, opts))
+	_, err = rpcClient.Call("unknown")
+	Expect(err).To(BeNil())
+	<-traceparents
+
+	span = recorder.Ended()[1]
+	Expect(span.Status().Code).To(Equal(codes.Error))
+	Expect(attributeMap(span)["rpc.jsonrpc.error_code"].AsInt64()).To(Equal(int64(-32601)))
+
+	// batches
+	rpcClient = jsonrpc.NewClientWithOpts(server.URL+"/batch", Instrument(nil, opts))
+	_, err = rpcClient.CallBatch(jsonrpc.RPCRequests{jsonrpc.NewRequest("a"), jsonrpc.NewRequest("b")})
+	Expect(err).To(BeNil())
+	<-traceparents
+
+	span = recorder.Ended()[2]
+	Expect(span.Name()).To(Equal("batch"))
+	Expect(attributeMap(span)["rpc.jsonrpc.batch_size"].AsInt64()).To(Equal(int64(2)))
+	Expect(attributeMap(span)["rpc.jsonrpc.error_count"].AsInt64()).To(Equal(int64(1)))
+}
+
+func TestInterceptor_Error(t *testing.T) {
+	RegisterTestingT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	rpcClient := jsonrpc.NewClientWithOpts("http://127.0.0.1:1", Instrument(nil, &Opts{TracerProvider: tracerProvider}))
+	_, err := rpcClient.Call("something")
+	Expect(err).NotTo(BeNil())
+
+	// the span of the caller is the parent
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "parent")
+	_, err = rpcClient.CallContext(ctx, "something")
+	Expect(err).NotTo(BeNil())
+	parent.End()
+
+	spans := recorder.Ended()
+	Expect(spans).To(HaveLen(3))
+	Expect(spans[0].Status().Code).To(Equal(codes.Error))
+	Expect(spans[0].Events()).To(HaveLen(1))
+	Expect(spans[1].Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+}
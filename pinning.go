@@ -0,0 +1,110 @@
+package jsonrpc
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrCertificatePin is returned if the certificate of a server does not match the pins of CertificatePinOpts.
+var ErrCertificatePin = errors.New("certificate does not match the pinned certificates")
+
+// CertificatePinOpts pins the certificates of https endpoints, see RPCClientOpts.
+//
+// Connections fail unless a certificate of the verified chain of the server matches one of the pins,
+// in addition to the normal certificate verification. Only the leaf certificate is matched if verification is skipped
+// (InsecureSkipVerify). Pin the key of an intermediate certificate authority
+// or add backup pins, so that renewed certificates are still accepted.
+//
+// SPKIHashes: base64 encoded SHA-256 hashes of the public keys (SubjectPublicKeyInfo) as returned by SPKIHash(),
+// e.g. from: openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//
+// CertificateHashes: hex encoded SHA-256 fingerprints of the certificates, colons are ignored
+//
+// Verify: called with the certificates of the server (leaf first) instead of the pins if there are none,
+// or after a pin matched. The connection fails if it returns an error.
+type CertificatePinOpts struct {
+	SPKIHashes        []string
+	CertificateHashes []string
+	Verify            func(certificates []*x509.Certificate) error
+}
+
+// SPKIHash returns the base64 encoded SHA-256 hash of the public key of certificate for CertificatePinOpts.SPKIHashes.
+func SPKIHash(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// pinTLSConfig returns a copy of config (or a new config if nil) that verifies the pins.
+func pinTLSConfig(config *tls.Config, opts CertificatePinOpts) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config = config.Clone()
+
+	spkiHashes := make(map[string]bool, len(opts.SPKIHashes))
+	for _, hash := range opts.SPKIHashes {
+		spkiHashes[hash] = true
+	}
+	certificateHashes := make(map[string]bool, len(opts.CertificateHashes))
+	for _, hash := range opts.CertificateHashes {
+		certificateHashes[strings.ToLower(strings.Replace(hash, ":", "", -1))] = true
+	}
+
+	verify := config.VerifyPeerCertificate
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if verify != nil {
+			if err := verify(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+
+		certificates := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			certificate, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certificates = append(certificates, certificate)
+		}
+
+		if len(spkiHashes) > 0 || len(certificateHashes) > 0 {
+			// the pins are only matched against the verified chains, the server can send any other certificate,
+			// e.g. the pinned one next to a leaf of another certificate authority.
+			// Without verification (InsecureSkipVerify) only the leaf is trusted to belong to the server.
+			candidates := certificates
+			if len(candidates) > 1 {
+				candidates = candidates[:1]
+			}
+			if len(verifiedChains) > 0 {
+				candidates = nil
+				for _, chain := range verifiedChains {
+					candidates = append(candidates, chain...)
+				}
+			}
+
+			matched := false
+			for _, certificate := range candidates {
+				hash := sha256.Sum256(certificate.Raw)
+				if spkiHashes[SPKIHash(certificate)] || certificateHashes[hex.EncodeToString(hash[:])] {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return ErrCertificatePin
+			}
+		}
+
+		if opts.Verify != nil {
+			return opts.Verify(certificates)
+		}
+		return nil
+	}
+
+	return config
+}
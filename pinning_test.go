@@ -0,0 +1,103 @@
+package jsonrpc
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCertificatePins(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca := newTestCertificate(nil, "ca")
+	server := newTLSServer(ca)
+	defer server.Close()
+	leaf := server.TLS.Certificates[0].Leaf
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.certificate)
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{newTestCertificate(ca, "client").tlsCertificate()},
+		RootCAs:      rootCAs,
+	}
+	call := func(pins CertificatePinOpts) error {
+		_, err := NewClientWithOpts(server.URL, &RPCClientOpts{TLSConfig: tlsConfig, CertificatePins: &pins}).Call("something")
+		return err
+	}
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	colons := strings.ToUpper(hex.EncodeToString(fingerprint[:1]) + ":" + hex.EncodeToString(fingerprint[1:]))
+
+	Expect(call(CertificatePinOpts{SPKIHashes: []string{SPKIHash(leaf)}})).To(Succeed())
+	Expect(call(CertificatePinOpts{SPKIHashes: []string{"backup", SPKIHash(ca.certificate)}})).To(Succeed())
+	Expect(call(CertificatePinOpts{CertificateHashes: []string{colons}})).To(Succeed())
+
+	// a certificate of another ca with the same name
+	other := newTestCertificate(nil, "ca")
+	err := call(CertificatePinOpts{SPKIHashes: []string{SPKIHash(other.certificate)}})
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring(ErrCertificatePin.Error()))
+
+	var certificates []*x509.Certificate
+	Expect(call(CertificatePinOpts{Verify: func(c []*x509.Certificate) error {
+		certificates = c
+		return nil
+	}})).To(Succeed())
+	Expect(certificates).To(HaveLen(1))
+	Expect(certificates[0].Subject.CommonName).To(Equal("server"))
+
+	err = call(CertificatePinOpts{SPKIHashes: []string{SPKIHash(leaf)}, Verify: func([]*x509.Certificate) error {
+		return errors.New("revoked")
+	}})
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("revoked"))
+
+	// the pins do not modify the shared config
+	Expect(tlsConfig.VerifyPeerCertificate).To(BeNil())
+}
+
+func TestCertificatePins_UnverifiedCertificates(t *testing.T) {
+	RegisterTestingT(t)
+
+	// the server has a valid certificate of another ca and sends the public pinned certificate along with it
+	pinned := newTestCertificate(nil, "pinned ca")
+	other := newTestCertificate(nil, "other ca")
+	leaf := newTestCertificate(other, "server")
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","result":"ok","id":0}`))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.der, pinned.der},
+		PrivateKey:  leaf.key,
+	}}}
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(pinned.certificate)
+	rootCAs.AddCert(other.certificate)
+	call := func(tlsConfig *tls.Config, pins CertificatePinOpts) error {
+		_, err := NewClientWithOpts(server.URL, &RPCClientOpts{TLSConfig: tlsConfig, CertificatePins: &pins}).Call("something")
+		return err
+	}
+
+	err := call(&tls.Config{RootCAs: rootCAs}, CertificatePinOpts{SPKIHashes: []string{SPKIHash(pinned.certificate)}})
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring(ErrCertificatePin.Error()))
+	Expect(call(&tls.Config{RootCAs: rootCAs}, CertificatePinOpts{SPKIHashes: []string{SPKIHash(other.certificate)}})).To(Succeed())
+
+	// without verification only the leaf is matched
+	insecure := &tls.Config{InsecureSkipVerify: true}
+	err = call(insecure, CertificatePinOpts{SPKIHashes: []string{SPKIHash(pinned.certificate)}})
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring(ErrCertificatePin.Error()))
+	Expect(call(insecure, CertificatePinOpts{SPKIHashes: []string{SPKIHash(leaf.certificate)}})).To(Succeed())
+}
@@ -0,0 +1,104 @@
+package jsonrpc
+
+import (
+	"context"
+	"path"
+	"time"
+)
+
+// MethodPolicy configures the retries and the timeout of calls of specific methods, see RPCClientOpts.
+//
+// Method: the method name or a pattern as used by path.Match(), e.g. "eth_get*"
+//
+// Retry: the retry policy for the methods, replaces the retry policy of the client, which is used if nil
+//
+// NoRetry: disables retries for the methods, e.g. for methods that are not idempotent
+//
+// Timeout: the maximum duration of a call including all retries, no timeout if 0
+type MethodPolicy struct {
+	Method  string
+	Retry   *RetryPolicy
+	NoRetry bool
+	Timeout time.Duration
+}
+
+// methodPolicy is a MethodPolicy with the defaults of the retry policy applied.
+type methodPolicy struct {
+	method  string
+	retry   *RetryPolicy
+	timeout time.Duration
+}
+
+func newMethodPolicies(policies []MethodPolicy, retry *RetryPolicy) []methodPolicy {
+	methodPolicies := make([]methodPolicy, 0, len(policies))
+	for _, policy := range policies {
+		p := methodPolicy{
+			method:  policy.Method,
+			retry:   retry,
+			timeout: policy.Timeout,
+		}
+		if policy.NoRetry {
+			p.retry = nil
+		} else if policy.Retry != nil {
+			p.retry = policy.Retry.withDefaults()
+		}
+		methodPolicies = append(methodPolicies, p)
+	}
+
+	return methodPolicies
+}
+
+// policy returns the retry policy for a call of the methods and ctx with the timeout of their method policy
+// and the idempotency key of the call. A batch call only uses a method policy if all its methods match the same policy.
+// The returned cancel function must be called when the call is done.
+func (client *rpcClient) policy(ctx context.Context, methods ...string) (context.Context, context.CancelFunc, *RetryPolicy) {
+	ctx = client.withIdempotencyKey(ctx, methods)
+
+	match := -1
+	for i, method := range methods {
+		m := client.matchPolicy(method)
+		if i > 0 && m != match {
+			match = -1
+			break
+		}
+		match = m
+	}
+
+	if match < 0 {
+		return ctx, func() {}, client.retry
+	}
+
+	policy := client.methodPolicies[match]
+	if policy.timeout <= 0 {
+		return ctx, func() {}, policy.retry
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, policy.timeout)
+	return ctx, cancel, policy.retry
+}
+
+// matchPolicy returns the index of the first method policy that matches method, -1 if there is none.
+func (client *rpcClient) matchPolicy(method string) int {
+	for i, policy := range client.methodPolicies {
+		if policy.method == method {
+			return i
+		}
+		if matched, err := path.Match(policy.method, method); err == nil && matched {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// batchMethods returns the methods of the requests of a batch.
+func batchMethods(requests RPCRequests) []string {
+	methods := make([]string, len(requests))
+	for i, request := range requests {
+		if request != nil {
+			methods[i] = request.Method
+		}
+	}
+
+	return methods
+}
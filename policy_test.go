@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMethodPolicies(t *testing.T) {
+	RegisterTestingT(t)
+
+	down, attempts := newFlakyServer(1000, http.StatusServiceUnavailable)
+	defer down.Close()
+
+	rpcClient := NewClientWithOpts(down.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		MethodPolicies: []MethodPolicy{
+			{Method: "broadcast_tx_commit", NoRetry: true},
+			{Method: "eth_*", Retry: &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}},
+			{Method: "eth_sendRawTransaction", NoRetry: true}, // never used, eth_* matches first
+		},
+	})
+
+	count := func(method string) int32 {
+		atomic.StoreInt32(attempts, 0)
+		_, err := rpcClient.Call(method)
+		Expect(err).NotTo(BeNil())
+		return atomic.LoadInt32(attempts)
+	}
+
+	Expect(count("broadcast_tx_commit")).To(Equal(int32(1)))
+	Expect(count("eth_call")).To(Equal(int32(5)))
+	Expect(count("eth_sendRawTransaction")).To(Equal(int32(5)))
+	Expect(count("net_version")).To(Equal(int32(2)))
+
+	// batches use a method policy only if all methods match it
+	atomic.StoreInt32(attempts, 0)
+	_, err := rpcClient.CallBatch(RPCRequests{NewRequest("eth_call"), NewRequest("eth_getBalance")})
+	Expect(err).NotTo(BeNil())
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(5)))
+
+	atomic.StoreInt32(attempts, 0)
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("eth_call"), NewRequest("broadcast_tx_commit")})
+	Expect(err).NotTo(BeNil())
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(2)))
+}
+
+func TestMethodPolicies_Timeout(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		MethodPolicies: []MethodPolicy{
+			{Method: "eth_call", Timeout: 20 * time.Millisecond},
+		},
+	})
+
+	start := time.Now()
+	_, err := rpcClient.Call("eth_call")
+	Expect(err).NotTo(BeNil())
+	Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+
+	// a shorter deadline of the caller is kept
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = rpcClient.CallContext(ctx, "eth_call")
+	Expect(err).NotTo(BeNil())
+	Expect(ctx.Err()).To(Equal(context.DeadlineExceeded))
+}
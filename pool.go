@@ -0,0 +1,73 @@
+package jsonrpc
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// ConnectionPoolOpts tunes the connections of the http transport that is created for the client, see RPCClientOpts.
+// Zero values keep the defaults of http.DefaultTransport.
+//
+// MaxIdleConns: the maximum number of idle connections to all hosts (default 100)
+//
+// MaxIdleConnsPerHost: the maximum number of idle connections per host (default 2).
+// Raise it for high request rates, otherwise connections are closed and opened again under load.
+//
+// MaxConnsPerHost: the maximum number of connections per host including active ones (default 0: no limit)
+//
+// IdleConnTimeout: how long an idle connection is kept open (default 90s)
+//
+// DialTimeout: the timeout of establishing a tcp connection (default 30s)
+//
+// KeepAlive: the interval of tcp keep-alive probes (default 30s)
+//
+// TLSHandshakeTimeout: the timeout of the TLS handshake (default 10s)
+type ConnectionPoolOpts struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	TLSHandshakeTimeout time.Duration
+}
+
+// apply sets the options on transport.
+func (opts *ConnectionPoolOpts) apply(transport *http.Transport) {
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	if opts.DialTimeout > 0 || opts.KeepAlive > 0 {
+		transport.DialContext = opts.dialer().DialContext
+	}
+}
+
+// dialer returns a dialer with the timeout and keep-alive of the options.
+func (opts *ConnectionPoolOpts) dialer() *net.Dialer {
+	dialer := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive}
+	if opts != nil && opts.DialTimeout > 0 {
+		dialer.Timeout = opts.DialTimeout
+	}
+	if opts != nil && opts.KeepAlive > 0 {
+		dialer.KeepAlive = opts.KeepAlive
+	}
+	return dialer
+}
@@ -0,0 +1,81 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+)
+
+func TestConnectionPool(t *testing.T) {
+	RegisterTestingT(t)
+
+	pool := &ConnectionPoolOpts{
+		MaxIdleConns:        500,
+		MaxIdleConnsPerHost: 50,
+		MaxConnsPerHost:     100,
+		IdleConnTimeout:     time.Minute,
+		DialTimeout:         time.Second,
+		TLSHandshakeTimeout: 2 * time.Second,
+	}
+	for _, protocol := range []HTTPProtocol{HTTPProtocolDefault, HTTPProtocolHTTP2} {
+		transport := NewHTTPTransport("http://localhost", &RPCClientOpts{ConnectionPool: pool, HTTPProtocol: protocol}).(*httpTransport)
+		roundTripper := transport.httpClient.Transport.(*http.Transport)
+		Expect(roundTripper).NotTo(BeIdenticalTo(http.DefaultTransport))
+		Expect(roundTripper.MaxIdleConns).To(Equal(500))
+		Expect(roundTripper.MaxIdleConnsPerHost).To(Equal(50))
+		Expect(roundTripper.MaxConnsPerHost).To(Equal(100))
+		Expect(roundTripper.IdleConnTimeout).To(Equal(time.Minute))
+		Expect(roundTripper.TLSHandshakeTimeout).To(Equal(2 * time.Second))
+	}
+
+	// zero values keep the defaults
+	transport := NewHTTPTransport("http://localhost", &RPCClientOpts{ConnectionPool: &ConnectionPoolOpts{MaxIdleConnsPerHost: 10}}).(*httpTransport)
+	roundTripper := transport.httpClient.Transport.(*http.Transport)
+	defaults := http.DefaultTransport.(*http.Transport)
+	Expect(roundTripper.MaxIdleConnsPerHost).To(Equal(10))
+	Expect(roundTripper.MaxIdleConns).To(Equal(defaults.MaxIdleConns))
+	Expect(roundTripper.IdleConnTimeout).To(Equal(defaults.IdleConnTimeout))
+	Expect(defaults.MaxIdleConnsPerHost).To(Equal(0))
+
+	transport = NewHTTPTransport("http://localhost", &RPCClientOpts{ConnectionPool: pool, HTTPProtocol: HTTPProtocolH2C}).(*httpTransport)
+	Expect(transport.httpClient.Transport).To(BeAssignableToTypeOf(&http2.Transport{}))
+}
+
+func TestConnectionPoolMaxConnsPerHost(t *testing.T) {
+	RegisterTestingT(t)
+
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":1,"id":0}`)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{ConnectionPool: &ConnectionPoolOpts{MaxConnsPerHost: 2, MaxIdleConnsPerHost: 2}})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := rpcClient.Call("something")
+			Expect(err).To(BeNil())
+		}()
+	}
+	wg.Wait()
+
+	Expect(atomic.LoadInt32(&connections)).To(BeNumerically("<=", 2))
+}
@@ -0,0 +1,28 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/url"
+	"runtime/pprof"
+)
+
+// pprofInterceptor labels the goroutine of a call with the method of the call, see RPCClientOpts.PprofLabels.
+func pprofInterceptor(ctx context.Context, call *CallInfo, invoke Invoker) error {
+	var err error
+	pprof.Do(ctx, pprof.Labels("rpc_method", call.Method()), func(ctx context.Context) {
+		err = invoke(ctx)
+	})
+	return err
+}
+
+// endpointLabel returns endpoint without credentials, query and fragment, which may be secret.
+func endpointLabel(endpoint string) string {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" {
+		return endpoint
+	}
+	endpointURL.User = nil
+	endpointURL.RawQuery = ""
+	endpointURL.Fragment = ""
+	return endpointURL.String()
+}
@@ -0,0 +1,81 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchProgress is the progress of a batch call, see WithBatchProgress().
+//
+// Completed: the number of requests whose http request completed, including notifications
+//
+// Total: the number of requests of the batch
+//
+// Errors: the number of responses with an RPCError so far
+//
+// Chunks: the number of http requests of the batch, more than 1 if it is split by MaxBatchSize
+//
+// CompletedChunks: the number of completed http requests
+type BatchProgress struct {
+	Completed       int
+	Total           int
+	Errors          int
+	Chunks          int
+	CompletedChunks int
+}
+
+type batchProgressContextKey struct{}
+
+// WithBatchProgress returns a copy of ctx that calls onProgress when an http request of a batch call that uses
+// the context completed, e.g. to display the progress of a backfill split into many chunks by MaxBatchSize.
+// onProgress is not called concurrently, also if chunks are sent in parallel.
+//
+// If onProgress returns an error, the batch call is aborted: the remaining chunks are not sent and the error
+// is returned wrapped by the batch call.
+func WithBatchProgress(ctx context.Context, onProgress func(BatchProgress) error) context.Context {
+	return context.WithValue(ctx, batchProgressContextKey{}, onProgress)
+}
+
+// batchProgress tracks the progress of a batch call and reports it to the callback of WithBatchProgress().
+type batchProgress struct {
+	mutex      sync.Mutex
+	progress   BatchProgress
+	onProgress func(BatchProgress) error
+}
+
+// newBatchProgress returns the progress of a batch call with ctx, nil if ctx has no progress callback.
+func newBatchProgress(ctx context.Context, total, chunks int) *batchProgress {
+	onProgress, _ := ctx.Value(batchProgressContextKey{}).(func(BatchProgress) error)
+	if onProgress == nil {
+		return nil
+	}
+
+	return &batchProgress{
+		progress:   BatchProgress{Total: total, Chunks: chunks},
+		onProgress: onProgress,
+	}
+}
+
+// chunkDone reports the responses of a completed chunk of requests, the error of the callback aborts the batch.
+// It is a no-op for nil.
+func (p *batchProgress) chunkDone(requests int, responses []*RPCResponse) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.progress.Completed += requests
+	p.progress.CompletedChunks++
+	for _, response := range responses {
+		if response != nil && response.Error != nil {
+			p.progress.Errors++
+		}
+	}
+
+	if err := p.onProgress(p.progress); err != nil {
+		return fmt.Errorf("rpc batch call: aborted: %w", err)
+	}
+	return nil
+}
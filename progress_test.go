@@ -0,0 +1,96 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWithBatchProgress(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests int32
+	server := newBatchServer(func(request *RPCRequest) *RPCResponse {
+		atomic.AddInt32(&requests, 1)
+		if request.Method == "fail" {
+			return &RPCResponse{Error: &RPCError{Code: 1, Message: "failed"}}
+		}
+		return &RPCResponse{Result: request.Method}
+	})
+	defer server.Close()
+
+	batch := func() RPCRequests {
+		return RPCRequests{NewRequest("a"), NewRequest("fail"), NewRequest("c"), NewRequest("fail"), NewRequest("e")}
+	}
+
+	var progress []BatchProgress
+	ctx := WithBatchProgress(context.Background(), func(p BatchProgress) error {
+		progress = append(progress, p)
+		return nil
+	})
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 2})
+	_, err := rpcClient.CallBatchContext(ctx, batch())
+	Expect(err).To(BeNil())
+	Expect(progress).To(Equal([]BatchProgress{
+		{Completed: 2, Total: 5, Errors: 1, Chunks: 3, CompletedChunks: 1},
+		{Completed: 4, Total: 5, Errors: 2, Chunks: 3, CompletedChunks: 2},
+		{Completed: 5, Total: 5, Errors: 2, Chunks: 3, CompletedChunks: 3},
+	}))
+
+	// a batch that is not split
+	progress = nil
+	_, err = NewClient(server.URL).CallBatchContext(ctx, batch())
+	Expect(err).To(BeNil())
+	Expect(progress).To(Equal([]BatchProgress{{Completed: 5, Total: 5, Errors: 2, Chunks: 1, CompletedChunks: 1}}))
+
+	// streamed batches
+	progress = nil
+	err = rpcClient.CallBatchStream(ctx, batch(), func(*RPCResponse) {})
+	Expect(err).To(BeNil())
+	Expect(progress).To(HaveLen(3))
+	Expect(progress[2]).To(Equal(BatchProgress{Completed: 5, Total: 5, Errors: 2, Chunks: 3, CompletedChunks: 3}))
+
+	// parallel chunks
+	progress = nil
+	parallel := NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 1, BatchParallelism: 3})
+	_, err = parallel.CallBatchContext(ctx, batch())
+	Expect(err).To(BeNil())
+	Expect(progress).To(HaveLen(5))
+	for i, p := range progress {
+		Expect(p.Completed).To(Equal(i + 1))
+	}
+	Expect(progress[4].Errors).To(Equal(2))
+}
+
+func TestWithBatchProgress_Abort(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests int32
+	server := newBatchServer(func(request *RPCRequest) *RPCResponse {
+		atomic.AddInt32(&requests, 1)
+		return &RPCResponse{Error: &RPCError{Code: 1, Message: "failed"}}
+	})
+	defer server.Close()
+
+	errTooManyErrors := errors.New("too many errors")
+	ctx := WithBatchProgress(context.Background(), func(p BatchProgress) error {
+		if p.Errors >= 4 {
+			return errTooManyErrors
+		}
+		return nil
+	})
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 2})
+	_, err := rpcClient.CallBatchContext(ctx, RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c"), NewRequest("d"), NewRequest("e")})
+	Expect(errors.Is(err, errTooManyErrors)).To(BeTrue())
+	Expect(err.Error()).To(Equal("rpc batch call: aborted: too many errors"))
+	// the last chunk was not sent
+	Expect(atomic.LoadInt32(&requests)).To(Equal(int32(4)))
+
+	err = rpcClient.CallBatchStream(ctx, RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c"), NewRequest("d"), NewRequest("e")}, func(*RPCResponse) {})
+	Expect(errors.Is(err, errTooManyErrors)).To(BeTrue())
+}
@@ -0,0 +1,147 @@
+// Package prometheus records Prometheus metrics of jsonrpc clients.
+//
+// It is a separate module, so that the jsonrpc module does not depend on Prometheus.
+package prometheus
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Opts configures the metrics.
+//
+// Namespace: prefix of the metric names (default "jsonrpc")
+//
+// Subsystem: optional second prefix of the metric names, e.g. to distinguish multiple clients
+//
+// Registerer: registers the metrics (default prometheus.DefaultRegisterer)
+//
+// Buckets: buckets of the latency histogram in seconds (default prometheus.DefBuckets)
+type Opts struct {
+	Namespace  string
+	Subsystem  string
+	Registerer prom.Registerer
+	Buckets    []float64
+}
+
+// Metrics implements jsonrpc.Metrics, set it as RPCClientOpts.Metrics.
+// The metrics are labeled by method ("batch" for batch calls) and endpoint:
+//
+//	<namespace>_client_requests_total: the number of calls
+//	<namespace>_client_errors_total: the number of errors by code, which is the RPC error code,
+//	  the http status code of an HTTPError or "transport" for any other error of the call
+//	<namespace>_client_retries_total: the number of retried attempts
+//	<namespace>_client_request_duration_seconds: histogram of the duration of the calls including retries
+type Metrics struct {
+	requests *prom.CounterVec
+	errors   *prom.CounterVec
+	retries  *prom.CounterVec
+	duration *prom.HistogramVec
+}
+
+// NewMetrics creates and registers the metrics.
+// An error is returned if the metrics can not be registered, e.g. because they are already registered.
+// Use a different Namespace, Subsystem or Registerer for every client.
+//
+// opts: Opts provide custom configuration, may be nil
+func NewMetrics(opts *Opts) (*Metrics, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "jsonrpc"
+	}
+	registerer := opts.Registerer
+	if registerer == nil {
+		registerer = prom.DefaultRegisterer
+	}
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prom.DefBuckets
+	}
+	subsystem := "client"
+	if opts.Subsystem != "" {
+		subsystem = opts.Subsystem + "_client"
+	}
+
+	labels := []string{"method", "endpoint"}
+	metrics := &Metrics{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Number of JSON-RPC calls.",
+		}, labels),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "errors_total",
+			Help:      "Number of JSON-RPC errors by code.",
+		}, append(labels, "code")),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "retries_total",
+			Help:      "Number of retried JSON-RPC requests.",
+		}, labels),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of JSON-RPC calls including retries.",
+			Buckets:   buckets,
+		}, labels),
+	}
+
+	for _, collector := range []prom.Collector{metrics.requests, metrics.errors, metrics.retries, metrics.duration} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return metrics, nil
+}
+
+// ObserveCall implements jsonrpc.Metrics.
+func (m *Metrics) ObserveCall(call *jsonrpc.CallInfo) {
+	method, endpoint := call.Method(), endpointLabel(call.Endpoint)
+
+	m.requests.WithLabelValues(method, endpoint).Inc()
+	m.duration.WithLabelValues(method, endpoint).Observe(call.Duration.Seconds())
+	if call.Attempts > 1 {
+		m.retries.WithLabelValues(method, endpoint).Add(float64(call.Attempts - 1))
+	}
+
+	if call.Err != nil {
+		code := "transport"
+		var httpError *jsonrpc.HTTPError
+		if errors.As(call.Err, &httpError) {
+			code = strconv.Itoa(httpError.Code)
+		}
+		m.errors.WithLabelValues(method, endpoint, code).Inc()
+		return
+	}
+
+	for _, response := range call.Responses {
+		if response != nil && response.Error != nil {
+			m.errors.WithLabelValues(method, endpoint, strconv.Itoa(response.Error.Code)).Inc()
+		}
+	}
+}
+
+// endpointLabel removes credentials, query and fragment of the endpoint, they may be secret.
+func endpointLabel(endpoint string) string {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" {
+		return endpoint
+	}
+	endpointURL.User = nil
+	endpointURL.RawQuery = ""
+	endpointURL.Fragment = ""
+	return endpointURL.String()
+}
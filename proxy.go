@@ -0,0 +1,127 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyOpts sends the requests through a proxy, see RPCClientOpts.
+//
+// URL: the url of the proxy, http://, https:// or socks5://, e.g. "socks5://proxy.internal:1080".
+// Credentials can be set as user info of the url or with Username and Password.
+//
+// Username, Password: the credentials for the proxy, sent as Proxy-Authorization for http proxies
+// and with username/password authentication for SOCKS5 proxies
+//
+// NoProxy: the hosts that are connected directly, in the format of the NO_PROXY environment variable:
+// comma separated host names (matching also their subdomains, a leading "." or "*." is ignored), IP addresses and CIDR ranges,
+// each with an optional port, or "*" for all hosts
+type ProxyOpts struct {
+	URL      string
+	Username string
+	Password string
+	NoProxy  string
+}
+
+// proxyFunc returns the proxy function for http.Transport and websocket.Dialer.
+func (opts *ProxyOpts) proxyFunc() func(*http.Request) (*url.URL, error) {
+	proxyURL, err := url.Parse(opts.URL)
+	if err == nil && (proxyURL.Scheme == "" || proxyURL.Host == "") {
+		err = fmt.Errorf("proxy url %q must have a scheme and host", opts.URL)
+	}
+	if err == nil && opts.Username != "" {
+		proxyURL.User = url.UserPassword(opts.Username, opts.Password)
+	}
+	noProxy := parseNoProxy(opts.NoProxy)
+
+	return func(request *http.Request) (*url.URL, error) {
+		if err != nil {
+			return nil, MarkPermanent(err)
+		}
+		if noProxy.match(request.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// noProxyRules are the parsed rules of ProxyOpts.NoProxy.
+type noProxyRules struct {
+	all   bool
+	hosts []noProxyHost
+	cidrs []*net.IPNet
+}
+
+// noProxyHost is a host name or IP address with an optional port.
+type noProxyHost struct {
+	host string
+	port string
+}
+
+func parseNoProxy(noProxy string) noProxyRules {
+	var rules noProxyRules
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			rules.all = true
+			continue
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			rules.cidrs = append(rules.cidrs, cidr)
+			continue
+		}
+
+		var rule noProxyHost
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			rule.host, rule.port = host, port
+		} else {
+			rule.host = strings.Trim(entry, "[]")
+		}
+		rule.host = strings.TrimPrefix(strings.TrimPrefix(rule.host, "*"), ".")
+		rules.hosts = append(rules.hosts, rule)
+	}
+	return rules
+}
+
+// match returns true if the host of u is connected directly.
+func (rules noProxyRules) match(u *url.URL) bool {
+	if rules.all {
+		return true
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" || u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+
+	ip := net.ParseIP(host)
+	for _, cidr := range rules.cidrs {
+		if ip != nil && cidr.Contains(ip) {
+			return true
+		}
+	}
+	for _, rule := range rules.hosts {
+		if rule.port != "" && rule.port != port {
+			continue
+		}
+		if ip != nil {
+			if ruleIP := net.ParseIP(rule.host); ruleIP != nil && ruleIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if host == rule.host || strings.HasSuffix(host, "."+rule.host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package jsonrpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// socks5TestServer is a SOCKS5 proxy with username/password authentication that connects every request to target.
+type socks5TestServer struct {
+	listener net.Listener
+	target   string
+
+	mutex     sync.Mutex
+	addresses []string
+}
+
+func newSOCKS5TestServer(target string) *socks5TestServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	s := &socks5TestServer{listener: listener, target: target}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socks5TestServer) URL() string {
+	return "socks5://" + s.listener.Addr().String()
+}
+
+func (s *socks5TestServer) Close() {
+	s.listener.Close()
+}
+
+func (s *socks5TestServer) serve(conn net.Conn) {
+	defer conn.Close()
+	read := func(n int) []byte {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil
+		}
+		return buf
+	}
+
+	// greeting: version, methods
+	header := read(2)
+	if header == nil || read(int(header[1])) == nil {
+		return
+	}
+	conn.Write([]byte{5, 2})
+
+	// username/password authentication
+	version := read(2)
+	if version == nil {
+		return
+	}
+	username := string(read(int(version[1])))
+	password := string(read(int(read(1)[0])))
+	if username != "user" || password != "secret" {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	// connect request: version, command, reserved, address type, address, port
+	request := read(4)
+	var host string
+	switch request[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		host = string(read(int(read(1)[0])))
+	default:
+		return
+	}
+	port := binary.BigEndian.Uint16(read(2))
+	s.mutex.Lock()
+	s.addresses = append(s.addresses, net.JoinHostPort(host, strconv.Itoa(int(port))))
+	s.mutex.Unlock()
+
+	upstream, err := net.Dial("tcp", s.target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestProxyHTTP(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests []*http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"proxied","id":0}`)
+	}))
+	defer proxy.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"direct","id":0}`)
+	}))
+	defer server.Close()
+
+	proxyOpts := &ProxyOpts{URL: proxy.URL, Username: "user", Password: "secret", NoProxy: "localhost, .internal:8545"}
+	for _, protocol := range []HTTPProtocol{HTTPProtocolDefault, HTTPProtocolHTTP2} {
+		requests = nil
+		res, err := NewClientWithOpts("http://rpc.example.com/rpc", &RPCClientOpts{Proxy: proxyOpts, HTTPProtocol: protocol}).Call("something")
+		Expect(err).To(BeNil())
+		Expect(res.Result).To(Equal("proxied"))
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].RequestURI).To(Equal("http://rpc.example.com/rpc"))
+		user, password, _ := (&http.Request{Header: http.Header{"Authorization": requests[0].Header["Proxy-Authorization"]}}).BasicAuth()
+		Expect(user + ":" + password).To(Equal("user:secret"))
+	}
+
+	// the server is not proxied with NoProxy
+	requests = nil
+	proxyOpts.NoProxy = "127.0.0.1"
+	res, err := NewClientWithOpts(server.URL, &RPCClientOpts{Proxy: proxyOpts}).Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("direct"))
+	Expect(requests).To(BeEmpty())
+
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{Proxy: &ProxyOpts{URL: "proxy:8080"}}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring(`proxy url "proxy:8080" must have a scheme and host`))
+	Expect(IsRetryable(err)).To(BeFalse())
+}
+
+func TestProxySOCKS5(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%q,"id":0}`, r.Host)
+	}))
+	defer server.Close()
+	proxy := newSOCKS5TestServer(server.Listener.Addr().String())
+	defer proxy.Close()
+
+	res, err := NewClientWithOpts("http://rpc.internal:8545", &RPCClientOpts{
+		Proxy: &ProxyOpts{URL: proxy.URL(), Username: "user", Password: "secret"},
+	}).Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("rpc.internal:8545"))
+
+	proxyURL, _ := url.Parse(proxy.URL())
+	proxyURL.User = url.UserPassword("user", "wrong")
+	_, err = NewClientWithOpts("http://rpc.internal:8545", &RPCClientOpts{Proxy: &ProxyOpts{URL: proxyURL.String()}}).Call("something")
+	Expect(err).To(HaveOccurred())
+
+	Expect(proxy.addresses).To(Equal([]string{"rpc.internal:8545"}))
+}
+
+func TestProxyWebSocket(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newWSTestServer()
+	defer server.Close()
+	proxy := newSOCKS5TestServer(server.Listener.Addr().String())
+	defer proxy.Close()
+
+	rpcClient, err := DialWSWithOpts("ws://rpc.internal/ws", &WSClientOpts{RPCClientOpts: RPCClientOpts{
+		Proxy: &ProxyOpts{URL: "socks5://user:secret@" + proxy.listener.Addr().String()},
+	}})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("something"))
+	Expect(proxy.addresses).To(Equal([]string{"rpc.internal:80"}))
+}
+
+func TestNoProxy(t *testing.T) {
+	RegisterTestingT(t)
+
+	rules := parseNoProxy("example.com, *.internal, .corp:8443, 10.0.0.0/8, ::1, [fd00::1]:8545, 192.168.1.1")
+	tests := map[string]bool{
+		"http://example.com":          true,
+		"https://rpc.example.com/rpc": true,
+		"http://notexample.com":       false,
+		"http://rpc.internal:8545":    true,
+		"https://rpc.corp:8443":       true,
+		"https://rpc.corp":            false,
+		"http://10.1.2.3:8545":        true,
+		"http://11.1.2.3":             false,
+		"http://[::1]:8545":           true,
+		"http://[fd00::1]:8545":       true,
+		"http://[fd00::1]:80":         false,
+		"wss://192.168.1.1":           true,
+		"http://RPC.Example.COM":      true,
+	}
+	for rawURL, expected := range tests {
+		u, err := url.Parse(rawURL)
+		Expect(err).To(BeNil())
+		Expect(rules.match(u)).To(Equal(expected), rawURL)
+	}
+
+	all, _ := url.Parse("http://anything")
+	Expect(parseNoProxy(" * ").match(all)).To(BeTrue())
+	Expect(parseNoProxy("").match(all)).To(BeFalse())
+}
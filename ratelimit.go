@@ -0,0 +1,226 @@
+package jsonrpc
+
+import (
+	"context"
+	"math"
+	"net"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRateLimitIdleTimeout = 10 * time.Minute
+
+// RateLimit is a token bucket: Rate tokens per second are added up to Burst tokens and every request takes a token.
+//
+// Rate: the allowed requests per second, unlimited if 0
+//
+// Burst: the allowed requests at once (default: Rate rounded up, at least 1)
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitOpts configures RateLimitMiddleware().
+//
+// Limit: the limit of every client for the methods without own limit
+//
+// Methods: own limits for methods by name or pattern as used by path.Match(), e.g. "debug_*".
+// The requests of a client are counted separately for every entry. If several patterns match a method,
+// the exact name is used, otherwise the most specific pattern (with the most characters that are not wildcards).
+//
+// Key: returns the key of the client of a request, e.g. an API key (default RateLimitByIP).
+// Requests with an empty key are not limited.
+//
+// IdleTimeout: the buckets of clients without requests are removed after this time (default 10min)
+type RateLimitOpts struct {
+	Limit       RateLimit
+	Methods     map[string]RateLimit
+	Key         func(ctx context.Context, request *ServerRequest) string
+	IdleTimeout time.Duration
+}
+
+// RateLimitByIP returns the ip address of the http client of a request, or "" if it was not received over http.
+func RateLimitByIP(ctx context.Context, request *ServerRequest) string {
+	httpRequest := HTTPRequestFromContext(ctx)
+	if httpRequest == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(httpRequest.RemoteAddr)
+	if err != nil {
+		return httpRequest.RemoteAddr
+	}
+	return host
+}
+
+// RateLimitByHeader returns a key function for RateLimitOpts that limits the clients by the value
+// of an http header, e.g. "X-Api-Key", or by ip address if the request has no such header.
+func RateLimitByHeader(name string) func(ctx context.Context, request *ServerRequest) string {
+	return func(ctx context.Context, request *ServerRequest) string {
+		if httpRequest := HTTPRequestFromContext(ctx); httpRequest != nil {
+			if value := httpRequest.Header.Get(name); value != "" {
+				return name + ":" + value
+			}
+		}
+		return RateLimitByIP(ctx, request)
+	}
+}
+
+// RateLimitMiddleware returns a server Middleware that limits the requests per client with token buckets.
+//
+// Requests over the limit are rejected with the error code -32005 (limit exceeded) of EIP-1474,
+// the data of the error has the seconds until the next request is allowed, e.g. {"retryAfter":0.25}.
+func RateLimitMiddleware(opts *RateLimitOpts) Middleware {
+	return newRateLimiter(opts).middleware
+}
+
+// rateLimiter holds the token buckets of the clients.
+type rateLimiter struct {
+	limit       RateLimit
+	methods     map[string]RateLimit
+	patterns    []string
+	key         func(ctx context.Context, request *ServerRequest) string
+	idleTimeout time.Duration
+
+	mutex     sync.Mutex
+	buckets   map[rateLimitKey]*tokenBucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+// rateLimitKey identifies the bucket of a client for a limit, limit is the method pattern or "" for the default limit.
+type rateLimitKey struct {
+	limit  string
+	client string
+}
+
+// tokenBucket holds the tokens of a client at the time last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(opts *RateLimitOpts) *rateLimiter {
+	limiterOpts := RateLimitOpts{}
+	if opts != nil {
+		limiterOpts = *opts
+	}
+
+	l := &rateLimiter{
+		limit:       limiterOpts.Limit,
+		methods:     limiterOpts.Methods,
+		key:         limiterOpts.Key,
+		idleTimeout: limiterOpts.IdleTimeout,
+		buckets:     make(map[rateLimitKey]*tokenBucket),
+		now:         time.Now,
+	}
+	for pattern := range l.methods {
+		l.patterns = append(l.patterns, pattern)
+	}
+	sort.Slice(l.patterns, func(i, j int) bool {
+		return morePatternSpecific(l.patterns[i], l.patterns[j])
+	})
+	if l.key == nil {
+		l.key = RateLimitByIP
+	}
+	if l.idleTimeout <= 0 {
+		l.idleTimeout = defaultRateLimitIdleTimeout
+	}
+	return l
+}
+
+func (l *rateLimiter) middleware(next Handler) Handler {
+	return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		client := l.key(ctx, request)
+		if client == "" {
+			return next(ctx, request)
+		}
+
+		pattern, limit := l.methodLimit(request.Method)
+		if wait := l.take(rateLimitKey{limit: pattern, client: client}, limit); wait > 0 {
+			retryAfter := math.Ceil(wait.Seconds()*1000) / 1000
+			return nil, &RPCError{
+				Code:    rpcErrorLimitExceeded,
+				Message: "rate limit exceeded",
+				Data:    map[string]float64{"retryAfter": retryAfter},
+			}
+		}
+		return next(ctx, request)
+	}
+}
+
+// methodLimit returns the pattern and limit of method, or "" and the default limit.
+func (l *rateLimiter) methodLimit(method string) (string, RateLimit) {
+	if limit, ok := l.methods[method]; ok {
+		return method, limit
+	}
+	for _, pattern := range l.patterns {
+		if ok, err := path.Match(pattern, method); err == nil && ok {
+			return pattern, l.methods[pattern]
+		}
+	}
+	return "", l.limit
+}
+
+// morePatternSpecific returns true if pattern a is more specific than b: it has more characters that are not wildcards,
+// or fewer "*" wildcards. Patterns that are equally specific are ordered by name, so that the order is stable.
+func morePatternSpecific(a, b string) bool {
+	literalsA := len(a) - strings.Count(a, "*") - strings.Count(a, "?")
+	literalsB := len(b) - strings.Count(b, "*") - strings.Count(b, "?")
+	if literalsA != literalsB {
+		return literalsA > literalsB
+	}
+	if starsA, starsB := strings.Count(a, "*"), strings.Count(b, "*"); starsA != starsB {
+		return starsA < starsB
+	}
+	return a < b
+}
+
+// take takes a token from the bucket of key and returns 0, or the time until the next token if the bucket is empty.
+func (l *rateLimiter) take(key rateLimitKey, limit RateLimit) time.Duration {
+	if limit.Rate <= 0 {
+		return 0
+	}
+	burst := float64(limit.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(limit.Rate))
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*limit.Rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0
+	}
+	return time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+}
+
+// sweep removes the buckets without requests for idleTimeout, at most once per idleTimeout.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
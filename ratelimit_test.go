@@ -0,0 +1,110 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Unix(1000, 0)
+	limiter := newRateLimiter(&RateLimitOpts{
+		Limit:   RateLimit{Rate: 2, Burst: 2},
+		Methods: map[string]RateLimit{"debug_*": {Rate: 0.5}, "free": {}},
+		Key:     RateLimitByHeader("X-Api-Key"),
+	})
+	limiter.now = func() time.Time { return now }
+
+	server := NewServerWithOpts(&ServerOpts{Middleware: []Middleware{limiter.middleware}})
+	for _, method := range []string{"eth_call", "debug_trace", "free"} {
+		server.Register(method, func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+			return "ok", nil
+		})
+	}
+
+	call := func(method string, remoteAddr string, apiKey string) string {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"`+method+`","id":1}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			request.Header.Set("X-Api-Key", apiKey)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+	ok := `{"jsonrpc":"2.0","result":"ok","id":1}`
+
+	// burst of 2, then limited
+	Expect(call("eth_call", "10.0.0.1:1234", "")).To(Equal(ok))
+	Expect(call("eth_call", "10.0.0.1:1235", "")).To(Equal(ok))
+	Expect(call("eth_call", "10.0.0.1:1236", "")).
+		To(Equal(`{"jsonrpc":"2.0","error":{"code":-32005,"message":"rate limit exceeded","data":{"retryAfter":0.5}},"id":1}`))
+
+	// other clients and methods with own limits have own buckets
+	Expect(call("eth_call", "10.0.0.2:1234", "")).To(Equal(ok))
+	Expect(call("eth_call", "10.0.0.1:1234", "key")).To(Equal(ok))
+	Expect(call("debug_trace", "10.0.0.1:1234", "")).To(Equal(ok))
+	Expect(call("debug_trace", "10.0.0.1:1234", "")).
+		To(Equal(`{"jsonrpc":"2.0","error":{"code":-32005,"message":"rate limit exceeded","data":{"retryAfter":2}},"id":1}`))
+	for i := 0; i < 10; i++ {
+		Expect(call("free", "10.0.0.1:1234", "")).To(Equal(ok))
+	}
+
+	// tokens are refilled
+	now = now.Add(250 * time.Millisecond)
+	Expect(call("eth_call", "10.0.0.1:1234", "")).To(ContainSubstring(`"retryAfter":0.25`))
+	now = now.Add(250 * time.Millisecond)
+	Expect(call("eth_call", "10.0.0.1:1234", "")).To(Equal(ok))
+
+	// requests without key are not limited
+	for i := 0; i < 3; i++ {
+		Expect(string(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"eth_call","id":1}`)))).To(Equal(ok))
+	}
+
+	// idle buckets are removed
+	Expect(limiter.buckets).To(HaveLen(4))
+	now = now.Add(defaultRateLimitIdleTimeout)
+	Expect(call("eth_call", "10.0.0.3:1234", "")).To(Equal(ok))
+	Expect(limiter.buckets).To(HaveLen(1))
+}
+
+func TestRateLimitMethodLimit(t *testing.T) {
+	RegisterTestingT(t)
+
+	limiter := newRateLimiter(&RateLimitOpts{
+		Limit: RateLimit{Rate: 1},
+		Methods: map[string]RateLimit{
+			"*":            {Rate: 2},
+			"eth_*":        {Rate: 3},
+			"eth_get*":     {Rate: 4},
+			"eth_call":     {Rate: 5},
+			"eth_get????":  {Rate: 6},
+			"eth_getBlock": {Rate: 7},
+		},
+	})
+
+	// the same limit every time, regardless of the order of the map
+	for i := 0; i < 20; i++ {
+		for method, expected := range map[string]string{
+			"eth_call":         "eth_call",
+			"eth_getBlock":     "eth_getBlock",
+			"eth_getCode":      "eth_get????",
+			"eth_getBalance":   "eth_get*",
+			"eth_chainId":      "eth_*",
+			"net_version":      "*",
+			"debug/traceBlock": "",
+		} {
+			pattern, _ := limiter.methodLimit(method)
+			Expect(pattern).To(Equal(expected), method)
+		}
+	}
+	Expect(limiter.patterns).To(Equal([]string{"eth_getBlock", "eth_call", "eth_get????", "eth_get*", "eth_*", "*"}))
+}
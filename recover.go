@@ -0,0 +1,50 @@
+package jsonrpc
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+)
+
+// RecoverOpts configures RecoverMiddleware().
+//
+// Logger: the logger of the recovered panics (default: the standard logger of the log package)
+//
+// Stack: log the stack trace of the panicking goroutine with the panic
+type RecoverOpts struct {
+	Logger *log.Logger
+	Stack  bool
+}
+
+// RecoverMiddleware returns a server Middleware that recovers from panics of the following middleware and handlers,
+// so a bad request can't take down the whole process. The panic is logged and the request gets an internal error
+// (-32603) response, without the panic value, which may contain internal details.
+//
+// It should be the first (outermost) middleware, see ServerOpts.
+func RecoverMiddleware(opts *RecoverOpts) Middleware {
+	logf := log.Printf
+	stack := false
+	if opts != nil {
+		if opts.Logger != nil {
+			logf = opts.Logger.Printf
+		}
+		stack = opts.Stack
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *ServerRequest) (result interface{}, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					if stack {
+						logf("jsonrpc: panic in method %v: %v\n%s", request.Method, recovered, debug.Stack())
+					} else {
+						logf("jsonrpc: panic in method %v: %v", request.Method, recovered)
+					}
+					result, err = nil, InternalError(nil)
+				}
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
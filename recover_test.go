@@ -0,0 +1,43 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	var logs bytes.Buffer
+	server := NewServerWithOpts(&ServerOpts{Middleware: []Middleware{RecoverMiddleware(&RecoverOpts{Logger: log.New(&logs, "", 0)})}})
+	server.Register("panic", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		panic("secret details")
+	})
+	server.Register("ok", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "ok", nil
+	})
+
+	response := server.HandleMessage(context.Background(), []byte(`[{"jsonrpc":"2.0","method":"panic","id":1},{"jsonrpc":"2.0","method":"ok","id":2}]`))
+	Expect(string(response)).To(Equal(`[{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":1},{"jsonrpc":"2.0","result":"ok","id":2}]`))
+	Expect(logs.String()).To(Equal("jsonrpc: panic in method panic: secret details\n"))
+
+	// with stack trace
+	logs.Reset()
+	server = NewServerWithOpts(&ServerOpts{
+		BatchConcurrency: 2,
+		Middleware:       []Middleware{RecoverMiddleware(&RecoverOpts{Logger: log.New(&logs, "", 0), Stack: true})},
+	})
+	server.Register("panic", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var m map[string]int
+		m["nil map"] = 1
+		return nil, nil
+	})
+	response = server.HandleMessage(context.Background(), []byte(`[{"jsonrpc":"2.0","method":"panic","id":1}]`))
+	Expect(string(response)).To(Equal(`[{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":1}]`))
+	Expect(logs.String()).To(HavePrefix("jsonrpc: panic in method panic: assignment to entry in nil map\ngoroutine "))
+	Expect(logs.String()).To(ContainSubstring("recover_test.go"))
+}
@@ -0,0 +1,95 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// redactedValue replaces the values of sensitive params.
+const redactedValue = "[REDACTED]"
+
+// Redactor is called with the method and params of a request whenever the params are echoed
+// into error messages or debug output. It returns the params that should be shown instead.
+//
+// The params passed to a Redactor must not be modified, the request is sent as it is.
+type Redactor func(method string, params interface{}) interface{}
+
+// RedactFields returns a Redactor that replaces the value of every object field
+// with one of the given names by "[REDACTED]". Names are matched case insensitive
+// on every level of the params, e.g.:
+//
+//	RedactFields("privateKey", "password")
+//
+// {"user": "Alex", "password": "secret"} is shown as {"user": "Alex", "password": "[REDACTED]"}
+func RedactFields(names ...string) Redactor {
+	denylist := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		denylist[strings.ToLower(name)] = struct{}{}
+	}
+
+	return func(method string, params interface{}) interface{} {
+		if params == nil || len(denylist) == 0 {
+			return params
+		}
+
+		js, err := json.Marshal(params)
+		if err != nil {
+			return redactedValue
+		}
+
+		var generic interface{}
+		decoder := json.NewDecoder(bytes.NewReader(js))
+		decoder.UseNumber()
+		if err := decoder.Decode(&generic); err != nil {
+			return redactedValue
+		}
+
+		return redactValue(generic, denylist)
+	}
+}
+
+func redactValue(value interface{}, denylist map[string]struct{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if _, ok := denylist[strings.ToLower(key)]; ok {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field, denylist)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactValue(elem, denylist)
+		}
+	}
+
+	return value
+}
+
+// describeCall returns "method(params)" with the params passed through the redactor of the client,
+// or "method()" if the client has neither a redactor nor echoes the params.
+func (client *rpcClient) describeCall(request *RPCRequest) string {
+	return request.Method + "(" + client.describeParams(request.Method, request.Params) + ")"
+}
+
+// describeParams returns the params as json after they were passed through the redactor of the client.
+// Params are omitted without redactor unless ParamsInErrors is set, and if they cannot be marshaled,
+// since their string representation may contain secrets.
+func (client *rpcClient) describeParams(method string, params interface{}) string {
+	if params == nil || (client.redactor == nil && !client.paramsInErrors) {
+		return ""
+	}
+
+	if client.redactor != nil {
+		params = client.redactor(method, params)
+	}
+
+	js, err := json.Marshal(params)
+	if err != nil {
+		return "..."
+	}
+
+	return string(js)
+}
@@ -0,0 +1,82 @@
+package jsonrpc
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRedactFields(t *testing.T) {
+	RegisterTestingT(t)
+
+	redactor := RedactFields("password", "PrivateKey")
+
+	Expect(redactor("method", nil)).To(BeNil())
+
+	redacted := redactor("login", map[string]interface{}{
+		"user":     "Alex",
+		"password": "secret",
+		"nested": []interface{}{
+			map[string]interface{}{"privateKey": "0xabc"},
+		},
+	})
+	Expect(redacted).To(Equal(map[string]interface{}{
+		"user":     "Alex",
+		"password": "[REDACTED]",
+		"nested": []interface{}{
+			map[string]interface{}{"privateKey": "[REDACTED]"},
+		},
+	}))
+
+	// the original params are not modified
+	type credentials struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+	}
+	params := &credentials{User: "Alex", Password: "secret"}
+	redacted = redactor("login", params)
+	Expect(params.Password).To(Equal("secret"))
+	Expect(redacted).To(Equal(map[string]interface{}{
+		"user":     "Alex",
+		"password": "[REDACTED]",
+	}))
+}
+
+func TestRpcClient_RedactorInErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	// params are not echoed by default
+	rpcClient := NewClient(httpServer.URL)
+	responseBody = ``
+	_, err := rpcClient.Call("login", map[string]string{"user": "Alex", "password": "secret"})
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring(`login()`))
+	Expect(strings.Contains(err.Error(), "secret")).To(BeFalse())
+
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{ParamsInErrors: true})
+	_, err = rpcClient.Call("login", map[string]string{"user": "Alex", "password": "secret"})
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring(`login({"password":"secret","user":"Alex"})`))
+
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		Redactor: RedactFields("password"),
+	})
+	_, err = rpcClient.Call("login", map[string]string{"user": "Alex", "password": "secret"})
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring(`login({"password":"[REDACTED]","user":"Alex"})`))
+	Expect(strings.Contains(err.Error(), "secret")).To(BeFalse())
+
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		Redactor: func(method string, params interface{}) interface{} {
+			return "hidden"
+		},
+	})
+	_, err = rpcClient.Call("sign", "0xprivate")
+	<-requestChan
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring(`sign("hidden")`))
+}
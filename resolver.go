@@ -0,0 +1,145 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resolver returns the current endpoint URLs of a client created with NewClientWithResolver(),
+// e.g. from DNS SRV records (see SRVResolver) or a service registry like Consul or etcd.
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// ResolverFunc is an adapter to use a function as Resolver.
+type ResolverFunc func(ctx context.Context) ([]string, error)
+
+// Resolve calls f(ctx).
+func (f ResolverFunc) Resolve(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// SRVResolver is a Resolver that looks up the endpoints in DNS SRV records.
+// The endpoints are ordered by priority and randomized by weight, as returned by net.LookupSRV().
+//
+// Service, Proto, Name: the SRV record, e.g. "jsonrpc", "tcp" and "example.com" for _jsonrpc._tcp.example.com.
+// If Service and Proto are empty, Name is looked up directly.
+//
+// Scheme: the scheme of the endpoint URLs (default "http")
+//
+// Path: the path of the endpoint URLs, e.g. "/rpc"
+//
+// Resolver: the DNS resolver (default net.DefaultResolver)
+type SRVResolver struct {
+	Service  string
+	Proto    string
+	Name     string
+	Scheme   string
+	Path     string
+	Resolver *net.Resolver
+}
+
+// Resolve looks up the SRV records and returns their endpoint URLs.
+func (r *SRVResolver) Resolve(ctx context.Context) ([]string, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return srvEndpoints(records, r.Scheme, r.Path), nil
+}
+
+// srvEndpoints returns the endpoint URLs of SRV records.
+func srvEndpoints(records []*net.SRV, scheme string, path string) []string {
+	if scheme == "" {
+		scheme = "http"
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	endpoints := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		endpoints = append(endpoints, scheme+"://"+net.JoinHostPort(host, strconv.Itoa(int(record.Port)))+path)
+	}
+
+	return endpoints
+}
+
+const defaultResolveInterval = 30 * time.Second
+
+// NewClientWithResolver returns a new EndpointsClient like NewClientWithEndpoints(),
+// but its endpoints are resolved by resolver, initially and then every FailoverOpts.ResolveInterval.
+// Endpoints that are kept by a resolution keep their state, e.g. their cooldown.
+//
+// An error is returned if the initial resolution fails or returns no endpoints.
+// If a later resolution fails or returns no endpoints, the current endpoints are kept.
+// Close() stops the resolution.
+func NewClientWithResolver(resolver Resolver, opts *RPCClientOpts) (EndpointsClient, error) {
+	clientOpts, failoverOpts := endpointsClientOpts(opts)
+	interval := failoverOpts.ResolveInterval
+	if interval <= 0 {
+		interval = defaultResolveInterval
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+	endpoints, err := resolver.Resolve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("could not resolve endpoints: no endpoints")
+	}
+
+	transport := newFailoverTransport(newEndpointFactory(clientOpts, failoverOpts), failoverOpts)
+	transport.setEndpoints(endpoints)
+	transport.start()
+	go transport.resolveLoop(resolver, interval)
+
+	clientOpts.CircuitBreaker = nil
+	return &endpointsClient{
+		rpcClient: newRPCClient(transport, &clientOpts),
+		transport: transport,
+	}, nil
+}
+
+// resolveLoop resolves the endpoints every interval until the transport is closed.
+func (t *failoverTransport) resolveLoop(resolver Resolver, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.closed:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		go func() {
+			select {
+			case <-t.closed:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		endpoints, err := resolver.Resolve(ctx)
+		cancel()
+
+		if err == nil && len(endpoints) > 0 {
+			t.setEndpoints(endpoints)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewClientWithResolver(t *testing.T) {
+	RegisterTestingT(t)
+
+	first, _ := newFlakyServer(0, http.StatusServiceUnavailable)
+	defer first.Close()
+	second, _ := newFlakyServer(0, http.StatusServiceUnavailable)
+	defer second.Close()
+
+	var mutex sync.Mutex
+	endpoints := []string{first.URL}
+	var resolveErr error
+	resolver := ResolverFunc(func(ctx context.Context) ([]string, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return endpoints, resolveErr
+	})
+	set := func(e []string, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		endpoints, resolveErr = e, err
+	}
+	names := func(client EndpointsClient) []string {
+		var names []string
+		for _, e := range client.Endpoints() {
+			names = append(names, e.Endpoint)
+		}
+		return names
+	}
+
+	rpcClient, err := NewClientWithResolver(resolver, &RPCClientOpts{
+		Failover: &FailoverOpts{ResolveInterval: 10 * time.Millisecond},
+	})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+	Expect(names(rpcClient)).To(Equal([]string{first.URL}))
+
+	set([]string{second.URL, first.URL}, nil)
+	Eventually(func() []string { return names(rpcClient) }).Should(Equal([]string{second.URL, first.URL}))
+
+	// failed or empty resolutions keep the endpoints
+	set(nil, errors.New("lookup failed"))
+	time.Sleep(30 * time.Millisecond)
+	Expect(names(rpcClient)).To(Equal([]string{second.URL, first.URL}))
+
+	set([]string{}, nil)
+	time.Sleep(30 * time.Millisecond)
+	Expect(names(rpcClient)).To(Equal([]string{second.URL, first.URL}))
+
+	set([]string{first.URL}, nil)
+	Eventually(func() []string { return names(rpcClient) }).Should(Equal([]string{first.URL}))
+
+	// the initial resolution must succeed
+	_, err = NewClientWithResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+		return nil, errors.New("lookup failed")
+	}), nil)
+	Expect(err).NotTo(BeNil())
+
+	_, err = NewClientWithResolver(ResolverFunc(func(ctx context.Context) ([]string, error) {
+		return nil, nil
+	}), nil)
+	Expect(err).NotTo(BeNil())
+}
+
+func TestFailoverTransport_SetEndpoints(t *testing.T) {
+	RegisterTestingT(t)
+
+	transport := newFailoverTransport(func(name string) endpointTransport {
+		return endpointTransport{name: name, transport: transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+			return nil, errors.New("connection refused")
+		})}
+	}, FailoverOpts{})
+	transport.setEndpoints([]string{"a", "b"})
+
+	_, err := transport.Send(context.Background(), []byte(`{}`))
+	Expect(err).NotTo(BeNil())
+
+	// the state of endpoints that are kept is kept
+	transport.setEndpoints([]string{"c", "a"})
+	states := transport.states()
+	Expect(states).To(HaveLen(2))
+	Expect(states[0].Endpoint).To(Equal("c"))
+	Expect(states[0].Healthy).To(BeTrue())
+	Expect(states[1].Endpoint).To(Equal("a"))
+	Expect(states[1].Healthy).To(BeFalse())
+
+	transport.setEndpoints(nil)
+	_, err = transport.Send(context.Background(), []byte(`{}`))
+	Expect(err).NotTo(BeNil())
+	Expect(IsRetryable(err)).To(BeFalse())
+}
+
+func TestSRVEndpoints(t *testing.T) {
+	RegisterTestingT(t)
+
+	records := []*net.SRV{
+		{Target: "node1.example.com.", Port: 8545},
+		{Target: "node2.example.com.", Port: 443},
+	}
+	Expect(srvEndpoints(records, "", "")).To(Equal([]string{
+		"http://node1.example.com:8545",
+		"http://node2.example.com:443",
+	}))
+	Expect(srvEndpoints(records, "https", "rpc")).To(Equal([]string{
+		"https://node1.example.com:8545/rpc",
+		"https://node2.example.com:443/rpc",
+	}))
+}
@@ -0,0 +1,71 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// ResponseTooLargeError is returned (wrapped in a TransportError) if a response body exceeds
+// RPCClientOpts.MaxResponseSize or MaxBatchResponseSize. The request is not retried.
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+// Error function is provided to be used as error object.
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response too large: the body exceeds the limit of %v bytes", e.Limit)
+}
+
+// Temporary returns false, the response is as large when the request is retried.
+func (e *ResponseTooLargeError) Temporary() bool {
+	return false
+}
+
+// limitedReader returns a ResponseTooLargeError when more than limit bytes are read.
+type limitedReader struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func newLimitedReader(r io.ReadCloser, limit int64) *limitedReader {
+	return &limitedReader{ReadCloser: r, limit: limit, remaining: limit}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, &ResponseTooLargeError{Limit: r.limit}
+	}
+	// read one byte more than allowed to detect bodies that exceed the limit
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), &ResponseTooLargeError{Limit: r.limit}
+	}
+	return n, err
+}
+
+// receive sends body with the transport of the client and returns the response body,
+// or a ResponseTooLargeError as soon as it exceeds limit (no limit if limit <= 0).
+func (client *rpcClient) receive(ctx context.Context, body []byte, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return client.transport.Send(ctx, body)
+	}
+
+	responseBody, err := sendStream(ctx, client.transport, body)
+	if responseBody == nil {
+		return nil, err
+	}
+	defer responseBody.Close()
+
+	data, readErr := ioutil.ReadAll(newLimitedReader(responseBody, limit))
+	if readErr != nil {
+		return nil, readErr
+	}
+	return data, err
+}
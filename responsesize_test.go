@@ -0,0 +1,78 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMaxResponseSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		result := strings.Repeat("a", 1000)
+		if strings.HasPrefix(string(body), "[") {
+			fmt.Fprintf(w, `[{"jsonrpc":"2.0","result":%q,"id":0},{"jsonrpc":"2.0","result":%q,"id":1}]`, result, result)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%q,"id":0}`, result)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		MaxResponseSize:      500,
+		MaxBatchResponseSize: 3000,
+		Retry:                &RetryPolicy{MaxAttempts: 3},
+	})
+
+	_, err := rpcClient.Call("something")
+	var tooLarge *ResponseTooLargeError
+	Expect(errors.As(err, &tooLarge)).To(BeTrue())
+	Expect(tooLarge.Limit).To(Equal(int64(500)))
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(err.Error()).To(ContainSubstring("response too large: the body exceeds the limit of 500 bytes"))
+	Expect(IsRetryable(err)).To(BeFalse())
+	Expect(requests).To(Equal(1))
+
+	// the batch limit applies to batches
+	responses, err := rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(2))
+
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchResponseSize: 1500})
+	res, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(HaveLen(1000))
+
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(errors.As(err, &tooLarge)).To(BeTrue())
+
+	var streamed []*RPCResponse
+	err = rpcClient.CallBatchStream(context.Background(), RPCRequests{NewRequest("a"), NewRequest("b")}, func(response *RPCResponse) {
+		streamed = append(streamed, response)
+	})
+	Expect(errors.As(err, &tooLarge)).To(BeTrue())
+	Expect(streamed).To(HaveLen(1))
+}
+
+func TestLimitedReader(t *testing.T) {
+	RegisterTestingT(t)
+
+	data, err := ioutil.ReadAll(newLimitedReader(ioutil.NopCloser(strings.NewReader("12345")), 5))
+	Expect(err).To(BeNil())
+	Expect(string(data)).To(Equal("12345"))
+
+	data, err = ioutil.ReadAll(newLimitedReader(ioutil.NopCloser(strings.NewReader("123456")), 5))
+	Expect(err).To(MatchError(&ResponseTooLargeError{Limit: 5}))
+	Expect(string(data)).To(Equal("12345"))
+}
@@ -0,0 +1,161 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures automatic retries of failed requests, see RPCClientOpts.
+//
+// Only requests that failed with a retryable error are retried (see IsRetryable()), e.g. connection errors
+// or http status codes like 503. RPC errors returned by the server are never retried.
+// Note that the server may have processed a failed request, so only enable retries for idempotent methods.
+//
+// MaxAttempts: the maximum number of attempts, including the first one (default 3)
+//
+// InitialBackoff: the delay before the first retry (default 100ms)
+//
+// MaxBackoff: the maximum delay between two attempts (default 10s)
+//
+// Multiplier: the factor by which the delay grows after every retry (default 2)
+//
+// Jitter: the fraction of the delay that is randomized, e.g. 0.2 randomizes the delay by ±10% (default 0.2).
+// A negative value disables the jitter.
+//
+// RetryStatusCodes: the http status codes that are retried (default 408, 425, 429, 502, 503 and 504)
+//
+// Retryable: decides if a failed request is retried, replacing the default decision. err is a *TransportError or *HTTPError.
+//
+// MaxRetryAfter: the longest delay requested by the server with a Retry-After header (e.g. for 429 or 503)
+// that is waited instead of the backoff. If the server requests a longer delay, the request is not retried (default 30s).
+//
+// MaxElapsedTime: the maximum time from the first attempt until the start of a retry, unlimited if 0
+//
+// Budget: limits the ratio of retries to requests (see RetryBudget), unlimited if nil
+type RetryPolicy struct {
+	MaxAttempts      int
+	InitialBackoff   time.Duration
+	MaxBackoff       time.Duration
+	Multiplier       float64
+	Jitter           float64
+	RetryStatusCodes []int
+	Retryable        func(err error) bool
+	MaxRetryAfter    time.Duration
+	MaxElapsedTime   time.Duration
+	Budget           *RetryBudget
+}
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultRetryMultiplier     = 2
+	defaultRetryJitter         = 0.2
+	defaultRetryMaxRetryAfter  = 30 * time.Second
+)
+
+// withDefaults returns a copy of the policy with defaults for all fields that are not set.
+func (p RetryPolicy) withDefaults() *RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultRetryMultiplier
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = defaultRetryMaxRetryAfter
+	}
+	if p.Jitter == 0 {
+		p.Jitter = defaultRetryJitter
+	} else if p.Jitter < 0 {
+		p.Jitter = 0
+	} else if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+
+	return &p
+}
+
+// backoff returns the delay after the given failed attempt (starting at 1).
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff *= 1 - p.Jitter/2 + rand.Float64()*p.Jitter
+
+	return time.Duration(backoff)
+}
+
+// retryable returns true if a request that failed with the error of a transport should be sent again.
+func (p *RetryPolicy) retryable(err error) bool {
+	err = classifyTransportError(err)
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && p.RetryStatusCodes != nil {
+		for _, code := range p.RetryStatusCodes {
+			if httpErr.Code == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	return IsRetryable(err)
+}
+
+// do calls attempt until it succeeds, fails with an error that is not retryable,
+// the maximum number of attempts, the maximum elapsed time or the budget is reached or ctx is done.
+// The error of the last attempt is returned.
+func (p *RetryPolicy) do(ctx context.Context, attempt func() error) error {
+	start := time.Now()
+	if p.Budget != nil {
+		p.Budget.request()
+	}
+
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i >= p.MaxAttempts || ctx.Err() != nil || !p.retryable(err) {
+			return err
+		}
+
+		delay := p.backoff(i)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+			if httpErr.RetryAfter > p.MaxRetryAfter {
+				return err
+			}
+			delay = httpErr.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// the retry would fail anyway
+			return err
+		}
+		if p.MaxElapsedTime > 0 && time.Since(start)+delay > p.MaxElapsedTime {
+			return err
+		}
+		if p.Budget != nil && !p.Budget.retry() {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newFlakyServer returns a server that responds with status to the first failures requests.
+func newFlakyServer(failures int32, status int) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+
+	return server, &attempts
+}
+
+func TestRetry(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, attempts := newFlakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{InitialBackoff: time.Millisecond},
+	})
+	res, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(3)))
+
+	// the error of the last attempt is returned after MaxAttempts
+	down, attempts := newFlakyServer(100, http.StatusServiceUnavailable)
+	defer down.Close()
+
+	rpcClient = NewClientWithOpts(down.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(4)))
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	RegisterTestingT(t)
+
+	server, attempts := newFlakyServer(1, http.StatusBadRequest)
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{InitialBackoff: time.Millisecond},
+	})
+	_, err := rpcClient.CallBatch(RPCRequests{NewRequest("something")})
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(1)))
+
+	// selected status codes
+	atomic.StoreInt32(attempts, 0)
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{InitialBackoff: time.Millisecond, RetryStatusCodes: []int{http.StatusBadRequest}},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(atomic.LoadInt32(attempts)).To(Equal(int32(2)))
+}
+
+func TestRetry_TransportErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	attempts := 0
+	rpcClient := NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return []byte(`{"jsonrpc":"2.0","result":"ok","id":0}`), nil
+	}), &RPCClientOpts{Retry: &RetryPolicy{InitialBackoff: time.Millisecond}})
+
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(attempts).To(Equal(2))
+
+	// canceled requests are not retried
+	attempts = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	rpcClient = NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		attempts++
+		cancel()
+		return nil, errors.New("connection reset")
+	}), &RPCClientOpts{Retry: &RetryPolicy{InitialBackoff: time.Millisecond}})
+
+	_, err = rpcClient.CallContext(ctx, "something")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(attempts).To(Equal(1))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	RegisterTestingT(t)
+
+	policy := (&RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: -1}).withDefaults()
+	Expect(policy.MaxAttempts).To(Equal(3))
+	Expect(policy.backoff(1)).To(Equal(100 * time.Millisecond))
+	Expect(policy.backoff(2)).To(Equal(200 * time.Millisecond))
+	Expect(policy.backoff(3)).To(Equal(400 * time.Millisecond))
+	Expect(policy.backoff(10)).To(Equal(time.Second))
+
+	policy = (&RetryPolicy{InitialBackoff: 100 * time.Millisecond}).withDefaults()
+	for i := 0; i < 100; i++ {
+		Expect(policy.backoff(1)).To(BeNumerically("~", 100*time.Millisecond, 10*time.Millisecond))
+	}
+}
+
+func TestRetry_RetryAfter(t *testing.T) {
+	RegisterTestingT(t)
+
+	attempts := 0
+	rpcClient := NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, &HTTPError{Code: http.StatusTooManyRequests, RetryAfter: 100 * time.Millisecond}
+		}
+		return []byte(`{"jsonrpc":"2.0","result":"ok","id":0}`), nil
+	}), &RPCClientOpts{Retry: &RetryPolicy{InitialBackoff: time.Millisecond}})
+
+	start := time.Now()
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(attempts).To(Equal(2))
+	Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+
+	// delays longer than MaxRetryAfter or the deadline of the context are not waited
+	attempts = 0
+	rpcClient = NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		attempts++
+		return nil, &HTTPError{Code: http.StatusServiceUnavailable, RetryAfter: time.Minute}
+	}), &RPCClientOpts{Retry: &RetryPolicy{InitialBackoff: time.Millisecond}})
+
+	_, err = rpcClient.Call("something")
+	Expect(err.(*HTTPError).RetryAfter).To(Equal(time.Minute))
+	Expect(attempts).To(Equal(1))
+
+	attempts = 0
+	rpcClient = NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		attempts++
+		return nil, &HTTPError{Code: http.StatusServiceUnavailable, RetryAfter: time.Second}
+	}), &RPCClientOpts{Retry: &RetryPolicy{InitialBackoff: time.Millisecond}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = rpcClient.CallContext(ctx, "something")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(attempts).To(Equal(1))
+}
+
+func TestRetry_MaxElapsedTime(t *testing.T) {
+	RegisterTestingT(t)
+
+	down, attempts := newFlakyServer(100, http.StatusServiceUnavailable)
+	defer down.Close()
+
+	rpcClient := NewClientWithOpts(down.URL, &RPCClientOpts{
+		Retry: &RetryPolicy{
+			MaxAttempts:    100,
+			InitialBackoff: 20 * time.Millisecond,
+			Multiplier:     1,
+			Jitter:         -1,
+			MaxElapsedTime: 50 * time.Millisecond,
+		},
+	})
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(atomic.LoadInt32(attempts)).To(BeNumerically("<=", int32(3)))
+	Expect(atomic.LoadInt32(attempts)).To(BeNumerically(">=", int32(2)))
+}
@@ -0,0 +1,434 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Error codes of the JSON-RPC 2.0 specification, used by the Server for its error responses.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Handler handles the calls of a method registered on a Server.
+//
+// It returns the result of the call, which is encoded as json, or an error.
+// An error of type *RPCError (see errors.As(), NewError() and InvalidParams()) is sent to the client as it is,
+// errors that implement CodedError with their code. All other errors are mapped by ServerOpts.ErrorMapper
+// or sent as internal error (-32603) without their text (see ServerOpts.InternalErrorMessages).
+type Handler func(ctx context.Context, request *ServerRequest) (interface{}, error)
+
+// Middleware wraps the dispatch of the requests of a Server, e.g. for auth, logging, metrics or validation.
+//
+// It returns a Handler that usually calls next, the handler of the method wrapped by the following middleware,
+// or returns an error without calling it. Middleware is called for all valid requests, also for notifications
+// and methods that are not registered, for which next returns an *RPCError with the code -32601.
+type Middleware func(next Handler) Handler
+
+// ServerRequest is a request received by a Server.
+//
+// Method: the name of the called method
+//
+// Params: the raw json params, nil if the request has no params
+//
+// ID: the id of the request, null for notifications
+//
+// Notification: true if the request has no id, the handler is called but no response is sent
+type ServerRequest struct {
+	Method       string
+	Params       json.RawMessage
+	ID           ID
+	Notification bool
+}
+
+// serverRequestContextKey is the context key of the request a handler is called for.
+type serverRequestContextKey struct{}
+
+// ServerRequestFromContext returns the request a handler is called for, e.g. to know in methods registered
+// with RegisterService() if they are called as notification. It returns nil outside of handlers.
+func ServerRequestFromContext(ctx context.Context) *ServerRequest {
+	request, _ := ctx.Value(serverRequestContextKey{}).(*ServerRequest)
+	return request
+}
+
+// DecodeParams decodes the params of the request into out, e.g. a pointer to a struct for named params
+// or a pointer to a slice for positional params. out is left as it is if the request has no params.
+//
+// The returned error is an *RPCError with the code -32602 (invalid params), so handlers can return it as it is.
+func (request *ServerRequest) DecodeParams(out interface{}) error {
+	if len(request.Params) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(request.Params, out); err != nil {
+		return &RPCError{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+// Server is a JSON-RPC 2.0 server that dispatches requests to the handlers registered for their methods.
+//
+// Methods can be registered at any time, also while the server handles requests.
+// Server implements http.Handler, see ServerOpts for the http configuration.
+type Server struct {
+	mutex                sync.RWMutex
+	handlers             map[string]Handler
+	subscriptionHandlers map[string]map[string]SubscriptionHandler
+	conns                map[*ServerConn]struct{}
+	disabledNamespaces   map[string]bool
+	handle               Handler
+
+	// the server of a version (see Version()) falls back to the methods of its parent
+	parent   *Server
+	versions map[string]*Server
+	opts     ServerOpts
+
+	path             string
+	allowedMethods   []string
+	contentTypes     []string
+	batchConcurrency int
+	maxRequestSize   int64
+	errorMapper      func(err error) *RPCError
+	cors             *cors
+
+	internalErrorMessages bool
+
+	sendQueueSize         int
+	disconnectSlowClients bool
+}
+
+// ServerOpts can be provided to NewServerWithOpts() to change the configuration of the Server.
+//
+// Path: the url path the server responds to, requests to other paths get 404 Not Found (default: any path)
+//
+// AllowedMethods: the accepted http methods, other methods get 405 Method Not Allowed (default POST).
+// GET requests carry the request in the query parameter "request", as sent by clients with GETEncodingPayload.
+//
+// ContentTypes: the accepted media types of request bodies, other media types get 415 Unsupported Media Type
+// (default application/json), "*" accepts any media type
+//
+// MaxRequestSize: the maximum size of http request bodies in bytes, larger requests get 413 Request Entity Too Large
+// (default 10 MiB), no limit if negative
+//
+// BatchConcurrency: how many requests of a batch are handled concurrently (default 1: one after another)
+//
+// Middleware: wraps the dispatch of every request (see Middleware), the first middleware is the outermost
+//
+// ErrorMapper: returns the error object for errors of handlers that are neither *RPCError nor CodedError,
+// e.g. for sentinel errors like sql.ErrNoRows, nil sends the error as internal error (-32603)
+//
+// InternalErrorMessages: if true, the text of the errors that are sent as internal error is the message,
+// e.g. for development. By default the message is "internal error", so that internal details are not sent to clients.
+//
+// CORS: allows browsers to call the server from other origins (see CORSOpts), disabled if nil
+//
+// SendQueueSize: how many messages are queued for a persistent connection (see ServerConn) while the client
+// receives slower than the server sends (default 64)
+//
+// DisconnectSlowClients: if true, connections whose send queue is full when a broadcast is sent are closed,
+// by default only the broadcast is dropped for them (see Broadcast())
+//
+// DisabledNamespaces: the namespaces whose methods are not served at first, e.g. "debug" (see Namespace())
+type ServerOpts struct {
+	Path                  string
+	AllowedMethods        []string
+	ContentTypes          []string
+	MaxRequestSize        int64
+	BatchConcurrency      int
+	Middleware            []Middleware
+	ErrorMapper           func(err error) *RPCError
+	InternalErrorMessages bool
+	CORS                  *CORSOpts
+	SendQueueSize         int
+	DisconnectSlowClients bool
+	DisabledNamespaces    []string
+}
+
+// NewServer returns a new Server without registered methods.
+func NewServer() *Server {
+	return NewServerWithOpts(nil)
+}
+
+// NewServerWithOpts returns a new Server without registered methods with custom configuration.
+//
+// opts: ServerOpts provide custom configuration
+func NewServerWithOpts(opts *ServerOpts) *Server {
+	server := &Server{
+		handlers:           make(map[string]Handler),
+		conns:              make(map[*ServerConn]struct{}),
+		disabledNamespaces: make(map[string]bool),
+		allowedMethods:     []string{http.MethodPost},
+		contentTypes:       []string{"application/json"},
+		batchConcurrency:   1,
+		maxRequestSize:     defaultMaxRequestSize,
+		sendQueueSize:      defaultSendQueueSize,
+	}
+	server.handle = server.dispatch
+
+	if opts == nil {
+		return server
+	}
+
+	server.opts = *opts
+	server.path = opts.Path
+	if len(opts.AllowedMethods) > 0 {
+		server.allowedMethods = opts.AllowedMethods
+	}
+	if len(opts.ContentTypes) > 0 {
+		server.contentTypes = opts.ContentTypes
+	}
+	if opts.MaxRequestSize != 0 {
+		server.maxRequestSize = opts.MaxRequestSize
+	}
+	if opts.BatchConcurrency > 0 {
+		server.batchConcurrency = opts.BatchConcurrency
+	}
+	server.errorMapper = opts.ErrorMapper
+	server.internalErrorMessages = opts.InternalErrorMessages
+	if opts.SendQueueSize > 0 {
+		server.sendQueueSize = opts.SendQueueSize
+	}
+	server.disconnectSlowClients = opts.DisconnectSlowClients
+	for _, namespace := range opts.DisabledNamespaces {
+		server.disabledNamespaces[namespace] = true
+	}
+	server.cors = newCORS(opts.CORS, server.allowedMethods)
+	for i := len(opts.Middleware) - 1; i >= 0; i-- {
+		server.handle = opts.Middleware[i](server.handle)
+	}
+
+	return server
+}
+
+// Register registers handler for the calls of method.
+//
+// It panics if method is empty, begins with "rpc." (reserved by the specification), is already registered
+// or if handler is nil.
+func (s *Server) Register(method string, handler Handler) {
+	if method == "" {
+		panic("jsonrpc: empty method name")
+	}
+	if strings.HasPrefix(method, "rpc.") {
+		panic("jsonrpc: method names beginning with rpc. are reserved: " + method)
+	}
+	if handler == nil {
+		panic("jsonrpc: nil handler for method " + method)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.handlers[method]; ok {
+		panic("jsonrpc: method registered twice: " + method)
+	}
+	s.handlers[method] = handler
+}
+
+// handler returns the handler of method, or nil if method is not registered or its namespace is disabled.
+// The server of a version falls back to the handlers of its parent.
+func (s *Server) handler(method string) Handler {
+	if !s.namespaceEnabled(methodNamespace(method)) {
+		return nil
+	}
+
+	s.mutex.RLock()
+	handler := s.handlers[method]
+	s.mutex.RUnlock()
+	if handler == nil && s.parent != nil {
+		return s.parent.handler(method)
+	}
+	return handler
+}
+
+// HandleMessage handles a json encoded request or batch and returns the json encoded response.
+//
+// Requests that can not be parsed or are no valid JSON-RPC 2.0 requests get an error response
+// with the code -32700 (parse error) or -32600 (invalid request), calls of methods that are not registered
+// an error response with the code -32601 (method not found).
+//
+// Notifications, requests without id, are handled but get no response, so nil is returned for them.
+// The responses of a batch are returned as array in the order of the requests, without responses for
+// notifications. It returns nil if a batch has only notifications.
+func (s *Server) HandleMessage(ctx context.Context, message []byte) []byte {
+	message = bytes.TrimSpace(message)
+	if !json.Valid(message) {
+		return encodeServerResponse(errorResponse(NullID(), &RPCError{Code: CodeParseError, Message: "parse error"}))
+	}
+	if message[0] == '[' {
+		return s.handleBatch(ctx, message)
+	}
+
+	response := s.handleRequest(ctx, message)
+	if response == nil {
+		return nil
+	}
+	return encodeServerResponse(response)
+}
+
+// handleBatch handles the requests of a batch with up to batchConcurrency requests at a time.
+func (s *Server) handleBatch(ctx context.Context, message []byte) []byte {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(message, &messages); err != nil || len(messages) == 0 {
+		return encodeServerResponse(errorResponse(NullID(), &RPCError{Code: CodeInvalidRequest, Message: "invalid request: empty batch"}))
+	}
+
+	responses := make([]*serverResponse, len(messages))
+	if s.batchConcurrency == 1 {
+		for i, message := range messages {
+			responses[i] = s.handleRequest(ctx, message)
+		}
+	} else {
+		var wg sync.WaitGroup
+		semaphore := make(chan struct{}, s.batchConcurrency)
+		for i, message := range messages {
+			semaphore <- struct{}{}
+			wg.Add(1)
+			go func(i int, message json.RawMessage) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+				responses[i] = s.handleRequest(ctx, message)
+			}(i, message)
+		}
+		wg.Wait()
+	}
+
+	var buf bytes.Buffer
+	for _, response := range responses {
+		if response == nil {
+			continue
+		}
+		if buf.Len() == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.Write(encodeServerResponse(response))
+	}
+	if buf.Len() == 0 {
+		// only notifications
+		return nil
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+// handleRequest decodes and calls a single request, it returns nil for notifications.
+func (s *Server) handleRequest(ctx context.Context, message []byte) *serverResponse {
+	request, rpcErr := decodeServerRequest(message)
+	if rpcErr != nil {
+		return errorResponse(request.ID, rpcErr)
+	}
+
+	response := s.call(ctx, request)
+	if request.Notification {
+		return nil
+	}
+	return response
+}
+
+// call calls the handler of request through the middleware and returns the response.
+func (s *Server) call(ctx context.Context, request *ServerRequest) *serverResponse {
+	result, err := s.handle(context.WithValue(ctx, serverRequestContextKey{}, request), request)
+	if err != nil {
+		return errorResponse(request.ID, s.rpcError(err))
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(request.ID, &RPCError{Code: CodeInternalError, Message: "could not encode result: " + err.Error()})
+	}
+	return &serverResponse{JSONRPC: jsonrpcVersion, Result: data, ID: request.ID}
+}
+
+// dispatch calls the handler registered for the method of request.
+func (s *Server) dispatch(ctx context.Context, request *ServerRequest) (interface{}, error) {
+	handler := s.handler(request.Method)
+	if handler == nil {
+		return nil, MethodNotFound(request.Method)
+	}
+	return handler(ctx, request)
+}
+
+// serverRequestJSON has the members of a request as raw json, so invalid members can be told apart.
+type serverRequestJSON struct {
+	JSONRPC json.RawMessage `json:"jsonrpc"`
+	Method  json.RawMessage `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// decodeServerRequest decodes a request from valid json, requests without id are notifications.
+// If it is no valid request, it returns the error and a request with the id if the id could be decoded.
+func decodeServerRequest(message []byte) (*ServerRequest, *RPCError) {
+	request := &ServerRequest{ID: NullID()}
+
+	var raw serverRequestJSON
+	if message[0] != '{' || json.Unmarshal(message, &raw) != nil {
+		return request, &RPCError{Code: CodeInvalidRequest, Message: "invalid request: must be an object"}
+	}
+
+	request.Notification = len(raw.ID) == 0
+	if len(raw.ID) > 0 {
+		if err := json.Unmarshal(raw.ID, &request.ID); err != nil {
+			request.ID = NullID()
+			return request, &RPCError{Code: CodeInvalidRequest, Message: "invalid request: " + err.Error()}
+		}
+	}
+
+	var version string
+	if json.Unmarshal(raw.JSONRPC, &version) != nil || version != jsonrpcVersion {
+		return request, &RPCError{Code: CodeInvalidRequest, Message: `invalid request: jsonrpc must be "2.0"`}
+	}
+
+	if json.Unmarshal(raw.Method, &request.Method) != nil || request.Method == "" {
+		return request, &RPCError{Code: CodeInvalidRequest, Message: "invalid request: method must be a non-empty string"}
+	}
+
+	switch params := bytes.TrimSpace(raw.Params); {
+	case len(params) == 0 || bytes.Equal(params, []byte("null")):
+	case params[0] == '[' || params[0] == '{':
+		request.Params = params
+	default:
+		return request, &RPCError{Code: CodeInvalidRequest, Message: "invalid request: params must be an array or an object"}
+	}
+
+	return request, nil
+}
+
+// serverResponse is a response of the Server. Unlike RPCResponse it encodes a nil result as null,
+// because successful responses must have a result member.
+type serverResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      ID              `json:"id"`
+}
+
+func errorResponse(id ID, rpcErr *RPCError) *serverResponse {
+	return &serverResponse{JSONRPC: jsonrpcVersion, Error: rpcErr, ID: id}
+}
+
+// encodeServerResponse encodes response, responses whose error data can not be encoded are sent without data.
+func encodeServerResponse(response *serverResponse) []byte {
+	data, err := json.Marshal(response)
+	if err != nil && response.Error != nil {
+		rpcErr := *response.Error
+		rpcErr.Data = nil
+		response.Error = &rpcErr
+		data, err = json.Marshal(response)
+	}
+	if err != nil {
+		panic(fmt.Sprintf("jsonrpc: could not encode response: %v", err))
+	}
+	return data
+}
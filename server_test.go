@@ -0,0 +1,254 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newAddServer returns a server with the method "add" that adds two numbers.
+func newAddServer() *Server {
+	server := NewServer()
+	server.Register("add", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var params []int
+		if err := request.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		if len(params) != 2 {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "expected 2 params", Data: len(params)}
+		}
+		return params[0] + params[1], nil
+	})
+	return server
+}
+
+func TestServerHandleMessage(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newAddServer()
+	server.Register("nothing", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, nil
+	})
+	server.Register("fail", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, fmt.Errorf("wrapped: %w", &RPCError{Code: 42, Message: "failed", Data: "details"})
+	})
+	server.Register("broken", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, errors.New("broken")
+	})
+	server.Register("unencodable", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return make(chan int), nil
+	})
+
+	tests := []struct {
+		request  string
+		response string
+	}{
+		{`{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`, `{"jsonrpc":"2.0","result":3,"id":1}`},
+		{` {"id":"a","params":[3,4],"method":"add","jsonrpc":"2.0"} `, `{"jsonrpc":"2.0","result":7,"id":"a"}`},
+		{`{"jsonrpc":"2.0","method":"nothing","id":null}`, `{"jsonrpc":"2.0","result":null,"id":null}`},
+		{`{"jsonrpc":"2.0","method":"add","params":[1],"id":2}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"expected 2 params","data":1},"id":2}`},
+		{`{"jsonrpc":"2.0","method":"add","params":{"a":1},"id":3}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params: json: cannot unmarshal object into Go value of type []int"},"id":3}`},
+		{`{"jsonrpc":"2.0","method":"fail","id":4}`, `{"jsonrpc":"2.0","error":{"code":42,"message":"failed","data":"details"},"id":4}`},
+		{`{"jsonrpc":"2.0","method":"broken","id":5}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":5}`},
+		{`{"jsonrpc":"2.0","method":"unencodable","id":6}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"could not encode result: json: unsupported type: chan int"},"id":6}`},
+		{`{"jsonrpc":"2.0","method":"missing","id":7}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: missing"},"id":7}`},
+		{`{"jsonrpc":"2.0","method":"add","params":[1,2],"id":8`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`},
+		{``, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`},
+		{`1`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: must be an object"},"id":null}`},
+		{`{"jsonrpc":"1.0","method":"add","id":9}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: jsonrpc must be \"2.0\""},"id":9}`},
+		{`{"jsonrpc":"2.0","method":1,"id":10}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: method must be a non-empty string"},"id":10}`},
+		{`{"jsonrpc":"2.0","method":"add","params":1,"id":11}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: params must be an array or an object"},"id":11}`},
+		{`{"jsonrpc":"2.0","method":"add","id":1.5}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: invalid id 1.5: must be an integer, a string or null"},"id":null}`},
+	}
+
+	for _, test := range tests {
+		response := server.HandleMessage(context.Background(), []byte(test.request))
+		Expect(string(response)).To(Equal(test.response), test.request)
+	}
+}
+
+func TestServerRegister(t *testing.T) {
+	RegisterTestingT(t)
+
+	handler := func(ctx context.Context, request *ServerRequest) (interface{}, error) { return nil, nil }
+	server := NewServer()
+	server.Register("a", handler)
+
+	Expect(func() { server.Register("a", handler) }).To(Panic())
+	Expect(func() { server.Register("", handler) }).To(Panic())
+	Expect(func() { server.Register("rpc.discover", handler) }).To(Panic())
+	Expect(func() { server.Register("b", nil) }).To(Panic())
+}
+
+func TestServerRequestDecodeParams(t *testing.T) {
+	RegisterTestingT(t)
+
+	var params struct {
+		Name string `json:"name"`
+	}
+	params.Name = "default"
+	Expect((&ServerRequest{}).DecodeParams(&params)).To(Succeed())
+	Expect(params.Name).To(Equal("default"))
+
+	Expect((&ServerRequest{Params: []byte(`{"name":"alice"}`)}).DecodeParams(&params)).To(Succeed())
+	Expect(params.Name).To(Equal("alice"))
+
+	err := (&ServerRequest{Params: []byte(`[1]`)}).DecodeParams(&params)
+	var rpcErr *RPCError
+	Expect(errors.As(err, &rpcErr)).To(BeTrue())
+	Expect(rpcErr.Code).To(Equal(CodeInvalidParams))
+}
+
+func TestServerHandleBatch(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newAddServer()
+	server.Register("notify", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, errors.New("not sent")
+	})
+
+	tests := []struct {
+		request  string
+		response string
+	}{
+		{
+			`[{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1},{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","method":"add","params":[3,4],"id":"b"}]`,
+			`[{"jsonrpc":"2.0","result":3,"id":1},{"jsonrpc":"2.0","result":7,"id":"b"}]`,
+		},
+		{
+			`[{"jsonrpc":"2.0","method":"missing","id":1},{"foo":"boo"},1,{"jsonrpc":"2.0","method":"missing"}]`,
+			`[{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: missing"},"id":1},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: jsonrpc must be \"2.0\""},"id":null},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: must be an object"},"id":null}]`,
+		},
+		{`[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: empty batch"},"id":null}`},
+		{`[{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"parse error"},"id":null}`},
+		{`[{"jsonrpc":"2.0","method":"notify"},{"jsonrpc":"2.0","method":"missing"}]`, ``},
+	}
+
+	for _, test := range tests {
+		response := server.HandleMessage(context.Background(), []byte(test.request))
+		Expect(string(response)).To(Equal(test.response), test.request)
+	}
+}
+
+func TestServerBatchConcurrency(t *testing.T) {
+	RegisterTestingT(t)
+
+	var running, maxRunning int32
+	server := NewServerWithOpts(&ServerOpts{BatchConcurrency: 2})
+	server.Register("sleep", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return request.ID, nil
+	})
+
+	rpcClient := ClientFromHandler(server)
+	requests := RPCRequests{NewRequest("sleep"), NewRequest("sleep"), NewNotification("sleep"), NewRequest("sleep"), NewRequest("sleep")}
+	responses, err := rpcClient.CallBatch(requests)
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(4))
+	for i, response := range responses {
+		// in the order of the requests
+		Expect(response.ID).To(Equal(requests[[]int{0, 1, 3, 4}[i]].ID))
+		Expect(response.Result).To(Equal(json.Number(response.ID.String())))
+	}
+	Expect(atomic.LoadInt32(&maxRunning)).To(Equal(int32(2)))
+
+	// only notifications
+	responses, err = rpcClient.CallBatch(RPCRequests{NewNotification("sleep"), NewNotification("sleep")})
+	Expect(err).To(BeNil())
+	Expect(responses).To(BeEmpty())
+}
+
+func TestServerNotification(t *testing.T) {
+	RegisterTestingT(t)
+
+	notified := make(chan *ServerRequest, 1)
+	server := NewServer()
+	server.Register("log", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		Expect(ServerRequestFromContext(ctx)).To(Equal(request))
+		notified <- request
+		return "ignored", nil
+	})
+
+	response := server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"log","params":["started"]}`))
+	Expect(response).To(BeNil())
+	request := <-notified
+	Expect(request.Notification).To(BeTrue())
+	Expect(request.ID.IsNull()).To(BeTrue())
+
+	// a null id is no notification
+	response = server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"log","id":null}`))
+	Expect(string(response)).To(Equal(`{"jsonrpc":"2.0","result":"ignored","id":null}`))
+	Expect((<-notified).Notification).To(BeFalse())
+
+	// errors of notifications are not sent either, invalid requests are answered
+	Expect(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"missing"}`))).To(BeNil())
+	Expect(string(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0"}`)))).
+		To(Equal(`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request: method must be a non-empty string"},"id":null}`))
+
+	Expect(ServerRequestFromContext(context.Background())).To(BeNil())
+}
+
+func TestServerMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+				calls = append(calls, name+" "+request.Method)
+				result, err := next(ctx, request)
+				calls = append(calls, fmt.Sprintf("%v done: %v %v", name, result, err))
+				return result, err
+			}
+		}
+	}
+	auth := func(next Handler) Handler {
+		return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+			if request.Method == "secret" {
+				return nil, &RPCError{Code: -32001, Message: "unauthorized"}
+			}
+			return next(ctx, request)
+		}
+	}
+
+	server := NewServerWithOpts(&ServerOpts{Middleware: []Middleware{record("outer"), auth, record("inner")}})
+	server.Register("echo", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		calls = append(calls, "handler")
+		return "ok", nil
+	})
+	server.Register("secret", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		calls = append(calls, "secret handler")
+		return "secret", nil
+	})
+
+	Expect(string(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"echo","id":1}`)))).
+		To(Equal(`{"jsonrpc":"2.0","result":"ok","id":1}`))
+	Expect(calls).To(Equal([]string{"outer echo", "inner echo", "handler", "inner done: ok <nil>", "outer done: ok <nil>"}))
+
+	calls = nil
+	Expect(string(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"secret","id":2}`)))).
+		To(Equal(`{"jsonrpc":"2.0","error":{"code":-32001,"message":"unauthorized"},"id":2}`))
+	Expect(calls).To(Equal([]string{"outer secret", "outer done: <nil> -32001:unauthorized"}))
+
+	// also called for methods that are not registered
+	calls = nil
+	Expect(string(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"missing","id":3}`)))).
+		To(Equal(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: missing"},"id":3}`))
+	Expect(calls).To(Equal([]string{"outer missing", "inner missing", "inner done: <nil> -32601:method not found: missing", "outer done: <nil> -32601:method not found: missing"}))
+}
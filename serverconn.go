@@ -0,0 +1,314 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultMaxConnRequests = 16
+	defaultSendQueueSize   = 64
+)
+
+// ErrConnClosed is returned when a notification is sent over a ServerConn that is closed.
+var ErrConnClosed = errors.New("connection closed")
+
+// serverConnContextKey is the context key of the connection a call was received over.
+type serverConnContextKey struct{}
+
+// ServerConnFromContext returns the persistent connection a call was received over, e.g. to send notifications
+// to the client in a Handler. It returns nil if the call was not received over a persistent connection.
+func ServerConnFromContext(ctx context.Context) *ServerConn {
+	conn, _ := ctx.Value(serverConnContextKey{}).(*ServerConn)
+	return conn
+}
+
+// ServerConn is a persistent connection of a client to a Server, e.g. a websocket connection.
+// The requests of a connection are handled concurrently, the server can send notifications at any time
+// and call methods of the client, see Call().
+//
+// The messages to the client are sent from a send queue (see ServerOpts.SendQueueSize), so a slow client
+// does not block the handlers of other connections or Server.Broadcast().
+type ServerConn struct {
+	server *Server
+	conn   messageConn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	queue         chan []byte
+	writeLoopDone chan struct{}
+
+	mutex         sync.Mutex
+	subscriptions map[ID]*ServerSubscription
+	values        map[interface{}]interface{}
+	closed        bool
+
+	pendingMutex sync.Mutex
+	pending      map[ID]chan []byte
+	lastID       int64
+}
+
+func newServerConn(ctx context.Context, server *Server, conn messageConn) *ServerConn {
+	c := &ServerConn{
+		server:        server,
+		conn:          conn,
+		queue:         make(chan []byte, server.sendQueueSize),
+		writeLoopDone: make(chan struct{}),
+		subscriptions: make(map[ID]*ServerSubscription),
+		pending:       make(map[ID]chan []byte),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.WithValue(ctx, serverConnContextKey{}, c))
+	return c
+}
+
+// Notify sends a notification to the client, params are encoded like the params of requests.
+// It waits while the send queue of the connection is full and returns ErrConnClosed if the connection is closed.
+func (c *ServerConn) Notify(method string, params interface{}) error {
+	message, err := json.Marshal(&RPCNotification{JSONRPC: jsonrpcVersion, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	return c.write(message)
+}
+
+// Call sends a request to the client and waits for its response, e.g. for callbacks of LSP-like protocols.
+// The client handles the request with the Server of its RPCClientOpts. params are encoded like the params
+// of RPCClient.Call().
+//
+// It returns an error if ctx is done or the connection is closed before the response arrives.
+// The error object of the response is returned as RPCResponse.Error, like by RPCClient.Call().
+func (c *ServerConn) Call(ctx context.Context, method string, params ...interface{}) (*RPCResponse, error) {
+	request := NewRequest(method, params...)
+	response := make(chan []byte, 1)
+
+	c.pendingMutex.Lock()
+	c.lastID++
+	request.ID = NumberID(c.lastID)
+	c.pending[request.ID] = response
+	c.pendingMutex.Unlock()
+	defer func() {
+		c.pendingMutex.Lock()
+		delete(c.pending, request.ID)
+		c.pendingMutex.Unlock()
+	}()
+
+	message, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(message); err != nil {
+		return nil, err
+	}
+
+	select {
+	case message := <-response:
+		var rpcResponse *RPCResponse
+		decoder := json.NewDecoder(bytes.NewReader(message))
+		decoder.UseNumber()
+		if err := decoder.Decode(&rpcResponse); err != nil || rpcResponse == nil {
+			return nil, fmt.Errorf("rpc call %v(): invalid response: %v", method, err)
+		}
+		return rpcResponse, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, ErrConnClosed
+	}
+}
+
+// CallFor sends a request to the client like Call() and decodes the result of the response into out.
+// The error object of the response is returned as *RPCError.
+func (c *ServerConn) CallFor(ctx context.Context, out interface{}, method string, params ...interface{}) error {
+	response, err := c.Call(ctx, method, params...)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	return response.GetObject(out)
+}
+
+// dispatchResponse delivers a response of the client to the pending call with its id,
+// it returns false if message is no response to a pending call.
+func (c *ServerConn) dispatchResponse(message []byte) bool {
+	entries, err := parseMessage(message)
+	if err != nil || isRequest(entries) {
+		return false
+	}
+	ids := envelopeIDs(entries)
+	if len(ids) != 1 {
+		return false
+	}
+
+	c.pendingMutex.Lock()
+	defer c.pendingMutex.Unlock()
+	response, ok := c.pending[ids[0]]
+	if !ok {
+		return false
+	}
+	delete(c.pending, ids[0])
+	response <- message
+	return true
+}
+
+// Context returns the context of the connection, it has the http request of the connection
+// (see HTTPRequestFromContext()) and is canceled when the connection is closed.
+func (c *ServerConn) Context() context.Context {
+	return c.ctx
+}
+
+// Set stores value for key on the connection, e.g. the topics a client has subscribed to
+// or the authenticated user, for the filters of Server.BroadcastFilter().
+func (c *ServerConn) Set(key interface{}, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.values == nil {
+		c.values = make(map[interface{}]interface{})
+	}
+	c.values[key] = value
+}
+
+// Value returns the value stored for key with Set(), or nil.
+func (c *ServerConn) Value(key interface{}) interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.values[key]
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (c *ServerConn) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+// Close closes the connection and ends its subscriptions.
+func (c *ServerConn) Close() error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.closed = true
+	subscriptions := c.subscriptions
+	c.subscriptions = nil
+	c.mutex.Unlock()
+
+	c.cancel()
+	for _, subscription := range subscriptions {
+		subscription.end()
+	}
+	return c.conn.close()
+}
+
+// write adds message to the send queue, it waits while the queue is full.
+func (c *ServerConn) write(message []byte) error {
+	select {
+	case <-c.ctx.Done():
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case <-c.ctx.Done():
+		return ErrConnClosed
+	case c.queue <- message:
+		return nil
+	}
+}
+
+// tryWrite adds message to the send queue without waiting. If the queue is full, the message is dropped
+// and the connection is closed if the server disconnects slow clients. It returns false if message was not queued.
+func (c *ServerConn) tryWrite(message []byte) bool {
+	select {
+	case <-c.ctx.Done():
+		return false
+	default:
+	}
+
+	select {
+	case c.queue <- message:
+		return true
+	default:
+		if c.server.disconnectSlowClients {
+			go c.Close()
+		}
+		return false
+	}
+}
+
+// writeLoop sends the messages of the send queue until the connection is closed,
+// the messages are sent one at a time because the connection does not support concurrent writes.
+func (c *ServerConn) writeLoop() {
+	defer close(c.writeLoopDone)
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case message := <-c.queue:
+			if err := c.conn.writeMessage(message); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// serveConn handles the messages of conn with up to maxRequests requests at a time, until the connection is closed.
+func (s *Server) serveConn(ctx context.Context, conn messageConn, maxRequests int) {
+	c := newServerConn(ctx, s, conn)
+	s.addConn(c)
+	defer s.removeConn(c)
+	go c.writeLoop()
+
+	if maxRequests <= 0 {
+		maxRequests = defaultMaxConnRequests
+	}
+	semaphore := make(chan struct{}, maxRequests)
+	var wg sync.WaitGroup
+
+	for {
+		message, err := conn.readMessage()
+		if err != nil {
+			break
+		}
+		if c.dispatchResponse(message) {
+			continue
+		}
+
+		// the slot is taken by the goroutine, the messages are still read while maxRequests are handled,
+		// so that handlers waiting for responses of the client (see Call()) get them
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+			case <-c.ctx.Done():
+				return
+			}
+			defer func() { <-semaphore }()
+			c.handle(message)
+		}()
+	}
+
+	// the pending requests are canceled, their responses are not sent anymore
+	c.Close()
+	wg.Wait()
+	<-c.writeLoopDone
+}
+
+// handle handles a message and sends the response, the subscriptions created by the message are started
+// after the response was sent, so their notifications follow the response.
+func (c *ServerConn) handle(message []byte) {
+	started := &startedSubscriptions{}
+	ctx := context.WithValue(c.ctx, startedSubscriptionsContextKey{}, started)
+
+	if response := c.server.HandleMessage(ctx, message); response != nil {
+		c.write(response)
+	}
+	started.start()
+}
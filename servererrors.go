@@ -0,0 +1,75 @@
+package jsonrpc
+
+import (
+	"errors"
+)
+
+// CodedError is implemented by errors that are sent by the Server with their own error code
+// instead of -32603 (internal error), the error text is the message.
+type CodedError interface {
+	error
+	ErrorCode() int
+}
+
+// DataError is implemented by errors that are sent by the Server with data, in addition to CodedError.
+type DataError interface {
+	error
+	ErrorData() interface{}
+}
+
+// NewError returns an error object with a custom application error code and optional structured data,
+// e.g. NewError(-32000, "insufficient funds", map[string]string{"account": "0x..."}).
+//
+// The codes from -32768 to -32000 are reserved by the specification, -32000 to -32099 for server errors.
+func NewError(code int, message string, data interface{}) *RPCError {
+	return &RPCError{Code: code, Message: message, Data: data}
+}
+
+// InvalidParams returns an invalid params error (-32602) with optional data, e.g. the invalid fields.
+func InvalidParams(data interface{}) *RPCError {
+	return &RPCError{Code: CodeInvalidParams, Message: "invalid params", Data: data}
+}
+
+// MethodNotFound returns a method not found error (-32601) for method.
+func MethodNotFound(method string) *RPCError {
+	return &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + method}
+}
+
+// InternalError returns an internal error (-32603) with optional data.
+func InternalError(data interface{}) *RPCError {
+	return &RPCError{Code: CodeInternalError, Message: "internal error", Data: data}
+}
+
+// rpcError returns the error object that is sent for err:
+//   - the *RPCError in the chain of err
+//   - the code, text and data of the CodedError in the chain of err
+//   - the error object of ServerOpts.ErrorMapper
+//   - an internal error, with the text of err only if ServerOpts.InternalErrorMessages is set,
+//     so that details like queries, file paths or upstream urls are not sent to clients
+func (s *Server) rpcError(err error) *RPCError {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr != nil {
+		return rpcErr
+	}
+
+	var coded CodedError
+	if errors.As(err, &coded) {
+		rpcErr = &RPCError{Code: coded.ErrorCode(), Message: coded.Error()}
+		var data DataError
+		if errors.As(err, &data) {
+			rpcErr.Data = data.ErrorData()
+		}
+		return rpcErr
+	}
+
+	if s.errorMapper != nil {
+		if rpcErr := s.errorMapper(err); rpcErr != nil {
+			return rpcErr
+		}
+	}
+
+	if s.internalErrorMessages {
+		return &RPCError{Code: CodeInternalError, Message: err.Error()}
+	}
+	return InternalError(nil)
+}
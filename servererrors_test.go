@@ -0,0 +1,88 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+var errNotFound = errors.New("not found")
+
+type balanceError struct {
+	account string
+}
+
+func (e *balanceError) Error() string          { return "insufficient funds" }
+func (e *balanceError) ErrorCode() int         { return -32010 }
+func (e *balanceError) ErrorData() interface{} { return map[string]string{"account": e.account} }
+
+type codeOnlyError struct{}
+
+func (e codeOnlyError) Error() string  { return "code only" }
+func (e codeOnlyError) ErrorCode() int { return -32011 }
+
+func TestServerErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(NewError(-32000, "custom", 1)).To(Equal(&RPCError{Code: -32000, Message: "custom", Data: 1}))
+	Expect(InvalidParams("a")).To(Equal(&RPCError{Code: CodeInvalidParams, Message: "invalid params", Data: "a"}))
+	Expect(MethodNotFound("x")).To(Equal(&RPCError{Code: CodeMethodNotFound, Message: "method not found: x"}))
+	Expect(InternalError(nil)).To(Equal(&RPCError{Code: CodeInternalError, Message: "internal error"}))
+
+	server := NewServerWithOpts(&ServerOpts{
+		ErrorMapper: func(err error) *RPCError {
+			if errors.Is(err, errNotFound) {
+				return NewError(-32004, "resource not found", nil)
+			}
+			return nil
+		},
+	})
+	errs := map[string]error{
+		"invalid":  InvalidParams(map[string]string{"amount": "must be positive"}),
+		"balance":  fmt.Errorf("transfer: %w", &balanceError{account: "alice"}),
+		"code":     codeOnlyError{},
+		"notFound": fmt.Errorf("lookup: %w", errNotFound),
+		"other":    errors.New("other"),
+	}
+	for method, err := range errs {
+		err := err
+		server.Register(method, func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+			return nil, err
+		})
+	}
+
+	tests := map[string]string{
+		"invalid":  `{"code":-32602,"message":"invalid params","data":{"amount":"must be positive"}}`,
+		"balance":  `{"code":-32010,"message":"insufficient funds","data":{"account":"alice"}}`,
+		"code":     `{"code":-32011,"message":"code only"}`,
+		"notFound": `{"code":-32004,"message":"resource not found"}`,
+		"other":    `{"code":-32603,"message":"internal error"}`,
+	}
+	for method, rpcErr := range tests {
+		response := server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"`+method+`","id":1}`))
+		Expect(string(response)).To(Equal(`{"jsonrpc":"2.0","error":`+rpcErr+`,"id":1}`), method)
+	}
+}
+
+func TestServerInternalErrorMessages(t *testing.T) {
+	RegisterTestingT(t)
+
+	failing := func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, errors.New(`query "SELECT * FROM accounts" failed: open /var/lib/db: permission denied`)
+	}
+	request := []byte(`{"jsonrpc":"2.0","method":"fail","id":1}`)
+
+	// the text of the error is not sent by default
+	server := NewServer()
+	server.Register("fail", failing)
+	Expect(string(server.HandleMessage(context.Background(), request))).
+		To(Equal(`{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":1}`))
+
+	server = NewServerWithOpts(&ServerOpts{InternalErrorMessages: true})
+	server.Register("fail", failing)
+	Expect(string(server.HandleMessage(context.Background(), request))).
+		To(ContainSubstring(`"message":"query \"SELECT * FROM accounts\" failed: open /var/lib/db: permission denied"`))
+}
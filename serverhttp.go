@@ -0,0 +1,107 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxRequestSize is the default of ServerOpts.MaxRequestSize.
+const defaultMaxRequestSize = 10 << 20
+
+// httpRequestContextKey is the context key of the http request a call was received with.
+type httpRequestContextKey struct{}
+
+// HTTPRequestFromContext returns the http request a call was received with, e.g. to read its headers
+// or the address of the client in a Handler. It returns nil if the call was not received by ServeHTTP().
+func HTTPRequestFromContext(ctx context.Context) *http.Request {
+	request, _ := ctx.Value(httpRequestContextKey{}).(*http.Request)
+	return request
+}
+
+// ServeHTTP handles a JSON-RPC request received over http, so the Server can be mounted on any mux or router,
+// e.g. http.Handle("/rpc", server).
+//
+// Requests to other paths than ServerOpts.Path, with other http methods than ServerOpts.AllowedMethods
+// or with other content types than ServerOpts.ContentTypes are rejected with an http error,
+// as are request bodies larger than ServerOpts.MaxRequestSize.
+// Preflight requests of browsers are answered if ServerOpts.CORS allows the origin.
+// The JSON-RPC response is sent with status 200, also for JSON-RPC errors,
+// notifications and batches of only notifications are answered with 204 No Content.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.path != "" && r.URL.Path != s.path {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.cors.handle(w, r) {
+		return
+	}
+
+	if !containsFold(s.allowedMethods, r.Method) {
+		w.Header().Set("Allow", strings.Join(s.allowedMethods, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var message []byte
+	if r.Method == http.MethodGet {
+		message = []byte(r.URL.Query().Get(defaultGETParam))
+	} else {
+		if !s.acceptsContentType(r.Header.Get("Content-Type")) {
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if s.maxRequestSize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxRequestSize)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		message = body
+	}
+
+	ctx := context.WithValue(r.Context(), httpRequestContextKey{}, r)
+	response := s.HandleMessage(ctx, message)
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// acceptsContentType returns true if the media type of contentType is one of the accepted content types.
+func (s *Server) acceptsContentType(contentType string) bool {
+	if containsFold(s.contentTypes, "*") {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return containsFold(s.contentTypes, mediaType)
+}
+
+// containsFold returns true if values contains value, compared case-insensitively.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
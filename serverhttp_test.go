@@ -0,0 +1,143 @@
+package jsonrpc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerServeHTTP(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newAddServer()
+	server.Register("userAgent", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return HTTPRequestFromContext(ctx).UserAgent(), nil
+	})
+
+	rpcClient := ClientFromHandlerWithOpts(server, &RPCClientOpts{UserAgent: "test-agent"})
+	var sum int
+	Expect(rpcClient.CallFor(&sum, "add", 1, 2)).To(Succeed())
+	Expect(sum).To(Equal(3))
+
+	var userAgent string
+	Expect(rpcClient.CallFor(&userAgent, "userAgent")).To(Succeed())
+	Expect(userAgent).To(Equal("test-agent"))
+
+	response, err := rpcClient.Call("add", 1)
+	Expect(err).To(BeNil())
+	Expect(response.Error.Code).To(Equal(CodeInvalidParams))
+
+	// GET is not allowed by default
+	getClient := ClientFromHandlerWithOpts(server, &RPCClientOpts{HTTPMethod: http.MethodGet})
+	_, err = getClient.Call("add", 1, 2)
+	Expect(err).NotTo(BeNil())
+	Expect(err.(*HTTPError).Code).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func TestServerServeHTTPOpts(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServerWithOpts(&ServerOpts{
+		Path:           "/rpc",
+		AllowedMethods: []string{http.MethodPost, http.MethodGet},
+		ContentTypes:   []string{"application/json", "text/plain"},
+	})
+	server.Register("echo", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var params []string
+		err := request.DecodeParams(&params)
+		return params, err
+	})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	post := func(path string, contentType string) (int, string) {
+		res, err := http.Post(httpServer.URL+path, contentType, strings.NewReader(`{"jsonrpc":"2.0","method":"echo","params":["a"],"id":1}`))
+		Expect(err).To(BeNil())
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	status, body := post("/rpc", "application/json; charset=utf-8")
+	Expect(status).To(Equal(http.StatusOK))
+	Expect(body).To(Equal(`{"jsonrpc":"2.0","result":["a"],"id":1}`))
+
+	res, err := http.Post(httpServer.URL+"/rpc", "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"echo"}`))
+	Expect(err).To(BeNil())
+	res.Body.Close()
+	Expect(res.StatusCode).To(Equal(http.StatusNoContent))
+
+	status, _ = post("/rpc", "TEXT/PLAIN")
+	Expect(status).To(Equal(http.StatusOK))
+
+	status, _ = post("/rpc", "application/x-www-form-urlencoded")
+	Expect(status).To(Equal(http.StatusUnsupportedMediaType))
+
+	status, _ = post("/rpc", "")
+	Expect(status).To(Equal(http.StatusUnsupportedMediaType))
+
+	status, _ = post("/other", "application/json")
+	Expect(status).To(Equal(http.StatusNotFound))
+
+	req, _ := http.NewRequest(http.MethodPut, httpServer.URL+"/rpc", nil)
+	res, err = http.DefaultClient.Do(req)
+	Expect(err).To(BeNil())
+	res.Body.Close()
+	Expect(res.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	Expect(res.Header.Get("Allow")).To(Equal("POST, GET"))
+
+	// requests of GET clients are read from the url
+	rpcClient := NewClientWithOpts(httpServer.URL+"/rpc", &RPCClientOpts{HTTPMethod: http.MethodGet})
+	var echo []string
+	Expect(rpcClient.CallFor(&echo, "echo", "b")).To(Succeed())
+	Expect(echo).To(Equal([]string{"b"}))
+
+	// any content type
+	server = NewServerWithOpts(&ServerOpts{ContentTypes: []string{"*"}})
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+	Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+}
+
+func TestServerServeHTTPMaxRequestSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServerWithOpts(&ServerOpts{MaxRequestSize: 64})
+	server.Register("echo", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var params []string
+		err := request.DecodeParams(&params)
+		return params, err
+	})
+
+	post := func(server *Server, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := post(server, `{"jsonrpc":"2.0","method":"echo","params":["a"],"id":1}`)
+	Expect(recorder.Code).To(Equal(http.StatusOK))
+	Expect(recorder.Body.String()).To(Equal(`{"jsonrpc":"2.0","result":["a"],"id":1}`))
+
+	large := `{"jsonrpc":"2.0","method":"echo","params":["` + strings.Repeat("a", 64) + `"],"id":1}`
+	recorder = post(server, large)
+	Expect(recorder.Code).To(Equal(http.StatusRequestEntityTooLarge))
+
+	// no limit
+	server = NewServerWithOpts(&ServerOpts{MaxRequestSize: -1})
+	server.Register("echo", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "ok", nil
+	})
+	Expect(post(server, large).Code).To(Equal(http.StatusOK))
+
+	// the default limit
+	Expect(NewServer().maxRequestSize).To(Equal(int64(defaultMaxRequestSize)))
+}
@@ -0,0 +1,127 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// SlogMiddlewareOpts configures the access logs of SlogMiddleware().
+//
+// Logger: the logger (default slog.Default())
+//
+// Level: the level of successful requests (default slog.LevelInfo)
+//
+// ErrorLevel: the level of requests whose handler returned an error (default slog.LevelWarn)
+//
+// SampleRate: the share of successful requests that are logged, e.g. 0.1 logs every 10th request (default 1: all).
+// Failed and slow requests are always logged.
+//
+// SlowThreshold: requests that take at least this long are logged at SlowLevel, disabled if 0
+//
+// SlowLevel: the level of slow requests (default slog.LevelWarn)
+type SlogMiddlewareOpts struct {
+	Logger        *slog.Logger
+	Level         slog.Leveler
+	ErrorLevel    slog.Leveler
+	SampleRate    float64
+	SlowThreshold time.Duration
+	SlowLevel     slog.Leveler
+}
+
+// SlogMiddleware returns a server Middleware that logs the requests with log/slog, e.g.
+//
+//	server := jsonrpc.NewServerWithOpts(&jsonrpc.ServerOpts{
+//		Middleware: []jsonrpc.Middleware{jsonrpc.SlogMiddleware(&jsonrpc.SlogMiddlewareOpts{SampleRate: 0.1})},
+//	})
+//
+// The record contains the method, id (or notification), duration, outcome and the address of the http client,
+// and the error or the RPC error code and message.
+//
+// opts: SlogMiddlewareOpts provide custom configuration, may be nil
+func SlogMiddleware(opts *SlogMiddlewareOpts) Middleware {
+	if opts == nil {
+		opts = &SlogMiddlewareOpts{}
+	}
+	var level, errorLevel, slowLevel slog.Leveler = slog.LevelInfo, slog.LevelWarn, slog.LevelWarn
+	if opts.Level != nil {
+		level = opts.Level
+	}
+	if opts.ErrorLevel != nil {
+		errorLevel = opts.ErrorLevel
+	}
+	if opts.SlowLevel != nil {
+		slowLevel = opts.SlowLevel
+	}
+	sampleRate := opts.SampleRate
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+	var count uint64
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+			logger := opts.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+
+			start := time.Now()
+			result, err := next(ctx, request)
+			duration := time.Since(start)
+
+			slow := opts.SlowThreshold > 0 && duration >= opts.SlowThreshold
+			recordLevel, msg := level.Level(), "rpc request"
+			switch {
+			case err != nil:
+				recordLevel, msg = errorLevel.Level(), "rpc request failed"
+			case slow:
+				recordLevel, msg = slowLevel.Level(), "slow rpc request"
+			default:
+				// every request counts, so that the share of logged requests is sampleRate
+				n := atomic.AddUint64(&count, 1)
+				if uint64(float64(n)*sampleRate) == uint64(float64(n-1)*sampleRate) {
+					return result, err
+				}
+			}
+			if !logger.Enabled(ctx, recordLevel) {
+				return result, err
+			}
+
+			attrs := []slog.Attr{slog.String("method", request.Method)}
+			if request.Notification {
+				attrs = append(attrs, slog.Bool("notification", true))
+			} else {
+				attrs = append(attrs, slog.String("id", request.ID.String()))
+			}
+			attrs = append(attrs, slog.Duration("duration", duration))
+			if slow {
+				attrs = append(attrs, slog.Bool("slow", true))
+			}
+			if httpRequest := HTTPRequestFromContext(ctx); httpRequest != nil {
+				attrs = append(attrs, slog.String("client", httpRequest.RemoteAddr))
+			}
+
+			if err == nil {
+				attrs = append(attrs, slog.String("outcome", "ok"))
+			} else {
+				attrs = append(attrs, slog.String("outcome", "error"))
+				var rpcErr *RPCError
+				var coded CodedError
+				switch {
+				case errors.As(err, &rpcErr) && rpcErr != nil:
+					attrs = append(attrs, slog.Int("rpc_error_code", rpcErr.Code), slog.String("rpc_error_message", rpcErr.Message))
+				case errors.As(err, &coded):
+					attrs = append(attrs, slog.Int("rpc_error_code", coded.ErrorCode()), slog.String("rpc_error_message", coded.Error()))
+				default:
+					attrs = append(attrs, slog.String("error", err.Error()))
+				}
+			}
+
+			logger.LogAttrs(ctx, recordLevel, msg, attrs...)
+			return result, err
+		}
+	}
+}
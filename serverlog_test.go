@@ -0,0 +1,85 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSlogMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == "duration" {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}))
+	server := NewServerWithOpts(&ServerOpts{Middleware: []Middleware{SlogMiddleware(&SlogMiddlewareOpts{
+		Logger:        logger,
+		SampleRate:    0.5,
+		SlowThreshold: 20 * time.Millisecond,
+	})}})
+	server.Register("ok", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "ok", nil
+	})
+	server.Register("slow", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return "ok", nil
+	})
+	server.Register("fail", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, InvalidParams(nil)
+	})
+	server.Register("broken", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, errors.New("broken")
+	})
+
+	records := func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]interface{}
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		output.Reset()
+		return records
+	}
+
+	// every second successful request is logged
+	for i := 1; i <= 4; i++ {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"ok","id":1}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.RemoteAddr = "10.0.0.1:1234"
+		server.ServeHTTP(httptest.NewRecorder(), request)
+	}
+	Expect(records()).To(Equal([]map[string]interface{}{
+		{"level": "INFO", "msg": "rpc request", "method": "ok", "id": "1", "client": "10.0.0.1:1234", "outcome": "ok"},
+		{"level": "INFO", "msg": "rpc request", "method": "ok", "id": "1", "client": "10.0.0.1:1234", "outcome": "ok"},
+	}))
+
+	// failed and slow requests are always logged
+	server.HandleMessage(context.Background(), []byte(`[`+
+		`{"jsonrpc":"2.0","method":"fail","id":"a"},`+
+		`{"jsonrpc":"2.0","method":"broken"},`+
+		`{"jsonrpc":"2.0","method":"slow","id":2}]`))
+	Expect(records()).To(Equal([]map[string]interface{}{
+		{"level": "WARN", "msg": "rpc request failed", "method": "fail", "id": "a", "outcome": "error", "rpc_error_code": float64(-32602), "rpc_error_message": "invalid params"},
+		{"level": "WARN", "msg": "rpc request failed", "method": "broken", "notification": true, "outcome": "error", "error": "broken"},
+		{"level": "WARN", "msg": "slow rpc request", "method": "slow", "id": "2", "slow": true, "outcome": "ok"},
+	}))
+}
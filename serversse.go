@@ -0,0 +1,136 @@
+package jsonrpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SSEServerOpts configures the Server-Sent Events handler of a Server, see SSEHandler().
+//
+// KeepAliveInterval: if > 0, a comment is sent in this interval, so proxies do not close idle streams
+type SSEServerOpts struct {
+	KeepAliveInterval time.Duration
+}
+
+// SSEHandler returns an http.Handler that streams notifications to clients as Server-Sent Events
+// (text/event-stream), e.g. for DialSSE() or the EventSource of browsers. The data of every event is a notification.
+//
+// Every stream is a persistent connection of the server that receives the notifications of Broadcast(),
+// clients can not send requests over it. The stream ends when the client disconnects.
+//
+// opts: SSEServerOpts provide custom configuration, may be nil
+func (s *Server) SSEHandler(opts *SSEServerOpts) http.Handler {
+	if opts == nil {
+		opts = &SSEServerOpts{}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cors.handle(w, r) {
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ctx := context.WithValue(r.Context(), httpRequestContextKey{}, r)
+		s.serveConn(ctx, newSSEServerConn(ctx, w, flusher, opts.KeepAliveInterval), 1)
+	})
+}
+
+// sseServerConn is the messageConn of an event stream, it only sends messages.
+type sseServerConn struct {
+	ctx     context.Context
+	mutex   sync.Mutex
+	w       io.Writer
+	flusher http.Flusher
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newSSEServerConn(ctx context.Context, w io.Writer, flusher http.Flusher, keepAliveInterval time.Duration) *sseServerConn {
+	c := &sseServerConn{
+		ctx:     ctx,
+		w:       w,
+		flusher: flusher,
+		closed:  make(chan struct{}),
+	}
+	if keepAliveInterval > 0 {
+		go c.keepAliveLoop(keepAliveInterval)
+	}
+	return c
+}
+
+func (c *sseServerConn) keepAliveLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			if c.write([]byte(":\n\n")) != nil {
+				return
+			}
+		}
+	}
+}
+
+// readMessage waits until the stream is closed, clients can not send messages over it.
+func (c *sseServerConn) readMessage() ([]byte, error) {
+	select {
+	case <-c.closed:
+	case <-c.ctx.Done():
+	}
+	return nil, io.EOF
+}
+
+func (c *sseServerConn) writeMessage(message []byte) error {
+	event := make([]byte, 0, len(message)+8)
+	event = append(event, "data: "...)
+	event = append(event, message...)
+	event = append(event, "\n\n"...)
+	return c.write(event)
+}
+
+func (c *sseServerConn) write(data []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	select {
+	case <-c.closed:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	if _, err := c.w.Write(data); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+// close waits for a pending write, the writer must not be used after the handler returned.
+func (c *sseServerConn) close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
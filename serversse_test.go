@@ -0,0 +1,61 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerSSEHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServer()
+	httpServer := httptest.NewServer(server.SSEHandler(nil))
+	defer httpServer.Close()
+
+	stream, err := DialSSE(httpServer.URL)
+	Expect(err).To(BeNil())
+	Eventually(func() int { return len(server.connections()) }).Should(Equal(1))
+
+	sent, err := server.Broadcast("block", map[string]int{"number": 1})
+	Expect(err).To(BeNil())
+	Expect(sent).To(Equal(1))
+	var notification *RPCNotification
+	Eventually(stream.Notifications()).Should(Receive(&notification))
+	Expect(notification.Method).To(Equal("block"))
+	var params struct {
+		Number int `json:"number"`
+	}
+	Expect(notification.GetObject(&params)).To(Succeed())
+	Expect(params.Number).To(Equal(1))
+
+	stream.Close()
+	Eventually(func() int { return len(server.connections()) }).Should(Equal(0))
+
+	res, err := http.Post(httpServer.URL, "application/json", nil)
+	Expect(err).To(BeNil())
+	res.Body.Close()
+	Expect(res.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func TestServerSSEHandlerKeepAlive(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServer()
+	httpServer := httptest.NewServer(server.SSEHandler(&SSEServerOpts{KeepAliveInterval: 10 * time.Millisecond}))
+	defer httpServer.Close()
+
+	res, err := http.Get(httpServer.URL)
+	Expect(err).To(BeNil())
+	defer res.Body.Close()
+	Expect(res.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	Expect(err).To(BeNil())
+	Expect(line).To(Equal(":\n"))
+}
@@ -0,0 +1,61 @@
+package jsonrpc
+
+import (
+	"context"
+	"io"
+	"net"
+)
+
+// StreamServerOpts configures ServeStream() and ServeListener().
+//
+// Framing: how messages are separated on the stream (default FramingNewline, as sent by IPC and TCP clients)
+//
+// MaxConcurrentRequests: how many requests of a connection are handled concurrently (default 16),
+// further requests wait until a handler returns
+//
+// ReadLimit: the maximum size of a message in bytes with FramingContentLength, the connection is closed
+// if a client announces a larger message (default: unlimited)
+type StreamServerOpts struct {
+	Framing               Framing
+	MaxConcurrentRequests int
+	ReadLimit             int64
+}
+
+// ServeStream serves JSON-RPC requests over a byte stream, e.g. an accepted unix socket or TCP connection,
+// or the stdin / stdout of the process (see StdioConn()). Like connections of WSHandler(), the stream is
+// a persistent connection: handlers can send notifications and call methods of the client with ServerConnFromContext().
+//
+// It blocks until the stream is closed or ctx is done, and closes the stream.
+//
+// opts: StreamServerOpts provide custom configuration, may be nil
+func (s *Server) ServeStream(ctx context.Context, conn io.ReadWriteCloser, opts *StreamServerOpts) {
+	if opts == nil {
+		opts = &StreamServerOpts{}
+	}
+
+	messageConn := newFramedConn(conn, opts.Framing, opts.ReadLimit)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// unblocks the read of a stream that is not closed by the client
+		<-ctx.Done()
+		messageConn.close()
+	}()
+
+	s.serveConn(ctx, messageConn, opts.MaxConcurrentRequests)
+}
+
+// ServeListener accepts connections on listener, e.g. a unix socket for IPC clients, and serves every connection
+// with ServeStream() in its own goroutine. It returns the error of Accept(), e.g. when the listener was closed.
+// The accepted connections are not closed by closing the listener.
+//
+// opts: StreamServerOpts provide custom configuration, may be nil
+func (s *Server) ServeListener(listener net.Listener, opts *StreamServerOpts) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeStream(context.Background(), conn, opts)
+	}
+}
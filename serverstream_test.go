@@ -0,0 +1,160 @@
+package jsonrpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// newCallbackServer returns a Server with the method "greet" that asks the client for a name with the method "name".
+func newCallbackServer() *Server {
+	server := NewServer()
+	server.Register("greet", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var name string
+		if err := ServerConnFromContext(ctx).CallFor(ctx, &name, "name", "greet"); err != nil {
+			return nil, err
+		}
+		return "hello " + name, nil
+	})
+	return server
+}
+
+func TestServerServeStream(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newCallbackServer()
+	clientServer := NewServer()
+	clientServer.Register("name", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var params []string
+		if err := request.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		return "client of " + params[0], nil
+	})
+
+	serverSide, clientSide := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		server.ServeStream(context.Background(), serverSide, nil)
+		close(done)
+	}()
+	rpcClient := NewStreamClient(clientSide, &StreamClientOpts{RPCClientOpts: RPCClientOpts{Server: clientServer}})
+
+	var greeting string
+	Expect(rpcClient.CallFor(&greeting, "greet")).To(Succeed())
+	Expect(greeting).To(Equal("hello client of greet"))
+
+	// the error object of the client is returned to the handler
+	server.Register("fail", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return nil, ServerConnFromContext(ctx).CallFor(ctx, nil, "missing")
+	})
+	response, err := rpcClient.Call("fail")
+	Expect(err).To(BeNil())
+	Expect(response.Error).To(Equal(&RPCError{Code: CodeMethodNotFound, Message: "method not found: missing"}))
+
+	rpcClient.Close()
+	Eventually(done).Should(BeClosed())
+}
+
+func TestServerServeStreamMaxConcurrentRequests(t *testing.T) {
+	RegisterTestingT(t)
+
+	// all handlers wait for the response of the client, while the client sends another request
+	server := newCallbackServer()
+	var asked int32
+	release := make(chan struct{})
+	clientServer := NewServer()
+	clientServer.Register("name", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		atomic.AddInt32(&asked, 1)
+		<-release
+		return "client", nil
+	})
+
+	serverSide, clientSide := net.Pipe()
+	go server.ServeStream(context.Background(), serverSide, &StreamServerOpts{MaxConcurrentRequests: 2})
+	rpcClient := NewStreamClient(clientSide, &StreamClientOpts{RPCClientOpts: RPCClientOpts{Server: clientServer}})
+	defer rpcClient.Close()
+
+	results := make(chan interface{}, 3)
+	greet := func() {
+		go func() {
+			var greeting string
+			if err := rpcClient.CallFor(&greeting, "greet"); err != nil {
+				results <- err
+				return
+			}
+			results <- greeting
+		}()
+	}
+	greet()
+	greet()
+	Eventually(func() int32 { return atomic.LoadInt32(&asked) }).Should(Equal(int32(2)))
+	greet()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 3; i++ {
+		Eventually(results, 2*time.Second).Should(Receive(Equal("hello client")))
+	}
+}
+
+func TestServerServeStreamWithoutClientServer(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServerWithOpts(&ServerOpts{InternalErrorMessages: true})
+	server.Register("ask", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return ServerConnFromContext(ctx).Call(ctx, "question")
+	})
+
+	serverSide, clientSide := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.ServeStream(ctx, serverSide, &StreamServerOpts{Framing: FramingContentLength})
+		close(done)
+	}()
+	rpcClient := NewStreamClient(clientSide, &StreamClientOpts{Framing: FramingContentLength})
+	defer rpcClient.Close()
+
+	// the client drops the request, so the call times out
+	response, err := rpcClient.Call("ask")
+	Expect(err).To(BeNil())
+	Expect(response.Error.Message).To(ContainSubstring(context.DeadlineExceeded.Error()))
+
+	// the stream is closed when ctx is done
+	cancel()
+	Eventually(done).Should(BeClosed())
+}
+
+func TestServerServeListener(t *testing.T) {
+	RegisterTestingT(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).To(BeNil())
+	server := newCallbackServer()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ServeListener(listener, nil)
+	}()
+
+	clientServer := NewServer()
+	clientServer.Register("name", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "tcp", nil
+	})
+	rpcClient, err := DialTCPWithOpts(listener.Addr().String(), &TCPClientOpts{RPCClientOpts: RPCClientOpts{Server: clientServer}})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	var greeting string
+	Expect(rpcClient.CallFor(&greeting, "greet")).To(Succeed())
+	Expect(greeting).To(Equal("hello tcp"))
+
+	listener.Close()
+	Eventually(served).Should(Receive(HaveOccurred()))
+}
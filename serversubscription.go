@@ -0,0 +1,292 @@
+package jsonrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// ErrSubscriptionEnded is returned when a notification is sent for a ServerSubscription that has ended.
+var ErrSubscriptionEnded = errors.New("subscription ended")
+
+// SubscriptionHandler starts a subscription of a client, see RegisterSubscription().
+//
+// It is called with the params of the subscribe request that follow the subscription name and returns an error
+// if the subscription can not be created. The notifications are sent with subscription.Notify(), e.g. from a goroutine,
+// until subscription.Done() is closed because the client unsubscribed or the connection was closed.
+type SubscriptionHandler func(ctx context.Context, subscription *ServerSubscription, request *ServerRequest) error
+
+// RegisterSubscription registers a subscription that clients of persistent connections (see WSHandler()) create
+// with the method <namespace>_subscribe and end with <namespace>_unsubscribe, e.g. for the namespace "eth"
+// and the name "newHeads":
+//
+//	--> {"jsonrpc":"2.0","method":"eth_subscribe","params":["newHeads"],"id":1}
+//	<-- {"jsonrpc":"2.0","result":"0xcd0c3e8af590364c09d0fa6a1210faf5","id":1}
+//	<-- {"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xcd0c3e8af590364c09d0fa6a1210faf5","result":{...}}}
+//	--> {"jsonrpc":"2.0","method":"eth_unsubscribe","params":["0xcd0c3e8af590364c09d0fa6a1210faf5"],"id":2}
+//
+// This is the format of ConnClient.Subscribe(). Without namespace the methods are subscribe, unsubscribe and subscription.
+// The subscriptions of a connection end when it is closed.
+//
+// It panics if name is empty, handler is nil or the subscription is already registered.
+func (s *Server) RegisterSubscription(namespace string, name string, handler SubscriptionHandler) {
+	if name == "" {
+		panic("jsonrpc: empty subscription name")
+	}
+	if handler == nil {
+		panic("jsonrpc: nil handler for subscription " + name)
+	}
+
+	s.mutex.Lock()
+	if s.subscriptionHandlers == nil {
+		s.subscriptionHandlers = make(map[string]map[string]SubscriptionHandler)
+	}
+	handlers, ok := s.subscriptionHandlers[namespace]
+	if !ok {
+		handlers = make(map[string]SubscriptionHandler)
+		s.subscriptionHandlers[namespace] = handlers
+	}
+	if _, ok := handlers[name]; ok {
+		s.mutex.Unlock()
+		panic("jsonrpc: subscription registered twice: " + name)
+	}
+	handlers[name] = handler
+	s.mutex.Unlock()
+
+	if !ok {
+		s.Register(serviceMethodName(namespace, "subscribe"), s.subscribe(namespace))
+		s.Register(serviceMethodName(namespace, "unsubscribe"), s.unsubscribe(namespace))
+	}
+}
+
+// subscriptionHandler returns the handler of the subscription, or nil if it is not registered.
+func (s *Server) subscriptionHandler(namespace string, name string) SubscriptionHandler {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.subscriptionHandlers[namespace][name]
+}
+
+// errNotificationsUnsupported is returned for subscribe requests that were not received over a persistent connection.
+var errNotificationsUnsupported = &RPCError{Code: CodeMethodNotFound, Message: "notifications not supported"}
+
+// subscribe returns the handler of the subscribe method of namespace.
+func (s *Server) subscribe(namespace string) Handler {
+	return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		conn := ServerConnFromContext(ctx)
+		if conn == nil {
+			return nil, errNotificationsUnsupported
+		}
+
+		var params []json.RawMessage
+		if err := request.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		var name string
+		if len(params) == 0 || json.Unmarshal(params[0], &name) != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: the first param must be the subscription name"}
+		}
+		handler := s.subscriptionHandler(namespace, name)
+		if handler == nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: unknown subscription " + name}
+		}
+
+		subscriptionRequest := *request
+		subscriptionRequest.Params = nil
+		if len(params) > 1 {
+			subscriptionRequest.Params, _ = json.Marshal(params[1:])
+		}
+
+		subscription, err := newServerSubscription(conn, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !conn.addSubscription(subscription) {
+			return nil, ErrConnClosed
+		}
+		if err := handler(ctx, subscription, &subscriptionRequest); err != nil {
+			conn.removeSubscription(subscription.id, namespace)
+			return nil, err
+		}
+
+		if started, ok := ctx.Value(startedSubscriptionsContextKey{}).(*startedSubscriptions); ok {
+			started.add(subscription)
+		} else {
+			subscription.start()
+		}
+		return subscription.id, nil
+	}
+}
+
+// unsubscribe returns the handler of the unsubscribe method of namespace, it returns false for unknown subscriptions.
+func (s *Server) unsubscribe(namespace string) Handler {
+	return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		conn := ServerConnFromContext(ctx)
+		if conn == nil {
+			return nil, errNotificationsUnsupported
+		}
+
+		var params []ID
+		if err := request.DecodeParams(&params); err != nil {
+			return nil, err
+		}
+		if len(params) != 1 {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: expected the subscription id"}
+		}
+
+		return conn.removeSubscription(params[0], namespace), nil
+	}
+}
+
+// addSubscription adds subscription to the connection, it returns false if the connection is closed.
+func (c *ServerConn) addSubscription(subscription *ServerSubscription) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return false
+	}
+	c.subscriptions[subscription.id] = subscription
+	return true
+}
+
+// removeSubscription ends the subscription id of namespace, it returns false if there is no such subscription.
+func (c *ServerConn) removeSubscription(id ID, namespace string) bool {
+	c.mutex.Lock()
+	subscription, ok := c.subscriptions[id]
+	if ok && subscription.namespace == namespace {
+		delete(c.subscriptions, id)
+	}
+	c.mutex.Unlock()
+
+	if !ok || subscription.namespace != namespace {
+		return false
+	}
+	subscription.end()
+	return true
+}
+
+// ServerSubscription is a subscription of a client of a persistent connection, see RegisterSubscription().
+type ServerSubscription struct {
+	id        ID
+	namespace string
+	method    string
+	conn      *ServerConn
+	done      chan struct{}
+
+	mutex   sync.Mutex
+	started bool
+	ended   bool
+	pending [][]byte
+}
+
+func newServerSubscription(conn *ServerConn, namespace string) (*ServerSubscription, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	return &ServerSubscription{
+		id:        StringID("0x" + hex.EncodeToString(random)),
+		namespace: namespace,
+		method:    serviceMethodName(namespace, "subscription"),
+		conn:      conn,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// ID returns the id of the subscription, which the client uses to unsubscribe.
+func (s *ServerSubscription) ID() ID {
+	return s.id
+}
+
+// Conn returns the connection of the subscription.
+func (s *ServerSubscription) Conn() *ServerConn {
+	return s.conn
+}
+
+// Done returns a channel that is closed when the subscription ends.
+func (s *ServerSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Notify sends a notification with result to the client. Notifications that are sent before the response
+// of the subscribe request are delayed until it was sent. It returns ErrSubscriptionEnded if the subscription has ended.
+func (s *ServerSubscription) Notify(result interface{}) error {
+	message, err := json.Marshal(&RPCNotification{
+		JSONRPC: jsonrpcVersion,
+		Method:  s.method,
+		Params: &subscriptionParams{
+			Subscription: s.id,
+			Result:       result,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ended {
+		return ErrSubscriptionEnded
+	}
+	if !s.started {
+		s.pending = append(s.pending, message)
+		return nil
+	}
+	return s.conn.write(message)
+}
+
+// subscriptionParams are the params of subscription notifications.
+type subscriptionParams struct {
+	Subscription ID          `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// start sends the delayed notifications, the following notifications are sent directly.
+func (s *ServerSubscription) start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.started = true
+	for _, message := range s.pending {
+		if s.ended || s.conn.write(message) != nil {
+			break
+		}
+	}
+	s.pending = nil
+}
+
+// end ends the subscription.
+func (s *ServerSubscription) end() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.ended {
+		s.ended = true
+		close(s.done)
+	}
+}
+
+// startedSubscriptionsContextKey is the context key of the subscriptions created while a message is handled.
+type startedSubscriptionsContextKey struct{}
+
+// startedSubscriptions collects the subscriptions created while a message is handled, they are started
+// when the response was sent.
+type startedSubscriptions struct {
+	mutex         sync.Mutex
+	subscriptions []*ServerSubscription
+}
+
+func (s *startedSubscriptions) add(subscription *ServerSubscription) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subscriptions = append(s.subscriptions, subscription)
+}
+
+func (s *startedSubscriptions) start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, subscription := range s.subscriptions {
+		subscription.start()
+	}
+}
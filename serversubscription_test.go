@@ -0,0 +1,99 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerRegisterSubscription(t *testing.T) {
+	RegisterTestingT(t)
+
+	ended := make(chan ID, 2)
+	server := NewServer()
+	server.RegisterSubscription("eth", "newHeads", func(ctx context.Context, subscription *ServerSubscription, request *ServerRequest) error {
+		var params []int
+		if err := request.DecodeParams(&params); err != nil {
+			return err
+		}
+		start := 0
+		if len(params) > 0 {
+			start = params[0]
+		}
+
+		// notifications before the response are delayed
+		Expect(subscription.Notify(start)).To(Succeed())
+		go func() {
+			for i := start + 1; ; i++ {
+				select {
+				case <-subscription.Done():
+					Expect(subscription.Notify(i)).To(Equal(ErrSubscriptionEnded))
+					ended <- subscription.ID()
+					return
+				case <-time.After(5 * time.Millisecond):
+					if subscription.Notify(i) != nil {
+						return
+					}
+				}
+			}
+		}()
+		return nil
+	})
+	server.RegisterSubscription("eth", "failing", func(ctx context.Context, subscription *ServerSubscription, request *ServerRequest) error {
+		return &RPCError{Code: -32000, Message: "not available"}
+	})
+	Expect(func() { server.RegisterSubscription("eth", "newHeads", nil) }).To(Panic())
+	Expect(func() {
+		server.RegisterSubscription("eth", "newHeads", func(context.Context, *ServerSubscription, *ServerRequest) error { return nil })
+	}).To(Panic())
+
+	httpServer := httptest.NewServer(server.WSHandler(nil))
+	defer httpServer.Close()
+	rpcClient, err := DialWS("ws" + strings.TrimPrefix(httpServer.URL, "http"))
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	subscription, err := rpcClient.Subscribe("eth_subscribe", "newHeads", 10)
+	Expect(err).To(BeNil())
+	id, _ := subscription.ID().Str()
+	Expect(id).To(HavePrefix("0x"))
+	for i := 10; i < 13; i++ {
+		var notification *RPCNotification
+		Eventually(subscription.Notifications()).Should(Receive(&notification))
+		var params struct {
+			Subscription string `json:"subscription"`
+			Result       int    `json:"result"`
+		}
+		Expect(notification.GetObject(&params)).To(Succeed())
+		Expect(notification.Method).To(Equal("eth_subscription"))
+		Expect(params.Subscription).To(Equal(id))
+		Expect(params.Result).To(Equal(i))
+	}
+
+	Expect(subscription.Unsubscribe()).To(Succeed())
+	Eventually(ended).Should(Receive(Equal(subscription.ID())))
+
+	var unsubscribed bool
+	Expect(rpcClient.CallFor(&unsubscribed, "eth_unsubscribe", id)).To(Succeed())
+	Expect(unsubscribed).To(BeFalse())
+
+	_, err = rpcClient.Subscribe("eth_subscribe", "failing")
+	Expect(err).To(Equal(&RPCError{Code: -32000, Message: "not available"}))
+	_, err = rpcClient.Subscribe("eth_subscribe", "unknown")
+	Expect(err.(*RPCError).Code).To(Equal(CodeInvalidParams))
+
+	// subscriptions end when the connection is closed
+	subscription, err = rpcClient.Subscribe("eth_subscribe", "newHeads")
+	Expect(err).To(BeNil())
+	rpcClient.Close()
+	Eventually(ended).Should(Receive(Equal(subscription.ID())))
+
+	// subscriptions need a persistent connection
+	response, err := ClientFromHandler(server).Call("eth_subscribe", "newHeads")
+	Expect(err).To(BeNil())
+	Expect(response.Error).To(Equal(&RPCError{Code: CodeMethodNotFound, Message: "notifications not supported"}))
+}
@@ -0,0 +1,36 @@
+package jsonrpc
+
+// Version returns the method set of version, e.g. "v2", to serve several versions of an API with one Server.
+//
+// The version is a Server with the configuration and middleware of this server, except Path, that serves
+// the methods of this server and the methods registered on the version itself, which take precedence.
+// So a version only registers the methods that changed, and mounts its own endpoints, e.g.:
+//
+//	http.Handle("/v1", server)
+//	http.Handle("/v2", server.Version("v2"))
+//
+// Namespaces that are disabled on this server are disabled in all versions, namespaces can also be disabled
+// for a single version. Broadcast() of a version reaches the clients connected to the version.
+// Version returns the same Server for the same version, versions of versions are versions of this server.
+func (s *Server) Version(version string) *Server {
+	if s.parent != nil {
+		return s.parent.Version(version)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if server, ok := s.versions[version]; ok {
+		return server
+	}
+
+	opts := s.opts
+	opts.Path = ""
+	opts.DisabledNamespaces = nil
+	server := NewServerWithOpts(&opts)
+	server.parent = s
+	if s.versions == nil {
+		s.versions = make(map[string]*Server)
+	}
+	s.versions[version] = server
+	return server
+}
@@ -0,0 +1,80 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerVersion(t *testing.T) {
+	RegisterTestingT(t)
+
+	calls := 0
+	server := NewServerWithOpts(&ServerOpts{
+		Path: "/v1",
+		Middleware: []Middleware{
+			func(next Handler) Handler {
+				return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+					calls++
+					return next(ctx, request)
+				}
+			},
+		},
+	})
+	eth := server.Namespace("eth")
+	eth.Register("getBlock", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "block v1", nil
+	})
+	eth.Register("chainId", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "0x1", nil
+	})
+
+	v2 := server.Version("v2")
+	Expect(server.Version("v2")).To(BeIdenticalTo(v2))
+	Expect(v2.Version("v2")).To(BeIdenticalTo(v2))
+	Expect(server.Version("v3")).NotTo(BeIdenticalTo(v2))
+	v2.Namespace("eth").Register("getBlock", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "block v2", nil
+	})
+	v2.Register("trace_call", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "trace", nil
+	})
+
+	v1Server := httptest.NewServer(server)
+	defer v1Server.Close()
+	v2Server := httptest.NewServer(v2)
+	defer v2Server.Close()
+	v1Client := NewClient(v1Server.URL + "/v1")
+	v2Client := NewClient(v2Server.URL + "/v2")
+
+	// the version overrides methods and inherits the others
+	var result string
+	Expect(v1Client.CallFor(&result, "eth_getBlock")).To(Succeed())
+	Expect(result).To(Equal("block v1"))
+	Expect(v2Client.CallFor(&result, "eth_getBlock")).To(Succeed())
+	Expect(result).To(Equal("block v2"))
+	Expect(v2Client.CallFor(&result, "eth_chainId")).To(Succeed())
+	Expect(result).To(Equal("0x1"))
+	Expect(v2Client.CallFor(&result, "trace_call")).To(Succeed())
+	response, err := v1Client.Call("trace_call")
+	Expect(err).To(BeNil())
+	Expect(response.Error.Code).To(Equal(CodeMethodNotFound))
+
+	// the middleware of the server wraps the methods of the version
+	Expect(calls).To(Equal(5))
+
+	// namespaces can be disabled for a single version or for all versions
+	v2.Namespace("trace").Disable()
+	response, err = v2Client.Call("trace_call")
+	Expect(err).To(BeNil())
+	Expect(response.Error.Code).To(Equal(CodeMethodNotFound))
+
+	eth.Disable()
+	Expect(v2.Namespace("eth").Enabled()).To(BeFalse())
+	v2.Namespace("eth").Enable()
+	response, err = v2Client.Call("eth_getBlock")
+	Expect(err).To(BeNil())
+	Expect(response.Error.Code).To(Equal(CodeMethodNotFound))
+}
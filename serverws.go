@@ -0,0 +1,66 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WSServerOpts configures the websocket handler of a Server, see WSHandler().
+//
+// Upgrader: upgrades the http connections, e.g. with CheckOrigin to allow browsers on other origins
+// (default: a websocket.Upgrader that allows requests without Origin header or from the same host)
+//
+// MaxConcurrentRequests: how many requests of a connection are handled concurrently (default 16),
+// further requests wait until a handler returns
+//
+// ReadLimit: the maximum size of a message in bytes, the connection is closed if a client sends a larger message
+// (default: unlimited)
+//
+// PingInterval: if > 0, a ping is sent in this interval. If no message or pong is received within
+// PingInterval + PongTimeout, the connection is closed.
+//
+// PongTimeout: the time to wait for the pong after a ping (default PingInterval)
+type WSServerOpts struct {
+	Upgrader              *websocket.Upgrader
+	MaxConcurrentRequests int
+	ReadLimit             int64
+	PingInterval          time.Duration
+	PongTimeout           time.Duration
+}
+
+// WSHandler returns an http.Handler that upgrades requests to websocket connections and serves JSON-RPC requests
+// over them, every message is a request or batch. The requests of a connection are handled concurrently,
+// handlers can send notifications to the client with ServerConnFromContext() and create subscriptions
+// (see RegisterSubscription()). The http request of the upgrade is available with HTTPRequestFromContext().
+//
+// opts: WSServerOpts provide custom configuration, may be nil
+func (s *Server) WSHandler(opts *WSServerOpts) http.Handler {
+	if opts == nil {
+		opts = &WSServerOpts{}
+	}
+	upgrader := opts.Upgrader
+	if upgrader == nil {
+		upgrader = &websocket.Upgrader{}
+	}
+	pongTimeout := opts.PongTimeout
+	if pongTimeout <= 0 {
+		pongTimeout = opts.PingInterval
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// the upgrader has responded with an http error
+			return
+		}
+		if opts.ReadLimit > 0 {
+			conn.SetReadLimit(opts.ReadLimit)
+		}
+
+		ctx := context.WithValue(r.Context(), httpRequestContextKey{}, r)
+		s.serveConn(ctx, newWSConn(conn, opts.PingInterval, pongTimeout), opts.MaxConcurrentRequests)
+	})
+}
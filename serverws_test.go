@@ -0,0 +1,163 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+func TestServerWSHandler(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newAddServer()
+	arrived := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server.Register("wait", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		arrived <- struct{}{}
+		<-release
+		return "done", nil
+	})
+	server.Register("remoteAddr", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return HTTPRequestFromContext(ctx) != nil && ServerConnFromContext(ctx) != nil, nil
+	})
+	httpServer := httptest.NewServer(server.WSHandler(&WSServerOpts{MaxConcurrentRequests: 2}))
+	defer httpServer.Close()
+
+	rpcClient, err := DialWS("ws" + strings.TrimPrefix(httpServer.URL, "http"))
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	var sum int
+	Expect(rpcClient.CallFor(&sum, "add", 1, 2)).To(Succeed())
+	Expect(sum).To(Equal(3))
+	var ok bool
+	Expect(rpcClient.CallFor(&ok, "remoteAddr")).To(Succeed())
+	Expect(ok).To(BeTrue())
+
+	// requests of a connection are handled concurrently
+	results := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			var result string
+			rpcClient.CallFor(&result, "wait")
+			results <- result
+		}()
+	}
+	Eventually(arrived).Should(HaveLen(2))
+	close(release)
+	Eventually(results).Should(Receive(Equal("done")))
+	Eventually(results).Should(Receive(Equal("done")))
+
+	responses, err := rpcClient.CallBatch(RPCRequests{NewRequest("add", 1, 1), NewRequest("add", 2, 2)})
+	Expect(err).To(BeNil())
+	Expect(responses).To(HaveLen(2))
+}
+
+func TestServerConnNotify(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServer()
+	closed := make(chan error, 1)
+	server.Register("hello", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		conn := ServerConnFromContext(ctx)
+		Expect(conn.Notify("greeting", []string{"hello"})).To(Succeed())
+		go func() {
+			<-conn.Done()
+			closed <- conn.Notify("greeting", nil)
+		}()
+		return "ok", nil
+	})
+	httpServer := httptest.NewServer(server.WSHandler(nil))
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	Expect(err).To(BeNil())
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"hello","id":1}`))).To(Succeed())
+
+	_, message, err := conn.ReadMessage()
+	Expect(err).To(BeNil())
+	Expect(string(message)).To(Equal(`{"jsonrpc":"2.0","method":"greeting","params":["hello"]}`))
+	_, message, err = conn.ReadMessage()
+	Expect(err).To(BeNil())
+	Expect(string(message)).To(Equal(`{"jsonrpc":"2.0","result":"ok","id":1}`))
+
+	// notifications are not answered
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"missing"}`))).To(Succeed())
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"missing","id":2}`))).To(Succeed())
+	_, message, err = conn.ReadMessage()
+	Expect(err).To(BeNil())
+	Expect(string(message)).To(Equal(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: missing"},"id":2}`))
+
+	conn.Close()
+	Eventually(closed).Should(Receive(Equal(ErrConnClosed)))
+}
+
+func TestServerWSHandlerOrigin(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServer()
+	httpServer := httptest.NewServer(server.WSHandler(&WSServerOpts{ReadLimit: 16, PingInterval: time.Second}))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	_, res, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": []string{"https://other.example.com"}})
+	Expect(err).NotTo(BeNil())
+	Expect(res.StatusCode).To(Equal(http.StatusForbidden))
+
+	// messages over the read limit close the connection
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	Expect(err).To(BeNil())
+	defer conn.Close()
+	Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"long","id":1}`))).To(Succeed())
+	_, _, err = conn.ReadMessage()
+	Expect(err).NotTo(BeNil())
+}
+
+func TestServerConnCall(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := newCallbackServer()
+	httpServer := httptest.NewServer(server.WSHandler(nil))
+	defer httpServer.Close()
+
+	notified := make(chan string, 1)
+	clientServer := NewServer()
+	clientServer.Register("name", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		return "websocket", nil
+	})
+	clientServer.Register("news", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		var params []string
+		request.DecodeParams(&params)
+		notified <- params[0]
+		return nil, nil
+	})
+	rpcClient, err := DialWSWithOpts("ws"+strings.TrimPrefix(httpServer.URL, "http"), &WSClientOpts{RPCClientOpts: RPCClientOpts{Server: clientServer}})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	var greeting string
+	Expect(rpcClient.CallFor(&greeting, "greet")).To(Succeed())
+	Expect(greeting).To(Equal("hello websocket"))
+
+	// notifications of the server are handled by the server of the client
+	Expect(server.Broadcast("news", []string{"breaking"})).To(Equal(1))
+	Eventually(notified).Should(Receive(Equal("breaking")))
+
+	var conn *ServerConn
+	server.Register("conn", func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		conn = ServerConnFromContext(ctx)
+		return nil, nil
+	})
+	_, err = rpcClient.Call("conn")
+	Expect(err).To(BeNil())
+	rpcClient.Close()
+	Eventually(conn.Done()).Should(BeClosed())
+	_, err = conn.Call(context.Background(), "name")
+	Expect(err).To(Equal(ErrConnClosed))
+}
@@ -0,0 +1,143 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterService registers the exported methods of service as methods of the server, so their params
+// don't have to be decoded by hand.
+//
+// Methods are registered if they have a context.Context as first param and return (result, error) or only error,
+// e.g.:
+//
+//	func (c *Calculator) Add(ctx context.Context, a, b int) (int, error)
+//
+// The method name is the name of the Go method with a lowercase first letter, prefixed with namespace and "_"
+// if namespace is not empty, e.g. "calc_add" for the namespace "calc".
+//
+// Positional params are decoded into the params of the method in order, trailing params that can be nil
+// (e.g. pointers) may be omitted. Named params are decoded into the only param of methods with a single param
+// besides the context. Params that can not be decoded are rejected with -32602 (invalid params).
+//
+// It panics if service has no method with a supported signature or if a method is already registered.
+func (s *Server) RegisterService(namespace string, service interface{}) {
+	value := reflect.ValueOf(service)
+	handlers := make(map[string]Handler)
+	for i := 0; i < value.NumMethod(); i++ {
+		method := value.Type().Method(i)
+		if method.PkgPath != "" {
+			continue
+		}
+		if handler, ok := methodHandler(value.Method(i)); ok {
+			handlers[serviceMethodName(namespace, method.Name)] = handler
+		}
+	}
+
+	if len(handlers) == 0 {
+		panic(fmt.Sprintf("jsonrpc: type %T has no methods of the form func(context.Context, ...) (result, error)", service))
+	}
+	for name, handler := range handlers {
+		s.Register(name, handler)
+	}
+}
+
+// serviceMethodName returns the rpc method name of the Go method name.
+func serviceMethodName(namespace string, name string) string {
+	first, size := utf8.DecodeRuneInString(name)
+	name = string(unicode.ToLower(first)) + name[size:]
+	if namespace == "" {
+		return name
+	}
+	return namespace + "_" + name
+}
+
+// methodHandler returns a handler that calls fn with the decoded params,
+// or false if fn has no signature that is supported by RegisterService().
+func methodHandler(fn reflect.Value) (Handler, bool) {
+	fnType := fn.Type()
+	if fnType.IsVariadic() || fnType.NumIn() == 0 || fnType.In(0) != contextType {
+		return nil, false
+	}
+	if fnType.NumOut() == 0 || fnType.NumOut() > 2 || fnType.Out(fnType.NumOut()-1) != errorType {
+		return nil, false
+	}
+
+	paramTypes := make([]reflect.Type, fnType.NumIn()-1)
+	for i := range paramTypes {
+		paramTypes[i] = fnType.In(i + 1)
+	}
+
+	return func(ctx context.Context, request *ServerRequest) (interface{}, error) {
+		params, err := decodeMethodParams(request.Params, paramTypes)
+		if err != nil {
+			return nil, err
+		}
+
+		results := fn.Call(append([]reflect.Value{reflect.ValueOf(ctx)}, params...))
+		if err, _ := results[len(results)-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		if len(results) == 1 {
+			return nil, nil
+		}
+		return results[0].Interface(), nil
+	}, true
+}
+
+// decodeMethodParams decodes params into values of types, see RegisterService().
+func decodeMethodParams(params json.RawMessage, types []reflect.Type) ([]reflect.Value, error) {
+	if len(params) > 0 && params[0] == '{' {
+		if len(types) != 1 {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: named params need a method with 1 param, got %v", len(types))}
+		}
+		value := reflect.New(types[0])
+		if err := json.Unmarshal(params, value.Interface()); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		return []reflect.Value{value.Elem()}, nil
+	}
+
+	var positional []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &positional); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+	}
+	if len(positional) > len(types) {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: too many params, want at most %v, got %v", len(types), len(positional))}
+	}
+
+	values := make([]reflect.Value, len(types))
+	for i, paramType := range types {
+		value := reflect.New(paramType)
+		if i < len(positional) {
+			if err := json.Unmarshal(positional[i], value.Interface()); err != nil {
+				return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: param %v: %v", i, err)}
+			}
+		} else if !nillable(paramType) {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: missing param %v", i)}
+		}
+		values[i] = value.Elem()
+	}
+	return values, nil
+}
+
+// nillable returns true if values of t can be nil, so they may be omitted.
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	default:
+		return false
+	}
+}
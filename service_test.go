@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type calculator struct{}
+
+type divideParams struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+func (c *calculator) Add(ctx context.Context, a, b int) (int, error) {
+	return a + b, nil
+}
+
+func (c *calculator) Divide(ctx context.Context, params divideParams) (int, error) {
+	if params.B == 0 {
+		return 0, &RPCError{Code: 1, Message: "division by zero"}
+	}
+	return params.A / params.B, nil
+}
+
+func (c *calculator) Greet(ctx context.Context, name string, greeting *string) (string, error) {
+	if greeting == nil {
+		return "hello " + name, nil
+	}
+	return *greeting + " " + name, nil
+}
+
+func (c *calculator) Reset(ctx context.Context) error {
+	return errors.New("not allowed")
+}
+
+// methods without context or error are not registered
+func (c *calculator) Helper(a int) int {
+	return a
+}
+
+func TestServerRegisterService(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := NewServer()
+	server.RegisterService("calc", &calculator{})
+
+	tests := []struct {
+		request  string
+		response string
+	}{
+		{`{"jsonrpc":"2.0","method":"calc_add","params":[1,2],"id":1}`, `{"jsonrpc":"2.0","result":3,"id":1}`},
+		{`{"jsonrpc":"2.0","method":"calc_divide","params":{"a":6,"b":3},"id":2}`, `{"jsonrpc":"2.0","result":2,"id":2}`},
+		{`{"jsonrpc":"2.0","method":"calc_divide","params":[{"a":6,"b":2}],"id":3}`, `{"jsonrpc":"2.0","result":3,"id":3}`},
+		{`{"jsonrpc":"2.0","method":"calc_divide","params":{"a":6,"b":0},"id":4}`, `{"jsonrpc":"2.0","error":{"code":1,"message":"division by zero"},"id":4}`},
+		{`{"jsonrpc":"2.0","method":"calc_greet","params":["bob"],"id":5}`, `{"jsonrpc":"2.0","result":"hello bob","id":5}`},
+		{`{"jsonrpc":"2.0","method":"calc_greet","params":["bob","hi"],"id":6}`, `{"jsonrpc":"2.0","result":"hi bob","id":6}`},
+		{`{"jsonrpc":"2.0","method":"calc_reset","id":7}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"internal error"},"id":7}`},
+		{`{"jsonrpc":"2.0","method":"calc_add","params":[1],"id":8}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params: missing param 1"},"id":8}`},
+		{`{"jsonrpc":"2.0","method":"calc_add","params":[1,2,3],"id":9}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params: too many params, want at most 2, got 3"},"id":9}`},
+		{`{"jsonrpc":"2.0","method":"calc_add","params":[1,"2"],"id":10}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params: param 1: json: cannot unmarshal string into Go value of type int"},"id":10}`},
+		{`{"jsonrpc":"2.0","method":"calc_add","params":{"a":1},"id":11}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"invalid params: named params need a method with 1 param, got 2"},"id":11}`},
+		{`{"jsonrpc":"2.0","method":"calc_helper","params":[1],"id":12}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: calc_helper"},"id":12}`},
+	}
+
+	for _, test := range tests {
+		response := server.HandleMessage(context.Background(), []byte(test.request))
+		Expect(string(response)).To(Equal(test.response), test.request)
+	}
+
+	// without namespace
+	server = NewServer()
+	server.RegisterService("", &calculator{})
+	Expect(string(server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"add","params":[2,2],"id":1}`)))).
+		To(Equal(`{"jsonrpc":"2.0","result":4,"id":1}`))
+
+	// registered twice or without methods
+	Expect(func() { server.RegisterService("", &calculator{}) }).To(Panic())
+	Expect(func() { server.RegisterService("calc", calculator{}) }).To(Panic())
+}
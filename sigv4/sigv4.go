@@ -0,0 +1,109 @@
+// Package sigv4 signs the http requests of jsonrpc clients with AWS Signature Version 4,
+// e.g. for Amazon Managed Blockchain or JSON-RPC endpoints behind Amazon API Gateway with IAM authorization.
+//
+// It is a separate module, so that the jsonrpc module does not depend on the AWS SDK.
+package sigv4
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Opts configures the signing of the requests.
+//
+// Credentials: the AWS credentials, e.g. the Credentials of the aws.Config of config.LoadDefaultConfig().
+// Wrap providers that do not cache their credentials with aws.NewCredentialsCache().
+//
+// Region: the AWS region of the endpoint, e.g. "us-east-1"
+//
+// Service: the signing name of the service (default "execute-api" for Amazon API Gateway),
+// e.g. "managedblockchain" for Amazon Managed Blockchain
+type Opts struct {
+	Credentials aws.CredentialsProvider
+	Region      string
+	Service     string
+}
+
+// Middleware returns http middleware for jsonrpc.RPCClientOpts.HTTPMiddleware that signs every request, e.g.
+//
+//	cfg, err := config.LoadDefaultConfig(ctx)
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+//		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{
+//			sigv4.Middleware(sigv4.Opts{Credentials: cfg.Credentials, Region: cfg.Region, Service: "managedblockchain"}),
+//		},
+//	})
+//
+// Retries are signed again, so that their signatures do not expire.
+func Middleware(opts Opts) func(http.RoundTripper) http.RoundTripper {
+	if opts.Service == "" {
+		opts.Service = "execute-api"
+	}
+	signer := v4.NewSigner()
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if opts.Credentials == nil {
+				return nil, errors.New("sigv4: no credentials")
+			}
+			credentials, err := opts.Credentials.Retrieve(req.Context())
+			if err != nil {
+				return nil, err
+			}
+
+			body, err := readBody(req)
+			if err != nil {
+				return nil, err
+			}
+			hash := sha256.Sum256(body)
+
+			// the request of the caller must not be modified
+			req = req.Clone(req.Context())
+			if req.Body != nil {
+				req.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			err = signer.SignHTTP(req.Context(), credentials, req, hex.EncodeToString(hash[:]), opts.Service, opts.Region, time.Now())
+			if err != nil {
+				return nil, err
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// readBody returns the body of req without consuming it.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return body, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,96 @@
+package sigv4
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aurora-is-near/go-jsonrpc/v3"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	. "github.com/onsi/gomega"
+)
+
+func TestMiddleware(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests []*http.Request
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		w.Write([]byte(`{"jsonrpc":"2.0","result":"ok","id":0}`))
+	}))
+	defer server.Close()
+
+	rpcClient := jsonrpc.NewClientWithOpts(server.URL, &jsonrpc.RPCClientOpts{
+		HTTPMiddleware: []func(http.RoundTripper) http.RoundTripper{
+			Middleware(Opts{
+				Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "SESSION"),
+				Region:      "us-east-1",
+				Service:     "managedblockchain",
+			}),
+		},
+	})
+	res, err := rpcClient.Call("eth_blockNumber")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("ok"))
+
+	Expect(requests).To(HaveLen(1))
+	Expect(bodies[0]).To(Equal(`{"method":"eth_blockNumber","id":0,"jsonrpc":"2.0"}`))
+	Expect(requests[0].Header.Get("Authorization")).To(MatchRegexp(
+		`^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/us-east-1/managedblockchain/aws4_request, SignedHeaders=\S*content-type;host;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$`))
+	Expect(requests[0].Header.Get("X-Amz-Date")).NotTo(BeEmpty())
+	Expect(requests[0].Header.Get("X-Amz-Security-Token")).To(Equal("SESSION"))
+}
+
+func TestMiddlewareSignature(t *testing.T) {
+	RegisterTestingT(t)
+
+	var authorization []string
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		authorization = append(authorization, req.Header.Get("Authorization"))
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	roundTripper := Middleware(Opts{Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), Region: "eu-west-1"})(next)
+
+	send := func(body string) {
+		req, err := http.NewRequest(http.MethodPost, "https://abc.execute-api.eu-west-1.amazonaws.com/rpc", strings.NewReader(body))
+		Expect(err).To(BeNil())
+		_, err = roundTripper.RoundTrip(req)
+		Expect(err).To(BeNil())
+		// the request of the caller is not modified
+		Expect(req.Header.Get("Authorization")).To(BeEmpty())
+	}
+	send(`{"method":"a"}`)
+	send(`{"method":"a"}`)
+	send(`{"method":"b"}`)
+
+	Expect(authorization[0]).To(ContainSubstring("/eu-west-1/execute-api/aws4_request"))
+	// the signature depends on the body
+	Expect(authorization[2]).NotTo(Equal(authorization[0]))
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		panic("not sent")
+	})
+	req, err := http.NewRequest(http.MethodPost, "https://example.com", strings.NewReader("{}"))
+	Expect(err).To(BeNil())
+
+	_, err = Middleware(Opts{Region: "us-east-1"})(next).RoundTrip(req)
+	Expect(err).To(MatchError("sigv4: no credentials"))
+
+	failing := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{}, errors.New("expired")
+	})
+	_, err = Middleware(Opts{Credentials: failing, Region: "us-east-1"})(next).RoundTrip(req)
+	Expect(err).To(MatchError("expired"))
+}
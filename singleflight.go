@@ -0,0 +1,116 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+)
+
+// SingleflightOpts configures the coalescing of identical calls, see RPCClientOpts.
+//
+// Concurrent calls with the same method, params and headers of the context (see WithHeaders()) are sent as a single request
+// and all callers get its response.
+// The callers share the result of the response, so it must not be modified.
+// Only Call(), CallNamed() and CallFor() are coalesced, CallRaw() and batch calls are always sent.
+//
+// Methods: the method names or patterns as used by path.Match() whose calls are coalesced, all methods if empty.
+// Only read-only methods should be coalesced.
+type SingleflightOpts struct {
+	Methods []string
+}
+
+// singleflight coalesces identical calls that are in flight.
+type singleflight struct {
+	methods []string
+
+	mutex sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a call in flight, done is closed when response and err are set.
+type flight struct {
+	done     chan struct{}
+	response *RPCResponse
+	err      error
+}
+
+func newSingleflight(opts *SingleflightOpts) *singleflight {
+	if opts == nil {
+		return nil
+	}
+
+	return &singleflight{
+		methods: opts.Methods,
+		calls:   make(map[string]*flight),
+	}
+}
+
+// key returns the key of identical calls of request, "" if calls of request are not coalesced.
+// Calls are only identical if they are sent to the same endpoint (see WithEndpoint() and WithEndpointVars())
+// with the same headers (see WithHeaders()), so that callers with e.g. different tenants don't share responses.
+func (s *singleflight) key(ctx context.Context, request *RPCRequest) string {
+	matched := len(s.methods) == 0
+	for _, pattern := range s.methods {
+		if ok, err := path.Match(pattern, request.Method); pattern == request.Method || (err == nil && ok) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return ""
+	}
+
+	params, err := json.Marshal(request.Params)
+	if err != nil {
+		return ""
+	}
+	// the names of the headers are sorted by json.Marshal
+	headers, err := json.Marshal(contextHeaders(ctx))
+	if err != nil {
+		return ""
+	}
+
+	return endpointKey(ctx) + "\x00" + string(headers) + "\x00" + request.Method + "\x00" + string(params)
+}
+
+// do calls call unless an identical call is in flight, then its response is returned.
+// If the identical call was cancelled by the context of its caller, call is called instead.
+func (s *singleflight) do(ctx context.Context, key string, call func() (*RPCResponse, error)) (*RPCResponse, error) {
+	for {
+		s.mutex.Lock()
+		if f, ok := s.calls[key]; ok {
+			s.mutex.Unlock()
+
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, &TransportError{err: fmt.Errorf("rpc call: %w", ctx.Err())}
+			}
+
+			if (errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+				continue
+			}
+			if f.response == nil {
+				return nil, f.err
+			}
+			response := *f.response
+			return &response, f.err
+		}
+
+		f := &flight{done: make(chan struct{})}
+		s.calls[key] = f
+		s.mutex.Unlock()
+
+		f.response, f.err = call()
+
+		s.mutex.Lock()
+		delete(s.calls, key)
+		s.mutex.Unlock()
+		close(f.done)
+
+		return f.response, f.err
+	}
+}
@@ -0,0 +1,168 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSingleflight(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Singleflight: &SingleflightOpts{Methods: []string{"eth_get*"}},
+	})
+
+	type result struct {
+		response *RPCResponse
+		err      error
+	}
+	results := make(chan result, 10)
+	call := func(method string, params ...interface{}) {
+		go func() {
+			response, err := rpcClient.Call(method, params...)
+			results <- result{response, err}
+		}()
+	}
+
+	call("eth_getBalance", "0x1")
+	Eventually(func() int32 { return atomic.LoadInt32(&requests) }).Should(Equal(int32(1)))
+	for i := 0; i < 4; i++ {
+		call("eth_getBalance", "0x1")
+	}
+	call("eth_getBalance", "0x2")
+	call("eth_call", "0x1")
+	call("eth_call", "0x1")
+	Eventually(func() int32 { return atomic.LoadInt32(&requests) }).Should(Equal(int32(4)))
+
+	// wait until the coalesced calls are waiting
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 8; i++ {
+		r := <-results
+		Expect(r.err).To(BeNil())
+		Expect(r.response.Result).To(Equal("ok"))
+	}
+	Expect(atomic.LoadInt32(&requests)).To(Equal(int32(4)))
+}
+
+func TestSingleflight_Headers(t *testing.T) {
+	RegisterTestingT(t)
+
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%q,"id":0}`, r.Header.Get("X-Tenant"))
+	}))
+	defer server.Close()
+	var releaseOnce sync.Once
+	releaseAll := func() { releaseOnce.Do(func() { close(release) }) }
+	defer releaseAll()
+
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{Singleflight: &SingleflightOpts{}})
+
+	results := make(chan interface{}, 3)
+	call := func(tenant string) {
+		go func() {
+			ctx := WithHeaders(context.Background(), http.Header{"X-Tenant": {tenant}})
+			response, err := rpcClient.CallContext(ctx, "balance")
+			if err != nil {
+				results <- err
+				return
+			}
+			results <- tenant + ":" + response.Result.(string)
+		}()
+	}
+
+	call("a")
+	Eventually(func() int32 { return atomic.LoadInt32(&requests) }).Should(Equal(int32(1)))
+	call("a")
+	call("b")
+	Eventually(func() int32 { return atomic.LoadInt32(&requests) }).Should(Equal(int32(2)))
+
+	// wait until the coalesced call is waiting
+	time.Sleep(20 * time.Millisecond)
+	releaseAll()
+
+	var received []interface{}
+	for i := 0; i < 3; i++ {
+		received = append(received, <-results)
+	}
+	Expect(received).To(ConsistOf("a:a", "a:a", "b:b"))
+	Expect(atomic.LoadInt32(&requests)).To(Equal(int32(2)))
+}
+
+func TestSingleflight_Cancel(t *testing.T) {
+	RegisterTestingT(t)
+
+	s := newSingleflight(&SingleflightOpts{})
+	key := s.key(context.Background(), NewRequest("eth_getBalance", "0x1"))
+	Expect(key).NotTo(BeEmpty())
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leader := make(chan error, 1)
+	go func() {
+		_, err := s.do(leaderCtx, key, func() (*RPCResponse, error) {
+			close(started)
+			<-leaderCtx.Done()
+			return nil, &TransportError{err: fmt.Errorf("rpc call: %w", leaderCtx.Err())}
+		})
+		leader <- err
+	}()
+	<-started
+
+	// the follower calls itself if the leader is cancelled
+	follower := make(chan *RPCResponse, 1)
+	go func() {
+		response, _ := s.do(context.Background(), key, func() (*RPCResponse, error) {
+			return &RPCResponse{Result: "own"}, nil
+		})
+		follower <- response
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancelLeader()
+
+	Expect(errors.Is(<-leader, context.Canceled)).To(BeTrue())
+	Expect((<-follower).Result).To(Equal("own"))
+
+	// a follower that is cancelled stops waiting
+	block := make(chan struct{})
+	defer close(block)
+	go s.do(context.Background(), key, func() (*RPCResponse, error) {
+		<-block
+		return nil, nil
+	})
+	Eventually(func() int {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return len(s.calls)
+	}).Should(Equal(1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.do(ctx, key, func() (*RPCResponse, error) {
+		return nil, errors.New("not called")
+	})
+	Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+}
@@ -0,0 +1,162 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// SlogOpts configures the logging of SlogInterceptor().
+//
+// Logger: the logger (default slog.Default())
+//
+// Level: the level of successful calls (default slog.LevelDebug)
+//
+// ErrorLevel: the level of failed calls and calls that returned RPC errors (default slog.LevelWarn)
+//
+// LogParams: log the params of the requests
+//
+// LogResults: log the results of the responses
+//
+// Redactor: called with the params and results before they are logged, e.g. to hide private keys (see RedactFields())
+type SlogOpts struct {
+	Logger     *slog.Logger
+	Level      slog.Leveler
+	ErrorLevel slog.Leveler
+	LogParams  bool
+	LogResults bool
+	Redactor   Redactor
+}
+
+// SlogInterceptor returns an Interceptor that logs every call with log/slog, e.g.
+//
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+//		Interceptors: []jsonrpc.Interceptor{jsonrpc.SlogInterceptor(&jsonrpc.SlogOpts{LogParams: true})},
+//	})
+//
+// The record contains the method, id (batch_size for batches), duration, attempts, endpoint and http status of the call,
+// and the error or the RPC error code and message.
+//
+// opts: SlogOpts provide custom configuration, may be nil
+func SlogInterceptor(opts *SlogOpts) Interceptor {
+	if opts == nil {
+		opts = &SlogOpts{}
+	}
+	var level, errorLevel slog.Leveler = slog.LevelDebug, slog.LevelWarn
+	if opts.Level != nil {
+		level = opts.Level
+	}
+	if opts.ErrorLevel != nil {
+		errorLevel = opts.ErrorLevel
+	}
+
+	return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+		logger := opts.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		if !logger.Enabled(ctx, level.Level()) && !logger.Enabled(ctx, errorLevel.Level()) {
+			return invoke(ctx)
+		}
+
+		err := invoke(ctx)
+
+		attrs := []slog.Attr{slog.String("method", call.Method())}
+		if call.Batch {
+			attrs = append(attrs, slog.Int("batch_size", len(call.Requests)))
+		} else if len(call.Requests) == 1 && call.Requests[0] != nil {
+			attrs = append(attrs, slog.String("id", call.Requests[0].ID.String()))
+		}
+		attrs = append(attrs, slog.Duration("duration", call.Duration), slog.Int("attempts", call.Attempts))
+		if call.Endpoint != "" {
+			attrs = append(attrs, slog.String("endpoint", call.Endpoint))
+		}
+		if call.HTTPStatus != 0 {
+			attrs = append(attrs, slog.Int("http_status", call.HTTPStatus))
+		}
+
+		if opts.LogParams {
+			if call.Batch {
+				params := make([]json.RawMessage, len(call.Requests))
+				for i, request := range call.Requests {
+					if request != nil {
+						params[i] = opts.redact(request.Method, request.Params)
+					}
+				}
+				attrs = append(attrs, slog.Any("params", params))
+			} else if len(call.Requests) == 1 && call.Requests[0] != nil {
+				attrs = append(attrs, slog.Any("params", opts.redact(call.Requests[0].Method, call.Requests[0].Params)))
+			}
+		}
+
+		failed := err != nil
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		rpcErrors := 0
+		for _, response := range call.Responses {
+			if response != nil && response.Error != nil {
+				rpcErrors++
+			}
+		}
+		if rpcErrors > 0 {
+			failed = true
+			if call.Batch {
+				attrs = append(attrs, slog.Int("rpc_error_count", rpcErrors))
+			} else {
+				attrs = append(attrs,
+					slog.Int("rpc_error_code", call.Responses[0].Error.Code),
+					slog.String("rpc_error_message", call.Responses[0].Error.Message),
+				)
+			}
+		}
+
+		if opts.LogResults && len(call.Responses) > 0 {
+			if call.Batch {
+				methods := make(map[string]string, len(call.Requests))
+				for _, request := range call.Requests {
+					if request != nil {
+						methods[request.ID.String()] = request.Method
+					}
+				}
+				results := make(map[string]json.RawMessage, len(call.Responses))
+				for _, response := range call.Responses {
+					if response != nil && response.Error == nil {
+						id := response.ID.String()
+						results[id] = opts.redact(methods[id], response.Result)
+					}
+				}
+				attrs = append(attrs, slog.Any("results", results))
+			} else if call.Responses[0] != nil && call.Responses[0].Error == nil {
+				attrs = append(attrs, slog.Any("result", opts.redact(call.Method(), call.Responses[0].Result)))
+			}
+		}
+
+		if failed {
+			logger.LogAttrs(ctx, errorLevel.Level(), "rpc call failed", attrs...)
+		} else {
+			logger.LogAttrs(ctx, level.Level(), "rpc call", attrs...)
+		}
+
+		return err
+	}
+}
+
+// redact returns value as json after it was passed through the redactor.
+// The value is omitted if it cannot be marshaled, since its string representation may contain secrets.
+func (opts *SlogOpts) redact(method string, value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	if opts.Redactor != nil {
+		value = opts.Redactor(method, value)
+	}
+
+	js, err := json.Marshal(value)
+	if err != nil {
+		return json.RawMessage(`"..."`)
+	}
+
+	return js
+}
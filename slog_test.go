@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSlogInterceptor(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case bytes.HasPrefix(body, []byte("[")):
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","result":{"token":"secret"},"id":0},{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":1}]`)
+		case bytes.Contains(body, []byte("failing")):
+			fmt.Fprint(w, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"not found"},"id":0}`)
+		default:
+			fmt.Fprint(w, `{"jsonrpc":"2.0","result":{"token":"secret","balance":1},"id":0}`)
+		}
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Interceptors: []Interceptor{SlogInterceptor(&SlogOpts{
+			Logger:     logger,
+			LogParams:  true,
+			LogResults: true,
+			Redactor:   RedactFields("password", "token"),
+		})},
+	})
+
+	_, err := rpcClient.Call("login", map[string]string{"user": "alex", "password": "secret"})
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("failing")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b", 1)})
+	Expect(err).To(BeNil())
+
+	Expect(output.String()).NotTo(ContainSubstring("secret"))
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var record map[string]interface{}
+		Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+		delete(record, "time")
+		delete(record, "duration")
+		records = append(records, record)
+	}
+
+	Expect(records).To(Equal([]map[string]interface{}{
+		{
+			"level":       "DEBUG",
+			"msg":         "rpc call",
+			"method":      "login",
+			"id":          "0",
+			"attempts":    float64(1),
+			"endpoint":    server.URL,
+			"http_status": float64(200),
+			"params":      map[string]interface{}{"user": "alex", "password": "[REDACTED]"},
+			"result":      map[string]interface{}{"token": "[REDACTED]", "balance": float64(1)},
+		},
+		{
+			"level":             "WARN",
+			"msg":               "rpc call failed",
+			"method":            "failing",
+			"id":                "0",
+			"attempts":          float64(1),
+			"endpoint":          server.URL,
+			"http_status":       float64(200),
+			"params":            nil,
+			"rpc_error_code":    float64(-32601),
+			"rpc_error_message": "not found",
+		},
+		{
+			"level":           "WARN",
+			"msg":             "rpc call failed",
+			"method":          "batch",
+			"batch_size":      float64(2),
+			"attempts":        float64(1),
+			"endpoint":        server.URL,
+			"http_status":     float64(200),
+			"params":          []interface{}{nil, []interface{}{float64(1)}},
+			"rpc_error_count": float64(1),
+			"results":         map[string]interface{}{"0": map[string]interface{}{"token": "[REDACTED]"}},
+		},
+	}))
+}
+
+func TestSlogInterceptorLevel(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, nil))
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Interceptors: []Interceptor{SlogInterceptor(&SlogOpts{Logger: logger})},
+	})
+
+	// successful calls are logged at debug level by default
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(output.String()).To(BeEmpty())
+
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{
+		Interceptors: []Interceptor{SlogInterceptor(&SlogOpts{Logger: logger, Level: slog.LevelInfo})},
+	})
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	Expect(output.String()).To(ContainSubstring("level=INFO msg=\"rpc call\" method=something id=0"))
+}
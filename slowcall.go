@@ -0,0 +1,90 @@
+package jsonrpc
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// SlowCallOpts configures the slow call detection, see RPCClientOpts.
+//
+// Threshold: calls that take longer, including retries, are reported (default 1s)
+//
+// MethodThresholds: thresholds for specific methods, e.g. for methods that are known to be slow.
+// Batch calls use the threshold of "batch".
+//
+// OnSlowCall: called for every slow call after it returned, must be safe for concurrent use
+type SlowCallOpts struct {
+	Threshold        time.Duration
+	MethodThresholds map[string]time.Duration
+	OnSlowCall       func(ctx context.Context, slow *SlowCall)
+}
+
+const (
+	defaultSlowCallThreshold = time.Second
+
+	// maxSlowCallSummary is the maximum length of SlowCall.Summary.
+	maxSlowCallSummary = 256
+)
+
+// SlowCall describes a call that took longer than its threshold.
+//
+// Summary: the method and params of the call as "method(params)", the params are passed through the Redactor of the client
+// and only included if it has a Redactor or ParamsInErrors is set.
+// Batch calls are summarized as "batch(method, method, ...)". The summary is truncated to 256 bytes.
+//
+// Threshold: the threshold that was exceeded
+//
+// Call: the call with its duration, endpoint, attempts and result (see CallInfo)
+type SlowCall struct {
+	Summary   string
+	Threshold time.Duration
+	Call      *CallInfo
+}
+
+// slowCallInterceptor returns an interceptor that reports slow calls.
+func (client *rpcClient) slowCallInterceptor(opts SlowCallOpts) Interceptor {
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = defaultSlowCallThreshold
+	}
+
+	return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+		err := invoke(ctx)
+
+		callThreshold, ok := opts.MethodThresholds[call.Method()]
+		if !ok {
+			callThreshold = threshold
+		}
+		if call.Duration > callThreshold && opts.OnSlowCall != nil {
+			opts.OnSlowCall(ctx, &SlowCall{
+				Summary:   client.summarizeCall(call),
+				Threshold: callThreshold,
+				Call:      call,
+			})
+		}
+
+		return err
+	}
+}
+
+// summarizeCall returns the summary of a SlowCall.
+func (client *rpcClient) summarizeCall(call *CallInfo) string {
+	var summary string
+	if call.Batch {
+		methods := make([]string, 0, len(call.Requests))
+		for _, request := range call.Requests {
+			if request != nil {
+				methods = append(methods, request.Method)
+			}
+		}
+		summary = "batch(" + strings.Join(methods, ", ") + ")"
+	} else if len(call.Requests) == 1 && call.Requests[0] != nil {
+		summary = client.describeCall(call.Requests[0])
+	}
+
+	if len(summary) > maxSlowCallSummary {
+		summary = summary[:maxSlowCallSummary-3] + "..."
+	}
+	return summary
+}
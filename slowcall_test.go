@@ -0,0 +1,75 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSlowCall(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("slow")) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if bytes.HasPrefix(body, []byte("[")) {
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","result":"ok","id":0},{"jsonrpc":"2.0","result":"ok","id":1}]`)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":"ok","id":0}`)
+	}))
+	defer server.Close()
+
+	var slowCalls []*SlowCall
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		Redactor: RedactFields("password"),
+		SlowCall: &SlowCallOpts{
+			Threshold:        20 * time.Millisecond,
+			MethodThresholds: map[string]time.Duration{"slowButExpected": time.Second},
+			OnSlowCall: func(ctx context.Context, slow *SlowCall) {
+				slowCalls = append(slowCalls, slow)
+			},
+		},
+	})
+
+	_, err := rpcClient.Call("fast")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("slow", map[string]string{"password": "secret"})
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("slowButExpected")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("slow"), NewRequest("other")})
+	Expect(err).To(BeNil())
+
+	Expect(slowCalls).To(HaveLen(2))
+	Expect(slowCalls[0].Summary).To(Equal(`slow({"password":"[REDACTED]"})`))
+	Expect(slowCalls[0].Threshold).To(Equal(20 * time.Millisecond))
+	Expect(slowCalls[0].Call.Method()).To(Equal("slow"))
+	Expect(slowCalls[0].Call.Duration).To(BeNumerically(">=", 50*time.Millisecond))
+	Expect(slowCalls[0].Call.Endpoint).To(Equal(server.URL))
+	Expect(slowCalls[1].Summary).To(Equal("batch(slow, other)"))
+}
+
+func TestSlowCallSummary(t *testing.T) {
+	RegisterTestingT(t)
+
+	client := &rpcClient{paramsInErrors: true}
+	summary := client.summarizeCall(&CallInfo{Requests: RPCRequests{NewRequest("method", strings.Repeat("a", 300))}})
+	Expect(summary).To(HaveLen(maxSlowCallSummary))
+	Expect(summary).To(HavePrefix(`method(["aaa`))
+	Expect(summary).To(HaveSuffix("aaa..."))
+
+	// params are omitted without redactor
+	client = &rpcClient{}
+	Expect(client.summarizeCall(&CallInfo{Requests: RPCRequests{NewRequest("method", "secret")}})).To(Equal("method()"))
+}
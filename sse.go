@@ -0,0 +1,273 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry is the reconnect delay if the server did not send a retry field.
+const defaultSSERetry = 3 * time.Second
+
+// SSEClientOpts can be provided to DialSSEWithOpts() to change configuration of the SSE stream.
+//
+// HTTPClient: provide a custom http.Client (e.g. to set a proxy, or tls options). It should not have a Timeout,
+// because the response never ends.
+//
+// CustomHeaders: provide custom headers, e.g. to set BasicAuth
+//
+// UserAgent: the User-Agent header (default "go-jsonrpc/<version>"), a User-Agent of CustomHeaders takes precedence
+//
+// Reconnect: if true, the stream is reopened when the connection is lost, with the "Last-Event-ID" header
+// of the last received event. The server can change the delay with the "retry" field (default 3s).
+type SSEClientOpts struct {
+	HTTPClient    *http.Client
+	CustomHeaders map[string]string
+	UserAgent     string
+	Reconnect     bool
+}
+
+// SSEStream receives JSON-RPC notifications that are pushed by the server as Server-Sent Events (text/event-stream).
+// The data of every event must be a notification object, other events are dropped.
+type SSEStream struct {
+	endpoint      string
+	httpClient    *http.Client
+	customHeaders map[string]string
+	userAgent     string
+	reconnect     bool
+
+	lastEventID string
+	retry       time.Duration
+
+	ctx           context.Context
+	cancel        context.CancelFunc
+	notifications chan *RPCNotification
+	err           chan error
+	done          chan struct{}
+}
+
+// DialSSE opens a stream of Server-Sent Events with default configuration.
+//
+// endpoint: URL of the event stream
+func DialSSE(endpoint string) (*SSEStream, error) {
+	return DialSSEWithOpts(endpoint, nil)
+}
+
+// DialSSEWithOpts opens a stream of Server-Sent Events with custom configuration.
+//
+// endpoint: URL of the event stream
+//
+// opts: SSEClientOpts provide custom configuration
+func DialSSEWithOpts(endpoint string, opts *SSEClientOpts) (*SSEStream, error) {
+	if opts == nil {
+		opts = &SSEClientOpts{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &SSEStream{
+		endpoint:      endpoint,
+		httpClient:    opts.HTTPClient,
+		customHeaders: opts.CustomHeaders,
+		userAgent:     userAgent(opts.UserAgent),
+		reconnect:     opts.Reconnect,
+		retry:         defaultSSERetry,
+		ctx:           ctx,
+		cancel:        cancel,
+		notifications: make(chan *RPCNotification, subscriptionBufferSize),
+		err:           make(chan error, 1),
+		done:          make(chan struct{}),
+	}
+	if s.httpClient == nil {
+		s.httpClient = &http.Client{}
+	}
+
+	body, err := s.open()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go s.readLoop(body)
+
+	return s, nil
+}
+
+// Notifications returns the channel that receives the notifications.
+// The channel is closed when the stream ends.
+func (s *SSEStream) Notifications() <-chan *RPCNotification {
+	return s.notifications
+}
+
+// Err returns a channel that receives the error that ended the stream, e.g. because the connection was lost.
+// The channel is closed without error when Close() is called.
+func (s *SSEStream) Err() <-chan error {
+	return s.err
+}
+
+// Close ends the stream and closes the connection.
+func (s *SSEStream) Close() error {
+	s.cancel()
+	<-s.done
+	return nil
+}
+
+// open sends the request for the event stream and returns the body of the response.
+func (s *SSEStream) open() (io.ReadCloser, error) {
+	request, err := http.NewRequestWithContext(s.ctx, http.MethodGet, s.endpoint, nil)
+	if err != nil {
+		return nil, MarkPermanent(err)
+	}
+
+	request.Header.Set("Accept", "text/event-stream")
+	request.Header.Set("Cache-Control", "no-cache")
+	request.Header.Set("User-Agent", s.userAgent)
+	for k, v := range s.customHeaders {
+		request.Header.Set(k, v)
+	}
+	if s.lastEventID != "" {
+		request.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, &TransportError{err: fmt.Errorf("sse stream: %w", err)}
+	}
+
+	if response.StatusCode >= 400 {
+		ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+		response.Body.Close()
+		return nil, &HTTPError{
+			Code: response.StatusCode,
+			err:  fmt.Errorf("sse stream status code: %v", response.StatusCode),
+		}
+	}
+	if mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		response.Body.Close()
+		return nil, MarkPermanent(fmt.Errorf("sse stream: unexpected content type %q", response.Header.Get("Content-Type")))
+	}
+
+	return response.Body, nil
+}
+
+func (s *SSEStream) readLoop(body io.ReadCloser) {
+	defer close(s.done)
+	defer close(s.notifications)
+	defer close(s.err)
+
+	for {
+		err := s.readEvents(body)
+		body.Close()
+		if s.ctx.Err() != nil {
+			return
+		}
+		if !s.reconnect {
+			s.err <- &TransportError{err: fmt.Errorf("sse stream: connection lost: %w", err)}
+			return
+		}
+
+		body = nil
+		for body == nil {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(s.retry):
+			}
+
+			var openErr error
+			body, openErr = s.open()
+			if openErr != nil && !IsRetryable(openErr) {
+				s.err <- openErr
+				return
+			}
+		}
+	}
+}
+
+// readEvents reads events from body until it fails and delivers them.
+func (s *SSEStream) readEvents(body io.Reader) error {
+	reader := bufio.NewReader(body)
+	var data bytes.Buffer
+	var eventID *string
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// an empty line dispatches the event
+			if eventID != nil {
+				s.lastEventID = *eventID
+				eventID = nil
+			}
+			if data.Len() > 0 {
+				if !s.deliver(data.Bytes()) {
+					return context.Canceled
+				}
+				data.Reset()
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment, e.g. keepalive
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				eventID = &value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// deliver decodes the data of an event to a notification and sends it to the notifications channel.
+// Returns false if the stream was closed while waiting for the receiver.
+func (s *SSEStream) deliver(data []byte) bool {
+	if ids, err := messageIDs(data); err != nil || len(ids) > 0 {
+		// not a notification
+		return true
+	}
+
+	var notification *RPCNotification
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&notification); err != nil || notification == nil || notification.Method == "" {
+		return true
+	}
+
+	select {
+	case s.notifications <- notification:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
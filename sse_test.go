@@ -0,0 +1,100 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// newSSETestServer returns a server that sends two notifications and ends the stream.
+// The Last-Event-ID of every request is sent to lastEventIDs.
+func newSSETestServer(lastEventIDs chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keepalive\n\n")
+		fmt.Fprint(w, "retry: 10\n\n")
+		// a response is not a notification
+		fmt.Fprint(w, `data: {"jsonrpc":"2.0","result":1,"id":1}`+"\n\n")
+		fmt.Fprint(w, "id: 1\nevent: message\n")
+		fmt.Fprint(w, `data: {"jsonrpc":"2.0","method":"newHead",`+"\n"+`data: "params":{"number":1}}`+"\n\n")
+		fmt.Fprint(w, "id: 2\r\n")
+		fmt.Fprint(w, `data: {"jsonrpc":"2.0","method":"newHead","params":{"number":2}}`+"\r\n\r\n")
+		w.(http.Flusher).Flush()
+	}))
+}
+
+func TestSSEStream(t *testing.T) {
+	RegisterTestingT(t)
+
+	lastEventIDs := make(chan string, 10)
+	server := newSSETestServer(lastEventIDs)
+	defer server.Close()
+
+	stream, err := DialSSE(server.URL)
+	Expect(err).To(BeNil())
+	Expect(<-lastEventIDs).To(Equal(""))
+
+	for i := 1; i <= 2; i++ {
+		notification := <-stream.Notifications()
+		Expect(notification.Method).To(Equal("newHead"))
+		var params struct {
+			Number int `json:"number"`
+		}
+		Expect(notification.GetObject(&params)).To(BeNil())
+		Expect(params.Number).To(Equal(i))
+	}
+
+	// the stream ends with an error when the server closes the connection
+	err = <-stream.Err()
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	_, ok := <-stream.Notifications()
+	Expect(ok).To(BeFalse())
+	Expect(stream.Close()).To(BeNil())
+}
+
+func TestSSEStream_Reconnect(t *testing.T) {
+	RegisterTestingT(t)
+
+	lastEventIDs := make(chan string, 10)
+	server := newSSETestServer(lastEventIDs)
+	defer server.Close()
+
+	stream, err := DialSSEWithOpts(server.URL, &SSEClientOpts{Reconnect: true})
+	Expect(err).To(BeNil())
+
+	for i := 0; i < 4; i++ {
+		Expect(<-stream.Notifications()).NotTo(BeNil())
+	}
+	Expect(<-lastEventIDs).To(Equal(""))
+	Expect(<-lastEventIDs).To(Equal("2"))
+
+	Expect(stream.Close()).To(BeNil())
+	_, ok := <-stream.Err()
+	Expect(ok).To(BeFalse())
+}
+
+func TestDialSSE_Error(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Header().Set("Content-Type", "application/json")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := DialSSE(server.URL)
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	_, err = DialSSE(server.URL + "/json")
+	Expect(err).To(MatchError(ContainSubstring("unexpected content type")))
+	Expect(IsRetryable(err)).To(BeFalse())
+}
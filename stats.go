@@ -0,0 +1,192 @@
+package jsonrpc
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatsOpts enables the rolling call statistics of Client.Stats(), see RPCClientOpts.
+//
+// Window: the time span of the statistics (default 1m)
+//
+// MaxSamples: the maximum number of calls per method and endpoint that are kept for the percentiles (default 1000),
+// older calls are dropped first
+type StatsOpts struct {
+	Window     time.Duration
+	MaxSamples int
+}
+
+const (
+	defaultStatsWindow     = time.Minute
+	defaultStatsMaxSamples = 1000
+)
+
+// CallStats are the statistics of the calls of a method to an endpoint within the window of StatsOpts.
+// Batch calls have the method "batch".
+//
+// Errors: the number of failed calls and calls that returned an RPC error
+//
+// ErrorRate: Errors / Count
+//
+// P50, P95, P99: percentiles of the duration of the calls, including retries
+type CallStats struct {
+	Method    string
+	Endpoint  string
+	Count     int
+	Errors    int
+	ErrorRate float64
+	P50       time.Duration
+	P95       time.Duration
+	P99       time.Duration
+}
+
+type statsKey struct {
+	method   string
+	endpoint string
+}
+
+type statsSample struct {
+	time     time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// callStats collects the samples of the calls by method and endpoint.
+type callStats struct {
+	window     time.Duration
+	maxSamples int
+
+	mutex   sync.Mutex
+	samples map[statsKey][]statsSample
+
+	now func() time.Time
+}
+
+func newCallStats(opts StatsOpts) *callStats {
+	if opts.Window <= 0 {
+		opts.Window = defaultStatsWindow
+	}
+	if opts.MaxSamples <= 0 {
+		opts.MaxSamples = defaultStatsMaxSamples
+	}
+
+	return &callStats{
+		window:     opts.Window,
+		maxSamples: opts.MaxSamples,
+		samples:    make(map[statsKey][]statsSample),
+		now:        time.Now,
+	}
+}
+
+// interceptor returns an interceptor that records the calls.
+func (s *callStats) interceptor() Interceptor {
+	return func(ctx context.Context, call *CallInfo, invoke Invoker) error {
+		err := invoke(ctx)
+
+		failed := err != nil
+		for _, response := range call.Responses {
+			if response != nil && response.Error != nil {
+				failed = true
+			}
+		}
+		s.record(statsKey{method: call.Method(), endpoint: endpointLabel(call.Endpoint)}, call.Duration, failed)
+
+		return err
+	}
+}
+
+func (s *callStats) record(key statsKey, duration time.Duration, failed bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	samples := s.prune(key, now)
+	if len(samples) >= s.maxSamples {
+		samples = samples[len(samples)-s.maxSamples+1:]
+	}
+	s.samples[key] = append(samples, statsSample{time: now, duration: duration, failed: failed})
+}
+
+// prune drops the samples of key that are older than the window and returns the remaining ones.
+func (s *callStats) prune(key statsKey, now time.Time) []statsSample {
+	samples := s.samples[key]
+	i := sort.Search(len(samples), func(i int) bool {
+		return now.Sub(samples[i].time) < s.window
+	})
+	if i == len(samples) {
+		delete(s.samples, key)
+		return nil
+	}
+	samples = samples[i:]
+	s.samples[key] = samples
+	return samples
+}
+
+// stats returns the statistics sorted by method and endpoint.
+func (s *callStats) stats() []CallStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	var stats []CallStats
+	for key := range s.samples {
+		samples := s.prune(key, now)
+		if len(samples) == 0 {
+			continue
+		}
+
+		durations := make([]time.Duration, len(samples))
+		errors := 0
+		for i, sample := range samples {
+			durations[i] = sample.duration
+			if sample.failed {
+				errors++
+			}
+		}
+		sort.Slice(durations, func(i, j int) bool {
+			return durations[i] < durations[j]
+		})
+
+		stats = append(stats, CallStats{
+			Method:    key.method,
+			Endpoint:  key.endpoint,
+			Count:     len(samples),
+			Errors:    errors,
+			ErrorRate: float64(errors) / float64(len(samples)),
+			P50:       percentile(durations, 0.5),
+			P95:       percentile(durations, 0.95),
+			P99:       percentile(durations, 0.99),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Method != stats[j].Method {
+			return stats[i].Method < stats[j].Method
+		}
+		return stats[i].Endpoint < stats[j].Endpoint
+	})
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p (0 < p <= 1) of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+func (client *rpcClient) Stats() []CallStats {
+	if client.stats == nil {
+		return nil
+	}
+	return client.stats.stats()
+}
@@ -0,0 +1,71 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCallStats(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Unix(1000, 0)
+	stats := newCallStats(StatsOpts{Window: time.Minute, MaxSamples: 100})
+	stats.now = func() time.Time { return now }
+
+	a := statsKey{method: "a", endpoint: "http://one"}
+	for i := 1; i <= 100; i++ {
+		stats.record(a, time.Duration(i)*time.Millisecond, i%10 == 0)
+	}
+	stats.record(statsKey{method: "b", endpoint: "http://two"}, time.Second, false)
+
+	Expect(stats.stats()).To(Equal([]CallStats{
+		{Method: "a", Endpoint: "http://one", Count: 100, Errors: 10, ErrorRate: 0.1, P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond},
+		{Method: "b", Endpoint: "http://two", Count: 1, P50: time.Second, P95: time.Second, P99: time.Second},
+	}))
+
+	// MaxSamples drops the oldest samples
+	now = now.Add(30 * time.Second)
+	stats.record(a, time.Second, true)
+	Expect(stats.stats()[0]).To(Equal(CallStats{
+		Method: "a", Endpoint: "http://one", Count: 100, Errors: 11, ErrorRate: 0.11, P50: 51 * time.Millisecond, P95: 96 * time.Millisecond, P99: 100 * time.Millisecond,
+	}))
+
+	// samples older than the window are dropped
+	now = now.Add(40 * time.Second)
+	Expect(stats.stats()).To(Equal([]CallStats{
+		{Method: "a", Endpoint: "http://one", Count: 1, Errors: 1, ErrorRate: 1, P50: time.Second, P95: time.Second, P99: time.Second},
+	}))
+	now = now.Add(time.Minute)
+	Expect(stats.stats()).To(BeEmpty())
+	Expect(stats.samples).To(BeEmpty())
+}
+
+func TestStats(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"not found"},"id":0}`)
+	}))
+	defer server.Close()
+
+	Expect(NewClient(server.URL).Stats()).To(BeNil())
+
+	rpcClient := NewClientWithOpts(server.URL+"?key=secret", &RPCClientOpts{Stats: &StatsOpts{}})
+	_, err := rpcClient.Call("something")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("something")
+	Expect(err).To(BeNil())
+
+	stats := rpcClient.Stats()
+	Expect(stats).To(HaveLen(1))
+	Expect(stats[0].Method).To(Equal("something"))
+	Expect(stats[0].Endpoint).To(Equal(server.URL))
+	Expect(stats[0].Count).To(Equal(2))
+	Expect(stats[0].ErrorRate).To(Equal(1.0))
+	Expect(stats[0].P99).To(BeNumerically(">", 0))
+}
@@ -0,0 +1,195 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Framing defines how JSON-RPC messages are separated on a byte stream.
+type Framing int
+
+const (
+	// FramingNewline sends every message followed by a newline. Received messages are read as a stream of JSON values,
+	// so they don't need to be separated by newlines.
+	FramingNewline Framing = iota
+
+	// FramingContentLength prefixes every message with a "Content-Length" header, as done by the language server protocol:
+	//   Content-Length: 42\r\n
+	//   \r\n
+	//   {"jsonrpc":"2.0","method":"initialize",...}
+	FramingContentLength
+)
+
+// StreamClientOpts can be provided to NewStreamClient() to change configuration of the client.
+//
+// RPCClientOpts: the same options as for http clients. HTTPClient and CustomHeaders are not used.
+//
+// Framing: how messages are separated on the stream (default FramingNewline)
+type StreamClientOpts struct {
+	RPCClientOpts
+	Framing Framing
+}
+
+// NewStreamClient returns a ConnClient that sends its requests over an arbitrary byte stream,
+// e.g. the stdin / stdout of a child process (see StdioConn()).
+// The stream is owned by the client afterwards and closed by Close().
+//
+// opts: StreamClientOpts provide custom configuration
+func NewStreamClient(conn io.ReadWriteCloser, opts *StreamClientOpts) ConnClient {
+	if opts == nil {
+		opts = &StreamClientOpts{}
+	}
+
+	return newConnClient(newFramedConn(conn, opts.Framing, opts.maxMessageSize()), &opts.RPCClientOpts, nil)
+}
+
+// newFramedConn returns a messageConn that reads and writes messages on conn with the given framing.
+// Messages with a Content-Length above maxSize are rejected with a ResponseTooLargeError (no limit if maxSize <= 0).
+func newFramedConn(conn io.ReadWriteCloser, framing Framing, maxSize int64) messageConn {
+	switch framing {
+	case FramingContentLength:
+		c := newContentLengthConn(conn)
+		c.maxSize = maxSize
+		return c
+	default:
+		return newStreamConn(conn)
+	}
+}
+
+// maxMessageSize returns the maximum size of a message received over a persistent connection,
+// the larger of MaxResponseSize and MaxBatchResponseSize, or 0 if one of them is not limited.
+func (opts *RPCClientOpts) maxMessageSize() int64 {
+	if opts.MaxResponseSize <= 0 || opts.MaxBatchResponseSize <= 0 {
+		return 0
+	}
+	if opts.MaxResponseSize > opts.MaxBatchResponseSize {
+		return opts.MaxResponseSize
+	}
+	return opts.MaxBatchResponseSize
+}
+
+// StdioConn combines the stdout and stdin of a child process to an io.ReadWriteCloser that can be used with NewStreamClient(), e.g.
+//
+//	cmd := exec.Command("my-rpc-server")
+//	stdin, _ := cmd.StdinPipe()
+//	stdout, _ := cmd.StdoutPipe()
+//	cmd.Start()
+//	rpcClient := jsonrpc.NewStreamClient(jsonrpc.StdioConn(stdout, stdin), nil)
+//
+// Close() closes both.
+func StdioConn(stdout io.ReadCloser, stdin io.WriteCloser) io.ReadWriteCloser {
+	return &stdioConn{ReadCloser: stdout, WriteCloser: stdin}
+}
+
+type stdioConn struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (c *stdioConn) Close() error {
+	// closing stdin first lets the child process exit gracefully
+	err := c.WriteCloser.Close()
+	if readErr := c.ReadCloser.Close(); err == nil {
+		err = readErr
+	}
+
+	return err
+}
+
+// streamConn reads and writes JSON-RPC messages as a stream of JSON values.
+// Every written message is followed by a newline.
+type streamConn struct {
+	conn    io.ReadWriteCloser
+	decoder *json.Decoder
+}
+
+func newStreamConn(conn io.ReadWriteCloser) *streamConn {
+	return &streamConn{
+		conn:    conn,
+		decoder: json.NewDecoder(conn),
+	}
+}
+
+func (c *streamConn) readMessage() ([]byte, error) {
+	var message json.RawMessage
+	if err := c.decoder.Decode(&message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (c *streamConn) writeMessage(message []byte) error {
+	_, err := c.conn.Write(append(message[:len(message):len(message)], '\n'))
+	return err
+}
+
+func (c *streamConn) close() error {
+	return c.conn.Close()
+}
+
+// contentLengthConn reads and writes JSON-RPC messages with a Content-Length header.
+type contentLengthConn struct {
+	conn    io.ReadWriteCloser
+	reader  *textproto.Reader
+	maxSize int64 // no limit if <= 0
+}
+
+func newContentLengthConn(conn io.ReadWriteCloser) *contentLengthConn {
+	return &contentLengthConn{
+		conn:   conn,
+		reader: textproto.NewReader(bufio.NewReader(conn)),
+	}
+}
+
+func (c *contentLengthConn) readMessage() ([]byte, error) {
+	header, err := c.reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	value := header.Get("Content-Length")
+	if value == "" {
+		return nil, errors.New("missing Content-Length header")
+	}
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", value)
+	}
+	if c.maxSize > 0 && length > c.maxSize {
+		return nil, &ResponseTooLargeError{Limit: c.maxSize}
+	}
+
+	// without limit the buffer grows with the received data, so a large Content-Length alone allocates nothing
+	var message bytes.Buffer
+	if _, err := io.CopyN(&message, c.reader.R, length); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return message.Bytes(), nil
+}
+
+func (c *contentLengthConn) writeMessage(message []byte) error {
+	frame := make([]byte, 0, len(message)+32)
+	frame = append(frame, "Content-Length: "...)
+	frame = strconv.AppendInt(frame, int64(len(message)), 10)
+	frame = append(frame, "\r\n\r\n"...)
+	frame = append(frame, message...)
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *contentLengthConn) close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,133 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+// serveTestStream responds to the requests on conn with respondTestRequest(), using the given framing.
+func serveTestStream(conn net.Conn, framing Framing) {
+	var c messageConn = newStreamConn(conn)
+	if framing == FramingContentLength {
+		c = newContentLengthConn(conn)
+	}
+	defer c.close()
+
+	var writeMutex sync.Mutex
+	write := func(v interface{}) {
+		message, _ := json.Marshal(v)
+		writeMutex.Lock()
+		defer writeMutex.Unlock()
+		c.writeMessage(message)
+	}
+
+	for {
+		message, err := c.readMessage()
+		if err != nil {
+			return
+		}
+		go respondTestRequest(message, write)
+	}
+}
+
+func TestStreamClient(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, framing := range []Framing{FramingNewline, FramingContentLength} {
+		clientConn, serverConn := net.Pipe()
+		go serveTestStream(serverConn, framing)
+
+		rpcClient := NewStreamClient(clientConn, &StreamClientOpts{Framing: framing})
+
+		res, err := rpcClient.Call("hello", "world")
+		Expect(err).To(BeNil())
+		Expect(res.Result).To(Equal("hello"))
+
+		responses, err := rpcClient.CallBatch(RPCRequests{
+			NewRequest("first"),
+			NewRequest("second"),
+		})
+		Expect(err).To(BeNil())
+		Expect(responses).To(HaveLen(2))
+
+		subscription, err := rpcClient.Subscribe("test_subscribe", 1)
+		Expect(err).To(BeNil())
+		Expect((<-subscription.Notifications()).Params).To(HaveKeyWithValue("subscription", subscription.ID().String()))
+
+		Expect(rpcClient.Close()).To(BeNil())
+	}
+}
+
+func TestContentLengthConn(t *testing.T) {
+	RegisterTestingT(t)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	c := newContentLengthConn(clientConn)
+
+	// additional headers are ignored
+	go serverConn.Write([]byte("Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}"))
+	message, err := c.readMessage()
+	Expect(err).To(BeNil())
+	Expect(string(message)).To(Equal("{}"))
+
+	go func() {
+		buf := make([]byte, 64)
+		n, _ := serverConn.Read(buf)
+		serverConn.Write(buf[:n])
+	}()
+	Expect(c.writeMessage([]byte(`{"id":1}`))).To(BeNil())
+	message, err = c.readMessage()
+	Expect(err).To(BeNil())
+	Expect(string(message)).To(Equal(`{"id":1}`))
+
+	go serverConn.Write([]byte("Content-Type: application/json\r\n\r\n{}"))
+	_, err = c.readMessage()
+	Expect(err).To(MatchError("missing Content-Length header"))
+}
+
+func TestContentLengthConn_MaxSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	c := newFramedConn(clientConn, FramingContentLength, 16)
+
+	go serverConn.Write([]byte("Content-Length: 9999999999\r\n\r\n"))
+	_, err := c.readMessage()
+	Expect(err).To(Equal(&ResponseTooLargeError{Limit: 16}))
+
+	// without limit a large Content-Length is read as the data arrives
+	clientConn, serverConn = net.Pipe()
+	c = newFramedConn(clientConn, FramingContentLength, 0)
+	go func() {
+		serverConn.Write([]byte("Content-Length: 9999999999\r\n\r\n{}"))
+		serverConn.Close()
+	}()
+	_, err = c.readMessage()
+	Expect(err).To(Equal(io.ErrUnexpectedEOF))
+}
+
+func TestStreamClient_MaxResponseSize(t *testing.T) {
+	RegisterTestingT(t)
+
+	clientConn, serverConn := net.Pipe()
+	go serveTestStream(serverConn, FramingContentLength)
+	rpcClient := NewStreamClient(clientConn, &StreamClientOpts{
+		RPCClientOpts: RPCClientOpts{MaxResponseSize: 16, MaxBatchResponseSize: 32},
+		Framing:       FramingContentLength,
+	})
+	defer rpcClient.Close()
+
+	_, err := rpcClient.Call("a_method_with_a_long_name")
+	Expect(err).NotTo(BeNil())
+	var tooLarge *ResponseTooLargeError
+	Expect(errors.As(err, &tooLarge)).To(BeTrue())
+	Expect(tooLarge.Limit).To(Equal(int64(32)))
+}
@@ -0,0 +1,289 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSubscriptionOverflow ends a subscription whose notifications are not received fast enough.
+var ErrSubscriptionOverflow = errors.New("subscription notification buffer overflow")
+
+// subscriptionBufferSize is the number of notifications that are buffered per subscription.
+const subscriptionBufferSize = 256
+
+// RPCNotification represents a JSON-RPC notification object, a request object without id.
+//
+// Method: the method of the notification, e.g. "eth_subscription"
+//
+// Params: the params of the notification, may be nil
+//
+// See: http://www.jsonrpc.org/specification#notification
+type RPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// GetObject converts the params of the notification to an arbitrary type.
+//
+// The function works as you would expect it from json.Unmarshal()
+func (notification *RPCNotification) GetObject(toType interface{}) error {
+	js, err := json.Marshal(notification.Params)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(js, toType)
+}
+
+// Subscription is a subscription for notifications pushed by the server, created by ConnClient.Subscribe().
+//
+// Notifications are matched to the subscription by the "subscription" field of their params
+// (e.g. {"method": "eth_subscription", "params": {"subscription": "0x1", "result": {...}}}).
+type Subscription struct {
+	id ID // guarded by transport.pendingMutex
+
+	transport         *connTransport
+	method            string
+	params            interface{}
+	unsubscribeMethod string
+	call              func(method string, params ...interface{}) (*RPCResponse, error)
+	resubscribe       func() error
+
+	notifications chan *RPCNotification
+	err           chan error
+	started       bool // guarded by transport.pendingMutex
+	done          bool // guarded by transport.pendingMutex
+}
+
+// ID returns the subscription id returned by the server.
+// The id changes when the subscription is re-established after a reconnect.
+func (s *Subscription) ID() ID {
+	s.transport.pendingMutex.Lock()
+	defer s.transport.pendingMutex.Unlock()
+
+	return s.id
+}
+
+// Notifications returns the channel that receives the notifications of the subscription.
+// The channel is closed when the subscription ends.
+func (s *Subscription) Notifications() <-chan *RPCNotification {
+	return s.notifications
+}
+
+// Err returns a channel that receives the error that ended the subscription, e.g. because the connection was lost.
+// The channel is closed without error when Unsubscribe() is called.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription and sends the unsubscribe request to the server
+// (e.g. "eth_unsubscribe" for subscriptions created with "eth_subscribe").
+func (s *Subscription) Unsubscribe() error {
+	if !s.transport.endSubscription(s, nil) {
+		return nil
+	}
+
+	res, err := s.call(s.unsubscribeMethod, []interface{}{s.ID()})
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+// unsubscribeMethod returns the method to end a subscription created with the given method,
+// e.g. "eth_subscribe" -> "eth_unsubscribe".
+func unsubscribeMethod(subscribeMethod string) string {
+	if strings.HasSuffix(subscribeMethod, "subscribe") {
+		return strings.TrimSuffix(subscribeMethod, "subscribe") + "unsubscribe"
+	}
+
+	return subscribeMethod + "_unsubscribe"
+}
+
+func (client *connClient) Subscribe(method string, params ...interface{}) (*Subscription, error) {
+	subscription := &Subscription{
+		transport:         client.transport,
+		method:            method,
+		params:            Params(params...),
+		unsubscribeMethod: unsubscribeMethod(method),
+		call:              client.Call,
+		notifications:     make(chan *RPCNotification, subscriptionBufferSize),
+		err:               make(chan error, 1),
+	}
+	subscription.resubscribe = func() error {
+		return client.subscribe(subscription)
+	}
+
+	if err := client.subscribe(subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// subscribe sends the subscribe request of the subscription and registers it with the returned subscription id.
+func (client *connClient) subscribe(subscription *Subscription) error {
+	request := &RPCRequest{
+		Method:  subscription.method,
+		Params:  subscription.params,
+		JSONRPC: jsonrpcVersion,
+	}
+	client.setEmptyParams(request)
+
+	id, err := client.idGenerator.NextID()
+	if err != nil {
+		return fmt.Errorf("rpc call %v: could not generate id: %w", client.describeCall(request), err)
+	}
+	request.ID = id
+
+	// the subscription is registered by the read loop as soon as the response arrives,
+	// so that no notification that directly follows the response gets lost
+	client.transport.expectSubscription(id, subscription)
+	defer client.transport.forgetSubscription(id)
+
+	res, err := client.doCall(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if !client.transport.subscriptionStarted(subscription) {
+		return fmt.Errorf("rpc call %v: invalid subscription id %v", client.describeCall(request), res.Result)
+	}
+
+	return nil
+}
+
+func (t *connTransport) expectSubscription(requestID ID, subscription *Subscription) {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	t.subscribing[requestID] = subscription
+}
+
+func (t *connTransport) forgetSubscription(requestID ID) {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	delete(t.subscribing, requestID)
+}
+
+// startSubscription registers the subscription for a response to a subscribe request.
+// Must be called with pendingMutex held.
+func (t *connTransport) startSubscription(subscription *Subscription, message []byte) {
+	var response struct {
+		Result *ID `json:"result"`
+	}
+	if err := json.Unmarshal(message, &response); err != nil || response.Result == nil {
+		return
+	}
+
+	subscription.id = *response.Result
+	subscription.started = true
+	if subscription.done {
+		return
+	}
+	t.subscriptions[subscription.id] = subscription
+	t.active[subscription] = struct{}{}
+}
+
+func (t *connTransport) subscriptionStarted(subscription *Subscription) bool {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	return subscription.started
+}
+
+func (t *connTransport) subscriptionDone(subscription *Subscription) bool {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	return subscription.done
+}
+
+// lostSubscriptions returns the active subscriptions that were not re-established after the connection was lost.
+func (t *connTransport) lostSubscriptions() []*Subscription {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	var subscriptions []*Subscription
+	for subscription := range t.active {
+		if !subscription.started {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	return subscriptions
+}
+
+// endSubscription removes the subscription and closes its channels. err is sent to the Err() channel if not nil.
+// Returns false if the subscription already ended.
+func (t *connTransport) endSubscription(subscription *Subscription, err error) bool {
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	return t.endSubscriptionLocked(subscription, err)
+}
+
+func (t *connTransport) endSubscriptionLocked(subscription *Subscription, err error) bool {
+	if subscription.done {
+		return false
+	}
+
+	subscription.done = true
+	if t.subscriptions[subscription.id] == subscription {
+		delete(t.subscriptions, subscription.id)
+	}
+	delete(t.active, subscription)
+	if err != nil {
+		subscription.err <- err
+	}
+	close(subscription.err)
+	close(subscription.notifications)
+
+	return true
+}
+
+// dispatchNotification delivers a notification to its subscription,
+// it returns false if it is no notification of an active subscription.
+func (t *connTransport) dispatchNotification(envelope *messageEnvelope) bool {
+	var params struct {
+		Subscription *ID `json:"subscription"`
+	}
+	if err := json.Unmarshal(envelope.Params, &params); err != nil || params.Subscription == nil {
+		return false
+	}
+
+	t.pendingMutex.Lock()
+	defer t.pendingMutex.Unlock()
+
+	subscription, ok := t.subscriptions[*params.Subscription]
+	if !ok {
+		return false
+	}
+
+	notification := &RPCNotification{JSONRPC: envelope.JSONRPC, Method: *envelope.Method}
+	decoder := json.NewDecoder(bytes.NewReader(envelope.Params))
+	decoder.UseNumber()
+	if err := decoder.Decode(&notification.Params); err != nil {
+		return false
+	}
+
+	select {
+	case subscription.notifications <- notification:
+	default:
+		t.endSubscriptionLocked(subscription, ErrSubscriptionOverflow)
+		go subscription.call(subscription.unsubscribeMethod, []interface{}{subscription.id})
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package jsonrpc
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestConnClient_Subscribe(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	// notifications directly following the response are not lost
+	subscription, err := rpcClient.Subscribe("test_subscribe", 3)
+	Expect(err).To(BeNil())
+	Expect(subscription.ID()).To(Equal(StringID("sub-1")))
+
+	for i := 0; i < 3; i++ {
+		notification := <-subscription.Notifications()
+		Expect(notification.Method).To(Equal("test_subscription"))
+
+		var params struct {
+			Subscription string `json:"subscription"`
+			Result       int    `json:"result"`
+		}
+		Expect(notification.GetObject(&params)).To(BeNil())
+		Expect(params.Subscription).To(Equal("sub-1"))
+		Expect(params.Result).To(Equal(i))
+	}
+
+	// notifications of other subscriptions are not received
+	other, err := rpcClient.Subscribe("test_subscribe", 1)
+	Expect(err).To(BeNil())
+	Expect((<-other.Notifications()).Params).To(HaveKeyWithValue("subscription", "sub-2"))
+
+	Expect(subscription.Unsubscribe()).To(BeNil())
+	_, ok := <-subscription.Notifications()
+	Expect(ok).To(BeFalse())
+	_, ok = <-subscription.Err()
+	Expect(ok).To(BeFalse())
+
+	// unsubscribing twice is a no-op
+	Expect(subscription.Unsubscribe()).To(BeNil())
+}
+
+func TestConnClient_SubscriptionEndsOnClose(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+
+	subscription, err := rpcClient.Subscribe("test_subscribe", 0)
+	Expect(err).To(BeNil())
+
+	server.dropConnections()
+
+	Expect(<-subscription.Err()).NotTo(BeNil())
+	_, ok := <-subscription.Notifications()
+	Expect(ok).To(BeFalse())
+	rpcClient.Close()
+}
+
+func TestConnClient_Resubscribe(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWSWithOpts(server.URL(), &WSClientOpts{Reconnect: true, ReconnectDelay: 10 * time.Millisecond})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	subscription, err := rpcClient.Subscribe("test_subscribe", 1)
+	Expect(err).To(BeNil())
+	Expect(subscription.ID()).To(Equal(StringID("sub-1")))
+	Expect((<-subscription.Notifications()).Params).To(HaveKeyWithValue("subscription", "sub-1"))
+
+	server.dropConnections()
+
+	// the subscribe request is sent again after the reconnect and the subscription continues with the new id
+	var notification *RPCNotification
+	Eventually(subscription.Notifications()).Should(Receive(&notification))
+	Expect(notification.Params).NotTo(HaveKeyWithValue("subscription", "sub-1"))
+	Expect(notification.Params).To(HaveKeyWithValue("subscription", subscription.ID().String()))
+	Expect(subscription.Err()).NotTo(Receive())
+
+	Expect(subscription.Unsubscribe()).To(BeNil())
+}
+
+func TestConnClient_SubscriptionOverflow(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	subscription, err := rpcClient.Subscribe("test_subscribe", subscriptionBufferSize+1)
+	Expect(err).To(BeNil())
+
+	Expect(<-subscription.Err()).To(Equal(ErrSubscriptionOverflow))
+	count := 0
+	for range subscription.Notifications() {
+		count++
+	}
+	Expect(count).To(Equal(subscriptionBufferSize))
+}
+
+func TestUnsubscribeMethod(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(unsubscribeMethod("eth_subscribe")).To(Equal("eth_unsubscribe"))
+	Expect(unsubscribeMethod("subscribe")).To(Equal("unsubscribe"))
+	Expect(unsubscribeMethod("watch")).To(Equal("watch_unsubscribe"))
+}
@@ -0,0 +1,78 @@
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+)
+
+// TCPClientOpts can be provided to DialTCPWithOpts() to change configuration of the TCP client.
+//
+// RPCClientOpts: the same options as for http clients. HTTPClient and CustomHeaders are not used,
+// TLSConfig enables TLS for the connection if it is not nil. Proxy is not supported, the dial fails if it is set.
+//
+// Framing: how messages are separated on the connection (default FramingNewline)
+//
+// Dialer: provide a custom net.Dialer (e.g. to set a timeout or keepalive), a zero net.Dialer is used if nil
+//
+// Reconnect, ReconnectDelay, MaxReconnectDelay: see WSClientOpts
+type TCPClientOpts struct {
+	RPCClientOpts
+	Framing           Framing
+	Dialer            *net.Dialer
+	Reconnect         bool
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+}
+
+// DialTCP connects to a JSON-RPC endpoint that speaks JSON-RPC directly over TCP (e.g. "10.0.0.1:4000")
+// and returns a ConnClient with default configuration.
+//
+// All calls are sent over a single persistent connection, the responses are matched to the requests by their id.
+func DialTCP(address string) (ConnClient, error) {
+	return DialTCPWithOpts(address, nil)
+}
+
+// DialTCPWithOpts connects to a JSON-RPC endpoint over TCP and returns a ConnClient with custom configuration.
+//
+// opts: TCPClientOpts provide custom configuration
+func DialTCPWithOpts(address string, opts *TCPClientOpts) (ConnClient, error) {
+	if opts == nil {
+		opts = &TCPClientOpts{}
+	}
+	if opts.Proxy != nil {
+		return nil, MarkPermanent(errors.New("the tcp client does not support Proxy"))
+	}
+
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	dial := func() (messageConn, error) {
+		var conn net.Conn
+		var err error
+		if opts.TLSConfig != nil {
+			conn, err = tls.DialWithDialer(dialer, "tcp", address, opts.TLSConfig)
+		} else {
+			conn, err = dialer.Dial("tcp", address)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return newFramedConn(conn, opts.Framing, opts.maxMessageSize()), nil
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return nil, &TransportError{err: err}
+	}
+
+	var reconnect *reconnector
+	if opts.Reconnect {
+		reconnect = newReconnector(dial, opts.ReconnectDelay, opts.MaxReconnectDelay)
+	}
+
+	return newConnClient(conn, &opts.RPCClientOpts, reconnect), nil
+}
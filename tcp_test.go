@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTCPClient(t *testing.T) {
+	RegisterTestingT(t)
+
+	for _, framing := range []Framing{FramingNewline, FramingContentLength} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+
+		var mutex sync.Mutex
+		var conns []net.Conn
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				mutex.Lock()
+				conns = append(conns, conn)
+				mutex.Unlock()
+				go serveTestStream(conn, framing)
+			}
+		}()
+
+		rpcClient, err := DialTCPWithOpts(listener.Addr().String(), &TCPClientOpts{
+			Framing:        framing,
+			Reconnect:      true,
+			ReconnectDelay: 10 * time.Millisecond,
+		})
+		Expect(err).To(BeNil())
+
+		// concurrent calls share the connection
+		results := make(chan interface{}, 10)
+		for i := 0; i < 10; i++ {
+			go func() {
+				res, err := rpcClient.Call("hello", 10)
+				if err != nil {
+					results <- err
+					return
+				}
+				results <- res.Result
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			Expect(<-results).To(Equal("hello"))
+		}
+		mutex.Lock()
+		Expect(conns).To(HaveLen(1))
+		for _, conn := range conns {
+			conn.Close()
+		}
+		mutex.Unlock()
+
+		// the connection is replaced when it is lost
+		Eventually(func() error {
+			_, err := rpcClient.Call("hello")
+			return err
+		}).Should(BeNil())
+
+		Expect(rpcClient.Close()).To(BeNil())
+		listener.Close()
+	}
+}
+
+func TestDialTCP_Error(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := DialTCP("127.0.0.1:1")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+}
+
+func TestTCPClient_TLS(t *testing.T) {
+	RegisterTestingT(t)
+
+	ca := newTestCertificate(nil, "ca")
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{newTestCertificate(ca, "server").tlsCertificate()},
+	})
+	Expect(err).To(BeNil())
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestStream(conn, FramingNewline)
+		}
+	}()
+
+	// the TLSConfig of RPCClientOpts is used for the connection
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(ca.certificate)
+	rpcClient, err := DialTCPWithOpts(listener.Addr().String(), &TCPClientOpts{
+		RPCClientOpts: RPCClientOpts{TLSConfig: &tls.Config{RootCAs: rootCAs}},
+	})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+}
+
+func TestDialTCP_Proxy(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := DialTCPWithOpts("127.0.0.1:1", &TCPClientOpts{
+		RPCClientOpts: RPCClientOpts{Proxy: &ProxyOpts{URL: "socks5://127.0.0.1:1080"}},
+	})
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring("does not support Proxy"))
+	Expect(IsRetryable(err)).To(BeFalse())
+}
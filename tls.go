@@ -0,0 +1,62 @@
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// TLSFiles are the PEM files of a TLS configuration, see LoadTLSConfig().
+//
+// CertFile, KeyFile: the client certificate and its private key for mutual TLS (mTLS), both or none must be set
+//
+// CAFile: the certificates of the certificate authorities that verify the server instead of the system roots, optional
+//
+// ServerName: overrides the server name that is sent (SNI) and verified, e.g. if the endpoint is an ip address, optional
+type TLSFiles struct {
+	CertFile   string
+	KeyFile    string
+	CAFile     string
+	ServerName string
+}
+
+// LoadTLSConfig returns a tls.Config for RPCClientOpts.TLSConfig that uses the given files, e.g.
+//
+//	tlsConfig, err := jsonrpc.LoadTLSConfig(jsonrpc.TLSFiles{CertFile: "client.crt", KeyFile: "client.key", CAFile: "ca.crt"})
+//	if err != nil {
+//		return err
+//	}
+//	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{TLSConfig: tlsConfig})
+func LoadTLSConfig(files TLSFiles) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: files.ServerName,
+	}
+
+	if files.CertFile != "" || files.KeyFile != "" {
+		if files.CertFile == "" || files.KeyFile == "" {
+			return nil, errors.New("tls: both the certificate and the key file must be set")
+		}
+		certificate, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if files.CAFile != "" {
+		pem, err := ioutil.ReadFile(files.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls: no certificates found in %v", files.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// testCertificate is a certificate with its private key.
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	der         []byte
+}
+
+// newTestCertificate returns a certificate signed by parent, a self-signed CA certificate if parent is nil.
+func newTestCertificate(parent *testCertificate, commonName string, dnsNames ...string) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	Expect(err).To(BeNil())
+	certificate, err := x509.ParseCertificate(der)
+	Expect(err).To(BeNil())
+
+	return &testCertificate{certificate: certificate, key: key, der: der}
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key, Leaf: c.certificate}
+}
+
+// writeFiles writes the certificate and the key as PEM files to dir and returns their paths.
+func (c *testCertificate) writeFiles(dir, name string) (string, string) {
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600)).To(Succeed())
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	Expect(err).To(BeNil())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)).To(Succeed())
+	return certFile, keyFile
+}
+
+// newTLSServer returns a server with a certificate of ca that requires a client certificate of ca.
+func newTLSServer(ca *testCertificate) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","result":%q,"id":0}`, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.certificate)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{newTestCertificate(ca, "server", "rpc.internal").tlsCertificate()},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	return server
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "jsonrpc-tls")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	ca := newTestCertificate(nil, "ca")
+	caFile, _ := ca.writeFiles(dir, "ca")
+	certFile, keyFile := newTestCertificate(ca, "client").writeFiles(dir, "client")
+
+	server := newTLSServer(ca)
+	defer server.Close()
+
+	tlsConfig, err := LoadTLSConfig(TLSFiles{CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ServerName: "rpc.internal"})
+	Expect(err).To(BeNil())
+	Expect(tlsConfig.ServerName).To(Equal("rpc.internal"))
+
+	for _, protocol := range []HTTPProtocol{HTTPProtocolDefault, HTTPProtocolHTTP2} {
+		rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{TLSConfig: tlsConfig, HTTPProtocol: protocol})
+		res, err := rpcClient.Call("something")
+		Expect(err).To(BeNil())
+		Expect(res.Result).To(Equal("client"))
+	}
+
+	// without the client certificate
+	tlsConfig, err = LoadTLSConfig(TLSFiles{CAFile: caFile})
+	Expect(err).To(BeNil())
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{TLSConfig: tlsConfig}).Call("something")
+	Expect(err).To(HaveOccurred())
+
+	// the server certificate is not trusted without the ca
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{}).Call("something")
+	Expect(err).To(HaveOccurred())
+}
+
+func TestLoadTLSConfigErrors(t *testing.T) {
+	RegisterTestingT(t)
+
+	dir, err := ioutil.TempDir("", "jsonrpc-tls")
+	Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	certFile, keyFile := newTestCertificate(nil, "ca").writeFiles(dir, "ca")
+
+	_, err = LoadTLSConfig(TLSFiles{CertFile: certFile})
+	Expect(err).To(MatchError("tls: both the certificate and the key file must be set"))
+
+	_, err = LoadTLSConfig(TLSFiles{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")})
+	Expect(err).To(HaveOccurred())
+
+	_, err = LoadTLSConfig(TLSFiles{CAFile: keyFile})
+	Expect(err).To(MatchError("tls: no certificates found in " + keyFile))
+
+	config, err := LoadTLSConfig(TLSFiles{})
+	Expect(err).To(BeNil())
+	Expect(config.Certificates).To(BeEmpty())
+	Expect(config.RootCAs).To(BeNil())
+}
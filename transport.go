@@ -0,0 +1,355 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport sends encoded JSON-RPC requests to the server and returns the encoded responses.
+//
+// The client does the JSON-RPC encoding (ids, validation, decoding of the responses), so custom transports
+// only have to deliver the messages, see NewClientWithTransport().
+type Transport interface {
+	// Send sends a request object or a batch array and returns the response body.
+	// The request should be aborted when ctx is done.
+	//
+	// An error of type *HTTPError may be returned together with the response body. The body is decoded anyway
+	// and the response is returned if it is valid (e.g. holds an RPCError), otherwise the HTTPError.
+	// All other errors are returned as TransportError.
+	Send(ctx context.Context, request []byte) ([]byte, error)
+}
+
+// StreamingTransport is implemented by transports that can return the response body before it is received completely,
+// which is used by Client.CallBatchStream().
+type StreamingTransport interface {
+	Transport
+
+	// SendStream is like Send() but returns the response body as stream that must be closed by the caller.
+	// An error of type *HTTPError may be returned together with the response body.
+	SendStream(ctx context.Context, request []byte) (io.ReadCloser, error)
+}
+
+// sendStream sends request with transport and returns the response body as stream,
+// also for transports that do not implement StreamingTransport.
+func sendStream(ctx context.Context, transport Transport, request []byte) (io.ReadCloser, error) {
+	if streaming, ok := transport.(StreamingTransport); ok {
+		return streaming.SendStream(ctx, request)
+	}
+
+	response, err := transport.Send(ctx, request)
+	if response == nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(response)), err
+}
+
+// classifyTransportError returns err of a transport as it is returned by the client:
+// *HTTPError as it is, all other errors as *TransportError.
+func classifyTransportError(err error) error {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+
+	return &TransportError{err: err}
+}
+
+// responseError returns err prefixed with the context of the response.
+// If httpErr is not nil, an HTTPError with its status code is returned.
+func responseError(prefix string, httpErr *HTTPError, err error) error {
+	if httpErr == nil {
+		return fmt.Errorf("%v: %w", prefix, err)
+	}
+
+	return &HTTPError{
+		Code:       httpErr.Code,
+		RetryAfter: httpErr.RetryAfter,
+		err:        fmt.Errorf("%v status code: %v. %w", prefix, httpErr.Code, err),
+	}
+}
+
+// NewHTTPTransport returns the Transport that is used by NewClientWithOpts(). It sends the requests as http POST requests.
+//
+// endpoint: JSON-RPC service URL to which JSON-RPC requests are sent.
+//
+// opts: only EndpointVars, HTTPClient, CookieJar, CustomHeaders, HeaderSet, UserAgent, HTTPProtocol, TLSConfig, CertificatePins, Proxy, ConnectionPool, HTTPMethod, GETEncoding, GETParam, Compression, ContentDecoders, IdempotencyKey.Header,
+// CorrelationID.Header, HTTPMiddleware, SignRequest, BearerToken, JWT, DigestAuth, APIKey, Debug, Redactor and PprofLabels are used, may be nil
+func NewHTTPTransport(endpoint string, opts *RPCClientOpts) Transport {
+	transport := &httpTransport{
+		endpoint:             endpoint,
+		httpClient:           &http.Client{},
+		customHeaders:        make(map[string]string),
+		method:               http.MethodPost,
+		getParam:             defaultGETParam,
+		idempotencyKeyHeader: defaultIdempotencyKeyHeader,
+		correlationIDHeader:  defaultCorrelationIDHeader,
+		userAgent:            defaultUserAgent(),
+	}
+
+	if opts != nil {
+		if opts.HTTPMethod != "" {
+			transport.method = strings.ToUpper(opts.HTTPMethod)
+		}
+		transport.getEncoding = opts.GETEncoding
+		if opts.GETParam != "" {
+			transport.getParam = opts.GETParam
+		}
+		if opts.Compression != nil {
+			transport.compressor = newCompressor(*opts.Compression)
+		}
+
+		if opts.HTTPClient != nil {
+			transport.httpClient = opts.HTTPClient
+		} else if opts.HTTPProtocol != HTTPProtocolDefault || opts.TLSConfig != nil || opts.CertificatePins != nil || opts.Proxy != nil || opts.ConnectionPool != nil {
+			tlsConfig := opts.TLSConfig
+			if opts.CertificatePins != nil {
+				tlsConfig = pinTLSConfig(tlsConfig, *opts.CertificatePins)
+			}
+			var proxy func(*http.Request) (*url.URL, error)
+			if opts.Proxy != nil {
+				proxy = opts.Proxy.proxyFunc()
+			}
+			transport.httpClient = &http.Client{Transport: newHTTPProtocolTransport(opts.HTTPProtocol, tlsConfig, proxy, opts.ConnectionPool)}
+		}
+
+		if opts.CookieJar != nil {
+			// the http client of the options must not be modified
+			httpClient := *transport.httpClient
+			httpClient.Jar = opts.CookieJar
+			transport.httpClient = &httpClient
+		}
+
+		transport.httpClient = wrapRoundTripper(transport.httpClient, httpMiddleware(opts))
+
+		if opts.CustomHeaders != nil {
+			for k, v := range opts.CustomHeaders {
+				transport.customHeaders[k] = v
+			}
+		}
+
+		if opts.IdempotencyKey != nil && opts.IdempotencyKey.Header != "" {
+			transport.idempotencyKeyHeader = opts.IdempotencyKey.Header
+		}
+
+		transport.headerSet = opts.HeaderSet
+		if opts.EndpointVars != nil {
+			transport.endpointVars = make(map[string]string, len(opts.EndpointVars))
+			for k, v := range opts.EndpointVars {
+				transport.endpointVars[k] = v
+			}
+		}
+		transport.userAgent = userAgent(opts.UserAgent)
+		transport.pprofLabels = opts.PprofLabels
+		transport.signRequest = opts.SignRequest
+
+		if opts.CorrelationID != nil && opts.CorrelationID.Header != "" {
+			transport.correlationIDHeader = opts.CorrelationID.Header
+		}
+	}
+
+	return transport
+}
+
+// httpMiddleware returns the HTTPMiddleware of opts followed by the middleware of the options that modify or dump
+// the http requests, so that the debug dump sees the requests as they are sent.
+func httpMiddleware(opts *RPCClientOpts) []func(http.RoundTripper) http.RoundTripper {
+	middleware := append([]func(http.RoundTripper) http.RoundTripper{}, opts.HTTPMiddleware...)
+	if opts.BearerToken != nil {
+		middleware = append(middleware, newBearerTokenMiddleware(*opts.BearerToken))
+	}
+	if opts.JWT != nil {
+		middleware = append(middleware, newJWTMiddleware(*opts.JWT))
+	}
+	if opts.DigestAuth != nil {
+		middleware = append(middleware, newDigestAuthMiddleware(*opts.DigestAuth))
+	}
+	var secrets []string
+	if opts.APIKey != nil {
+		middleware = append(middleware, newAPIKeyMiddleware(*opts.APIKey))
+		secrets = append(secrets, opts.APIKey.Key)
+	}
+	if opts.Debug != nil {
+		middleware = append(middleware, newDebugMiddleware(*opts.Debug, opts.Redactor, secrets...))
+	}
+	if opts.ContentDecoders != nil {
+		// innermost, so that the debug dump sees the decoded responses
+		middleware = append(middleware, newDecodingMiddleware(opts.ContentDecoders))
+	}
+	return middleware
+}
+
+// httpTransport sends requests as http POST requests.
+type httpTransport struct {
+	endpoint             string
+	httpClient           *http.Client
+	customHeaders        map[string]string
+	headerSet            *HeaderSet
+	endpointVars         map[string]string
+	userAgent            string
+	method               string
+	getEncoding          GETEncoding
+	getParam             string
+	compressor           *compressor
+	idempotencyKeyHeader string
+	correlationIDHeader  string
+	pprofLabels          bool
+	signRequest          func(request *http.Request, body []byte) error
+}
+
+func (t *httpTransport) Send(ctx context.Context, body []byte) ([]byte, error) {
+	responseBody, err := t.SendStream(ctx, body)
+	if responseBody == nil {
+		return nil, err
+	}
+	defer responseBody.Close()
+
+	data, readErr := ioutil.ReadAll(responseBody)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return data, err
+}
+
+func (t *httpTransport) SendStream(ctx context.Context, body []byte) (io.ReadCloser, error) {
+	body, compressed, err := t.compress(body)
+	if err != nil {
+		return nil, MarkPermanent(err)
+	}
+
+	endpoint, err := t.endpointFor(ctx)
+	if err != nil {
+		return nil, MarkPermanent(err)
+	}
+	request, err := t.newRequest(ctx, endpoint, body)
+	if err != nil {
+		// e.g. invalid endpoint url
+		return nil, MarkPermanent(err)
+	}
+	if compressed {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
+
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", t.userAgent)
+
+	// set default headers first, so that even content type and accept can be overwritten
+	for k, v := range t.customHeaders {
+		request.Header.Set(k, v)
+	}
+	t.headerSet.apply(request.Header)
+
+	for name, values := range contextHeaders(ctx) {
+		request.Header[name] = append([]string(nil), values...)
+	}
+
+	if key := idempotencyKey(ctx); key != "" {
+		request.Header.Set(t.idempotencyKeyHeader, key)
+	}
+
+	if id := correlationID(ctx); id != "" {
+		request.Header.Set(t.correlationIDHeader, id)
+	}
+
+	if t.signRequest != nil {
+		signedBody := body
+		if t.method == http.MethodGet {
+			signedBody = nil
+		}
+		if err := t.signRequest(request, signedBody); err != nil {
+			return nil, err
+		}
+	}
+
+	httpResponse, err := t.do(endpoint, request)
+	if err != nil {
+		recordEndpoint(ctx, endpoint, 0, nil)
+		return nil, err
+	}
+	recordEndpoint(ctx, endpoint, httpResponse.StatusCode, httpResponse.Header)
+
+	if httpResponse.StatusCode >= 400 {
+		return httpResponse.Body, &HTTPError{
+			Code:       httpResponse.StatusCode,
+			RetryAfter: parseRetryAfter(httpResponse.Header.Get("Retry-After"), time.Now()),
+			err:        fmt.Errorf("status code: %v", httpResponse.StatusCode),
+		}
+	}
+
+	return httpResponse.Body, nil
+}
+
+// do sends request with the http client, with the endpoint as pprof label if enabled.
+func (t *httpTransport) do(endpoint string, request *http.Request) (*http.Response, error) {
+	if !t.pprofLabels {
+		return t.httpClient.Do(request)
+	}
+
+	var response *http.Response
+	var err error
+	pprof.Do(request.Context(), pprof.Labels("endpoint", endpointLabel(endpoint)), func(ctx context.Context) {
+		response, err = t.httpClient.Do(request.WithContext(ctx))
+	})
+	return response, err
+}
+
+// compress compresses the body of a request if compression is enabled and the body is sent as http body.
+func (t *httpTransport) compress(body []byte) ([]byte, bool, error) {
+	if t.compressor == nil || t.method == http.MethodGet {
+		return body, false, nil
+	}
+	return t.compressor.compress(body)
+}
+
+// newRequest returns the http request for an encoded JSON-RPC request to endpoint.
+func (t *httpTransport) newRequest(ctx context.Context, endpoint string, body []byte) (*http.Request, error) {
+	if t.method != http.MethodGet {
+		request, err := http.NewRequestWithContext(ctx, t.method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", "application/json")
+		return request, nil
+	}
+
+	getEndpoint, err := getURL(endpoint, body, t.getEncoding, t.getParam)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequestWithContext(ctx, http.MethodGet, getEndpoint, nil)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either delay seconds or an http date.
+// Returns 0 if the value is empty or invalid, or the date has passed.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+
+	return date.Sub(now)
+}
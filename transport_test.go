@@ -0,0 +1,239 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// transportFunc is a Transport implemented by a function.
+type transportFunc func(ctx context.Context, request []byte) ([]byte, error)
+
+func (f transportFunc) Send(ctx context.Context, request []byte) ([]byte, error) {
+	return f(ctx, request)
+}
+
+func TestNewClientWithTransport(t *testing.T) {
+	RegisterTestingT(t)
+
+	var sent string
+	rpcClient := NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		sent = string(request)
+		return []byte(`{"jsonrpc":"2.0","result":3,"id":1}`), nil
+	}), &RPCClientOpts{IDGenerator: NewSequentialIDGenerator(1)})
+
+	var result int
+	Expect(rpcClient.CallFor(&result, "add", 1, 2)).To(BeNil())
+	Expect(result).To(Equal(3))
+	Expect(sent).To(Equal(`{"method":"add","params":[1,2],"id":1,"jsonrpc":"2.0"}`))
+
+	// transport errors are returned as TransportError
+	rpcClient = NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		return nil, context.DeadlineExceeded
+	}), nil)
+	_, err := rpcClient.Call("add", 1, 2)
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(err).To(MatchError(ContainSubstring("rpc call add(): context deadline exceeded")))
+
+	// the body of an http error is decoded
+	rpcClient = NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		return []byte(`{"jsonrpc":"2.0","error":{"code":-32000,"message":"overloaded"},"id":0}`), &HTTPError{Code: 500}
+	}), nil)
+	res, err := rpcClient.Call("add", 1, 2)
+	Expect(err).To(BeNil())
+	Expect(res.Error.Message).To(Equal("overloaded"))
+
+	rpcClient = NewClientWithTransport(transportFunc(func(ctx context.Context, request []byte) ([]byte, error) {
+		return []byte(`not json`), &HTTPError{Code: 503}
+	}), nil)
+	_, err = rpcClient.Call("add", 1, 2)
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(err.(*HTTPError).Code).To(Equal(503))
+	Expect(err).To(MatchError(ContainSubstring("rpc call add() status code: 503. could not decode body to rpc response")))
+}
+
+func TestHTTPTransport_Context(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewClient(server.URL).CallContext(ctx, "slow")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(err).To(MatchError(ContainSubstring(context.DeadlineExceeded.Error())))
+	Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+}
+
+func TestConnTransport_Context(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err = rpcClient.CallContext(ctx, "slow", 1000)
+	Expect(err).To(MatchError(ContainSubstring(context.Canceled.Error())))
+	Expect(IsRetryable(err)).To(BeFalse())
+
+	// the late response is dropped
+	res, err := rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+}
+
+func TestHTTPTransport_RetryAfter(t *testing.T) {
+	RegisterTestingT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Call("something")
+	Expect(err).To(BeAssignableToTypeOf(&HTTPError{}))
+	Expect(err.(*HTTPError).RetryAfter).To(Equal(120 * time.Second))
+}
+
+func TestHTTPTransport_SignRequest(t *testing.T) {
+	RegisterTestingT(t)
+
+	sign := func(body []byte) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Signature") != sign(append([]byte(r.Header.Get("X-Request-ID")), body...)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","result":"signed","id":0}`))
+	}))
+	defer server.Close()
+
+	var signed []string
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{
+		CorrelationID: &CorrelationIDOpts{},
+		SignRequest: func(request *http.Request, body []byte) error {
+			signed = append(signed, string(body))
+			// the headers are set before the request is signed
+			request.Header.Set("X-Signature", sign(append([]byte(request.Header.Get("X-Request-ID")), body...)))
+			return nil
+		},
+	})
+	res, err := rpcClient.Call("something", 1)
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("signed"))
+	Expect(signed).To(Equal([]string{`{"method":"something","params":[1],"id":0,"jsonrpc":"2.0"}`}))
+
+	// GET requests have no body
+	signed = nil
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{
+		HTTPMethod: http.MethodGet,
+		SignRequest: func(request *http.Request, body []byte) error {
+			signed = append(signed, string(body))
+			return nil
+		},
+	}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(signed).To(Equal([]string{""}))
+
+	// the request is not sent if signing fails
+	requests = 0
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{
+		SignRequest: func(*http.Request, []byte) error {
+			return errors.New("no key")
+		},
+	}).Call("something")
+	Expect(err).To(HaveOccurred())
+	Expect(err.Error()).To(ContainSubstring("no key"))
+	Expect(requests).To(Equal(0))
+}
+
+func TestHTTPTransport_CookieJar(t *testing.T) {
+	RegisterTestingT(t)
+
+	var backends []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("backend")
+		if err != nil {
+			http.SetCookie(w, &http.Cookie{Name: "backend", Value: fmt.Sprint(len(backends))})
+			backends = append(backends, "new")
+		} else {
+			backends = append(backends, cookie.Value)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if bytes.HasPrefix(body, []byte("[")) {
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","result":1,"id":0},{"jsonrpc":"2.0","result":2,"id":1}]`)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","result":1,"id":0}`)
+	}))
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	Expect(err).To(BeNil())
+	httpClient := &http.Client{}
+	rpcClient := NewClientWithOpts(server.URL, &RPCClientOpts{HTTPClient: httpClient, CookieJar: jar})
+	_, err = rpcClient.Call("first")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.Call("second")
+	Expect(err).To(BeNil())
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(BeNil())
+	Expect(backends).To(Equal([]string{"new", "0", "0"}))
+	Expect(httpClient.Jar).To(BeNil())
+
+	// without jar the cookies are ignored
+	backends = nil
+	rpcClient = NewClient(server.URL)
+	rpcClient.Call("first")
+	rpcClient.Call("second")
+	Expect(backends).To(Equal([]string{"new", "new"}))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	RegisterTestingT(t)
+
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	Expect(parseRetryAfter("", now)).To(Equal(time.Duration(0)))
+	Expect(parseRetryAfter("5", now)).To(Equal(5 * time.Second))
+	Expect(parseRetryAfter(" 5 ", now)).To(Equal(5 * time.Second))
+	Expect(parseRetryAfter("-5", now)).To(Equal(time.Duration(0)))
+	Expect(parseRetryAfter("Wed, 21 Oct 2015 07:28:30 GMT", now)).To(Equal(30 * time.Second))
+	Expect(parseRetryAfter("Wed, 21 Oct 2015 07:27:00 GMT", now)).To(Equal(time.Duration(0)))
+	Expect(parseRetryAfter("soon", now)).To(Equal(time.Duration(0)))
+}
@@ -0,0 +1,38 @@
+package jsonrpc
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+const modulePath = "github.com/aurora-is-near/go-jsonrpc/v3"
+
+var (
+	defaultUserAgentOnce  sync.Once
+	defaultUserAgentValue string
+)
+
+// defaultUserAgent returns "go-jsonrpc/<version>" with the version of this module in the build,
+// "go-jsonrpc/v3" if it is not known (e.g. in tests or with a replace directive).
+func defaultUserAgent() string {
+	defaultUserAgentOnce.Do(func() {
+		version := "v3"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, module := range info.Deps {
+				if module.Path == modulePath && module.Version != "" && module.Version != "(devel)" && module.Replace == nil {
+					version = module.Version
+				}
+			}
+		}
+		defaultUserAgentValue = "go-jsonrpc/" + version
+	})
+	return defaultUserAgentValue
+}
+
+// userAgent returns the User-Agent of the options, the default User-Agent if it is empty.
+func userAgent(userAgent string) string {
+	if userAgent == "" {
+		return defaultUserAgent()
+	}
+	return userAgent
+}
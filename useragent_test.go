@@ -0,0 +1,61 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+func TestUserAgent(t *testing.T) {
+	RegisterTestingT(t)
+
+	userAgents := make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("User-Agent")
+		switch r.URL.Path {
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				conn.Close()
+			}
+		case "/sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+		default:
+			fmt.Fprint(w, `{"jsonrpc":"2.0","result":1,"id":0}`)
+		}
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Call("something")
+	Expect(err).To(BeNil())
+	Expect(<-userAgents).To(Equal("go-jsonrpc/v3"))
+
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{UserAgent: "my-indexer/1.2"}).Call("something")
+	Expect(err).To(BeNil())
+	Expect(<-userAgents).To(Equal("my-indexer/1.2"))
+
+	// a User-Agent of the custom headers takes precedence
+	_, err = NewClientWithOpts(server.URL, &RPCClientOpts{UserAgent: "my-indexer/1.2", CustomHeaders: map[string]string{"User-Agent": "custom"}}).Call("something")
+	Expect(err).To(BeNil())
+	Expect(<-userAgents).To(Equal("custom"))
+
+	wsClient, err := DialWSWithOpts("ws"+server.URL[len("http"):]+"/ws", &WSClientOpts{RPCClientOpts: RPCClientOpts{UserAgent: "my-indexer/1.2"}})
+	Expect(err).To(BeNil())
+	wsClient.Close()
+	Expect(<-userAgents).To(Equal("my-indexer/1.2"))
+
+	stream, err := DialSSE(server.URL + "/sse")
+	Expect(err).To(BeNil())
+	stream.Close()
+	Expect(<-userAgents).To(Equal("go-jsonrpc/v3"))
+
+	healthCheck := NewHTTPTransport(server.URL, &RPCClientOpts{UserAgent: "health/1"}).(*httpTransport)
+	Expect(healthCheck.ping(context.Background())).To(Succeed())
+	Expect(<-userAgents).To(Equal("health/1"))
+}
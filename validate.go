@@ -0,0 +1,122 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidRequest is returned (wrapped) if a request is rejected by the client before it is sent.
+//
+// Use errors.Is(err, ErrInvalidRequest) to check for it.
+var ErrInvalidRequest = errors.New("invalid request")
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Validate checks if the request is valid according to the JSON-RPC 2.0 specification, before it is sent:
+//   - the method must not be empty
+//   - the method must not start with "rpc." since these names are reserved for rpc-internal methods and extensions
+//   - params must be omitted or encode to a json array or object
+//
+// The returned error wraps ErrInvalidRequest.
+func (RPCRequest *RPCRequest) Validate() error {
+	if RPCRequest.Method == "" {
+		return fmt.Errorf("%w: method must not be empty", ErrInvalidRequest)
+	}
+
+	if strings.HasPrefix(RPCRequest.Method, "rpc.") {
+		return fmt.Errorf("%w: method %q is reserved for rpc-internal methods and extensions", ErrInvalidRequest, RPCRequest.Method)
+	}
+
+	if err := validateParams(RPCRequest.Params); err != nil {
+		return fmt.Errorf("%w: method %v: %v", ErrInvalidRequest, RPCRequest.Method, err)
+	}
+
+	return nil
+}
+
+// validateParams checks if params encode to a json array or object.
+// The type of the params is used for this decision, only types with custom json encoding are encoded.
+func validateParams(params interface{}) error {
+	if params == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(params)
+	for {
+		if value.Type().Implements(jsonMarshalerType) {
+			return validateMarshaledParams(params)
+		}
+		if value.Kind() != reflect.Ptr && value.Kind() != reflect.Interface {
+			break
+		}
+		if value.IsNil() {
+			return errors.New("params must not be null")
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array:
+		return nil
+	case reflect.Slice:
+		// []byte is encoded as base64 string
+		if value.Type().Elem().Kind() == reflect.Uint8 && !value.Type().Elem().Implements(jsonMarshalerType) {
+			return fmt.Errorf("params of type %v must be an array or object, not a string", value.Type())
+		}
+		if value.IsNil() {
+			return errors.New("params must not be null")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("params of type %v must be an array or object", value.Type())
+}
+
+func validateMarshaledParams(params interface{}) error {
+	js, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("params can not be encoded: %v", err)
+	}
+
+	js = bytes.TrimSpace(js)
+	if len(js) == 0 || (js[0] != '[' && js[0] != '{') {
+		return fmt.Errorf("params must be an array or object, got %.20s", js)
+	}
+
+	return nil
+}
+
+// Validate checks if the requests are a valid batch before they are sent:
+//   - the batch must not be empty
+//   - every request must be valid (see RPCRequest.Validate())
+//   - the ids of the requests must be unique, notifications have no id
+//
+// The returned error wraps ErrInvalidRequest.
+func (requests RPCRequests) Validate() error {
+	if len(requests) == 0 {
+		return fmt.Errorf("%w: empty request list", ErrInvalidRequest)
+	}
+
+	positions := make(map[ID]int, len(requests))
+	for i, req := range requests {
+		if req == nil {
+			return fmt.Errorf("%w: request %v is nil", ErrInvalidRequest, i)
+		}
+		if err := req.Validate(); err != nil {
+			return fmt.Errorf("request %v: %w", i, err)
+		}
+		if req.Notification {
+			continue
+		}
+		if j, ok := positions[req.ID]; ok {
+			return fmt.Errorf("%w: requests %v and %v have the same id %v", ErrInvalidRequest, j, i, req.ID)
+		}
+		positions[req.ID] = i
+	}
+
+	return nil
+}
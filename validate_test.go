@@ -0,0 +1,140 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRPCRequest_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	valid := []*RPCRequest{
+		NewRequest("noParams"),
+		NewRequest("primitive", 1),
+		NewRequest("struct", &Person{Name: "Alex"}),
+		NewRequest("slice", []int{1, 2}),
+		NewNamedRequest("map", map[string]interface{}{"a": 1}),
+		{Method: "array", Params: [2]int{1, 2}},
+		{Method: "raw", Params: json.RawMessage(`[1]`)},
+		{Method: "paramsRaw", Params: ParamsRaw(json.RawMessage(` {"a": 1}`))},
+	}
+	for _, req := range valid {
+		Expect(req.Validate()).To(BeNil(), req.Method)
+	}
+
+	invalid := []*RPCRequest{
+		{Method: ""},
+		{Method: "rpc.discover"},
+		{Method: "number", Params: 3},
+		{Method: "string", Params: "abc"},
+		{Method: "bytes", Params: []byte("abc")},
+		{Method: "nilPointer", Params: (*Person)(nil)},
+		{Method: "nilSlice", Params: []int(nil)},
+		{Method: "rawString", Params: json.RawMessage(`"abc"`)},
+		{Method: "time", Params: time.Now()},
+		{Method: "unsupported", Params: ParamsRaw(make(chan int))},
+	}
+	for _, req := range invalid {
+		err := req.Validate()
+		Expect(err).NotTo(BeNil(), req.Method)
+		Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+	}
+}
+
+func TestRpcClient_Validation(t *testing.T) {
+	RegisterTestingT(t)
+	rpcClient := NewClient(httpServer.URL)
+
+	// invalid requests are never sent, so no request arrives at the test server
+	_, err := rpcClient.Call("")
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+
+	_, err = rpcClient.Call("rpc.discover")
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+	Expect(err.Error()).To(ContainSubstring("reserved"))
+
+	_, err = rpcClient.CallRaw(&RPCRequest{Method: "number", Params: 3})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+
+	_, err = rpcClient.CallBatchRaw(RPCRequests{NewRequest("valid"), nil})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{
+		DisableValidation: true,
+	})
+	responseBody = `{"result": {}}`
+	_, err = rpcClient.Call("rpc.discover")
+	Expect((<-requestChan).body).To(Equal(`{"method":"rpc.discover","id":0,"jsonrpc":"2.0"}`))
+	Expect(err).To(BeNil())
+}
+
+func TestRPCRequests_Validate(t *testing.T) {
+	RegisterTestingT(t)
+
+	Expect(RPCRequests{NewRequest("a"), NewNotification("b"), NewNotification("c")}.Validate()).To(Succeed())
+
+	invalid := map[string]RPCRequests{
+		"empty request list":                   {},
+		"request 1 is nil":                     {NewRequest("a"), nil},
+		"request 1: invalid request: method":   {NewRequest("a"), NewRequest("")},
+		"requests 0 and 2 have the same id 0":  {NewRequest("a"), NewNotification("b"), NewRequest("c")},
+		"requests 0 and 1 have the same id ab": {{Method: "a", ID: StringID("ab")}, {Method: "b", ID: StringID("ab")}},
+	}
+	for message, requests := range invalid {
+		err := requests.Validate()
+		Expect(err).To(MatchError(ContainSubstring(message)))
+		Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+	}
+}
+
+func TestRpcClient_BatchValidation(t *testing.T) {
+	RegisterTestingT(t)
+	rpcClient := NewClientWithOpts(httpServer.URL, &RPCClientOpts{MaxBatchRequests: 2, MaxBatchBytes: 100})
+
+	// invalid batches are never sent, so no request arrives at the test server
+	_, err := rpcClient.CallBatchRaw(RPCRequests{
+		{JSONRPC: "2.0", Method: "a", ID: NumberID(1)},
+		{JSONRPC: "2.0", Method: "b", ID: NumberID(1)},
+	})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+	Expect(err.Error()).To(Equal("rpc batch call: invalid request: requests 0 and 1 have the same id 1"))
+
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c")})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+	Expect(err.Error()).To(Equal("rpc batch call: invalid request: 3 requests exceed the limit of 2 requests"))
+
+	err = rpcClient.CallBatchStream(context.Background(), RPCRequests{NewRequest("a"), NewRequest("b"), NewRequest("c")}, func(*RPCResponse) {})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a", strings.Repeat("x", 100))})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+	Expect(err.Error()).To(MatchRegexp(`^rpc batch call: invalid request: \d+ bytes exceed the limit of 100 bytes$`))
+
+	// the limits are checked without validation
+	rpcClient = NewClientWithOpts(httpServer.URL, &RPCClientOpts{MaxBatchRequests: 1, DisableValidation: true})
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(errors.Is(err, ErrInvalidRequest)).To(BeTrue())
+
+	// each chunk of a split batch has its own byte limit
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		fmt.Fprint(w, `[{"jsonrpc":"2.0","result":1,"id":0}]`)
+	}))
+	defer server.Close()
+	rpcClient = NewClientWithOpts(server.URL, &RPCClientOpts{MaxBatchSize: 1, MaxBatchBytes: 60})
+	_, err = rpcClient.CallBatch(RPCRequests{NewRequest("a"), NewRequest("b")})
+	Expect(err).To(BeNil())
+	Expect(bodies).To(Equal([]string{`[{"method":"a","id":0,"jsonrpc":"2.0"}]`, `[{"method":"b","id":1,"jsonrpc":"2.0"}]`}))
+}
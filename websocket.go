@@ -0,0 +1,178 @@
+package jsonrpc
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WSClientOpts can be provided to DialWSWithOpts() to change configuration of the websocket client.
+//
+// RPCClientOpts: the same options as for http clients. HTTPClient is not used, CustomHeaders, HeaderSet and UserAgent are sent with the handshake request.
+// The connection is established through Proxy if it is set, replacing the proxy of the Dialer.
+//
+// Dialer: provide a custom websocket.Dialer (e.g. to set a proxy, or tls options), websocket.DefaultDialer is used if nil
+//
+// Reconnect: if true, a lost connection is replaced by a new one and all active subscriptions are re-established
+// (the subscribe requests are sent again and the subscriptions continue with the new subscription ids).
+// Calls that are pending when the connection is lost or that are sent while reconnecting return a retryable TransportError.
+// Notifications pushed by the server while the client is not connected are lost.
+//
+// ReconnectDelay: the delay after the first failed reconnect attempt, doubled after every further failed attempt (default 500ms)
+//
+// MaxReconnectDelay: the maximum delay between two reconnect attempts (default 30s)
+//
+// PingInterval: if > 0, a ping is sent in this interval. If no message or pong is received within PingInterval + PongTimeout,
+// the connection is considered lost (and replaced if Reconnect is set). This detects half-open connections,
+// e.g. behind load balancers, on which calls would otherwise wait forever.
+//
+// PongTimeout: the time to wait for the pong after a ping (default PingInterval)
+type WSClientOpts struct {
+	RPCClientOpts
+	Dialer            *websocket.Dialer
+	Reconnect         bool
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	PingInterval      time.Duration
+	PongTimeout       time.Duration
+}
+
+// DialWS connects to a JSON-RPC websocket endpoint (ws:// or wss://) and returns a ConnClient with default configuration.
+//
+// endpoint: JSON-RPC websocket URL to which JSON-RPC requests are sent.
+func DialWS(endpoint string) (ConnClient, error) {
+	return DialWSWithOpts(endpoint, nil)
+}
+
+// DialWSWithOpts connects to a JSON-RPC websocket endpoint (ws:// or wss://) and returns a ConnClient with custom configuration.
+//
+// endpoint: JSON-RPC websocket URL to which JSON-RPC requests are sent.
+//
+// opts: WSClientOpts provide custom configuration
+func DialWSWithOpts(endpoint string, opts *WSClientOpts) (ConnClient, error) {
+	if opts == nil {
+		opts = &WSClientOpts{}
+	}
+
+	dialer := opts.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	if opts.Proxy != nil {
+		// the dialer of the options must not be modified
+		proxied := *dialer
+		proxied.Proxy = opts.Proxy.proxyFunc()
+		dialer = &proxied
+	}
+
+	header := make(http.Header)
+	header.Set("User-Agent", userAgent(opts.UserAgent))
+	for k, v := range opts.CustomHeaders {
+		header.Set(k, v)
+	}
+
+	pongTimeout := opts.PongTimeout
+	if pongTimeout <= 0 {
+		pongTimeout = opts.PingInterval
+	}
+
+	dial := func() (messageConn, error) {
+		header := header.Clone()
+		opts.HeaderSet.apply(header)
+		conn, _, err := dialer.Dial(endpoint, header)
+		if err != nil {
+			return nil, err
+		}
+		return newWSConn(conn, opts.PingInterval, pongTimeout), nil
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return nil, &TransportError{err: err}
+	}
+
+	var reconnect *reconnector
+	if opts.Reconnect {
+		reconnect = newReconnector(dial, opts.ReconnectDelay, opts.MaxReconnectDelay)
+	}
+
+	return newConnClient(conn, &opts.RPCClientOpts, reconnect), nil
+}
+
+// NewWSClient returns a ConnClient that sends its requests over an already established websocket connection.
+// The connection is owned by the client afterwards and closed by Close().
+// The client can not reconnect, use DialWSWithOpts() with Reconnect for that.
+//
+// opts: RPCClientOpts provide custom configuration, HTTPClient and CustomHeaders are not used.
+func NewWSClient(conn *websocket.Conn, opts *RPCClientOpts) ConnClient {
+	return newConnClient(newWSConn(conn, 0, 0), opts, nil)
+}
+
+// wsConn sends every JSON-RPC message as websocket text message.
+type wsConn struct {
+	conn *websocket.Conn
+
+	// timeout is the read deadline after every received message or pong, 0 if pings are disabled
+	timeout   time.Duration
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newWSConn returns a wsConn for conn. If pingInterval > 0, pings are sent in that interval
+// and reads fail if nothing was received within pingInterval + pongTimeout.
+func newWSConn(conn *websocket.Conn, pingInterval, pongTimeout time.Duration) *wsConn {
+	c := &wsConn{
+		conn:   conn,
+		closed: make(chan struct{}),
+	}
+
+	if pingInterval > 0 {
+		c.timeout = pingInterval + pongTimeout
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+		c.conn.SetPongHandler(func(string) error {
+			return c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+		})
+		go c.pingLoop(pingInterval, pongTimeout)
+	}
+
+	return c
+}
+
+func (c *wsConn) pingLoop(interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-ticker.C:
+			// a failed ping is detected by the read deadline
+			c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout))
+		}
+	}
+}
+
+func (c *wsConn) readMessage() ([]byte, error) {
+	_, message, err := c.conn.ReadMessage()
+	if err == nil && c.timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	return message, err
+}
+
+func (c *wsConn) writeMessage(message []byte) error {
+	return c.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+func (c *wsConn) close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	// best effort, the server may already be gone
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	return c.conn.Close()
+}
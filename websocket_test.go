@@ -0,0 +1,351 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+)
+
+// wsTestServer is a websocket JSON-RPC server that responds to every request with respondTestRequest().
+type wsTestServer struct {
+	*httptest.Server
+	mutex sync.Mutex
+	conns []*websocket.Conn
+}
+
+func newWSTestServer() *wsTestServer {
+	s := &wsTestServer{}
+	upgrader := websocket.Upgrader{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conns = append(s.conns, conn)
+		s.mutex.Unlock()
+
+		var writeMutex sync.Mutex
+		write := func(v interface{}) {
+			writeMutex.Lock()
+			defer writeMutex.Unlock()
+			conn.WriteJSON(v)
+		}
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			go respondTestRequest(message, write)
+		}
+	}))
+
+	return s
+}
+
+func (s *wsTestServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http")
+}
+
+// respondTestRequest responds to a request or batch request of the test servers with the method as result.
+// A request with a number as first param is answered after that many milliseconds.
+// Batches are answered in reverse order.
+func respondTestRequest(message []byte, write func(interface{})) {
+	if message[0] == '[' {
+		var requests []*testRequest
+		json.Unmarshal(message, &requests)
+		responses := make([]*RPCResponse, 0, len(requests))
+		// respond in reverse order
+		for i := len(requests) - 1; i >= 0; i-- {
+			responses = append(responses, requests[i].response())
+		}
+		write(responses)
+		return
+	}
+
+	var request testRequest
+	json.Unmarshal(message, &request)
+	switch request.Method {
+	case "test_subscribe":
+		// respond with the subscription id and directly push the requested number of notifications
+		write(&RPCResponse{JSONRPC: "2.0", Result: "sub-" + request.ID.String(), ID: request.ID})
+		count, _ := request.Params[0].(float64)
+		for i := 0; i < int(count); i++ {
+			write(&RPCNotification{JSONRPC: "2.0", Method: "test_subscription", Params: map[string]interface{}{
+				"subscription": "sub-" + request.ID.String(),
+				"result":       i,
+			}})
+		}
+		return
+	case "test_unsubscribe":
+		write(&RPCResponse{JSONRPC: "2.0", Result: true, ID: request.ID})
+		return
+	case "test_invalid":
+		// respond like a server that could not detect the id of the request
+		write(&RPCResponse{JSONRPC: "2.0", Error: &RPCError{Code: -32600, Message: "invalid request"}, ID: NullID()})
+		return
+	}
+	if len(request.Params) > 0 {
+		if delay, ok := request.Params[0].(float64); ok {
+			time.Sleep(time.Duration(delay) * time.Millisecond)
+		}
+	}
+	write(request.response())
+}
+
+// dropConnections closes all server side connections without close handshake.
+func (s *wsTestServer) dropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+type testRequest struct {
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+	ID     ID            `json:"id"`
+}
+
+func (r *testRequest) response() *RPCResponse {
+	return &RPCResponse{JSONRPC: "2.0", Result: r.Method, ID: r.ID}
+}
+
+func TestWSClient_Call(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+	Expect(res.ID).To(Equal(NumberID(1)))
+
+	var result string
+	err = rpcClient.CallFor(&result, "world")
+	Expect(err).To(BeNil())
+	Expect(result).To(Equal("world"))
+}
+
+func TestWSClient_NullIDError(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	// the error response without id is delivered to the pending call instead of being dropped
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := rpcClient.CallContext(ctx, "test_invalid")
+	Expect(err).To(BeNil())
+	Expect(res.Error).To(Equal(&RPCError{Code: -32600, Message: "invalid request"}))
+
+	// the connection is still usable
+	res, err = rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+}
+
+func TestWSClient_ConcurrentCalls(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	// slow calls are answered after fast calls, every caller gets its own response
+	results := make(chan []interface{}, 20)
+	for i := 0; i < 20; i++ {
+		go func(i int) {
+			method := "method" + string(rune('a'+i))
+			res, err := rpcClient.Call(method, (20-i)*2)
+			if err != nil {
+				results <- []interface{}{method, err}
+				return
+			}
+			results <- []interface{}{method, res.Result}
+		}(i)
+	}
+	for i := 0; i < 20; i++ {
+		result := <-results
+		Expect(result[1]).To(Equal(result[0]))
+	}
+}
+
+func TestWSClient_CallBatch(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	res, err := rpcClient.CallBatch(RPCRequests{
+		NewRequest("first"),
+		NewRequest("second"),
+	})
+	Expect(err).To(BeNil())
+	Expect(len(res)).To(Equal(2))
+	Expect(res.GetByID(NumberID(1)).Result).To(Equal("first"))
+	Expect(res.GetByID(NumberID(2)).Result).To(Equal("second"))
+
+	// ids of pending requests must be unique
+	done := make(chan error)
+	go func() {
+		_, err := rpcClient.CallRaw(&RPCRequest{Method: "slow", Params: []int{200}, ID: StringID("a"), JSONRPC: "2.0"})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	res, err = rpcClient.CallBatchRaw(RPCRequests{
+		{Method: "first", ID: StringID("b"), JSONRPC: "2.0"},
+		{Method: "second", ID: StringID("a"), JSONRPC: "2.0"},
+	})
+	Expect(err).NotTo(BeNil())
+	Expect(IsRetryable(err)).To(BeFalse())
+	Expect(res).To(BeNil())
+	Expect(<-done).To(BeNil())
+}
+
+func TestWSClient_Close(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWS(server.URL())
+	Expect(err).To(BeNil())
+
+	// pending calls return an error when the client is closed
+	done := make(chan error)
+	go func() {
+		_, err := rpcClient.Call("slow", 1000)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	Expect(rpcClient.Close()).To(BeNil())
+	Expect(<-done).NotTo(BeNil())
+
+	_, err = rpcClient.Call("hello")
+	Expect(err).NotTo(BeNil())
+	Expect(err.Error()).To(ContainSubstring(ErrClientClosed.Error()))
+
+	// a lost connection is reported as retryable transport error
+	rpcClient, err = DialWS(server.URL())
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+	server.dropConnections()
+	_, err = rpcClient.Call("hello")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+}
+
+func TestWSClient_Reconnect(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	rpcClient, err := DialWSWithOpts(server.URL(), &WSClientOpts{Reconnect: true, ReconnectDelay: 10 * time.Millisecond})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	// pending calls fail with a retryable error when the connection is lost
+	done := make(chan error)
+	go func() {
+		_, err := rpcClient.Call("slow", 1000)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	server.dropConnections()
+	err = <-done
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+
+	// the connection is replaced by a new one
+	Eventually(func() error {
+		_, err := rpcClient.Call("hello")
+		return err
+	}).Should(BeNil())
+
+	res, err := rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+
+	// reconnect attempts stop when the client is closed
+	server.Close()
+	server.dropConnections()
+	Expect(rpcClient.Close()).To(BeNil())
+	_, err = rpcClient.Call("hello")
+	Expect(err.Error()).To(ContainSubstring(ErrClientClosed.Error()))
+}
+
+func TestWSClient_Ping(t *testing.T) {
+	RegisterTestingT(t)
+	server := newWSTestServer()
+	defer server.Close()
+
+	// the server answers the pings, so the connection stays alive while idle
+	rpcClient, err := DialWSWithOpts(server.URL(), &WSClientOpts{
+		PingInterval: 10 * time.Millisecond,
+		PongTimeout:  200 * time.Millisecond,
+	})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	res, err := rpcClient.Call("hello")
+	Expect(err).To(BeNil())
+	Expect(res.Result).To(Equal("hello"))
+
+	// a server that stops responding is detected
+	upgrader := websocket.Upgrader{}
+	unresponsive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(time.Second)
+	}))
+	defer unresponsive.Close()
+
+	rpcClient, err = DialWSWithOpts("ws"+strings.TrimPrefix(unresponsive.URL, "http"), &WSClientOpts{
+		PingInterval: 10 * time.Millisecond,
+	})
+	Expect(err).To(BeNil())
+	defer rpcClient.Close()
+
+	start := time.Now()
+	_, err = rpcClient.Call("hello")
+	Expect(err).To(BeAssignableToTypeOf(&TransportError{}))
+	Expect(IsRetryable(err)).To(BeTrue())
+	Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+}
+
+func TestDialWS_Error(t *testing.T) {
+	RegisterTestingT(t)
+
+	_, err := DialWS("ws://127.0.0.1:1")
+	Expect(err).NotTo(BeNil())
+	Expect(IsRetryable(err)).To(BeTrue())
+}